@@ -0,0 +1,130 @@
+// Package adoption models an adoption application for a specific cat:
+// the answers collected by the multi-step form, per-step validation,
+// the review workflow (submitted -> under review -> approved/rejected) and storage.
+package adoption
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Application is everything we know about one person's request to adopt one cat
+//
+// NESTED STRUCTS group the answers by form step, which keeps validation
+// and rendering for each step focused on its own fields
+type Application struct {
+	ID      string `json:"id"`
+	CatSlug string `json:"cat_slug"`
+	Status  Status `json:"status"`
+
+	Applicant  Applicant   `json:"applicant"`
+	Home       Home        `json:"home"`
+	References []Reference `json:"references"`
+	Agreement  Agreement   `json:"agreement"`
+
+	// History is the AUDIT TRAIL of status changes, oldest first
+	History []StatusChange `json:"history"`
+
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	SubmittedAt time.Time `json:"submitted_at,omitempty"`
+}
+
+// Applicant - step 1
+type Applicant struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
+
+// Home - step 2: the environment the cat would live in
+type Home struct {
+	HousingType        string `json:"housing_type"` // one of HousingTypes
+	OwnsHome           bool   `json:"owns_home"`
+	LandlordAllowsPets bool   `json:"landlord_allows_pets"` // only relevant to renters
+	Adults             int    `json:"adults"`
+	Children           int    `json:"children"`
+	OtherPets          string `json:"other_pets"`
+	HoursAlone         int    `json:"hours_alone"` // typical hours per day the cat would be alone
+}
+
+// HousingTypes are the accepted values for Home.HousingType
+var HousingTypes = []string{"house", "apartment", "condo", "other"}
+
+// Reference - step 3: someone who can vouch for the applicant
+type Reference struct {
+	Name         string `json:"name"`
+	Phone        string `json:"phone"`
+	Relationship string `json:"relationship"`
+}
+
+// ReferencesRequired is how many references the form asks for
+const ReferencesRequired = 2
+
+// Agreement - step 4: the applicant confirms the adoption terms
+type Agreement struct {
+	AcceptsTerms     bool   `json:"accepts_terms"`
+	AcceptsHomeVisit bool   `json:"accepts_home_visit"`
+	Signature        string `json:"signature"` // typed full name
+}
+
+// StatusChange records who moved an application to which status, and why
+type StatusChange struct {
+	From Status    `json:"from"`
+	To   Status    `json:"to"`
+	By   string    `json:"by"` // "applicant" or the staff member's name
+	Note string    `json:"note,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// New starts a draft application for a cat
+func New(catSlug string) Application {
+	now := time.Now().UTC()
+	return Application{
+		ID:         newID(),
+		CatSlug:    catSlug,
+		Status:     StatusDraft,
+		References: make([]Reference, ReferencesRequired),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// Transition moves the application to a new status if the workflow allows it
+// POINTER RECEIVER: this method modifies the application, so it needs the original, not a copy
+func (a *Application) Transition(to Status, by, note string) error {
+	if !a.Status.CanTransition(to) {
+		return TransitionError{From: a.Status, To: to}
+	}
+
+	now := time.Now().UTC()
+	a.History = append(a.History, StatusChange{From: a.Status, To: to, By: by, Note: note, At: now})
+	a.Status = to
+	a.UpdatedAt = now
+	if to == StatusSubmitted {
+		a.SubmittedAt = now
+	}
+	return nil
+}
+
+// Editable reports whether the applicant may still change their answers
+func (a Application) Editable() bool {
+	return a.Status == StatusDraft
+}
+
+// newID returns a random, unguessable identifier
+// The ID doubles as the applicant's "key" to their draft, so it must not be predictable
+// crypto/rand (not math/rand) is the right source for anything security related
+func newID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf) // crypto/rand.Read never fails on supported platforms
+	return hex.EncodeToString(buf)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. NESTED STRUCTS - answers grouped by form step
+// 2. POINTER vs VALUE RECEIVERS - Transition mutates, Editable only reads
+// 3. AUDIT TRAIL - every status change is appended to History
+// 4. CRYPTO/RAND - unguessable IDs for capability-style URLs
@@ -0,0 +1,56 @@
+package adoption
+
+import (
+	"strings"
+	"sync"
+)
+
+// Locks serializes changes to one application at a time
+// Handlers load an application, change it and save it whole; two requests
+// doing that at once would each save their own copy, and the later one would
+// silently undo the earlier. Holding the application's lock from Get to Save
+// makes the second request load what the first one saved.
+// The zero value is ready to use; a Locks must not be copied after first use
+type Locks struct {
+	mu   sync.Mutex
+	byID map[string]*idLock
+}
+
+// idLock is one application's mutex, shared by everyone holding or waiting for it
+type idLock struct {
+	sync.Mutex
+	refs int // holders plus waiters: at 0 nobody needs it and it is dropped
+}
+
+// Lock blocks until no one else holds id's lock and returns the function that
+// releases it - call it with defer. Only applications in use have an entry,
+// so the map doesn't grow with every ID ever seen
+func (l *Locks) Lock(id string) (unlock func()) {
+	l.mu.Lock()
+	if l.byID == nil {
+		l.byID = make(map[string]*idLock)
+	}
+	e, ok := l.byID[id]
+	if !ok {
+		e = &idLock{}
+		id = strings.Clone(id) // a map key outlives the request: don't keep rweb's buffer
+		l.byID[id] = e
+	}
+	e.refs++
+	l.mu.Unlock()
+
+	e.Lock()
+	return func() {
+		e.Unlock()
+		l.mu.Lock()
+		if e.refs--; e.refs == 0 {
+			delete(l.byID, id)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. LOST UPDATES - read-modify-write needs a lock around the whole cycle
+// 2. PER-KEY LOCKING - requests for different applications don't wait on each other
+// 3. REFERENCE COUNTING - an entry lives only while someone holds or awaits it
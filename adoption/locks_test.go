@@ -0,0 +1,40 @@
+package adoption
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// Many goroutines read-modify-write the same values: with the lock held
+// around each cycle no increment is lost, and no entries are left behind
+func TestLocks(t *testing.T) {
+	var locks Locks
+	counts := map[string]int{"a": 0, "b": 0}
+	var mapMu sync.Mutex // guards the map itself; the Locks guard each cycle
+	get := func(id string) int { mapMu.Lock(); defer mapMu.Unlock(); return counts[id] }
+	set := func(id string, n int) { mapMu.Lock(); defer mapMu.Unlock(); counts[id] = n }
+
+	var wg sync.WaitGroup
+	for range 50 {
+		for _, id := range []string{"a", "b"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock := locks.Lock(id)
+				defer unlock()
+				n := get(id)      // load...
+				runtime.Gosched() // ...let the others run, as a slow handler would...
+				set(id, n+1)      // ...modify and save
+			}()
+		}
+	}
+	wg.Wait()
+
+	if counts["a"] != 50 || counts["b"] != 50 {
+		t.Errorf("counts = %v, want 50 each", counts)
+	}
+	if len(locks.byID) != 0 {
+		t.Errorf("%d locks left behind", len(locks.byID))
+	}
+}
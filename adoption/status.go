@@ -0,0 +1,82 @@
+package adoption
+
+import "fmt"
+
+// Status is where an application sits in the review workflow
+type Status string
+
+const (
+	StatusDraft       Status = "draft"        // still being filled in by the applicant
+	StatusSubmitted   Status = "submitted"    // sent, waiting for staff to pick it up
+	StatusUnderReview Status = "under_review" // staff are checking references, etc.
+	StatusApproved    Status = "approved"
+	StatusRejected    Status = "rejected"
+	StatusWithdrawn   Status = "withdrawn" // the applicant changed their mind
+)
+
+// AllStatuses lists the statuses in workflow order (handy for filters and dropdowns)
+var AllStatuses = []Status{
+	StatusDraft, StatusSubmitted, StatusUnderReview,
+	StatusApproved, StatusRejected, StatusWithdrawn,
+}
+
+// STATE MACHINE as a MAP: each status maps to the statuses it may move to
+// Anything not listed here is an illegal transition
+// Approved, rejected and withdrawn are FINAL - they have no outgoing edges
+var transitions = map[Status][]Status{
+	StatusDraft:       {StatusSubmitted, StatusWithdrawn},
+	StatusSubmitted:   {StatusUnderReview, StatusRejected, StatusWithdrawn},
+	StatusUnderReview: {StatusApproved, StatusRejected, StatusWithdrawn},
+}
+
+// Label is the human-friendly form of a status, e.g. "Under review"
+func (s Status) Label() string {
+	switch s {
+	case StatusUnderReview:
+		return "Under review"
+	case "":
+		return ""
+	default:
+		// Capitalize the first letter - statuses are plain ASCII
+		return string(s[0]-'a'+'A') + string(s[1:])
+	}
+}
+
+// Next returns the statuses this status may move to
+func (s Status) Next() []Status {
+	return transitions[s]
+}
+
+// StaffNext returns the moves staff may make from s: all of Next except
+// submitting, which only the applicant does, once the whole application
+// validates (see app.Adoption.SaveStep). Staff may still withdraw a draft
+func (s Status) StaffNext() []Status {
+	var next []Status
+	for _, to := range transitions[s] {
+		if to != StatusSubmitted {
+			next = append(next, to)
+		}
+	}
+	return next
+}
+
+// CanTransition reports whether moving from s to to is allowed
+func (s Status) CanTransition(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CUSTOM ERROR TYPE: carries the details of a rejected transition
+// Callers can use errors.As to get at From and To
+type TransitionError struct {
+	From, To Status
+}
+
+// Error implements the built-in error interface
+func (e TransitionError) Error() string {
+	return fmt.Sprintf("cannot move an application from %q to %q", e.From, e.To)
+}
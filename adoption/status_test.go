@@ -0,0 +1,96 @@
+package adoption
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// The workflow as a table: every status against every status
+func TestCanTransition(t *testing.T) {
+	allowed := map[Status][]Status{
+		StatusDraft:       {StatusSubmitted, StatusWithdrawn},
+		StatusSubmitted:   {StatusUnderReview, StatusRejected, StatusWithdrawn},
+		StatusUnderReview: {StatusApproved, StatusRejected, StatusWithdrawn},
+		// FINAL statuses go nowhere
+		StatusApproved:  nil,
+		StatusRejected:  nil,
+		StatusWithdrawn: nil,
+	}
+	for _, from := range AllStatuses {
+		if got := from.Next(); !slices.Equal(got, allowed[from]) {
+			t.Errorf("%s.Next() = %v, want %v", from, got, allowed[from])
+		}
+		for _, to := range slices.Concat(AllStatuses, []Status{"", "unknown"}) {
+			want := slices.Contains(allowed[from], to)
+			if got := from.CanTransition(to); got != want {
+				t.Errorf("%s -> %q: CanTransition = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+	// Only the applicant submits: staff never get that choice
+	staff := map[Status][]Status{
+		StatusDraft:       {StatusWithdrawn},
+		StatusSubmitted:   {StatusUnderReview, StatusRejected, StatusWithdrawn},
+		StatusUnderReview: {StatusApproved, StatusRejected, StatusWithdrawn},
+	}
+	for _, from := range AllStatuses {
+		if got := from.StaffNext(); !slices.Equal(got, staff[from]) {
+			t.Errorf("%s.StaffNext() = %v, want %v", from, got, staff[from])
+		}
+	}
+	if next := Status("unknown").Next(); len(next) != 0 {
+		t.Errorf("an unknown status moves to %v", next)
+	}
+}
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Status
+		to      Status
+		wantErr bool
+	}{
+		{"submit a draft", StatusDraft, StatusSubmitted, false},
+		{"withdraw a draft", StatusDraft, StatusWithdrawn, false},
+		{"review", StatusSubmitted, StatusUnderReview, false},
+		{"approve after review", StatusUnderReview, StatusApproved, false},
+		{"skip the review", StatusSubmitted, StatusApproved, true},
+		{"approve a draft", StatusDraft, StatusApproved, true},
+		{"reopen a rejection", StatusRejected, StatusUnderReview, true},
+		{"back to draft", StatusSubmitted, StatusDraft, true},
+		{"stay put", StatusDraft, StatusDraft, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("luna")
+			app.Status = tt.from
+			err := app.Transition(tt.to, "sam", "a note")
+
+			if tt.wantErr {
+				// ERRORS.AS: the handler relies on getting the details back out
+				var tErr TransitionError
+				if !errors.As(err, &tErr) || tErr.From != tt.from || tErr.To != tt.to {
+					t.Fatalf("err = %v, want a TransitionError from %s to %s", err, tt.from, tt.to)
+				}
+				if app.Status != tt.from || len(app.History) != 0 {
+					t.Errorf("a refused move changed the application: %s, %v", app.Status, app.History)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if app.Status != tt.to || len(app.History) != 1 {
+				t.Fatalf("status %s, history %v", app.Status, app.History)
+			}
+			if ch := app.History[0]; ch.From != tt.from || ch.To != tt.to || ch.By != "sam" || ch.Note != "a note" {
+				t.Errorf("history %+v", ch)
+			}
+			if submitted := !app.SubmittedAt.IsZero(); submitted != (tt.to == StatusSubmitted) {
+				t.Errorf("SubmittedAt = %v after moving to %s", app.SubmittedAt, tt.to)
+			}
+		})
+	}
+}
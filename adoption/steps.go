@@ -0,0 +1,266 @@
+package adoption
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"form_exer/forms"
+)
+
+// Step describes one page of the multi-step application form
+type Step struct {
+	Number int    // 1-based, used in URLs: /applications/:id/step/:step
+	Key    string // stable identifier
+	Title  string // message key, e.g. "adoption.step.applicant"
+}
+
+// Steps in the order the applicant fills them in
+var Steps = []Step{
+	{Number: 1, Key: "applicant", Title: "adoption.step.applicant"},
+	{Number: 2, Key: "home", Title: "adoption.step.home"},
+	{Number: 3, Key: "references", Title: "adoption.step.references"},
+	{Number: 4, Key: "agreement", Title: "adoption.step.agreement"},
+}
+
+// StepByNumber looks up a step, reporting false for out-of-range numbers
+func StepByNumber(n int) (Step, bool) {
+	if n < 1 || n > len(Steps) {
+		return Step{}, false
+	}
+	return Steps[n-1], true
+}
+
+// Form field names, shared by the form components and ApplyStep
+// Reference fields are built with RefField(i, "name") etc.
+const (
+	FieldName    = "applicant_name"
+	FieldEmail   = "applicant_email"
+	FieldPhone   = "applicant_phone"
+	FieldAddress = "applicant_address"
+
+	FieldHousingType  = "home_housing_type"
+	FieldOwnsHome     = "home_owns"
+	FieldLandlordOK   = "home_landlord_allows_pets"
+	FieldAdults       = "home_adults"
+	FieldChildren     = "home_children"
+	FieldOtherPets    = "home_other_pets"
+	FieldHoursAlone   = "home_hours_alone"
+	FieldAcceptsTerms = "agree_terms"
+	FieldAcceptsVisit = "agree_home_visit"
+	FieldSignature    = "agree_signature"
+)
+
+// RefField names the field for part of reference i (0-based), e.g. RefField(0, "phone") -> "ref1_phone"
+func RefField(i int, part string) string {
+	return fmt.Sprintf("ref%d_%s", i+1, part)
+}
+
+// StepSchema describes the fields of one step, for the API documentation
+// Nothing is required to save a draft; Required marks what ValidateStep
+// insists on before the application can move past the step
+func StepSchema(step int) forms.Schema {
+	switch step {
+	case 1:
+		return forms.Schema{
+			{Name: FieldName, Required: true},
+			{Name: FieldEmail, Required: true},
+			{Name: FieldPhone, Required: true, Description: "at least 7 digits"},
+			{Name: FieldAddress, Required: true},
+		}
+	case 2:
+		return forms.Schema{
+			{Name: FieldHousingType, Required: true, Enum: HousingTypes},
+			{Name: FieldOwnsHome, Type: forms.Boolean},
+			{Name: FieldLandlordOK, Type: forms.Boolean, Description: "required unless " + FieldOwnsHome + " is set"},
+			{Name: FieldAdults, Type: forms.Integer, Required: true, Description: "at least 1"},
+			{Name: FieldChildren, Type: forms.Integer},
+			{Name: FieldOtherPets},
+			{Name: FieldHoursAlone, Type: forms.Integer, Description: "0 to 24"},
+		}
+	case 3:
+		var s forms.Schema
+		for i := 0; i < ReferencesRequired; i++ {
+			for _, part := range []string{"name", "phone", "relationship"} {
+				s = append(s, forms.Field{Name: RefField(i, part), Required: true})
+			}
+		}
+		return s
+	case 4:
+		return forms.Schema{
+			{Name: FieldAcceptsTerms, Type: forms.Boolean, Required: true},
+			{Name: FieldAcceptsVisit, Type: forms.Boolean},
+			{Name: FieldSignature, Required: true, Description: "the applicant's name"},
+		}
+	}
+	return nil
+}
+
+// ApplyStep copies the submitted values for one step into the application
+// It returns errors only for values that could not be converted (e.g. "two" for a number);
+// business rules are checked separately by ValidateStep so drafts can be saved half-finished
+func (a *Application) ApplyStep(step int, get forms.ValueFunc) forms.FieldErrors {
+	errs := forms.FieldErrors{}
+
+	// HELPER CLOSURE: captures get and errs from the enclosing function
+	num := func(field string) int {
+		raw := strings.TrimSpace(get(field))
+		if raw == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			errs.Add(field, "error.whole_number")
+		}
+		return n
+	}
+	text := func(field string) string { return strings.TrimSpace(get(field)) }
+	checked := func(field string) bool { return get(field) != "" } // unchecked boxes aren't submitted at all
+
+	switch step {
+	case 1:
+		a.Applicant = Applicant{
+			Name:    text(FieldName),
+			Email:   text(FieldEmail),
+			Phone:   text(FieldPhone),
+			Address: text(FieldAddress),
+		}
+	case 2:
+		a.Home = Home{
+			HousingType:        text(FieldHousingType),
+			OwnsHome:           checked(FieldOwnsHome),
+			LandlordAllowsPets: checked(FieldLandlordOK),
+			Adults:             num(FieldAdults),
+			Children:           num(FieldChildren),
+			OtherPets:          text(FieldOtherPets),
+			HoursAlone:         num(FieldHoursAlone),
+		}
+	case 3:
+		a.References = make([]Reference, ReferencesRequired)
+		for i := range a.References {
+			a.References[i] = Reference{
+				Name:         text(RefField(i, "name")),
+				Phone:        text(RefField(i, "phone")),
+				Relationship: text(RefField(i, "relationship")),
+			}
+		}
+	case 4:
+		a.Agreement = Agreement{
+			AcceptsTerms:     checked(FieldAcceptsTerms),
+			AcceptsHomeVisit: checked(FieldAcceptsVisit),
+			Signature:        text(FieldSignature),
+		}
+	}
+	return errs
+}
+
+// ValidateStep checks the business rules for one step
+// The same rules run no matter how the data arrived, so they live here in the
+// domain package rather than in a handler
+//
+// MESSAGE KEYS, not sentences: the domain doesn't know the visitor's language,
+// so errors name a catalog entry ("error.email_invalid") and pages translate them
+// (see i18n.Translator.Errors). The English wording lives in i18n/locales/en.json
+func (a Application) ValidateStep(step int) forms.FieldErrors {
+	errs := forms.FieldErrors{}
+
+	switch step {
+	case 1:
+		ap := a.Applicant
+		required(errs, FieldName, ap.Name, "error.name_required")
+		required(errs, FieldEmail, ap.Email, "error.email_required")
+		if ap.Email != "" {
+			if _, err := mail.ParseAddress(ap.Email); err != nil {
+				errs.Add(FieldEmail, "error.email_invalid")
+			}
+		}
+		required(errs, FieldPhone, ap.Phone, "error.phone_required")
+		if ap.Phone != "" && countDigits(ap.Phone) < 7 {
+			errs.Add(FieldPhone, "error.phone_short")
+		}
+		required(errs, FieldAddress, ap.Address, "error.address_required")
+
+	case 2:
+		h := a.Home
+		if !contains(HousingTypes, h.HousingType) {
+			errs.Add(FieldHousingType, "error.housing_type")
+		}
+		if !h.OwnsHome && !h.LandlordAllowsPets {
+			errs.Add(FieldLandlordOK, "error.landlord")
+		}
+		if h.Adults < 1 {
+			errs.Add(FieldAdults, "error.adults")
+		}
+		if h.Children < 0 {
+			errs.Add(FieldChildren, "error.children")
+		}
+		if h.HoursAlone < 0 || h.HoursAlone > 24 {
+			errs.Add(FieldHoursAlone, "error.hours_alone")
+		}
+
+	case 3:
+		for i := 0; i < ReferencesRequired; i++ {
+			var ref Reference
+			if i < len(a.References) {
+				ref = a.References[i]
+			}
+			required(errs, RefField(i, "name"), ref.Name, "error.ref_name")
+			required(errs, RefField(i, "phone"), ref.Phone, "error.ref_phone")
+			required(errs, RefField(i, "relationship"), ref.Relationship, "error.ref_relationship")
+		}
+
+	case 4:
+		ag := a.Agreement
+		if !ag.AcceptsTerms {
+			errs.Add(FieldAcceptsTerms, "error.terms")
+		}
+		required(errs, FieldSignature, ag.Signature, "error.signature_required")
+		// EqualFold compares case-insensitively ("jane doe" == "Jane Doe")
+		if ag.Signature != "" && !strings.EqualFold(ag.Signature, a.Applicant.Name) {
+			errs.Add(FieldSignature, "error.signature_mismatch")
+		}
+	}
+	return errs
+}
+
+// Validate checks every step, returning the first step with problems (0 if none)
+// Used right before submission so a skipped step can't slip through
+func (a Application) Validate() (firstInvalidStep int, errs forms.FieldErrors) {
+	for _, s := range Steps {
+		if stepErrs := a.ValidateStep(s.Number); len(stepErrs) > 0 {
+			return s.Number, stepErrs
+		}
+	}
+	return 0, nil
+}
+
+func required(errs forms.FieldErrors, field, value, msg string) {
+	if strings.TrimSpace(value) == "" {
+		errs.Add(field, msg)
+	}
+}
+
+func countDigits(s string) (n int) {
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. FUNCTION PARAMETERS - forms.ValueFunc decouples parsing from the web framework
+// 2. SHARED FORM TYPES - forms.FieldErrors and forms.ValueFunc
+// 3. CLOSURES - num/text/checked helpers capture local state
+// 4. SEPARATION OF PARSING AND VALIDATION - drafts can be incomplete
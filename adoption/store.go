@@ -0,0 +1,121 @@
+package adoption
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound is returned when no application has the requested ID
+var ErrNotFound = errors.New("application not found")
+
+// Store persists applications
+// Same shape as cats.Repository so the two feel familiar side by side
+type Store interface {
+	// Get returns the application with the given ID, or ErrNotFound
+	Get(id string) (Application, error)
+
+	// Save inserts or replaces an application (matched by ID)
+	Save(app Application) error
+
+	// List returns every application, most recently updated first
+	List() ([]Application, error)
+}
+
+var _ Store = (*FileStore)(nil)
+
+// FileStore keeps all applications in one JSON file, cached in memory
+type FileStore struct {
+	path string
+	mu   sync.RWMutex
+	apps map[string]Application
+}
+
+// NewFileStore loads existing applications from path (a missing file means none yet)
+func NewFileStore(path string) (*FileStore, error) {
+	st := &FileStore{path: path, apps: make(map[string]Application)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading applications %q: %w", path, err)
+	}
+
+	var list []Application
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing applications %q: %w", path, err)
+	}
+	for _, a := range list {
+		st.apps[a.ID] = a
+	}
+	return st, nil
+}
+
+func (st *FileStore) Get(id string) (Application, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	a, ok := st.apps[id]
+	if !ok {
+		return Application{}, ErrNotFound
+	}
+	return a, nil
+}
+
+func (st *FileStore) Save(app Application) error {
+	if app.ID == "" {
+		return errors.New("application ID is required")
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	prev, existed := st.apps[app.ID]
+	st.apps[app.ID] = app
+	if err := st.persistLocked(); err != nil {
+		if existed {
+			st.apps[app.ID] = prev
+		} else {
+			delete(st.apps, app.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (st *FileStore) List() ([]Application, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.sortedLocked(), nil
+}
+
+func (st *FileStore) sortedLocked() []Application {
+	list := make([]Application, 0, len(st.apps))
+	for _, a := range st.apps {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UpdatedAt.After(list[j].UpdatedAt) })
+	return list
+}
+
+// persistLocked writes all applications via temp file + rename (see cats.FileRepository)
+func (st *FileStore) persistLocked() error {
+	data, err := json.MarshalIndent(st.sortedLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(st.path), 0755); err != nil {
+		return err
+	}
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil { // 0600: applications hold personal data
+		return err
+	}
+	return os.Rename(tmp, st.path)
+}
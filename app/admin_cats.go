@@ -0,0 +1,333 @@
+package app
+
+// The staff-only cat management pages. Every route here is wrapped by the admin
+// guard (HTTP Basic auth, see Register), and every change is written to the audit
+// log with the name of the staff member who made it.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"form_exer/apperr"
+	"form_exer/audit"
+	"form_exer/auth"
+	"form_exer/cats"
+	"form_exer/events"
+	"form_exer/forms"
+	"form_exer/storage"
+	"form_exer/tracing"
+	"form_exer/web/pages"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// maxPhotoBytes caps the size of a single cat photo
+const maxPhotoBytes = 8 << 20 // 8 MiB (BIT SHIFT: 8 * 2^20)
+
+// CatAdmin handles create/update/retire/delete for cats plus photo uploads
+type CatAdmin struct {
+	Cats    cats.Repository
+	Photos  *storage.Store // limited to images (see New)
+	Audit   audit.Log
+	Metrics *siteMetrics   // may be nil
+	Events  *events.Broker // may be nil
+}
+
+// record writes an audit entry; a failure to audit is treated as a failure of the action
+func (h CatAdmin) record(ctx rweb.Context, action, target string, changes []audit.Change) error {
+	return h.Audit.Record(audit.Entry{
+		Actor: auth.CurrentUser(ctx), Action: action, Target: target, Changes: changes,
+	})
+}
+
+// statusChanged tells the staff pages when a save moved a cat to another status
+func (h CatAdmin) statusChanged(ctx rweb.Context, before, after cats.Cat) {
+	if before.Status == after.Status {
+		return
+	}
+	notify(ctx, h.Events, events.CatStatus, "", events.CatStatusChange{
+		Slug: after.Slug, Name: after.Name, From: string(before.Status), To: string(after.Status),
+	})
+}
+
+// loadCat fetches the cat named in the ":slug" path parameter; an unknown slug is a 404
+func (h CatAdmin) loadCat(ctx rweb.Context) (cat cats.Cat, found bool, err error) {
+	cat, err = h.Cats.Get(ctx.Request().PathParam("slug"))
+	if errors.Is(err, cats.ErrNotFound) {
+		return cat, false, apperr.NotFound("notfound.cat")
+	}
+	return cat, err == nil, err
+}
+
+// List shows all cats, whatever their status
+func (h CatAdmin) List(ctx rweb.Context) error {
+	list, err := h.Cats.List()
+	if err != nil {
+		return err
+	}
+	page := pages.AdminCats
+	page.Cats = list
+	return renderPage(ctx, &page)
+}
+
+// New shows an empty cat form
+func (h CatAdmin) New(ctx rweb.Context) error {
+	page := pages.NewAdminCatFormPage(cats.Cat{Status: cats.StatusAvailable}, true)
+	return renderPage(ctx, &page)
+}
+
+// Create saves a new cat
+func (h CatAdmin) Create(ctx rweb.Context) error {
+	form, err := forms.Parse(ctx.Request())
+	if err != nil {
+		// APPERR: a body we can't parse is the client's mistake - 400, not 500
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+
+	var cat cats.Cat
+	errs := cat.ApplyForm(form.Value)
+	cat.Slug = strings.TrimSpace(form.Value(cats.FieldSlug))
+	if cat.Slug == "" {
+		cat.Slug = cats.Slugify(cat.Name)
+	}
+
+	errs.Merge(cat.Validate())
+	if _, err := h.Cats.Get(cat.Slug); err == nil {
+		errs.Add(cats.FieldSlug, "Another cat already uses this slug.")
+	}
+	if len(errs) > 0 {
+		page := pages.NewAdminCatFormPage(cat, true)
+		page.Errors = errs
+		ctx.Response().SetStatus(http.StatusUnprocessableEntity)
+		return renderPage(ctx, &page)
+	}
+
+	if err := h.Cats.Save(cat); err != nil {
+		return err
+	}
+	if err := h.record(ctx, "cat.create", cat.Slug, catChanges(cats.Cat{}, cat)); err != nil {
+		return err
+	}
+	h.statusChanged(ctx, cats.Cat{}, cat)
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminCatEditPath(cat.Slug))
+}
+
+// Edit shows the form for an existing cat
+func (h CatAdmin) Edit(ctx rweb.Context) error {
+	cat, found, err := h.loadCat(ctx)
+	if !found || err != nil {
+		return err
+	}
+	page := pages.NewAdminCatFormPage(cat, false)
+	if ctx.Request().QueryParam("saved") != "" {
+		page.Notice = "Changes saved."
+	}
+	return renderPage(ctx, &page)
+}
+
+// Update saves changes to an existing cat
+func (h CatAdmin) Update(ctx rweb.Context) error {
+	before, found, err := h.loadCat(ctx)
+	if !found || err != nil {
+		return err
+	}
+
+	form, err := forms.Parse(ctx.Request())
+	if err != nil {
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+
+	after := before // COPY; ApplyForm changes only the copy
+	errs := after.ApplyForm(form.Value)
+	errs.Merge(after.Validate())
+	if len(errs) > 0 {
+		page := pages.NewAdminCatFormPage(after, false)
+		page.Errors = errs
+		ctx.Response().SetStatus(http.StatusUnprocessableEntity)
+		return renderPage(ctx, &page)
+	}
+
+	if err := h.Cats.Save(after); err != nil {
+		return err
+	}
+	if err := h.record(ctx, "cat.update", after.Slug, catChanges(before, after)); err != nil {
+		return err
+	}
+	h.statusChanged(ctx, before, after)
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminCatEditPath(after.Slug)+"?saved=1")
+}
+
+// Retire is a soft delete - the cat disappears from the public site but its record is kept
+func (h CatAdmin) Retire(ctx rweb.Context) error {
+	cat, found, err := h.loadCat(ctx)
+	if !found || err != nil {
+		return err
+	}
+	before := cat
+	cat.Status = cats.StatusRetired
+	if err := h.Cats.Save(cat); err != nil {
+		return err
+	}
+	if err := h.record(ctx, "cat.retire", cat.Slug, catChanges(before, cat)); err != nil {
+		return err
+	}
+	h.statusChanged(ctx, before, cat)
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminCatsPath)
+}
+
+// Delete is a hard delete, removing uploaded photos too
+func (h CatAdmin) Delete(ctx rweb.Context) error {
+	cat, found, err := h.loadCat(ctx)
+	if !found || err != nil {
+		return err
+	}
+	if err := h.Cats.Delete(cat.Slug); err != nil {
+		return err
+	}
+	for _, p := range cat.Photos {
+		if name, ok := h.Photos.NameFromURL(p.URL); ok {
+			_ = h.Photos.Delete(name) // best effort - the record is already gone
+		}
+	}
+	if err := h.record(ctx, "cat.delete", cat.Slug, catChanges(cat, cats.Cat{})); err != nil {
+		return err
+	}
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminCatsPath)
+}
+
+// AddPhoto uploads a photo (multipart/form-data)
+func (h CatAdmin) AddPhoto(ctx rweb.Context) error {
+	cat, found, err := h.loadCat(ctx)
+	if !found || err != nil {
+		return err
+	}
+	// forms.Parse rather than req.GetFormFile - see forms.Form for why
+	form, err := forms.Parse(ctx.Request())
+	if err != nil {
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+
+	// showErr re-renders the edit page with a message under the upload form
+	showErr := func(status int, msg string) error {
+		page := pages.NewAdminCatFormPage(cat, false)
+		page.Errors = map[string]string{"photo": msg}
+		ctx.Response().SetStatus(status)
+		return renderPage(ctx, &page)
+	}
+
+	file, header, err := form.File("photo")
+	if err != nil {
+		return showErr(http.StatusBadRequest, "Please choose a photo to upload.")
+	}
+	defer file.Close()
+
+	stored, err := saveTraced(ctx, h.Photos, uploadPhoto, header.Filename, file)
+	switch {
+	case errors.Is(err, storage.ErrTooLarge):
+		h.Metrics.upload(uploadPhoto, "too_large", 0)
+		return showErr(http.StatusRequestEntityTooLarge, fmt.Sprintf("Photos must be smaller than %d MB.", maxPhotoBytes>>20))
+	case errors.Is(err, storage.ErrTypeNotAllowed):
+		h.Metrics.upload(uploadPhoto, "rejected", 0)
+		return showErr(http.StatusUnsupportedMediaType, "Only image files can be used as photos.")
+	case err != nil:
+		return err
+	}
+	h.Metrics.upload(uploadPhoto, "stored", stored.Size)
+
+	alt := strings.TrimSpace(form.Value("alt"))
+	if alt == "" {
+		alt = "Photo of " + cat.Name
+	}
+	cat.Photos = append(cat.Photos, cats.Photo{URL: stored.URL, Alt: alt})
+	if err := h.Cats.Save(cat); err != nil {
+		_ = h.Photos.Delete(stored.Name)
+		return err
+	}
+	if err := h.record(ctx, "cat.photo.add", cat.Slug, []audit.Change{{Field: "photos", To: stored.URL}}); err != nil {
+		return err
+	}
+	// Only the uploader's own pages are told: they are waiting for it
+	notify(ctx, h.Events, events.UploadComplete, auth.CurrentUser(ctx),
+		events.UploadNotice{Name: stored.Name, URL: stored.URL, Size: stored.Size, Cat: cat.Slug})
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminCatEditPath(cat.Slug)+"?saved=1")
+}
+
+// photoSchema describes AddPhoto's form for the API documentation
+var photoSchema = forms.Schema{
+	{Name: "photo", Type: forms.File, Required: true, Description: "an image"},
+	{Name: "alt", Description: "text for screen readers; default \"Photo of <name>\""},
+}
+
+// DeletePhoto removes a photo by its position in the list
+func (h CatAdmin) DeletePhoto(ctx rweb.Context) error {
+	cat, found, err := h.loadCat(ctx)
+	if !found || err != nil {
+		return err
+	}
+	i, err := strconv.Atoi(ctx.Request().PathParam("index"))
+	if err != nil || i < 0 || i >= len(cat.Photos) {
+		return ctx.Redirect(http.StatusSeeOther, pages.AdminCatEditPath(cat.Slug))
+	}
+
+	removed := cat.Photos[i]
+	// SLICE DELETION: append the part after i onto the part before i
+	// A new slice is built so the repository's copy is never aliased
+	cat.Photos = append(append([]cats.Photo{}, cat.Photos[:i]...), cat.Photos[i+1:]...)
+	if err := h.Cats.Save(cat); err != nil {
+		return err
+	}
+	if name, ok := h.Photos.NameFromURL(removed.URL); ok {
+		span := tracing.Start(ctx, "storage.delete")
+		span.RecordError(h.Photos.Delete(name)) // a leftover file is harmless, so it only shows in the trace
+		span.End()
+	}
+	if err := h.record(ctx, "cat.photo.remove", cat.Slug, []audit.Change{{Field: "photos", From: removed.URL}}); err != nil {
+		return err
+	}
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminCatEditPath(cat.Slug)+"?saved=1")
+}
+
+// AuditLog shows the most recent staff changes
+func (h CatAdmin) AuditLog(ctx rweb.Context) error {
+	entries, err := h.Audit.Recent(200)
+	if err != nil {
+		return err
+	}
+	page := pages.AdminAudit
+	page.Entries = entries
+	return renderPage(ctx, &page)
+}
+
+// catChanges lists the fields that differ between two versions of a cat
+// Comparing against the zero value cats.Cat{} gives "everything" for creates and deletes
+func catChanges(before, after cats.Cat) (changes []audit.Change) {
+	// ANONYMOUS STRUCT SLICE: a quick table of field name + both values
+	fields := []struct{ name, from, to string }{
+		{"name", before.Name, after.Name},
+		{"slug", before.Slug, after.Slug},
+		{"age_months", strconv.Itoa(before.AgeMonths), strconv.Itoa(after.AgeMonths)},
+		{"breed", before.Breed, after.Breed},
+		{"temperament", before.Temperament, after.Temperament},
+		{"description", before.Description, after.Description},
+		{"status", string(before.Status), string(after.Status)},
+		{"vaccinations", strings.Join(before.Health.Vaccinations, ", "), strings.Join(after.Health.Vaccinations, ", ")},
+		{"spayed_neutered", strconv.FormatBool(before.Health.SpayedNeutered), strconv.FormatBool(after.Health.SpayedNeutered)},
+		{"microchipped", strconv.FormatBool(before.Health.Microchipped), strconv.FormatBool(after.Health.Microchipped)},
+		{"health_notes", before.Health.Notes, after.Health.Notes},
+		{"good_with_kids", strconv.FormatBool(before.GoodWithKids), strconv.FormatBool(after.GoodWithKids)},
+		{"good_with_pets", strconv.FormatBool(before.GoodWithPets), strconv.FormatBool(after.GoodWithPets)},
+		{"photos", strconv.Itoa(len(before.Photos)), strconv.Itoa(len(after.Photos))},
+	}
+	for _, f := range fields {
+		if f.from != f.to {
+			changes = append(changes, audit.Change{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	return changes
+}
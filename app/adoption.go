@@ -0,0 +1,339 @@
+package app
+
+// The adoption application workflow: the applicant-facing multi-step form and
+// the staff review page. The staff routes are marked Admin in the route table,
+// so Register wraps them with auth.Require; status changes are audited.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"form_exer/adoption"
+	"form_exer/apperr"
+	"form_exer/audit"
+	"form_exer/auth"
+	"form_exer/cats"
+	"form_exer/forms"
+	"form_exer/web/pages"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Adoption handles applications from the first click on "Adopt" to the staff decision
+type Adoption struct {
+	Cats  cats.Repository
+	Apps  adoption.Store
+	Audit audit.Log
+
+	// Locks is held from load to save by every handler that changes an
+	// application, so concurrent posts don't overwrite each other.
+	// POINTER: Adoption is copied by value, the locks must be shared
+	Locks *adoption.Locks
+}
+
+// loadApp fetches an application and its cat together - every handler below needs both
+// MULTIPLE RETURN VALUES with a "found" flag keeps the handlers' error paths short
+func (h Adoption) loadApp(id string) (app adoption.Application, cat cats.Cat, found bool, err error) {
+	app, err = h.Apps.Get(id)
+	if errors.Is(err, adoption.ErrNotFound) {
+		return app, cat, false, nil
+	}
+	if err != nil {
+		return app, cat, false, err
+	}
+	cat, err = h.Cats.Get(app.CatSlug)
+	if errors.Is(err, cats.ErrNotFound) {
+		// The cat may have been removed since - still show the application
+		cat, err = cats.Cat{Slug: app.CatSlug, Name: app.CatSlug}, nil
+	}
+	return app, cat, err == nil, err
+}
+
+// Start creates a draft application and sends the visitor to its first step
+// POST-REDIRECT-GET pattern: the "Start adoption" button posts, creating the
+// draft, then the browser is redirected (303 See Other) to the first step.
+// It must be a POST - a GET link would get a draft saved for every crawler
+// and link prefetcher that followed it
+func (h Adoption) Start(ctx rweb.Context) error {
+	cat, err := h.Cats.Get(ctx.Request().PathParam("slug"))
+	if errors.Is(err, cats.ErrNotFound) {
+		return apperr.NotFound("notfound.cat")
+	}
+	if err != nil {
+		return err
+	}
+	if !cat.IsAvailable() {
+		return ctx.Redirect(http.StatusSeeOther, pages.CatDetailPath(cat.Slug))
+	}
+
+	app := adoption.New(cat.Slug)
+	if err := h.Apps.Save(app); err != nil {
+		return err
+	}
+	return ctx.Redirect(http.StatusSeeOther, pages.ApplicationStepPath(app.ID, 1))
+}
+
+// Status is the STATUS PAGE for the applicant
+func (h Adoption) Status(ctx rweb.Context) error {
+	app, cat, found, err := h.loadApp(ctx.Request().PathParam("id"))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return apperr.NotFound("notfound.application")
+	}
+	page := pages.NewAdoptionStatusPage(app, cat)
+	return renderPage(ctx, &page)
+}
+
+// ShowStep shows A STEP of the form, pre-filled from the saved draft
+func (h Adoption) ShowStep(ctx rweb.Context) error {
+	app, cat, found, err := h.loadApp(ctx.Request().PathParam("id"))
+	if err != nil {
+		return err
+	}
+	step, ok := parseStep(ctx.Request().PathParam("step"))
+	if !found || !ok {
+		return apperr.NotFound("notfound.application")
+	}
+	if !app.Editable() { // submitted applications are read-only
+		return ctx.Redirect(http.StatusSeeOther, pages.ApplicationPath(app.ID))
+	}
+	page := pages.NewAdoptionFormPage(app, cat, step)
+	return renderPage(ctx, &page)
+}
+
+// SaveStep saves A STEP - same "/:param" posting style as /post-form-data/:form_id
+func (h Adoption) SaveStep(ctx rweb.Context) error {
+	req := ctx.Request()
+	unlock := h.Locks.Lock(req.PathParam("id"))
+	defer unlock()
+
+	app, cat, found, err := h.loadApp(req.PathParam("id"))
+	if err != nil {
+		return err
+	}
+	step, ok := parseStep(req.PathParam("step"))
+	if !found || !ok {
+		return apperr.NotFound("notfound.application")
+	}
+	if !app.Editable() {
+		return ctx.Redirect(http.StatusSeeOther, pages.ApplicationPath(app.ID))
+	}
+
+	// forms.Parse gives us our own copy of the answers - they're stored, so they
+	// must not alias rweb's reusable request buffer (see forms.Form)
+	form, err := forms.Parse(req)
+	if err != nil {
+		// APPERR: a body we can't parse is the client's mistake - 400, not 500
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+
+	// METHOD VALUE: form.Value (without parentheses) is passed as a function
+	// It matches forms.ValueFunc's signature: func(string) string
+	parseErrs := app.ApplyStep(step.Number, form.Value)
+
+	// DRAFT SAVING: whatever was typed is kept, even if it doesn't validate yet
+	app.UpdatedAt = time.Now().UTC()
+	if err := h.Apps.Save(app); err != nil {
+		return err
+	}
+
+	page := pages.NewAdoptionFormPage(app, cat, step)
+
+	if form.Value("action") == "save" {
+		page.Notice = "adoption.notice.saved"
+		return renderPage(ctx, &page)
+	}
+
+	// SERVER-SIDE VALIDATION: never trust the browser - always re-check on the server
+	errs := app.ValidateStep(step.Number)
+	errs.Merge(parseErrs)
+	if len(errs) > 0 {
+		page.Errors = errs
+		// 422 Unprocessable Entity: the request was well-formed but the data is invalid
+		ctx.Response().SetStatus(http.StatusUnprocessableEntity)
+		return renderPage(ctx, &page)
+	}
+
+	// Not the last step yet? On to the next one
+	if step.Number < len(adoption.Steps) {
+		return ctx.Redirect(http.StatusSeeOther, pages.ApplicationStepPath(app.ID, step.Number+1))
+	}
+
+	// FINAL STEP: re-validate everything in case an earlier step was skipped
+	if badStep, errs := app.Validate(); badStep != 0 {
+		s, _ := adoption.StepByNumber(badStep)
+		page = pages.NewAdoptionFormPage(app, cat, s)
+		page.Errors = errs
+		ctx.Response().SetStatus(http.StatusUnprocessableEntity)
+		return renderPage(ctx, &page)
+	}
+
+	if err := app.Transition(adoption.StatusSubmitted, "applicant", ""); err != nil {
+		return err
+	}
+	if err := h.Apps.Save(app); err != nil {
+		return err
+	}
+	return ctx.Redirect(http.StatusSeeOther, pages.ApplicationPath(app.ID))
+}
+
+// Withdraw - the applicant changed their mind
+func (h Adoption) Withdraw(ctx rweb.Context) error {
+	unlock := h.Locks.Lock(ctx.Request().PathParam("id"))
+	defer unlock()
+
+	app, _, found, err := h.loadApp(ctx.Request().PathParam("id"))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return apperr.NotFound("notfound.application")
+	}
+	if err := app.Transition(adoption.StatusWithdrawn, "applicant", ""); err != nil {
+		// Already final - nothing to do, just show the current status
+		return ctx.Redirect(http.StatusSeeOther, pages.ApplicationPath(app.ID))
+	}
+	if err := h.Apps.Save(app); err != nil {
+		return err
+	}
+	return ctx.Redirect(http.StatusSeeOther, pages.ApplicationPath(app.ID))
+}
+
+// ===== STAFF ROUTES =====
+
+// AdminList lists applications with an optional ?status= filter
+func (h Adoption) AdminList(ctx rweb.Context) error {
+	filter := adoption.Status(ctx.Request().QueryParam("status"))
+	all, err := h.Apps.List()
+	if err != nil {
+		return err
+	}
+
+	page := pages.AdminApplications
+	page.Filter = filter
+	for _, a := range all {
+		if filter == "" || a.Status == filter {
+			page.Apps = append(page.Apps, a)
+		}
+	}
+	return renderPage(ctx, &page)
+}
+
+// AdminShow is one application with every answer, its history and the status form
+func (h Adoption) AdminShow(ctx rweb.Context) error {
+	app, cat, found, err := h.loadApp(ctx.Request().PathParam("id"))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return apperr.NotFound("notfound.application")
+	}
+	page := pages.NewAdminApplicationPage(app, cat)
+	return renderPage(ctx, &page)
+}
+
+// AdminSetStatus changes the status of one application
+func (h Adoption) AdminSetStatus(ctx rweb.Context) error {
+	req := ctx.Request()
+	unlock := h.Locks.Lock(req.PathParam("id"))
+	defer unlock()
+
+	app, cat, found, err := h.loadApp(req.PathParam("id"))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return apperr.NotFound("notfound.application")
+	}
+
+	form, err := forms.Parse(req)
+	if err != nil {
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+
+	from, to := app.Status, adoption.Status(form.Value("status"))
+	if slices.Contains(from.StaffNext(), to) {
+		err = app.Transition(to, auth.CurrentUser(ctx), form.Value("note"))
+	} else {
+		// Submitting is the applicant's move: it would skip Validate from here
+		err = adoption.TransitionError{From: from, To: to}
+	}
+
+	// ERRORS.AS extracts our custom error type so we can show a helpful message
+	var tErr adoption.TransitionError
+	if errors.As(err, &tErr) {
+		ctx.Response().SetStatus(http.StatusConflict)
+		page := pages.NewAdminApplicationPage(app, cat)
+		page.Error = tErr.Error()
+		return renderPage(ctx, &page)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := h.Apps.Save(app); err != nil {
+		return err
+	}
+	err = h.Audit.Record(audit.Entry{
+		Actor: auth.CurrentUser(ctx), Action: "application.status", Target: app.ID,
+		Changes: []audit.Change{{Field: "status", From: string(from), To: string(to)}},
+	})
+	if err != nil {
+		return err
+	}
+	// Back to the application, where the change now ends its history
+	return ctx.Redirect(http.StatusSeeOther, pages.AdminApplicationPath(app.ID))
+}
+
+// parseStep converts the ":step" path parameter into a known step
+func parseStep(raw string) (adoption.Step, bool) {
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return adoption.Step{}, false
+	}
+	return adoption.StepByNumber(n)
+}
+
+// FORM SCHEMAS for the API documentation
+
+// stepSchema describes what SaveStep accepts: one step's fields at a time
+// (only the step named in the URL is read), and which button was pressed
+func stepSchema() forms.Schema {
+	s := forms.Schema{{Name: "action", Enum: []string{"save", "next"}, Description: "save keeps a draft without checking it"}}
+	for _, step := range adoption.Steps {
+		for _, f := range adoption.StepSchema(step.Number) {
+			desc := fmt.Sprintf("step %d", step.Number)
+			if f.Required {
+				desc += ", needed to continue"
+			}
+			f.Description = strings.TrimSuffix(desc+". "+f.Description, " ")
+			f.Required = false // every step shares this body, so no one field is always sent
+			s = append(s, f)
+		}
+	}
+	return s
+}
+
+// statusSchema describes AdminSetStatus's form
+var statusSchema = forms.Schema{
+	{Name: "status", Required: true, Enum: applicationStatuses()},
+	{Name: "note", Description: "why, for the application's history"},
+}
+
+// applicationStatuses lists adoption.AllStatuses as plain strings
+func applicationStatuses() []string {
+	out := make([]string, len(adoption.AllStatuses))
+	for i, s := range adoption.AllStatuses {
+		out[i] = string(s)
+	}
+	return out
+}
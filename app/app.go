@@ -0,0 +1,460 @@
+// Package app is the cat adoption website itself: every route, its handler and
+// the middleware in front of them.
+//
+// main only reads configuration and opens the stores; it then hands both to
+// New and calls Register on a fresh rweb server:
+//
+//	a := app.New(cfg, app.Deps{Cats: catRepo, Apps: appStore, ...})
+//	a.Register(s)
+//
+// Handlers are methods on small NAMED TYPES (Site, Contact, Uploads, CatAdmin,
+// Adoption) that hold exactly the dependencies they use. Unlike closures inside
+// main(), they can be built directly in a test with fake or temporary stores.
+package app
+
+import (
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+
+	"form_exer/adoption"
+	"form_exer/assets"
+	"form_exer/audit"
+	"form_exer/auth"
+	"form_exer/cats"
+	"form_exer/cors"
+	"form_exer/crash"
+	"form_exer/events"
+	"form_exer/forms"
+	"form_exer/health"
+	"form_exer/i18n"
+	"form_exer/logging"
+	"form_exer/mail"
+	"form_exer/metrics"
+	"form_exer/openapi"
+	"form_exer/proxy"
+	"form_exer/security"
+	"form_exer/storage"
+	"form_exer/tracing"
+	"form_exer/web/pages"
+	"form_exer/web/theme"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Config holds the settings that change between deployments
+type Config struct {
+	// AdminUsers are the staff accounts for the admin pages; none means the admin pages refuse everyone
+	AdminUsers auth.Users
+
+	// AdminRealm is the name shown in the browser's login prompt
+	AdminRealm string
+
+	// ContactTo is where contact form messages are mailed
+	ContactTo string
+
+	// Logger receives application logs; each request gets a copy tagged with its ID
+	// nil means slog.Default()
+	Logger *slog.Logger
+
+	// AccessLog receives one line per request (nil turns the access log off)
+	AccessLog    io.Writer
+	AccessFormat logging.AccessFormat
+
+	// Tracer records a span per request, with child spans for storage, mail and
+	// rendering (nil turns tracing off)
+	Tracer *tracing.Tracer
+
+	// CrashDir receives a report file for every panic in a handler
+	// ("" still recovers panics, but writes no reports)
+	CrashDir string
+
+	// Security holds the security headers (CSP and friends) for each route group
+	// A missing GroupPages entry means security.DefaultPolicy() reporting to
+	// /csp-report; a missing GroupAdmin entry means the pages policy, sending
+	// no Referer at all
+	Security map[string]security.Policy
+
+	// CORS lets other origins call the routes of a group, e.g. a separate
+	// frontend calling the GroupAPI routes; groups without an entry are
+	// same-origin only
+	CORS map[string]cors.Policy
+}
+
+// ROUTE GROUPS: routes in a group share settings such as the security headers
+const (
+	GroupPages = "pages" // everything public, and error pages
+	GroupAdmin = "admin" // the staff pages
+	GroupAPI   = "api"   // endpoints scripts call, possibly from other origins
+)
+
+// CSPReportPath is where browsers post Content-Security-Policy violations
+const CSPReportPath = "/csp-report"
+
+// Deps are the stores and services the handlers use
+// Passing them in (DEPENDENCY INJECTION) instead of opening files here lets tests
+// hand in temporary directories or in-memory fakes
+type Deps struct {
+	Cats    cats.Repository
+	Apps    adoption.Store
+	Uploads *storage.Store
+	Audit   audit.Log
+	Mailer  mail.Sender
+
+	// Health serves /healthz and /readyz with the checks main registered on it;
+	// nil means no checks (readiness then only reflects shutdown)
+	Health *health.Health
+
+	// Events carries live notifications to the staff pages (see events.Path);
+	// nil makes a private broker. main closes it when shutting down
+	Events *events.Broker
+
+	// Proxies forward their path prefixes to pools of other servers (see
+	// proxyRoutes); main closes them when shutting down
+	Proxies []*proxy.Proxy
+}
+
+// App is the configured website, ready to be registered on a server
+type App struct {
+	cfg  Config
+	deps Deps
+
+	// METRICS: everything /metrics reports is registered on this registry
+	registry    *metrics.Registry
+	httpMetrics *metrics.HTTPMetrics
+	stats       *siteMetrics
+
+	// NAMED HANDLER TYPES, each holding only what it needs
+	site     Site
+	contact  Contact
+	uploads  Uploads
+	catAdmin CatAdmin
+	adoption Adoption
+}
+
+// New builds the handlers from the configuration and dependencies
+func New(cfg Config, deps Deps) *App {
+	if cfg.AdminRealm == "" {
+		cfg.AdminRealm = "Cat Adoption Staff"
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if deps.Health == nil {
+		deps.Health = health.New()
+	}
+	if deps.Events == nil {
+		deps.Events = events.New(events.Options{})
+	}
+	// maps.Clone: filling in defaults must not change the caller's map
+	cfg.Security = maps.Clone(cfg.Security)
+	if cfg.Security == nil {
+		cfg.Security = map[string]security.Policy{}
+	}
+	if _, ok := cfg.Security[GroupPages]; !ok {
+		p := security.DefaultPolicy()
+		p.ReportURI = CSPReportPath
+		cfg.Security[GroupPages] = p
+	}
+	if _, ok := cfg.Security[GroupAdmin]; !ok {
+		p := cfg.Security[GroupPages]
+		p.ReferrerPolicy = "no-referrer" // staff URLs name applicants; don't leak them to other sites
+		cfg.Security[GroupAdmin] = p
+	}
+	if _, ok := cfg.Security[GroupAPI]; !ok {
+		cfg.Security[GroupAPI] = cfg.Security[GroupPages]
+	}
+
+	// Each App has its own registry, so tests can build many Apps side by side
+	registry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	stats := newSiteMetrics(registry)
+	metrics.RegisterRuntime(registry)
+
+	return &App{
+		cfg:         cfg,
+		deps:        deps,
+		registry:    registry,
+		httpMetrics: httpMetrics,
+		stats:       stats,
+		site:        Site{Cats: deps.Cats},
+		contact:     Contact{Mailer: deps.Mailer, To: cfg.ContactTo, Metrics: stats, Events: deps.Events},
+		uploads:     Uploads{Store: deps.Uploads, Metrics: stats, Events: deps.Events, Progress: events.NewTracker(0)},
+		catAdmin: CatAdmin{
+			Cats: deps.Cats,
+			// Photos must be images, whatever the general upload rules are
+			Photos:  deps.Uploads.WithOptions(storage.Options{MaxBytes: maxPhotoBytes, AllowedTypes: []string{"image/"}}),
+			Audit:   deps.Audit,
+			Metrics: stats,
+			Events:  deps.Events,
+		},
+		adoption: Adoption{Cats: deps.Cats, Apps: deps.Apps, Audit: deps.Audit, Locks: new(adoption.Locks)},
+	}
+}
+
+// Route is one entry in the ROUTE TABLE
+type Route struct {
+	Method  string
+	Path    string
+	Handler rweb.Handler
+
+	// Admin routes are staff only - Register wraps them with auth.Require
+	Admin bool
+
+	// Localized routes are also served under each locale prefix, e.g. "/cats"
+	// at "/es/cats", so a link can name a language (see i18n.Middleware)
+	Localized bool
+
+	// API routes are called by scripts rather than visited; other origins may
+	// call them when Config.CORS has a GroupAPI policy
+	API bool
+
+	// DOCUMENTATION for /openapi.json and /docs (see docs.go); none of it
+	// changes how the route is served
+	Summary  string       // one line saying what the route does
+	Query    forms.Schema // query string parameters
+	Form     forms.Schema // fields of the posted form
+	Reply    any          // a value of the JSON reply's type, if the route can answer with JSON
+	Produces string       // media type of a reply that isn't an HTML page or JSON
+	Status   int          // success status, when it isn't 200 (e.g. 303 after a form post)
+
+	// Fragments are the parts of the page the route can send on their own,
+	// by name (see shared.FragmentHeader); Register refuses any other
+	Fragments []string
+}
+
+// Group names the route group r belongs to
+func (r Route) Group() string {
+	switch {
+	case r.Admin:
+		return GroupAdmin
+	case r.API:
+		return GroupAPI
+	}
+	return GroupPages
+}
+
+// Routes lists every route the site serves
+// A TABLE instead of a long run of s.Get/s.Post calls can be read at a glance,
+// and tests can check it without starting a server
+// METHOD VALUES: a.site.Home is a func(rweb.Context) error bound to a.site
+func (a *App) Routes() []Route {
+	const get, post = http.MethodGet, http.MethodPost
+
+	routes := []Route{
+		// ===== PUBLIC PAGES =====
+		{Method: get, Path: "/", Handler: a.site.Home, Localized: true,
+			Summary: "Home page with featured cats", Fragments: []string{pages.FragmentCatGrid}},
+		{Method: get, Path: "/contact", Handler: a.contact.Form, Localized: true,
+			Summary: "Contact form", Fragments: []string{pages.FragmentContactForm}},
+		{Method: post, Path: "/contact", Handler: a.contact.Submit,
+			Summary: "Send a message to the shelter", Form: contactSchema, Reply: contactResult{}},
+		{Method: get, Path: pages.CatListPath, Handler: a.site.CatList, Localized: true,
+			Summary: "Search the cats up for adoption", Query: cats.QuerySchema,
+			Fragments: []string{pages.FragmentCatResults}},
+		{Method: get, Path: "/cats/:slug", Handler: a.site.CatDetail, Localized: true,
+			Summary: "One cat's profile"},
+
+		// ===== ADOPTION APPLICATIONS =====
+		{Method: post, Path: "/cats/:slug/adopt", Handler: a.adoption.Start,
+			Summary: "Start an adoption application for a cat", Status: http.StatusSeeOther},
+		{Method: get, Path: "/applications/:id", Handler: a.adoption.Status,
+			Summary: "An application's status"},
+		{Method: get, Path: "/applications/:id/step/:step", Handler: a.adoption.ShowStep,
+			Summary: "One step of the application form"},
+		{Method: post, Path: "/applications/:id/step/:step", Handler: a.adoption.SaveStep,
+			Summary: "Save a step, then show the next one or submit", Form: stepSchema(), Status: http.StatusSeeOther},
+		{Method: post, Path: "/applications/:id/withdraw", Handler: a.adoption.Withdraw,
+			Summary: "Withdraw an application", Status: http.StatusSeeOther},
+
+		// ===== STAFF: APPLICATION REVIEW =====
+		{Method: get, Path: pages.AdminApplicationsPath, Handler: a.adoption.AdminList, Admin: true,
+			Summary: "Applications to review", Query: forms.Schema{{Name: "status", Enum: applicationStatuses()}}},
+		{Method: get, Path: "/admin/applications/:id", Handler: a.adoption.AdminShow, Admin: true,
+			Summary: "One application's answers and history, to decide on"},
+		{Method: post, Path: "/admin/applications/:id/status", Handler: a.adoption.AdminSetStatus, Admin: true,
+			Summary: "Move an application through the workflow", Form: statusSchema, Status: http.StatusSeeOther},
+
+		// ===== STAFF: CAT MANAGEMENT =====
+		{Method: get, Path: pages.AdminCatsPath, Handler: a.catAdmin.List, Admin: true,
+			Summary: "Every cat, including retired ones"},
+		{Method: get, Path: pages.AdminNewCatPath, Handler: a.catAdmin.New, Admin: true,
+			Summary: "Form for a new cat"},
+		{Method: post, Path: pages.AdminCatsPath, Handler: a.catAdmin.Create, Admin: true,
+			Summary: "Add a cat", Form: cats.FormSchema, Status: http.StatusSeeOther},
+		{Method: get, Path: "/admin/cats/:slug/edit", Handler: a.catAdmin.Edit, Admin: true,
+			Summary: "Form for editing a cat"},
+		{Method: post, Path: "/admin/cats/:slug/edit", Handler: a.catAdmin.Update, Admin: true,
+			Summary: "Save changes to a cat", Form: cats.FormSchema, Status: http.StatusSeeOther},
+		{Method: post, Path: "/admin/cats/:slug/retire", Handler: a.catAdmin.Retire, Admin: true,
+			Summary: "Retire a cat from the listing", Status: http.StatusSeeOther},
+		{Method: post, Path: "/admin/cats/:slug/delete", Handler: a.catAdmin.Delete, Admin: true,
+			Summary: "Delete a cat", Status: http.StatusSeeOther},
+		{Method: post, Path: "/admin/cats/:slug/photos", Handler: a.catAdmin.AddPhoto, Admin: true,
+			Summary: "Add a photo of a cat", Form: photoSchema, Status: http.StatusSeeOther},
+		{Method: post, Path: "/admin/cats/:slug/photos/:index/delete", Handler: a.catAdmin.DeletePhoto, Admin: true,
+			Summary: "Delete a photo of a cat", Status: http.StatusSeeOther},
+		{Method: get, Path: pages.AdminAuditPath, Handler: a.catAdmin.AuditLog, Admin: true,
+			Summary: "Who changed which cat, and when"},
+
+		// ===== STAFF: LIVE NOTIFICATIONS =====
+		// SERVER-SENT EVENTS: new messages, uploads and cat status changes, as
+		// they happen; each staff member also gets the events addressed to them
+		{Method: get, Path: events.Path, Handler: a.deps.Events.Handler(auth.CurrentUser), Admin: true,
+			Summary: "Live notifications (Server-Sent Events)", Query: events.QuerySchema, Produces: events.ContentType},
+
+		// ===== FILES AND DEMOS =====
+		{Method: get, Path: "/uploads/:name", Handler: a.uploads.Serve,
+			Summary: "An uploaded file", Produces: "application/octet-stream"},
+		{Method: post, Path: "/upload", Handler: a.uploads.Upload, API: true,
+			Summary: "Upload a file", Form: uploadSchema, Reply: storage.File{},
+			Query: forms.Schema{{Name: events.UploadIDParam, Description: "report progress to /upload/progress/{id}; 8-64 letters, digits, - or _"}}},
+		{Method: get, Path: events.ProgressPath, Handler: a.uploads.Progress.Handler, API: true,
+			Summary: "Progress of one upload (Server-Sent Events)", Produces: events.ContentType},
+		{Method: post, Path: "/post-form-data/:form_id", Handler: PostFormData, API: true,
+			Summary: "Echo a posted form", Form: postedFormSchema, Reply: postedForm{}, Produces: "text/plain"},
+
+		// GENERATED STYLESHEET: the theme's design tokens rendered as CSS classes
+		// e.g. /theme.css?name=dark - see web/theme for the tokens and rules
+		{Method: get, Path: theme.StylesheetPath, Handler: theme.ServeStylesheet,
+			Summary: "The site's stylesheet for a theme", Produces: "text/css",
+			Query: forms.Schema{{Name: "name", Description: "theme name; default light"}}},
+
+		// STATIC ASSETS embedded in the binary, e.g. the progressive-enhancement script
+		{Method: get, Path: assets.Route, Handler: assets.Serve,
+			Summary: "Scripts, styles and images the pages use", Produces: "application/octet-stream"},
+
+		// API DOCUMENTATION, generated from this table (see docs.go)
+		{Method: get, Path: pages.OpenAPIPath, Handler: a.serveOpenAPI,
+			Summary: "This API as an OpenAPI document", Produces: openapi.JSON},
+		{Method: get, Path: pages.APIDocsPath, Handler: a.serveAPIDocs,
+			Summary: "This API as a web page"},
+
+		// ===== OPERATIONS =====
+		// PROMETHEUS METRICS: request counts and latencies, uploads, contact
+		// submissions and Go runtime stats, for a monitoring server to scrape
+		{Method: get, Path: "/metrics", Handler: metrics.Handler(a.registry),
+			Summary: "Prometheus metrics", Produces: "text/plain"},
+
+		// HEALTH PROBES for the load balancer: alive, and ready for traffic
+		{Method: get, Path: "/healthz", Handler: a.deps.Health.Live,
+			Summary: "Liveness probe", Reply: map[string]string{}},
+		{Method: get, Path: "/readyz", Handler: a.deps.Health.Ready,
+			Summary: "Readiness probe; 503 while a check fails or during shutdown", Reply: health.Report{}},
+
+		// CSP VIOLATION REPORTS posted by browsers (see security.ReportHandler)
+		{Method: post, Path: CSPReportPath, Handler: security.ReportHandler(a.recordViolation),
+			Summary: "Content-Security-Policy violation reports from browsers", Status: http.StatusNoContent},
+	}
+
+	// ===== REVERSE PROXY =====
+	// Whole path prefixes served by other servers (see proxy.go)
+	return append(routes, a.proxyRoutes()...)
+}
+
+// Register installs the middleware and every route on s
+func (a *App) Register(s *rweb.Server) {
+	// METHOD CALL: Calling the Use() method on the server instance
+	// Use() registers middleware that runs before route handlers, in the order registered
+
+	// EVENT STREAMS leave rweb's pooled request context in a state the next
+	// request must not inherit; this has to run before anything else (see events.ResetContext)
+	s.Use(events.ResetContext)
+
+	// REQUEST IDS AND LOGGING come next, so everything after can log with
+	// logging.FromContext(ctx) and every line names the request it belongs to
+	s.Use(logging.Middleware(a.cfg.Logger))
+
+	// METRICS: counts and times every request (see the Route wrapping below)
+	s.Use(a.httpMetrics.Middleware)
+
+	// TRACING: a span per request, joining the caller's trace when there is one
+	if a.cfg.Tracer != nil {
+		s.Use(tracing.Middleware(a.cfg.Tracer))
+	}
+
+	// ACCESS LOG: one line per request, written once the response is ready
+	if a.cfg.AccessLog != nil {
+		s.Use(logging.AccessLog(a.cfg.AccessLog, a.cfg.AccessFormat))
+	}
+
+	// SECURITY HEADERS: a fresh CSP nonce and the public pages' policy for every
+	// response; routes in other groups replace the policy below
+	s.Use(security.Middleware(a.cfg.Security[GroupPages]))
+
+	// THEME SELECTION: picks light/dark/auto from ?theme= or the theme cookie
+	// for every request; pages read it back through renderPage (render.go)
+	s.Use(theme.Middleware)
+
+	// LANGUAGE SELECTION: picks the locale from a URL prefix (/es/cats), ?lang=,
+	// the lang cookie or the browser's Accept-Language header, in that order
+	s.Use(i18n.Middleware(i18n.Default))
+
+	// ERRORS: last, so it sees each handler's error first and can render the
+	// error page with the theme and language chosen above (see errors.go)
+	s.Use(handleErrors)
+
+	// PANIC RECOVERY: right after the error handler, so a panicking handler
+	// becomes a *crash.PanicError and gets the same 500 page as any other failure
+	s.Use(crash.Middleware(crash.Options{
+		Dir:     a.cfg.CrashDir,
+		OnPanic: func(r crash.Report) { a.stats.panicked(r.Route) },
+	}))
+
+	// STAFF ROUTES are wrapped one by one rather than grouped - see auth.Require for why
+	admin := auth.Require(a.cfg.AdminUsers, a.cfg.AdminRealm)
+
+	// PREFLIGHTS: each path open to other origins also needs an OPTIONS route,
+	// which has to know every method the path serves
+	type preflight struct {
+		policy  cors.Policy
+		methods []string
+	}
+	preflights := map[string]*preflight{}
+	var preflightPaths []string // map iteration order is random; keep registration order
+
+	for _, r := range a.Routes() {
+		h := withFragments(r.Fragments, r.Handler)
+		if r.Admin {
+			h = admin(h)
+		}
+		if g := r.Group(); g != GroupPages {
+			h = security.Route(a.cfg.Security[g], h)
+		}
+		if policy, ok := a.cfg.CORS[r.Group()]; ok {
+			h = cors.Route(policy, h)
+			if preflights[r.Path] == nil {
+				preflights[r.Path] = &preflight{policy: policy}
+				preflightPaths = append(preflightPaths, r.Path)
+			}
+			preflights[r.Path].methods = append(preflights[r.Path].methods, r.Method)
+		}
+		// Outermost, so even refused admin requests are labeled with their route
+		h = metrics.Route(r.Path, h)
+		paths := []string{r.Path}
+		if r.Localized {
+			paths = i18n.Default.LocalizedPaths(r.Path)
+		}
+		for _, p := range paths {
+			// AddMethod is what s.Get, s.Post, ... call underneath
+			s.AddMethod(r.Method, p, h)
+		}
+	}
+	for _, path := range preflightPaths {
+		p := preflights[path]
+		s.AddMethod(http.MethodOptions, path, metrics.Route(path, cors.Preflight(p.policy, p.methods)))
+	}
+
+	// STATIC FILE SERVING
+	// StaticFiles() serves files from the filesystem, relative to the working directory
+	// Parameters: (URL prefix, filesystem path, segments to strip)
+	// Example: Request to "/.well-known/some-file.txt" → serves ./.well-known/some-file.txt
+	s.StaticFiles("/.well-known/", "/", 0)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DEPENDENCY INJECTION - Stores and services come in through Deps
+// 2. ROUTE TABLES - Data describing routes, registered by one loop
+// 3. METHOD VALUES - a.site.Home is usable anywhere an rweb.Handler is
+// 4. HANDLER WRAPPING - Admin routes pass through auth.Require, every route through metrics.Route
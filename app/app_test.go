@@ -0,0 +1,181 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"form_exer/adoption"
+	"form_exer/audit"
+	"form_exer/auth"
+	"form_exer/cats"
+	"form_exer/events"
+	"form_exer/mail"
+	"form_exer/storage"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// newTestApp builds the app on temporary stores and registers it on a server that
+// is never started: s.Request runs a request through middleware and routing in memory
+func newTestApp(t *testing.T) (*App, *rweb.Server) {
+	t.Helper()
+	dir := t.TempDir()
+
+	catRepo, err := cats.NewFileRepository(filepath.Join(dir, "cats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []cats.Cat{
+		{Slug: "luna", Name: "Luna", AgeMonths: 48, Status: cats.StatusAvailable},
+		{Slug: "ghost", Name: "Ghost", AgeMonths: 120, Status: cats.StatusRetired},
+	} {
+		if err := catRepo.Save(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	apps, err := adoption.NewFileStore(filepath.Join(dir, "applications.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads, err := storage.New(filepath.Join(dir, "uploads"), "/uploads/", storage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.NewFileLog(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(
+		Config{
+			AdminUsers: auth.Users{"sam": "whiskers"},
+			ContactTo:  "staff@example.com",
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		},
+		Deps{Cats: catRepo, Apps: apps, Uploads: uploads, Audit: auditLog, Mailer: &mail.Outbox{}},
+	)
+	s := rweb.NewServer()
+	a.Register(s)
+	return a, s
+}
+
+// get performs an in-memory GET; headers are name/value pairs
+func get(s *rweb.Server, path string, headers ...string) rweb.Response {
+	var hs []rweb.Header
+	for i := 0; i+1 < len(headers); i += 2 {
+		hs = append(hs, rweb.Header{Key: headers[i], Value: headers[i+1]})
+	}
+	return s.Request(http.MethodGet, path, hs, nil)
+}
+
+func TestRoutesAreUnique(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	seen := map[string]bool{}
+	for _, r := range a.Routes() {
+		key := r.Method + " " + r.Path
+		if seen[key] {
+			t.Errorf("route %s is registered twice", key)
+		}
+		seen[key] = true
+		if r.Handler == nil {
+			t.Errorf("route %s has no handler", key)
+		}
+	}
+}
+
+// Every /admin page must be behind the login, and nothing else should be -
+// except the staff's event stream, which lives at the conventional /events
+func TestAdminRoutesAreMarked(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	for _, r := range a.Routes() {
+		staff := strings.HasPrefix(r.Path, "/admin") || r.Path == events.Path
+		if staff != r.Admin {
+			t.Errorf("%s %s: Admin = %v", r.Method, r.Path, r.Admin)
+		}
+	}
+}
+
+// Register really wraps the admin routes: without credentials each one answers 401
+func TestAdminRoutesRequireLogin(t *testing.T) {
+	a, s := newTestApp(t)
+
+	for _, r := range a.Routes() {
+		if !r.Admin {
+			continue
+		}
+		// Fill in route parameters with anything - the guard runs before the handler looks
+		path := strings.NewReplacer(":slug", "luna", ":id", "x", ":index", "0").Replace(r.Path)
+		resp := s.Request(r.Method, path, nil, nil)
+		if resp.Status() != http.StatusUnauthorized {
+			t.Errorf("%s %s without login: status %d, want 401", r.Method, path, resp.Status())
+		}
+	}
+}
+
+func TestHome(t *testing.T) {
+	_, s := newTestApp(t)
+
+	resp := get(s, "/")
+	if resp.Status() != http.StatusOK {
+		t.Fatalf("status %d", resp.Status())
+	}
+	body := string(resp.Body())
+	if !strings.Contains(body, "Luna") || strings.Contains(body, "Ghost") {
+		t.Error("home page should list available cats only")
+	}
+}
+
+// Localized routes answer under every locale prefix
+func TestLocalizedRoutes(t *testing.T) {
+	a, s := newTestApp(t)
+
+	for _, r := range a.Routes() {
+		if !r.Localized {
+			continue
+		}
+		path := strings.Replace(r.Path, ":slug", "luna", 1)
+		resp := get(s, "/es"+strings.TrimSuffix(path, "/"))
+		if resp.Status() != http.StatusOK {
+			t.Errorf("/es%s: status %d", path, resp.Status())
+		}
+		if lang := resp.Header("Content-Language"); lang != "es" {
+			t.Errorf("/es%s: Content-Language %q", path, lang)
+		}
+	}
+}
+
+func TestCatDetail(t *testing.T) {
+	_, s := newTestApp(t)
+
+	if resp := get(s, "/cats/luna"); resp.Status() != http.StatusOK {
+		t.Errorf("/cats/luna: status %d", resp.Status())
+	}
+	resp := get(s, "/cats/nobody")
+	if resp.Status() != http.StatusNotFound {
+		t.Errorf("/cats/nobody: status %d, want 404", resp.Status())
+	}
+	if !strings.Contains(string(resp.Body()), "<html") {
+		t.Error("404 should render the full not-found page")
+	}
+}
+
+func TestCatListHidesRetired(t *testing.T) {
+	_, s := newTestApp(t)
+
+	body := string(get(s, "/cats?status=retired").Body())
+	if strings.Contains(body, "Ghost") {
+		t.Error("retired cats must never be listed publicly")
+	}
+	for _, path := range []string{"/cats/ghost", "/es/cats/ghost"} {
+		resp := get(s, path)
+		if resp.Status() != http.StatusNotFound || strings.Contains(string(resp.Body()), "Ghost") {
+			t.Errorf("%s: status %d, want a 404 that doesn't name the cat", path, resp.Status())
+		}
+	}
+}
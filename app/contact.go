@@ -0,0 +1,166 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"form_exer/apperr"
+	"form_exer/events"
+	"form_exer/forms"
+	"form_exer/mail"
+	"form_exer/security"
+	"form_exer/tracing"
+	"form_exer/web/pages"
+
+	"github.com/rohanthewiz/element"
+	"github.com/rohanthewiz/rweb"
+)
+
+// Contact shows the contact form and mails what visitors send to the staff
+type Contact struct {
+	Mailer  mail.Sender
+	To      string         // staff address; "" turns mailing off
+	Metrics *siteMetrics   // may be nil
+	Events  *events.Broker // live notifications for the staff; may be nil
+}
+
+// Form shows the contact page
+func (h Contact) Form(ctx rweb.Context) error {
+	ctx.Response().SetHeader("Content-Type", "text/html; charset=utf-8")
+	page := pages.Contact // copy the singleton, then render the copy with this visitor's settings
+	return renderPage(ctx, &page)
+}
+
+// contactMessage is what the contact form sends, however it was encoded
+// The JSON tags give API clients the same field names as the HTML form
+type contactMessage struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Message string `json:"message"`
+}
+
+// contactSchema describes the contact form for the API documentation;
+// the Required flags mirror validate
+var contactSchema = forms.Schema{
+	{Name: "name", Required: true},
+	{Name: "email", Required: true, Description: "where the reply goes"},
+	{Name: "message", Required: true},
+}
+
+// validate applies the contact form's rules; messages are i18n keys
+// A message staff can't reply to, or with nothing in it, is rejected
+func (m contactMessage) validate() forms.FieldErrors {
+	errs := forms.FieldErrors{}
+	if strings.TrimSpace(m.Name) == "" {
+		errs.Add("name", "error.name_required")
+	}
+	if strings.TrimSpace(m.Email) == "" {
+		errs.Add("email", "error.email_required")
+	} else if !strings.Contains(m.Email, "@") {
+		errs.Add("email", "error.email_invalid")
+	}
+	if strings.TrimSpace(m.Message) == "" {
+		errs.Add("message", "error.message_required")
+	}
+	return errs
+}
+
+// contactResult is the JSON reply to an accepted message
+// EMBEDDED STRUCT: encoding/json flattens its fields into the same object:
+//
+//	{"status":"accepted","name":"Ann","email":"ann@example.com","message":"Hi"}
+type contactResult struct {
+	Status string `json:"status"`
+	contactMessage
+}
+
+// Submit handles the form data from the contact page
+//
+// CONTENT NEGOTIATION in both directions: the body may be urlencoded,
+// multipart or JSON (forms.Parse reads all three into the same values), and
+// the reply is an HTML page or JSON depending on the Accept header
+func (h Contact) Submit(ctx rweb.Context) error {
+	// VARY: the reply depends on Accept, so a cache must not hand a page to a
+	// client that asked for JSON (addVary keeps the Origin that cors.Route set)
+	addVary(ctx.Response(), "Accept")
+
+	// forms.Parse copies every value out of rweb's reusable request buffer, so
+	// the message may outlive this request (e.g. in a queue or a test's Outbox)
+	form, err := forms.Parse(ctx.Request())
+	if err != nil {
+		h.Metrics.contactSubmission("rejected")
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+	msg := contactMessage{Name: form.Value("name"), Email: form.Value("email"), Message: form.Value("message")}
+
+	// VALIDATION: the same rules whichever way the data arrived
+	// (and counted, so a spike of rejects shows up on the metrics dashboard)
+	if errs := msg.validate(); len(errs) > 0 {
+		h.Metrics.contactSubmission("rejected")
+		if wantsJSON(ctx) {
+			// problem+json with an "errors" member, from the central error handler
+			return apperr.Invalid(errs)
+		}
+		ctx.Response().SetStatus(http.StatusBadRequest)
+		return ctx.WriteHTML(contactReply(security.Nonce(ctx), "Please fill in your name, a valid email address and a message."))
+	}
+
+	// Mail the staff; Reply-To means they can answer the visitor directly
+	if h.To != "" {
+		// CHILD SPAN: the mail server's response time shows in the request's trace
+		span := tracing.Start(ctx, "mail.send")
+		err := h.Mailer.Send(mail.Message{
+			To:      h.To,
+			ReplyTo: msg.Email,
+			Subject: "Contact form: " + msg.Name,
+			Body:    msg.Message,
+		})
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			h.Metrics.contactSubmission("failed")
+			return fmt.Errorf("mailing contact message: %w", err)
+		}
+	}
+	h.Metrics.contactSubmission("accepted")
+	// STRUCT CONVERSION: ContactNotice has the same fields as contactMessage
+	notify(ctx, h.Events, events.ContactMessage, "", events.ContactNotice(msg))
+
+	if wantsJSON(ctx) {
+		return ctx.WriteJSON(contactResult{Status: "accepted", contactMessage: msg})
+	}
+
+	// ESCAPING: the builder writes text as it is, and these are the visitor's own words
+	outStr := fmt.Sprintf("Posted - name: %s, email: %s, message: %s",
+		html.EscapeString(msg.Name), html.EscapeString(msg.Email), html.EscapeString(msg.Message))
+	return ctx.WriteHTML(contactReply(security.Nonce(ctx), outStr))
+}
+
+// contactReply is the page shown after a submission, with msg as its text
+// nonce is the request's CSP nonce, which lets the inline <style> through
+func contactReply(nonce, msg string) string {
+	// FLUENT API / METHOD CHAINING: Building HTML dynamically
+	// element.NewBuilder() creates a new HTML builder
+	b := element.NewBuilder()
+
+	// INLINE STYLES UNDER A CSP: style="..." attributes are blocked outright,
+	// but a <style> tag carrying the response's nonce is allowed
+	b.Style("nonce", nonce).T("body{background-color:darkgreen} h1{color:maroon;background-color:#dfc673}")
+
+	// METHOD CHAINING with VARIADIC FUNCTIONS
+	// Body() creates a <body> tag
+	// R() is a variadic function - it accepts any number of arguments (components)
+	// Each method returns the builder, allowing us to chain calls
+	b.Body().R(
+		// H1() creates an <h1> tag, T() adds text content
+		b.H1().T("Welcome"),
+		b.Hr(),       // Hr() creates an <hr> horizontal rule tag
+		b.P().T(msg), // P() creates a <p> paragraph tag
+	)
+
+	// String() converts the builder to an HTML string
+	return b.String()
+}
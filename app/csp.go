@@ -0,0 +1,48 @@
+package app
+
+import (
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/logging"
+	"form_exer/security"
+)
+
+// recordViolation logs and counts a CSP violation a browser reported
+// A burst of them right after a deploy usually means the policy blocks
+// something the site really uses; a steady trickle is often browser extensions
+func (a *App) recordViolation(ctx rweb.Context, v security.Violation) {
+	logging.FromContext(ctx).Warn("csp violation",
+		"directive", v.EffectiveDirective,
+		"blocked", v.BlockedURI,
+		"document", v.DocumentURI,
+		"source", v.SourceFile,
+		"line", v.LineNumber,
+		"disposition", v.Disposition,
+	)
+	a.stats.cspViolation(labelOrOther(v.EffectiveDirective, knownDirectives), labelOrOther(v.Disposition, dispositions))
+}
+
+// Reports are posted by anyone, so label values come only from these sets:
+// arbitrary strings as labels would create a new time series per value
+var (
+	knownDirectives = map[string]bool{
+		"default-src": true, "script-src": true, "script-src-elem": true, "script-src-attr": true,
+		"style-src": true, "style-src-elem": true, "style-src-attr": true, "img-src": true,
+		"font-src": true, "connect-src": true, "media-src": true, "object-src": true,
+		"frame-src": true, "worker-src": true, "manifest-src": true, "base-uri": true,
+		"form-action": true, "frame-ancestors": true,
+	}
+	dispositions = map[string]bool{"enforce": true, "report": true}
+)
+
+// labelOrOther returns v if it is in known, "other" otherwise
+func labelOrOther(v string, known map[string]bool) string {
+	if known[v] {
+		return v
+	}
+	return "other"
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. UNTRUSTED INPUT IN METRICS - a fixed set of label values keeps cardinality bounded
+// 2. MAPS AS SETS - map[string]bool answers "is it one of these?"
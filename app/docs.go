@@ -0,0 +1,85 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+	"form_exer/forms"
+	"form_exer/i18n"
+	"form_exer/openapi"
+	"form_exer/web/pages"
+	"form_exer/web/shared"
+)
+
+// apiInfo heads the generated document
+var apiInfo = openapi.Info{
+	Title:   "Cat Adoption",
+	Version: "1.0",
+	Description: "Every route the site serves. Pages answer with HTML; routes with a JSON " +
+		"reply send it when the Accept header asks for application/json, and forms may " +
+		"be posted as JSON too.",
+}
+
+// OpenAPI describes every route as an OpenAPI document
+//
+// GENERATED FROM THE ROUTE TABLE: the same Routes() that Register installs,
+// so a new route is documented the moment it exists - a missing Summary
+// is the only way to leave it half described (app_test.go checks for that)
+func (a *App) OpenAPI() openapi.Document {
+	var routes []openapi.Route
+	preflight := map[string]bool{}
+
+	for _, r := range a.Routes() {
+		or := openapi.Route{
+			Method:    r.Method,
+			Path:      r.Path,
+			Summary:   r.Summary,
+			Tags:      []string{r.Group()},
+			Query:     r.Query,
+			Form:      r.Form,
+			Reply:     r.Reply,
+			Produces:  r.Produces,
+			Status:    r.Status,
+			BasicAuth: r.Admin,
+		}
+		if len(r.Fragments) > 0 {
+			or.Headers = forms.Schema{{Name: shared.FragmentHeader, Enum: r.Fragments,
+				Description: "send only this part of the page, as an HTML fragment"}}
+		}
+		if r.Localized {
+			or.AlsoAt = i18n.Default.LocalizedPaths(r.Path)[1:] // [0] is r.Path itself
+		}
+		routes = append(routes, or)
+
+		// The OPTIONS routes Register adds for paths open to other origins
+		if _, ok := a.cfg.CORS[r.Group()]; ok && !preflight[r.Path] {
+			preflight[r.Path] = true
+			routes = append(routes, openapi.Route{
+				Method:  http.MethodOptions,
+				Path:    r.Path,
+				Summary: "CORS preflight",
+				Tags:    []string{r.Group()},
+				Status:  http.StatusNoContent,
+			})
+		}
+	}
+	return openapi.Generate(apiInfo, routes, openapi.SchemaOf(apperr.Problem{}))
+}
+
+// serveOpenAPI answers /openapi.json, for tools: client generators, API explorers
+func (a *App) serveOpenAPI(ctx rweb.Context) error {
+	return ctx.WriteJSON(a.OpenAPI())
+}
+
+// serveAPIDocs answers /docs, the same document for people
+func (a *App) serveAPIDocs(ctx rweb.Context) error {
+	page := pages.APIDocs
+	page.Doc = a.OpenAPI()
+	return renderPage(ctx, &page)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DOCUMENTATION AS DATA - the route table feeds both the router and the docs
+// 2. ADAPTERS - app.Route is translated into the generator's own openapi.Route
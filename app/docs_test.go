@@ -0,0 +1,75 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"form_exer/cors"
+	"form_exer/openapi"
+)
+
+// Every route is in the document, with something to say about it
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	a, _ := newTestApp(t)
+	doc := a.OpenAPI()
+
+	for _, r := range a.Routes() {
+		if r.Summary == "" {
+			t.Errorf("%s %s has no Summary", r.Method, r.Path)
+		}
+		path := r.Path
+		for _, seg := range strings.Split(path, "/") {
+			if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+				path = strings.Replace(path, seg, "{"+seg[1:]+"}", 1)
+			}
+		}
+		item := doc.Paths[path]
+		if item == nil {
+			t.Errorf("%s is missing from the document", path)
+			continue
+		}
+		found := false
+		for _, mo := range item.Operations() {
+			found = found || mo.Method == r.Method
+		}
+		if !found {
+			t.Errorf("%s %s is missing from the document", r.Method, path)
+		}
+	}
+}
+
+// Preflight routes only exist when CORS is configured, and are documented then
+func TestOpenAPIPreflights(t *testing.T) {
+	a, _ := newTestApp(t)
+	if op := a.OpenAPI().Paths["/upload"].Options; op != nil {
+		t.Error("no CORS policy, yet an OPTIONS operation is documented")
+	}
+
+	a.cfg.CORS = map[string]cors.Policy{GroupAPI: {AllowedOrigins: []string{"https://app.example.com"}}}
+	if op := a.OpenAPI().Paths["/upload"].Options; op == nil || op.Responses["204"] == nil {
+		t.Errorf("preflight operation %+v", op)
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	_, s := newTestApp(t)
+
+	resp := get(s, "/openapi.json")
+	if resp.Status() != http.StatusOK {
+		t.Fatalf("status %d", resp.Status())
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(resp.Body(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != openapi.Version || doc.Paths["/cats/{slug}"] == nil {
+		t.Errorf("document %s %v", doc.OpenAPI, doc.Paths)
+	}
+
+	page := string(get(s, "/docs").Body())
+	if !strings.Contains(page, `id="post_contact"`) {
+		t.Error("/docs should show the contact form operation")
+	}
+}
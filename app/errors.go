@@ -0,0 +1,115 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"form_exer/adoption"
+	"form_exer/apperr"
+	"form_exer/cats"
+	"form_exer/logging"
+	"form_exer/storage"
+	"form_exer/tracing"
+	"form_exer/web/pages"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// handleErrors is the CENTRAL ERROR HANDLER: every error a handler returns, and
+// every request no route matched, ends up here and gets
+//   - the right status (apperr.Error says which; anything else is a 500)
+//   - a friendly page in the visitor's language, or problem+json for API clients
+//   - a log line with the request ID and the real cause
+//
+// It is registered after theme and i18n (the error page needs both) and returns
+// nil once the response is written, so rweb's bare "500 Internal Server Error"
+// fallback never runs - and middleware registered earlier (metrics, access log)
+// sees the final status.
+func handleErrors(ctx rweb.Context) error {
+	err := ctx.Next()
+	if err == nil {
+		// UNKNOWN ROUTES: rweb answers 404 with an empty body; give it a real page
+		if ctx.Response().Status() != http.StatusNotFound || len(ctx.Response().Body()) > 0 {
+			return nil
+		}
+		err = apperr.NotFound("notfound.message")
+	}
+
+	e := classify(err)
+	logger := logging.FromContext(ctx)
+	if e.Status >= 500 {
+		logger.Error("request failed", "status", e.Status, "path", ctx.Request().Path(), "err", err)
+		tracing.FromContext(ctx).RecordError(err)
+	} else {
+		// 4xx are the visitor's mistakes, not ours - worth a line, not an alert
+		logger.Info("request rejected", "status", e.Status, "path", ctx.Request().Path(), "err", err)
+	}
+	return writeError(ctx, e)
+}
+
+// classify turns any error into an *apperr.Error
+// The domain packages' sentinel errors are mapped here, in one place, so
+// handlers can simply return them
+func classify(err error) *apperr.Error {
+	if e, ok := apperr.As(err); ok {
+		return e
+	}
+	switch {
+	case errors.Is(err, cats.ErrNotFound):
+		return apperr.Wrap(err, http.StatusNotFound, "notfound.cat")
+	case errors.Is(err, adoption.ErrNotFound):
+		return apperr.Wrap(err, http.StatusNotFound, "notfound.application")
+	case errors.Is(err, storage.ErrTooLarge):
+		return apperr.Wrap(err, http.StatusRequestEntityTooLarge, "error.too_large")
+	case errors.Is(err, storage.ErrTypeNotAllowed):
+		return apperr.Wrap(err, http.StatusUnsupportedMediaType, "error.unsupported_type")
+	}
+	// Anything else is a bug or an outage: a generic message, the details only in the log
+	return apperr.Wrap(err, http.StatusInternalServerError, "error.internal")
+}
+
+// writeError replaces whatever the handler had written with the error response
+func writeError(ctx rweb.Context, e *apperr.Error) error {
+	resp := ctx.Response()
+	resp.SetBody(nil) // a handler may have written part of a page before failing
+	resp.SetStatus(e.Status)
+
+	t := requestSettings(ctx).I18n
+	requestID := logging.RequestID(ctx)
+
+	if wantsJSON(ctx) {
+		body, err := json.Marshal(apperr.Problem{
+			Type:      "about:blank", // "nothing more specific than the status code"
+			Title:     http.StatusText(e.Status),
+			Status:    e.Status,
+			Detail:    t.T(e.Message),
+			Instance:  ctx.Request().Path(),
+			RequestID: requestID,
+			Errors:    t.Errors(e.Fields),
+		})
+		if err != nil {
+			return err
+		}
+		resp.SetHeader("Content-Type", apperr.ProblemContentType)
+		return ctx.Bytes(body)
+	}
+
+	if e.Status == http.StatusNotFound {
+		page := pages.NotFound
+		page.Message = e.Message
+		return renderPage(ctx, &page)
+	}
+	page := pages.Error
+	page.Status = e.Status
+	page.Message = e.Message
+	if e.Status >= 500 {
+		page.RequestID = requestID // something to quote when reporting it
+	}
+	return renderPage(ctx, &page)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. CENTRALIZED ERROR HANDLING - handlers return errors, one place answers them
+// 2. ERRORS.IS / ERRORS.AS - mapping sentinel and typed errors to statuses
+// 3. CONTENT NEGOTIATION - the same failure as a page or as problem+json
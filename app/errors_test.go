@@ -0,0 +1,122 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"form_exer/apperr"
+	"form_exer/cats"
+	"form_exer/logging"
+	"form_exer/metrics"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// newErrorServer is a bare server with only the request ID and error handler
+// middleware, and routes that fail in the ways handlers do
+func newErrorServer() *rweb.Server {
+	s := rweb.NewServer()
+	s.Use(logging.Middleware(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	s.Use(handleErrors)
+	s.Get("/boom", func(ctx rweb.Context) error {
+		ctx.WriteHTML("<p>half a page") // written before failing - must not leak out
+		return errors.New("db down: /var/lib/secret.db")
+	})
+	s.Get("/cat", func(ctx rweb.Context) error {
+		return fmt.Errorf("loading cat: %w", cats.ErrNotFound)
+	})
+	s.Get("/big", func(ctx rweb.Context) error {
+		return apperr.TooLarge("error.too_large")
+	})
+	return s
+}
+
+func TestHandleErrorsInternal(t *testing.T) {
+	s := newErrorServer()
+
+	resp := get(s, "/boom")
+	if resp.Status() != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", resp.Status())
+	}
+	body := string(resp.Body())
+	if strings.Contains(body, "secret.db") || strings.Contains(body, "half a page") {
+		t.Error("the cause and the partial page must stay out of the response")
+	}
+	if id := resp.Header(logging.HeaderRequestID); id == "" || !strings.Contains(body, id) {
+		t.Errorf("the page should quote the request ID %q", id)
+	}
+}
+
+func TestHandleErrorsProblemJSON(t *testing.T) {
+	s := newErrorServer()
+
+	resp := get(s, "/boom", "Accept", "application/json")
+	if ct := resp.Header("Content-Type"); ct != apperr.ProblemContentType {
+		t.Errorf("Content-Type %q", ct)
+	}
+	var p apperr.Problem
+	if err := json.Unmarshal(resp.Body(), &p); err != nil {
+		t.Fatalf("body %q: %v", resp.Body(), err)
+	}
+	if p.Status != http.StatusInternalServerError || p.Title != "Internal Server Error" || p.Instance != "/boom" {
+		t.Errorf("problem = %+v", p)
+	}
+	if p.RequestID == "" || p.RequestID != resp.Header(logging.HeaderRequestID) {
+		t.Errorf("request_id %q should match the response header", p.RequestID)
+	}
+}
+
+// Sentinel errors from the domain packages map to their statuses, even wrapped
+func TestHandleErrorsStatuses(t *testing.T) {
+	s := newErrorServer()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/cat", http.StatusNotFound},
+		{"/big", http.StatusRequestEntityTooLarge},
+		{"/no/such/route", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp := get(s, tt.path)
+		if resp.Status() != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.path, resp.Status(), tt.want)
+		}
+		if !strings.Contains(string(resp.Body()), "<html") {
+			t.Errorf("%s: should render a full page", tt.path)
+		}
+	}
+}
+
+// A panicking handler gets the ordinary 500 page, is counted, and the server lives on
+func TestPanicRecovered(t *testing.T) {
+	_, s := newTestApp(t)
+	s.Get("/explode", metrics.Route("/explode", func(ctx rweb.Context) error {
+		var photos []string
+		return ctx.WriteString(photos[3]) // index out of range
+	}))
+
+	resp := get(s, "/explode")
+	if resp.Status() != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", resp.Status())
+	}
+	body := string(resp.Body())
+	if !strings.Contains(body, `class="error-page"`) || strings.Contains(body, "goroutine") {
+		t.Error("a panic should render the 500 page, without the stack")
+	}
+
+	scrape := string(get(s, "/metrics").Body())
+	if !strings.Contains(scrape, `panics_total{route="/explode"} 1`) {
+		t.Error("the panic should be counted in panics_total")
+	}
+	if resp := get(s, "/"); resp.Status() != http.StatusOK {
+		t.Errorf("after the panic: status %d", resp.Status())
+	}
+}
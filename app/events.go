@@ -0,0 +1,21 @@
+package app
+
+import (
+	"form_exer/events"
+	"form_exer/logging"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// notify publishes a live notification for the staff pages (see events.Broker)
+// It is BEST EFFORT: the visitor's request has already succeeded, so a failure
+// is only logged
+func notify(ctx rweb.Context, b *events.Broker, typ events.Type, user string, data any) {
+	if _, err := b.Publish(typ, user, data); err != nil {
+		logging.FromContext(ctx).Warn("publishing event failed", "type", typ, "err", err)
+	}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. BEST-EFFORT SIDE EFFECTS - logged, never turned into an error page
+// 2. NIL-SAFE DEPENDENCIES - a nil broker publishes nothing
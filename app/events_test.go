@@ -0,0 +1,46 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"form_exer/events"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Saving a cat with a new status tells the staff pages; other saves don't
+func TestRetirePublishesStatus(t *testing.T) {
+	a, s := newTestApp(t)
+	sub := a.deps.Events.Subscribe("sam", []events.Type{events.CatStatus}, 0, false)
+	defer a.deps.Events.Unsubscribe(sub)
+
+	login := []rweb.Header{{Key: "Authorization", Value: "Basic " + base64.StdEncoding.EncodeToString([]byte("sam:whiskers"))}}
+	if resp := s.Request(http.MethodPost, "/admin/cats/luna/retire", login, nil); resp.Status() != http.StatusSeeOther {
+		t.Fatalf("status %d", resp.Status())
+	}
+	if len(sub.C) != 1 {
+		t.Fatalf("%d events, want 1", len(sub.C))
+	}
+	ev := (<-sub.C).(rweb.SSEvent)
+	if !strings.HasPrefix(ev.Type, string(events.CatStatus)+"\n") {
+		t.Errorf("event type %q", ev.Type)
+	}
+	var got struct{ Data events.CatStatusChange }
+	if err := json.Unmarshal([]byte(ev.Data.(string)), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := events.CatStatusChange{Slug: "luna", Name: "Luna", From: "available", To: "retired"}
+	if got.Data != want {
+		t.Errorf("data %+v, want %+v", got.Data, want)
+	}
+
+	// Already retired: no change, no event
+	s.Request(http.MethodPost, "/admin/cats/luna/retire", login, nil)
+	if len(sub.C) != 0 {
+		t.Errorf("%d events for a save that changed nothing", len(sub.C))
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+	"form_exer/forms"
+	"form_exer/web/shared"
+)
+
+// fragmentCtxKey is the request-scoped storage key holding the fragment asked for
+const fragmentCtxKey = "app.fragment"
+
+// withFragments lets a route answer with part of its page (see shared.FragmentHeader)
+//
+// A route DECLARES the fragments it can return (Route.Fragments); asking for
+// any other is the client's mistake and gets a 400, rather than a whole page
+// the script would then paste into the middle of another one
+func withFragments(declared []string, next rweb.Handler) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		// VARY: the same URL answers with different HTML depending on the
+		// header, so caches must keep the two apart
+		if len(declared) > 0 {
+			addVary(ctx.Response(), shared.FragmentHeader)
+		}
+
+		name := forms.Header(ctx.Request(), shared.FragmentHeader)
+		if name == "" {
+			return next(ctx)
+		}
+		if !slices.Contains(declared, name) {
+			return apperr.BadRequest("error.unknown_fragment")
+		}
+		ctx.Set(fragmentCtxKey, strings.Clone(name)) // the header lives in rweb's reusable buffer
+		return next(ctx)
+	}
+}
+
+// requestedFragment is the fragment this request asked for, or ""
+func requestedFragment(ctx rweb.Context) string {
+	name, _ := ctx.Get(fragmentCtxKey).(string)
+	return name
+}
+
+// addVary adds name to the Vary header instead of replacing what others put there
+// (cors.Route, for one, sets "Vary: Origin")
+func addVary(resp rweb.Response, name string) {
+	if v := resp.Header("Vary"); v != "" {
+		name = v + ", " + name
+	}
+	resp.SetHeader("Vary", name)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. PARTIAL RESPONSES - one route, a whole page or just one component
+// 2. DECLARED CAPABILITIES - routes list their fragments; anything else is refused
+// 3. VARY - telling caches which request headers change the response
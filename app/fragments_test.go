@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"form_exer/web/shared"
+)
+
+func TestFragmentRequest(t *testing.T) {
+	_, s := newTestApp(t)
+
+	resp := get(s, "/es/cats", shared.FragmentHeader, "cat-results")
+	if resp.Status() != http.StatusOK {
+		t.Fatalf("status %d", resp.Status())
+	}
+	body := string(resp.Body())
+	if !strings.HasPrefix(body, "<div") || !strings.Contains(body, `id="cat-results"`) || strings.Contains(body, "<html") {
+		t.Errorf("want only the results, got %.120q", body)
+	}
+	if !strings.Contains(body, "Luna") || !strings.Contains(body, "1 gato") {
+		t.Errorf("fragment lost the page's data or language: %.200q", body)
+	}
+	if got := resp.Header(shared.FragmentHeader); got != "cat-results" {
+		t.Errorf("%s = %q", shared.FragmentHeader, got)
+	}
+
+	// Without the header, the same URL is the whole page - and caches are told so
+	full := get(s, "/cats")
+	if !strings.Contains(string(full.Body()), "<html") {
+		t.Error("a plain request should get the whole page")
+	}
+	if v := full.Header("Vary"); !strings.Contains(v, shared.FragmentHeader) {
+		t.Errorf("Vary = %q", v)
+	}
+}
+
+// Only declared fragments are served; anything else is refused, not answered with a page
+func TestFragmentNotDeclared(t *testing.T) {
+	_, s := newTestApp(t)
+
+	for _, path := range []string{"/cats", "/cats/luna"} {
+		resp := get(s, path, shared.FragmentHeader, "contact-form")
+		if resp.Status() != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, resp.Status())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"form_exer/metrics"
+)
+
+// siteMetrics are the counters the handlers update themselves, next to the
+// per-request ones metrics.HTTPMetrics records for every route
+//
+// Handler types hold a *siteMetrics that may be nil (e.g. a handler built
+// directly in a test), so every method checks for that first
+type siteMetrics struct {
+	uploads     *metrics.Counter // uploads_total{kind,result}
+	uploadBytes *metrics.Counter // upload_bytes_total{kind}
+	contact     *metrics.Counter // contact_submissions_total{result}
+	panics      *metrics.Counter // panics_total{route}
+	csp         *metrics.Counter // csp_violations_total{directive,disposition}
+}
+
+// Upload kinds: general file uploads and cat photos
+const (
+	uploadFile  = "file"
+	uploadPhoto = "photo"
+)
+
+func newSiteMetrics(reg *metrics.Registry) *siteMetrics {
+	return &siteMetrics{
+		uploads: reg.Counter("uploads_total",
+			"Upload attempts, by kind (file, photo) and result (stored, too_large, rejected).", "kind", "result"),
+		uploadBytes: reg.Counter("upload_bytes_total",
+			"Bytes of uploads stored, by kind.", "kind"),
+		contact: reg.Counter("contact_submissions_total",
+			"Contact form submissions, by result (accepted, rejected, failed).", "result"),
+		panics: reg.Counter("panics_total",
+			"Handler panics recovered, by route pattern.", "route"),
+		csp: reg.Counter("csp_violations_total",
+			"Content-Security-Policy violations reported by browsers, by directive and disposition (enforce, report).",
+			"directive", "disposition"),
+	}
+}
+
+// upload records one upload attempt; size counts only when it was stored
+func (m *siteMetrics) upload(kind, result string, size int64) {
+	if m == nil {
+		return
+	}
+	m.uploads.Inc(kind, result)
+	if result == "stored" {
+		m.uploadBytes.Add(float64(size), kind)
+	}
+}
+
+// contactSubmission records one contact form submission
+func (m *siteMetrics) contactSubmission(result string) {
+	if m == nil {
+		return
+	}
+	m.contact.Inc(result)
+}
+
+// panicked records one recovered panic
+func (m *siteMetrics) panicked(route string) {
+	if m == nil {
+		return
+	}
+	m.panics.Inc(route)
+}
+
+// cspViolation records one reported CSP violation
+func (m *siteMetrics) cspViolation(directive, disposition string) {
+	if m == nil {
+		return
+	}
+	m.csp.Inc(directive, disposition)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. NIL RECEIVERS - methods on a nil pointer can still run and do nothing
+// 2. LABELS - one counter, split by kind and result
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"net/http"
+)
+
+// proxyMethods are forwarded to the upstreams
+// OPTIONS is not: when the API group allows other origins, Register answers
+// the preflights itself, and two OPTIONS routes on one path can't coexist
+var proxyMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost,
+	http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// proxyRoutes lists a route per method for each proxy's prefix and everything
+// under it ("/api/legacy" and "/api/legacy/*path")
+//
+// They are API routes: other origins may call them when the API group allows
+// it, and they get the API group's security headers. An upstream's own error
+// pages are passed on as they are - except an empty 404, which gets the site's
+// not-found page (see handleErrors)
+func (a *App) proxyRoutes() []Route {
+	var routes []Route
+	for _, p := range a.deps.Proxies {
+		for _, path := range []string{p.Prefix(), p.Prefix() + "/*path"} {
+			for _, method := range proxyMethods {
+				routes = append(routes, Route{Method: method, Path: path, Handler: p.Handler, API: true,
+					Summary: "Forwarded to " + p.Prefix() + "'s upstream servers", Produces: "application/octet-stream"})
+			}
+		}
+	}
+	return routes
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. GENERATED ROUTES - one table entry per method and path, built in a loop
+// 2. METHOD VALUES - every route of a proxy shares p.Handler
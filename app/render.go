@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+	"form_exer/forms"
+	"form_exer/i18n"
+	"form_exer/security"
+	"form_exer/tracing"
+	"form_exer/web/shared"
+	"form_exer/web/theme"
+)
+
+// renderablePage is what every page type offers once shared.Page is embedded:
+// Render from the page itself, and Apply promoted from *shared.Page
+// An INTERFACE lets one helper render any page without knowing its concrete type
+type renderablePage interface {
+	Render() string
+	Apply(shared.Settings)
+}
+
+// renderPage fills in the visitor's settings and writes the page as HTML
+// Pass a POINTER (&page): Apply has a pointer receiver, so only *Home, *ContactPage, ...
+// satisfy the interface - and the settings must land on the page we render
+func renderPage(ctx rweb.Context, page renderablePage) error {
+	span := tracing.Start(ctx, "render")
+	span.SetAttr("page", fmt.Sprintf("%T", page)) // %T prints the type, e.g. *pages.CatListPage
+
+	page.Apply(requestSettings(ctx))
+
+	// PARTIAL RENDERING: just the component asked for, when the page has it;
+	// pages without it (an error page, say) are sent whole
+	if name := requestedFragment(ctx); name != "" {
+		if fp, ok := page.(shared.Fragmenter); ok {
+			if c, ok := fp.Fragment(name); ok {
+				span.SetAttr("fragment", name)
+				html := shared.RenderFragment(c)
+				span.End()
+				// Tells the script it got the fragment rather than a whole page
+				ctx.Response().SetHeader(shared.FragmentHeader, name)
+				return ctx.WriteHTML(html)
+			}
+		}
+	}
+
+	html := page.Render()
+	span.End()
+	return ctx.WriteHTML(html)
+}
+
+// wantsJSON reports whether the client asked for JSON rather than a page
+// (see apperr.PrefersJSON for how the Accept header is weighed)
+func wantsJSON(ctx rweb.Context) bool {
+	return apperr.PrefersJSON(forms.Header(ctx.Request(), "Accept"))
+}
+
+// requestSettings collects the per-visitor choices made by middleware
+func requestSettings(ctx rweb.Context) shared.Settings {
+	return shared.Settings{
+		Theme: theme.Current(ctx), I18n: i18n.FromContext(ctx), Nonce: security.Nonce(ctx),
+		Query: strings.Clone(ctx.Request().Query()), // Query points into a reused buffer
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+
+	"form_exer/apperr"
+	"form_exer/cats"
+	"form_exer/web/pages"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Site serves the public pages about cats
+// NAMED HANDLER TYPE: the struct holds the dependencies, each method is a handler
+type Site struct {
+	Cats cats.Repository
+}
+
+// Home shows the cats that can be adopted right now
+//
+// FUNCTION SIGNATURE: func(ctx rweb.Context) error
+//   - Takes one parameter: ctx (context with request/response data)
+//   - Returns an error (nil means success, non-nil error is handled by the framework)
+func (h Site) Home(ctx rweb.Context) error {
+	// METHOD CHAINING: ctx.Response() returns a response object, then we call SetHeader() on it
+	// SetHeader sets an HTTP response header (key-value pair)
+	ctx.Response().SetHeader("Content-Type", "text/html; charset=utf-8")
+
+	// Fetch the catalog and keep only the cats that can be adopted right now
+	allCats, err := h.Cats.List()
+	if err != nil {
+		return err
+	}
+
+	// COPY, THEN CUSTOMIZE: pages.HomePage is a struct value, so assigning it
+	// makes a copy. Filling in the copy never touches the shared singleton.
+	home := pages.HomePage
+	home.Cats = cats.FilterByStatus(allCats, cats.StatusAvailable)
+
+	// renderPage (render.go) applies the visitor's theme, calls the page's Render()
+	// method and sends the HTML back to the client with ctx.WriteHTML()
+	// The return statement returns the error (or nil) from writing the response
+	return renderPage(ctx, &home)
+}
+
+// CatList is the searchable catalog
+// Search, filters, sort and paging all come from the query string,
+// so any result page can be bookmarked. e.g. /cats?q=playful&kids=1&sort=youngest
+func (h Site) CatList(ctx rweb.Context) error {
+	allCats, err := h.Cats.List()
+	if err != nil {
+		return err
+	}
+
+	// Retired cats are kept for records only - never list them publicly,
+	// whatever status filter the URL asks for
+	listed := make([]cats.Cat, 0, len(allCats))
+	for _, c := range allCats {
+		if c.Status != cats.StatusRetired {
+			listed = append(listed, c)
+		}
+	}
+
+	// METHOD VALUE: QueryParam has the func(string) string shape ParseQuery expects
+	query := cats.ParseQuery(ctx.Request().QueryParam)
+	result := cats.Search(listed, query)
+	page := pages.NewCatListPage(query, result, cats.Breeds(listed))
+	return renderPage(ctx, &page)
+}
+
+// CatDetail is one cat's page
+// ROUTE PARAMETER: ":slug" captures the cat's identifier
+// Example: /cats/whiskers → slug = "whiskers"
+func (h Site) CatDetail(ctx rweb.Context) error {
+	cat, err := h.Cats.Get(ctx.Request().PathParam("slug"))
+
+	// ERRORS.IS compares against the repository's SENTINEL ERROR
+	// An unknown slug is the visitor's mistake (404), anything else is ours (500)
+	if errors.Is(err, cats.ErrNotFound) {
+		// APPERR: the central error handler (errors.go) renders the 404 page
+		return apperr.NotFound("notfound.cat_gone")
+	}
+	if err != nil {
+		return err
+	}
+	// Retired cats are kept for records only: to the public they don't exist,
+	// just as they are left out of the list
+	if cat.Status == cats.StatusRetired {
+		return apperr.NotFound("notfound.cat")
+	}
+
+	page := pages.NewCatDetailPage(cat)
+	return renderPage(ctx, &page)
+}
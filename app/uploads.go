@@ -0,0 +1,222 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"form_exer/apperr"
+	"form_exer/events"
+	"form_exer/forms"
+	"form_exer/logging"
+	"form_exer/storage"
+	"form_exer/tracing"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Uploads accepts files and serves them back
+type Uploads struct {
+	Store    *storage.Store
+	Metrics  *siteMetrics    // may be nil
+	Events   *events.Broker  // may be nil
+	Progress *events.Tracker // may be nil
+}
+
+// Serve sends an uploaded file back; images display inline, anything else downloads
+//
+// UNTRUSTED CONTENT: anyone can upload, so a file must never run as part of
+// the site. An uploaded HTML page shown inline would be served from our own
+// origin - with our cookies, and our visitors' trust. So only images are sent
+// as what they are; everything else is an opaque attachment, and the sandbox
+// CSP makes even a file opened anyway a page with no scripts and a unique origin
+func (h Uploads) Serve(ctx rweb.Context) error {
+	name := ctx.Request().PathParam("name")
+	f, contentType, err := h.Store.Open(name)
+	if err != nil {
+		return apperr.Wrap(err, http.StatusNotFound, "notfound.file")
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	resp := ctx.Response()
+	if strings.HasPrefix(contentType, "image/") {
+		resp.SetHeader("Content-Type", contentType)
+	} else {
+		resp.SetHeader("Content-Type", "application/octet-stream")
+		// FormatMediaType quotes the name; a stored extension comes from the uploader
+		resp.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	resp.SetHeader("Content-Security-Policy", "sandbox")
+	resp.SetHeader("X-Content-Type-Options", "nosniff")
+	resp.SetHeader("Cache-Control", "public, max-age=86400") // stored names never change
+	return ctx.Bytes(data)
+}
+
+// Upload is the FILE UPLOAD HANDLER
+// Demonstrates handling multipart/form-data (file uploads + regular form fields)
+// Test with: curl -X POST -F "vehicle=car" -F "file=@somefile.txt" http://localhost:8000/upload
+//
+// PROGRESS: with ?upload_id=<id>, a client watching /upload/progress/<id>
+// hears how the upload is going and how it ended (see events.Tracker)
+func (h Uploads) Upload(c rweb.Context) (err error) {
+	// Get the request object for convenience
+	req := c.Request()
+
+	// DEFERRED CLOSURE reading the NAMED RESULT: every failure below, however
+	// it returns, reaches the watcher as the message the uploader would see
+	// id stays empty - and nothing is reported - until it has been validated
+	var id string
+	defer func() {
+		if err != nil {
+			h.Progress.Fail(id, requestSettings(c).I18n.T(classify(err).Message))
+		}
+	}()
+	if raw := req.QueryParam(events.UploadIDParam); raw != "" {
+		if !events.ValidUploadID(raw) {
+			return apperr.BadRequest("error.bad_upload_id")
+		}
+		// strings.Clone: the ID is used after rweb reuses the request buffer (in the tracker)
+		id = strings.Clone(raw)
+	}
+	// rweb has read the whole body by now (see events.Tracker)
+	h.Progress.Update(id, events.PhaseReceived, int64(len(req.Body())), int64(len(req.Body())))
+
+	// PARSE THE FORM OURSELVES: rweb caches multipart forms on pooled request
+	// objects, so req.GetFormFile could hand us a previous request's file
+	// forms.Parse always decodes this request's own body (see forms.Form)
+	form, err := forms.Parse(req)
+	if err != nil {
+		// APPERR: a body we can't parse is the client's mistake - 400, not 500
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close() // removes any temp files used for large uploads
+
+	// MULTIPART FORM: Can contain both regular fields and file uploads
+	// Extract regular form field (not a file)
+	name := form.Value("vehicle")
+
+	// MULTIPLE RETURN VALUES: Go functions can return multiple values
+	// File returns 3 values: (file, fileHeader, error)
+	// The fileHeader carries the client's original file name
+	file, header, err := form.File("file")
+
+	// ERROR HANDLING PATTERN: Check if err is not nil
+	// In Go, errors are values and must be explicitly checked
+	// If there's an error, return it immediately (early return pattern)
+	// The central error handler (errors.go) turns it into a 400 page or problem+json
+	if err != nil {
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+
+	// DEFER for RESOURCE CLEANUP: Ensure file is closed when function exits
+	// This prevents resource leaks even if the function returns early or panics
+	// defer runs in LIFO order (Last In, First Out)
+	defer file.Close()
+
+	// DELEGATE TO THE UPLOAD SUBSYSTEM: it streams the file to disk under a
+	// unique name (so two uploads never overwrite each other) and enforces the size limit
+	stored, err := saveTraced(c, h.Store, uploadFile, header.Filename, file)
+	if errors.Is(err, storage.ErrTooLarge) {
+		h.Metrics.upload(uploadFile, "too_large", 0)
+		return apperr.Wrap(err, http.StatusRequestEntityTooLarge, "error.too_large") // 413
+	}
+	if err != nil {
+		return err
+	}
+	h.Metrics.upload(uploadFile, "stored", stored.Size)
+	h.Progress.Finish(id, stored.Size, stored)
+	// Anyone may upload, so every staff member hears about it
+	notify(c, h.Events, events.UploadComplete, "", events.UploadNotice{Name: stored.Name, URL: stored.URL, Size: stored.Size})
+
+	// LEVELED, STRUCTURED LOG: key/value pairs a log tool can filter on
+	logging.FromContext(c).Info("file uploaded",
+		"vehicle", name, "original_name", stored.OriginalName, "stored_as", stored.Name, "size", stored.Size)
+
+	// Reply with the stored file's metadata (name, size, URL, ...) as JSON
+	return c.WriteJSON(stored)
+}
+
+// saveTraced stores an upload inside a "storage.save" span, a child of the request's
+// span, so a slow disk shows up in the request's trace
+func saveTraced(ctx rweb.Context, store *storage.Store, kind, originalName string, r io.Reader) (storage.File, error) {
+	span := tracing.Start(ctx, "storage.save")
+	defer span.End() // DEFER: the span ends however we leave
+
+	stored, err := store.Save(originalName, r)
+	span.SetAttr("upload.kind", kind)
+	span.SetAttr("upload.size", stored.Size)
+	span.SetAttr("upload.content_type", stored.ContentType)
+	span.RecordError(err)
+	return stored, err
+}
+
+// PostFormData echoes a posted form - a plain function is a handler too
+// POST requests typically modify data on the server (non-idempotent - side effects)
+// ROUTE PARAMETERS: ":form_id" is a URL parameter that captures any value in that position
+// Example: /post-form-data/123 → form_id = "123"
+// Example: /post-form-data/abc → form_id = "abc"
+// Test with: curl -X POST http://localhost:8000/post-form-data/123 -d "dept=engineering&name=JohnDoe"
+// or, as JSON both ways:
+//
+//	curl -X POST http://localhost:8000/post-form-data/123 -H "Content-Type: application/json" \
+//	     -H "Accept: application/json" -d '{"dept": "engineering", "name": "JohnDoe"}'
+func PostFormData(ctx rweb.Context) error {
+	// FORM DATA EXTRACTION: forms.Parse reads urlencoded, multipart and JSON
+	// bodies alike, so the fields below don't care how they were sent
+	form, err := forms.Parse(ctx.Request())
+	if err != nil {
+		return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+	}
+	defer form.Close()
+
+	posted := postedForm{
+		FormID: strings.Clone(ctx.Request().PathParam("form_id")), // URL path parameter "123"
+		Dept:   form.Value("dept"),                                // form field "dept=engineering"
+		Name:   form.Value("name"),                                // form field "name=JohnDoe"
+	}
+
+	// JSON for clients that ask for it...
+	if wantsJSON(ctx) {
+		return ctx.WriteJSON(posted)
+	}
+
+	// STRING FORMATTING: fmt.Sprintf() works like printf - formats a string
+	// %s is a placeholder for string values
+	outStr := fmt.Sprintf("Posted - form_id: %s, dept: %s, name: %s", posted.FormID, posted.Dept, posted.Name)
+
+	// ...and plain text for everyone else
+	return ctx.WriteString(outStr)
+}
+
+// FORM SCHEMAS for the API documentation
+var (
+	uploadSchema = forms.Schema{
+		{Name: "vehicle", Description: "logged with the upload"},
+		{Name: "file", Type: forms.File, Required: true},
+	}
+	postedFormSchema = forms.Schema{{Name: "dept"}, {Name: "name"}}
+)
+
+// postedForm is PostFormData's JSON reply
+type postedForm struct {
+	FormID string `json:"form_id"`
+	Dept   string `json:"dept"`
+	Name   string `json:"name"`
+}
+
+// ===== EXAMPLE OUTPUT =====
+// >curl -X POST -d "dept=support" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/123
+// Posted - form_id: 123%
+// >curl -X POST -d "dept=support" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/123
+// Posted - form_id: 123, dept: support%
+// >curl -X POST -d "dept=support" -d "name=Sue" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/123
+// Posted - form_id: 123, dept: support%
+// >curl -X POST -d "dept=support" -d "name=Sue" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/roh
+// Posted - form_id: 123, dept: support, name:Sue%
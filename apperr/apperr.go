@@ -0,0 +1,99 @@
+// Package apperr gives handlers one way to fail: return an *Error that says
+// which HTTP status the visitor should get and what they should be told.
+//
+//	if errors.Is(err, cats.ErrNotFound) {
+//		return apperr.NotFound("notfound.cat")
+//	}
+//
+// A single error handler (see app/errors.go) turns every returned error into a
+// response - a friendly page for browsers, RFC 9457 problem details for API
+// clients - and logs the underlying cause. Handlers never write error pages
+// themselves, so every failure looks and is logged the same way.
+//
+// Errors that aren't an *Error are treated as 500 Internal Server Error: the
+// visitor sees a generic message and the details go only to the log.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is an application error with the response it should produce
+type Error struct {
+	Status int // HTTP status code, e.g. 404
+
+	// Message is shown to the visitor: an i18n message key such as
+	// "notfound.cat", or plain text (which passes through translation unchanged)
+	Message string
+
+	// Err is the underlying cause, for the log only - it may hold file paths
+	// or other details visitors shouldn't see
+	Err error
+
+	// Fields holds what is wrong with each field of a rejected form
+	// (field name -> message key), for clients that show errors next to inputs
+	Fields map[string]string
+}
+
+// Error describes the error for logs: "404 notfound.cat: <cause>"
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Unwrap lets errors.Is and errors.As look at the cause
+func (e *Error) Unwrap() error { return e.Err }
+
+// New returns an error answering with status and showing message
+func New(status int, message string) *Error {
+	return &Error{Status: status, Message: message}
+}
+
+// Wrap is New with an underlying cause for the log
+func Wrap(err error, status int, message string) *Error {
+	return &Error{Status: status, Message: message, Err: err}
+}
+
+// CONVENIENCE CONSTRUCTORS for the statuses handlers use most
+
+// BadRequest (400): the request itself is wrong, e.g. a missing form field
+func BadRequest(message string) *Error { return New(http.StatusBadRequest, message) }
+
+// Forbidden (403): the visitor is known but may not do this
+func Forbidden(message string) *Error { return New(http.StatusForbidden, message) }
+
+// NotFound (404): the thing asked for doesn't exist
+func NotFound(message string) *Error { return New(http.StatusNotFound, message) }
+
+// TooLarge (413): an upload or body is over the size limit
+func TooLarge(message string) *Error { return New(http.StatusRequestEntityTooLarge, message) }
+
+// Invalid (400): a submitted form broke the validation rules; fields says how
+// forms.FieldErrors can be passed as it is
+func Invalid(fields map[string]string) *Error {
+	return &Error{Status: http.StatusBadRequest, Message: "error.invalid", Fields: fields}
+}
+
+// As finds the *Error in err's chain
+// COMMA-OK: ok is false for errors that aren't application errors
+func As(err error) (e *Error, ok bool) {
+	ok = errors.As(err, &e)
+	return e, ok
+}
+
+// StatusOf returns the status err should produce: its own for an *Error, 500 otherwise
+func StatusOf(err error) int {
+	if e, ok := As(err); ok {
+		return e.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. CUSTOM ERROR TYPES - a struct with an Error method carries extra data
+// 2. ERROR WRAPPING - Unwrap keeps the cause visible to errors.Is and errors.As
+// 3. SEPARATING AUDIENCES - Message for the visitor, Err for the log
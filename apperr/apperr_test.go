@@ -0,0 +1,62 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStatusOf(t *testing.T) {
+	cause := errors.New("no such file")
+	wrapped := fmt.Errorf("loading photo: %w", Wrap(cause, http.StatusNotFound, "notfound.file"))
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{NotFound("x"), http.StatusNotFound},
+		{Forbidden("x"), http.StatusForbidden},
+		{TooLarge("x"), http.StatusRequestEntityTooLarge},
+		{BadRequest("x"), http.StatusBadRequest},
+		{Invalid(map[string]string{"email": "error.email_invalid"}), http.StatusBadRequest},
+		{wrapped, http.StatusNotFound}, // found through fmt.Errorf's %w
+		{cause, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := StatusOf(tt.err); got != tt.want {
+			t.Errorf("StatusOf(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+
+	// The cause stays reachable for errors.Is
+	if !errors.Is(wrapped, cause) {
+		t.Error("errors.Is should see the cause through Unwrap")
+	}
+	if got := wrapped.Error(); got != "loading photo: 404 notfound.file: no such file" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestPrefersJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false}, // a browser
+		{"application/json", true},
+		{"application/problem+json", true},
+		{"application/json, text/html;q=0.5", true},
+		{"text/html, application/json", false}, // a tie goes to HTML
+		{"application/json;q=0, text/html", false},
+		{"application/json;q=oops", false}, // malformed weight = not acceptable
+		{"APPLICATION/JSON", true},
+	}
+	for _, tt := range tests {
+		if got := PrefersJSON(tt.accept); got != tt.want {
+			t.Errorf("PrefersJSON(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package apperr
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 "problem details" object - the standard JSON shape
+// for HTTP API errors, so clients can handle every error the same way:
+//
+//	{"type":"about:blank","title":"Not Found","status":404,
+//	 "detail":"We couldn't find that cat.","instance":"/cats/tom","request_id":"9f2c..."}
+type Problem struct {
+	Type     string `json:"type"`     // a URI naming the kind of problem; about:blank = just the status
+	Title    string `json:"title"`    // short summary, the same for every problem of this type
+	Status   int    `json:"status"`   // the HTTP status code, repeated for convenience
+	Detail   string `json:"detail"`   // explanation of this occurrence, for a person
+	Instance string `json:"instance"` // the request path that failed
+
+	// EXTENSION MEMBERS: the spec allows extra fields. request_id lets a
+	// client quote the request when reporting a problem; errors maps each
+	// invalid form field to a message, to show next to the input
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// PrefersJSON reports whether an Accept header asks for JSON rather than HTML
+//
+// CONTENT NEGOTIATION: browsers send "text/html,...,*/*;q=0.8", API clients
+// send "application/json" (or problem+json). JSON wins only when it is weighted
+// strictly higher than HTML; with no preference (*/* or no header) we answer
+// with HTML, since the site is mostly visited by browsers.
+func PrefersJSON(accept string) bool {
+	var htmlQ, jsonQ float64
+	for _, r := range parseAccept(accept) {
+		switch {
+		case r.typ == "text/html" || r.typ == "application/xhtml+xml":
+			htmlQ = max(htmlQ, r.q)
+		case r.typ == "application/json" || strings.HasSuffix(r.typ, "+json"):
+			jsonQ = max(jsonQ, r.q)
+		}
+	}
+	return jsonQ > htmlQ
+}
+
+// mediaRange is one entry of an Accept header with its weight
+type mediaRange struct {
+	typ string
+	q   float64
+}
+
+// parseAccept splits "text/html, application/json;q=0.9" into weighted ranges,
+// best first; entries with q=0 ("not acceptable") and malformed weights are dropped
+func parseAccept(accept string) []mediaRange {
+	var out []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		typ, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if typ == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				parsed, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					q = 0
+				} else {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			out = append(out, mediaRange{typ: typ, q: q})
+		}
+	}
+	// STABLE SORT by weight keeps the header's order for equal weights
+	sort.SliceStable(out, func(i, j int) bool { return out[i].q > out[j].q })
+	return out
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. STANDARD ERROR FORMATS - RFC 9457 problem details for machines
+// 2. CONTENT NEGOTIATION - one URL, HTML or JSON depending on Accept
+// 3. QUALITY WEIGHTS - q=0.9 ranks below an unweighted entry
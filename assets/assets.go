@@ -0,0 +1,64 @@
+// Package assets holds the static files the pages link to - scripts, styles,
+// images - EMBEDDED in the binary, so a deployment is one file and a page can
+// never reference an asset the server doesn't have.
+//
+// Serve answers /assets/... from the embedded files:
+//
+//	<script src="/assets/js/enhance.js" defer></script>
+package assets
+
+import (
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+)
+
+// GO:EMBED copies the listed directories into the binary at build time
+//
+//go:embed css images js
+var files embed.FS
+
+// Prefix is where the assets are served; Route is the matching route pattern
+const (
+	Prefix = "/assets/"
+	Route  = Prefix + "*path"
+)
+
+// The scripts the pages load
+const (
+	EnhanceScript = Prefix + "js/enhance.js" // progressive enhancement for forms
+	LiveScript    = Prefix + "js/live.js"    // live notifications on the staff pages
+)
+
+// Serve answers a request for one embedded file
+//
+// Assets change only with a new release, so browsers may keep them for an
+// hour; a deploy is picked up within that time without any cache busting
+func Serve(ctx rweb.Context) error {
+	name := path.Clean(ctx.Request().Param("path"))
+	data, err := fs.ReadFile(files, name)
+	if err != nil {
+		// fs.ValidPath rejects "..", so nothing outside the embedded tree is reachable
+		return apperr.Wrap(err, http.StatusNotFound, "notfound.file")
+	}
+
+	resp := ctx.Response()
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	resp.SetHeader("Content-Type", contentType)
+	resp.SetHeader("Cache-Control", "public, max-age=3600")
+	return ctx.Bytes(data)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. EMBEDDED FILES - //go:embed bakes files into the binary as an embed.FS
+// 2. IO/FS - one interface for reading files, whether embedded or on disk
+// 3. CACHE HEADERS - static files can be cached; pages can't
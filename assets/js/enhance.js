@@ -0,0 +1,115 @@
+// enhance.js - PROGRESSIVE ENHANCEMENT for forms
+//
+// A form marked with data-enhance works without this script: the browser
+// posts it and shows the page the server answers with. When the script runs,
+// it takes over the submit instead:
+//
+//   1. the fields are sent with fetch(), asking for JSON (Accept: application/json)
+//   2. success: the form's data-success text is shown above the form and the form is cleared
+//   3. rejected: the problem+json "detail" is shown above the form and each
+//      entry of "errors" next to its field, the same way the server-rendered
+//      adoption form shows them (.field-error)
+//   4. the network fails: nothing is lost - the form is posted the old way
+//
+// The server decides everything: which fields are wrong, and what the
+// messages say (in the visitor's language). The script only places them.
+//
+// It is an external file rather than an inline <script>, so the
+// Content-Security-Policy's script-src 'self' lets it run without a nonce.
+(function () {
+  "use strict";
+
+  // Forms that upload files need multipart; everything else goes urlencoded,
+  // exactly as the browser would have sent it
+  function body(form) {
+    var data = new FormData(form);
+    if (form.enctype === "multipart/form-data") {
+      return data;
+    }
+    return new URLSearchParams(data);
+  }
+
+  // The message line above the form, created on first use
+  // role="status" makes screen readers announce each new message
+  function resultLine(form) {
+    var line = form.querySelector(".form-result");
+    if (!line) {
+      line = document.createElement("p");
+      line.className = "form-result";
+      line.setAttribute("role", "status");
+      form.insertBefore(line, form.firstChild);
+    }
+    return line;
+  }
+
+  function showResult(form, ok, text) {
+    var line = resultLine(form);
+    line.className = "form-result alert " + (ok ? "alert-success" : "alert-error");
+    line.textContent = text; // textContent, never innerHTML: messages may echo user input
+  }
+
+  function clearErrors(form) {
+    form.querySelectorAll(".field-error[data-for]").forEach(function (p) { p.remove(); });
+    form.querySelectorAll("[aria-invalid]").forEach(function (el) { el.removeAttribute("aria-invalid"); });
+  }
+
+  // One message per field, right after the input it is about
+  function showFieldErrors(form, errors) {
+    Object.keys(errors || {}).forEach(function (name) {
+      var input = form.elements.namedItem(name);
+      if (!input || !input.insertAdjacentElement) {
+        return; // no such field on this form (or a group of radios); detail covers it
+      }
+      var p = document.createElement("p");
+      p.className = "field-error";
+      p.dataset.for = name;
+      p.id = "error-" + name;
+      p.textContent = errors[name];
+      input.insertAdjacentElement("afterend", p);
+      input.setAttribute("aria-invalid", "true");
+      input.setAttribute("aria-describedby", p.id);
+    });
+  }
+
+  function enhance(form) {
+    form.addEventListener("submit", function (event) {
+      event.preventDefault();
+      var button = form.querySelector("[type=submit]");
+      if (button) {
+        button.disabled = true; // no double submissions while waiting
+      }
+
+      fetch(form.action, {
+        method: form.method.toUpperCase(),
+        body: body(form),
+        headers: { "Accept": "application/json" },
+        credentials: "same-origin"
+      })
+        .then(function (resp) {
+          return resp.json().then(function (data) {
+            clearErrors(form);
+            if (resp.ok) {
+              showResult(form, true, form.dataset.success || "OK");
+              form.reset();
+            } else {
+              showResult(form, false, data.detail || form.dataset.failure);
+              showFieldErrors(form, data.errors);
+            }
+          }, function () {
+            // Not JSON at all (a proxy's error page, say)
+            showResult(form, false, form.dataset.failure);
+          });
+        }, function () {
+          // NETWORK FAILURE: fall back to the plain form post
+          form.submit();
+        })
+        .finally(function () {
+          if (button) {
+            button.disabled = false;
+          }
+        });
+    });
+  }
+
+  document.querySelectorAll("form[data-enhance]").forEach(enhance);
+})();
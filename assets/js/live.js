@@ -0,0 +1,110 @@
+// live.js - LIVE UPDATES for the staff pages
+//
+// An element with data-events="/events" becomes a list of notifications
+// that fills in as things happen: contact messages, uploads, cat status
+// changes. The server pushes them as Server-Sent Events; EventSource keeps
+// the connection open and, if it drops, reconnects by itself - sending the
+// last event ID it saw, so nothing in between is lost.
+//
+// Two cases EventSource can't notice on its own are handled here: the server
+// ending a stream (a "reconnect" event) and a connection that silently went
+// dead (no heartbeat for too long). Either way the script opens a new stream
+// that starts after the last event it saw (?lastEventId=).
+//
+// Without the script the page is the same list it always was: reload to
+// see what's new.
+(function () {
+  "use strict";
+
+  var MAX_ITEMS = 20; // older notices drop off the end
+  var STALE_MS = 45000; // three of the server's 15s heartbeats
+  var RETRY_MS = 3000; // pause before opening a new stream
+
+  // What each kind of event says; data is the event's "data" member
+  var describe = {
+    "contact.message": function (data) {
+      return "New message from " + data.name + " <" + data.email + ">";
+    },
+    "upload.complete": function (data) {
+      var size = Math.max(1, Math.round(data.size / 1024)) + " KB";
+      return data.cat ? "Photo added to " + data.cat + " (" + size + ")" : "File uploaded: " + data.name + " (" + size + ")";
+    },
+    "cat.status": function (data) {
+      return data.from ? data.name + " is now " + data.to + " (was " + data.from + ")" : data.name + " was added as " + data.to;
+    }
+  };
+
+  function add(list, text, link) {
+    var item = document.createElement("li");
+    item.textContent = text; // textContent, never innerHTML: names come from visitors
+    if (link) {
+      var a = document.createElement("a");
+      a.href = link.href;
+      a.textContent = link.text;
+      item.appendChild(document.createTextNode(" "));
+      item.appendChild(a);
+    }
+    list.insertBefore(item, list.firstChild);
+    while (list.children.length > MAX_ITEMS) {
+      list.removeChild(list.lastChild);
+    }
+    list.hidden = false;
+  }
+
+  function listen(list) {
+    var source, watchdog;
+    var lastId = "";
+
+    function open() {
+      var url = list.dataset.events;
+      if (lastId) {
+        url += (url.indexOf("?") < 0 ? "?" : "&") + "lastEventId=" + encodeURIComponent(lastId);
+      }
+      source = new EventSource(url);
+      alive();
+
+      Object.keys(describe).forEach(function (type) {
+        source.addEventListener(type, function (event) {
+          seen(event);
+          var e = JSON.parse(event.data);
+          add(list, describe[type](e.data || {}));
+        });
+      });
+
+      source.addEventListener("heartbeat", alive);
+
+      // The server could not replay everything we missed (it restarted, or
+      // too much happened): the page itself is out of date
+      source.addEventListener("resync", function (event) {
+        seen(event);
+        add(list, "Some updates were missed.", { href: location.href, text: "Reload the page" });
+      });
+
+      source.addEventListener("reconnect", reopen);
+    }
+
+    // Remember where we are, for the next stream to start from
+    function seen(event) {
+      alive();
+      if (event.lastEventId) {
+        lastId = event.lastEventId;
+      }
+    }
+
+    // Any event proves the connection works; silence for STALE_MS means it doesn't
+    function alive() {
+      clearTimeout(watchdog);
+      watchdog = setTimeout(reopen, STALE_MS);
+    }
+
+    function reopen() {
+      clearTimeout(watchdog);
+      source.close();
+      setTimeout(open, RETRY_MS);
+    }
+
+    open();
+  }
+
+  document.querySelectorAll("[data-events]").forEach(listen);
+})();
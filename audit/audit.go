@@ -0,0 +1,118 @@
+// Package audit records who changed what, and when.
+// Entries are append-only: nothing in the application edits or deletes them.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Change is one field's before/after values
+type Change struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Entry is one recorded action
+type Entry struct {
+	At      time.Time `json:"at"`
+	Actor   string    `json:"actor"`  // the signed-in staff member
+	Action  string    `json:"action"` // e.g. "cat.create", "cat.update", "application.status"
+	Target  string    `json:"target"` // what was acted on, e.g. a cat slug
+	Changes []Change  `json:"changes,omitempty"`
+}
+
+// Log is where entries go
+type Log interface {
+	// Record appends an entry (At is filled in if left zero)
+	Record(e Entry) error
+
+	// Recent returns up to limit entries, newest first
+	Recent(limit int) ([]Entry, error)
+}
+
+var _ Log = (*FileLog)(nil)
+
+// FileLog appends entries to a JSON Lines file (one JSON object per line)
+// JSON Lines suits logs: appending never rewrites earlier entries, and the
+// file stays readable with ordinary tools like grep and tail
+type FileLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileLog(path string) (*FileLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &FileLog{path: path}, nil
+}
+
+func (l *FileLog) Record(e Entry) error {
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// O_APPEND: every write goes to the end of the file, even with other writers
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (l *FileLog) Recent(limit int) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil // nothing recorded yet
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var all []Entry
+	scanner := bufio.NewScanner(f) // SCANNER reads line by line
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip a damaged line rather than losing the whole log
+		}
+		all = append(all, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest first, capped at limit
+	out := make([]Entry, 0, min(limit, len(all)))
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, all[i])
+	}
+	return out, nil
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. APPEND-ONLY LOGS - O_APPEND and JSON Lines
+// 2. BUFIO.SCANNER - reading a file one line at a time
+// 3. BUILT-IN MIN - Go 1.21+ has min/max for ordered types
@@ -0,0 +1,135 @@
+// Package auth protects the staff-only admin pages with HTTP Basic authentication.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+	"form_exer/forms"
+)
+
+// userKey is the request-scoped storage key holding the signed-in username
+const userKey = "auth.user"
+
+// Users maps usernames to passwords
+type Users map[string]string
+
+// ParseUsers reads "alice:secret,bob:hunter2" (the ADMIN_USERS environment variable format)
+// Malformed entries are skipped
+func ParseUsers(spec string) Users {
+	users := Users{}
+	for _, pair := range strings.Split(spec, ",") {
+		name, pass, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && name != "" && pass != "" {
+			users[name] = pass
+		}
+	}
+	return users
+}
+
+// Check reports whether the username/password pair is valid
+//
+// CONSTANT-TIME COMPARISON: a normal == returns as soon as one byte differs, so an
+// attacker timing responses could guess a password byte by byte. Hashing both sides
+// to a fixed length and comparing with subtle.ConstantTimeCompare avoids that leak.
+func (u Users) Check(name, pass string) bool {
+	want, ok := u[name]
+	if !ok {
+		want = "\x00" // still do the comparison so unknown users take the same time
+	}
+	a, b := sha256.Sum256([]byte(pass)), sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1 && ok
+}
+
+// Require wraps a handler so it only runs for signed-in staff
+//
+// HANDLER WRAPPER (decorator): takes a handler, returns a new handler that does
+// extra work first. We wrap each admin route rather than using rweb group
+// middleware because rweb groups automatically continue to the route when a
+// middleware returns nil without calling Next() - a rejected request would
+// still reach the handler.
+//
+// CROSS-SITE REQUEST FORGERY: browsers resend saved Basic credentials with
+// any request to the site - including a form another site posts here. So a
+// request that changes something must also come from one of our own pages
+// (see sameOrigin), or it is refused with a 403.
+func Require(users Users, realm string) func(rweb.Handler) rweb.Handler {
+	return func(next rweb.Handler) rweb.Handler {
+		return func(ctx rweb.Context) error {
+			name, pass, ok := basicCredentials(ctx.Request().Header("Authorization"))
+			if !ok || !users.Check(name, pass) {
+				// 401 + WWW-Authenticate makes the browser show its login prompt
+				ctx.Response().SetHeader("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+				ctx.Response().SetStatus(http.StatusUnauthorized)
+				return ctx.WriteText("Authentication required.")
+			}
+
+			if !safeMethod(ctx.Request().Method()) && !sameOrigin(ctx.Request()) {
+				return apperr.Forbidden("error.cross_site")
+			}
+
+			ctx.Set(userKey, name)
+			return next(ctx)
+		}
+	}
+}
+
+// CurrentUser returns the signed-in staff member's name, or "" outside Require
+func CurrentUser(ctx rweb.Context) string {
+	// COMMA-OK TYPE ASSERTION: doesn't panic when the value is missing or another type
+	name, _ := ctx.Get(userKey).(string)
+	return name
+}
+
+// safeMethod: GET, HEAD and OPTIONS only read, so a forged one can do no harm
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sameOrigin reports whether a request came from one of the site's own pages
+//
+// FETCH METADATA: modern browsers say where a request came from in
+// Sec-Fetch-Site; older ones at least send Origin with every POST. A request
+// with neither didn't come from a browser's form or script (curl, say) and
+// carries only the credentials its sender chose to send.
+func sameOrigin(req rweb.ItfRequest) bool {
+	if site := forms.Header(req, "Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none" // "none": typed in or bookmarked
+	}
+	origin := forms.Header(req, "Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin) // "null" (a sandboxed page) has no host and fails below
+	if err != nil || u.Host == "" {
+		return false
+	}
+	// Only the host is compared: behind a TLS proxy the scheme differs
+	return strings.EqualFold(u.Host, forms.Header(req, "Host"))
+}
+
+// basicCredentials decodes an "Authorization: Basic <base64(user:pass)>" header
+func basicCredentials(header string) (name, pass string, ok bool) {
+	encoded, found := strings.CutPrefix(header, "Basic ")
+	if !found {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. HANDLER WRAPPERS - func(rweb.Handler) rweb.Handler adds behaviour around a route
+// 2. CONSTANT-TIME COMPARISON - defending against timing attacks
+// 3. REQUEST-SCOPED DATA - ctx.Set/ctx.Get carry the username to the handler
+// 4. CSRF PROTECTION - state changes only from the site's own pages
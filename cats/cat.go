@@ -0,0 +1,125 @@
+// Package cats contains the domain model for the adoptable cats shown on the site
+// along with the repository abstraction used to load and store them.
+// Keeping the data out of the page components means staff can change the catalog
+// without touching any rendering code.
+package cats
+
+import "fmt"
+
+// NAMED TYPE over string: Status gets its own type so the compiler keeps it
+// from being mixed up with any other string (a name, a breed, ...)
+type Status string
+
+// TYPED CONSTANTS: The set of statuses a cat can be in
+const (
+	StatusAvailable Status = "available" // ready to meet new families
+	StatusPending   Status = "pending"   // an adoption is in progress
+	StatusAdopted   Status = "adopted"   // found a forever home
+	StatusRetired   Status = "retired"   // no longer listed (kept for records)
+)
+
+// AllStatuses lists every status, for dropdowns and validation
+var AllStatuses = []Status{StatusAvailable, StatusPending, StatusAdopted, StatusRetired}
+
+// Photo is a single picture of a cat
+// Alt text is required for accessibility (screen readers announce it)
+type Photo struct {
+	URL string `json:"url"`
+	Alt string `json:"alt"`
+}
+
+// Cat is the core domain model
+//
+// STRUCT TAGS: The `json:"..."` annotations tell encoding/json which key to use
+// for each field when the cat is saved to (or loaded from) a file
+type Cat struct {
+	// Slug is the URL-friendly unique identifier, e.g. "whiskers" -> /cats/whiskers
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+
+	// AgeMonths stores age as a single number so cats can be sorted and filtered easily
+	// Use AgeLabel() for a human-friendly rendering
+	AgeMonths int `json:"age_months"`
+
+	Breed       string  `json:"breed"`
+	Temperament string  `json:"temperament"` // short personality summary shown on cards
+	Description string  `json:"description"` // the full story, shown on the detail page
+	Photos      []Photo `json:"photos"`
+	Health      Health  `json:"health"`
+	Status      Status  `json:"status"`
+
+	// Compatibility notes, used by the catalog filters ("good with kids", "good with pets")
+	// A false value means "not known to be" rather than "known not to be"
+	GoodWithKids bool `json:"good_with_kids"`
+	GoodWithPets bool `json:"good_with_pets"`
+}
+
+// Health groups the medical information adopters ask about
+// NESTED STRUCT: Cat contains a Health value (not a pointer), so every cat
+// always has a Health - its zero value simply means "nothing recorded yet"
+type Health struct {
+	Vaccinations   []string `json:"vaccinations"` // e.g. "FVRCP", "Rabies"
+	SpayedNeutered bool     `json:"spayed_neutered"`
+	Microchipped   bool     `json:"microchipped"`
+	Notes          string   `json:"notes"` // special diets, medications, vet notes
+}
+
+// AgeLabel renders the age the way people talk about it: "8 months", "1 year", "4 years"
+func (c Cat) AgeLabel() string {
+	// SWITCH with no condition: each case is a boolean expression (a tidy if/else chain)
+	switch {
+	case c.AgeMonths < 12:
+		return plural(c.AgeMonths, "month")
+	default:
+		return plural(c.AgeMonths/12, "year") // INTEGER DIVISION truncates toward zero
+	}
+}
+
+// MainPhoto returns the first photo, or the zero-value Photo if there are none
+// The zero value lets callers render without nil/len checks everywhere
+func (c Cat) MainPhoto() (p Photo) {
+	if len(c.Photos) > 0 {
+		p = c.Photos[0]
+	}
+	return
+}
+
+// FullDescription falls back to the short temperament blurb when no longer story has been written
+func (c Cat) FullDescription() string {
+	if c.Description != "" {
+		return c.Description
+	}
+	return c.Temperament
+}
+
+// IsAvailable reports whether the cat can currently be adopted
+func (c Cat) IsAvailable() bool {
+	return c.Status == StatusAvailable
+}
+
+// FilterByStatus returns only the cats with the given status
+// A NEW SLICE is built so the caller's slice is never modified
+func FilterByStatus(list []Cat, status Status) []Cat {
+	out := make([]Cat, 0, len(list))
+	for _, c := range list {
+		if c.Status == status {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// plural formats a count with a simple English plural
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. NAMED TYPES - Status is a distinct type built on string
+// 2. TYPED CONSTANTS - A fixed set of valid Status values
+// 3. STRUCT TAGS - Controlling JSON field names
+// 4. ZERO VALUES - MainPhoto returns an empty Photo instead of nil
+// 5. SLICE FILTERING - Building a new slice rather than mutating the input
@@ -0,0 +1,151 @@
+package cats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileRepository stores cats in a single JSON file
+// The whole catalog is small, so it is kept in memory and the file is
+// rewritten on every change
+//
+// COMPILE-TIME INTERFACE CHECK: This line fails to compile if FileRepository
+// ever stops satisfying Repository. The blank identifier (_) discards the value.
+var _ Repository = (*FileRepository)(nil)
+
+type FileRepository struct {
+	path string
+
+	// MUTEX: Handlers run concurrently (one goroutine per connection),
+	// so access to the map must be synchronized
+	// RWMutex allows many readers at once but only one writer
+	mu   sync.RWMutex
+	cats map[string]Cat // keyed by slug
+}
+
+// NewFileRepository loads the catalog from path
+// A missing file is not an error - it simply means the catalog starts out empty
+func NewFileRepository(path string) (*FileRepository, error) {
+	repo := &FileRepository{path: path, cats: make(map[string]Cat)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return repo, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cat catalog %q: %w", path, err)
+	}
+
+	var list []Cat
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing cat catalog %q: %w", path, err)
+	}
+	for _, c := range list {
+		repo.cats[c.Slug] = c
+	}
+	return repo, nil
+}
+
+// List returns every cat ordered by name
+func (r *FileRepository) List() ([]Cat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock() // DEFER guarantees the unlock even on early return
+	return r.sortedLocked(), nil
+}
+
+// Get returns a single cat by slug
+func (r *FileRepository) Get(slug string) (Cat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// COMMA-OK IDIOM: the second value reports whether the key was present
+	c, ok := r.cats[slug]
+	if !ok {
+		return Cat{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// Save inserts or replaces a cat and persists the catalog
+func (r *FileRepository) Save(cat Cat) error {
+	if cat.Slug == "" {
+		return errors.New("cat slug is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, existed := r.cats[cat.Slug]
+	r.cats[cat.Slug] = cat
+	if err := r.persistLocked(); err != nil {
+		// ROLLBACK: keep memory and disk consistent if the write failed
+		if existed {
+			r.cats[cat.Slug] = prev
+		} else {
+			delete(r.cats, cat.Slug)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a cat and persists the catalog
+func (r *FileRepository) Delete(slug string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, ok := r.cats[slug]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.cats, slug)
+	if err := r.persistLocked(); err != nil {
+		r.cats[slug] = prev
+		return err
+	}
+	return nil
+}
+
+// sortedLocked returns the cats as a slice sorted by name
+// The "Locked" suffix is a naming convention: the caller must already hold the mutex
+func (r *FileRepository) sortedLocked() []Cat {
+	list := make([]Cat, 0, len(r.cats))
+	for _, c := range r.cats {
+		list = append(list, c)
+	}
+	// MAP ITERATION ORDER IS RANDOM in Go, so sort for stable output
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// persistLocked writes the catalog to disk atomically:
+// write a temp file in the same directory, then rename it over the real file
+// A rename is atomic, so readers never see a half-written catalog
+func (r *FileRepository) persistLocked() error {
+	data, err := json.MarshalIndent(r.sortedLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. COMPILE-TIME INTERFACE CHECK - var _ Repository = (*FileRepository)(nil)
+// 2. SYNC.RWMUTEX - Safe concurrent access from many request goroutines
+// 3. ERROR WRAPPING - fmt.Errorf with %w keeps the original error inspectable
+// 4. ATOMIC FILE WRITES - temp file + rename
+// 5. ROLLBACK ON FAILURE - memory state only changes if the disk write succeeds
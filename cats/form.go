@@ -0,0 +1,135 @@
+package cats
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"form_exer/forms"
+)
+
+// Form field names used by the admin cat editor
+const (
+	FieldName         = "name"
+	FieldSlug         = "slug"
+	FieldAgeMonths    = "age_months"
+	FieldBreed        = "breed"
+	FieldTemperament  = "temperament"
+	FieldDescription  = "description"
+	FieldStatus       = "status"
+	FieldVaccinations = "vaccinations" // comma separated
+	FieldSpayed       = "spayed_neutered"
+	FieldMicrochipped = "microchipped"
+	FieldHealthNotes  = "health_notes"
+	FieldGoodWithKids = "good_with_kids"
+	FieldGoodWithPets = "good_with_pets"
+)
+
+// FormSchema describes the cat editor's fields, for the API documentation
+// Slug is ignored when an existing cat is edited; checkboxes are absent when unchecked
+var FormSchema = forms.Schema{
+	{Name: FieldName, Required: true},
+	{Name: FieldSlug, Description: "lowercase letters, numbers and dashes; new cats only"},
+	{Name: FieldAgeMonths, Type: forms.Integer, Description: "0 to 360"},
+	{Name: FieldBreed},
+	{Name: FieldTemperament, Required: true, Description: "a short personality summary for the card"},
+	{Name: FieldDescription},
+	{Name: FieldStatus, Required: true, Enum: statusNames()},
+	{Name: FieldVaccinations, Description: "comma separated"},
+	{Name: FieldSpayed, Type: forms.Boolean},
+	{Name: FieldMicrochipped, Type: forms.Boolean},
+	{Name: FieldHealthNotes},
+	{Name: FieldGoodWithKids, Type: forms.Boolean},
+	{Name: FieldGoodWithPets, Type: forms.Boolean},
+}
+
+// statusNames lists AllStatuses as plain strings
+func statusNames() []string {
+	names := make([]string, len(AllStatuses))
+	for i, s := range AllStatuses {
+		names[i] = string(s)
+	}
+	return names
+}
+
+// ApplyForm copies the editable fields from a submitted form into the cat
+// Photos and (for existing cats) the slug are managed separately, so they are left alone
+// It returns errors only for values that could not be converted; see Validate for the rules
+func (c *Cat) ApplyForm(get forms.ValueFunc) forms.FieldErrors {
+	errs := forms.FieldErrors{}
+	text := func(field string) string { return strings.TrimSpace(get(field)) }
+
+	c.Name = text(FieldName)
+	c.Breed = text(FieldBreed)
+	c.Temperament = text(FieldTemperament)
+	c.Description = text(FieldDescription)
+	c.Status = Status(text(FieldStatus))
+
+	c.AgeMonths = 0
+	if raw := text(FieldAgeMonths); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			errs.Add(FieldAgeMonths, "Please enter the age as a whole number of months.")
+		}
+		c.AgeMonths = n
+	}
+
+	c.Health.Vaccinations = nil
+	for _, v := range strings.Split(text(FieldVaccinations), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			c.Health.Vaccinations = append(c.Health.Vaccinations, v)
+		}
+	}
+	c.Health.SpayedNeutered = get(FieldSpayed) != ""
+	c.Health.Microchipped = get(FieldMicrochipped) != ""
+	c.Health.Notes = text(FieldHealthNotes)
+	c.GoodWithKids = get(FieldGoodWithKids) != ""
+	c.GoodWithPets = get(FieldGoodWithPets) != ""
+	return errs
+}
+
+// Validate checks the rules every saved cat must follow
+func (c Cat) Validate() forms.FieldErrors {
+	errs := forms.FieldErrors{}
+
+	if c.Name == "" {
+		errs.Add(FieldName, "Every cat needs a name.")
+	}
+	if c.Slug == "" || Slugify(c.Slug) != c.Slug {
+		errs.Add(FieldSlug, "Use lowercase letters, numbers and dashes only.")
+	}
+	if c.AgeMonths < 0 || c.AgeMonths > 30*12 {
+		errs.Add(FieldAgeMonths, "Age must be between 0 and 360 months.")
+	}
+	if c.Temperament == "" {
+		errs.Add(FieldTemperament, "Please add a short personality summary for the card.")
+	}
+
+	validStatus := false
+	for _, s := range AllStatuses {
+		validStatus = validStatus || s == c.Status
+	}
+	if !validStatus {
+		errs.Add(FieldStatus, "Please choose a status.")
+	}
+	return errs
+}
+
+// Slugify turns a name into a URL-friendly slug: "Mr. Whiskers Jr" -> "mr-whiskers-jr"
+func Slugify(name string) string {
+	var sb strings.Builder
+	dash := false // was the last character written a dash?
+
+	// RANGE OVER A STRING yields RUNES (Unicode code points), not bytes
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			sb.WriteRune(r)
+			dash = false
+		case sb.Len() > 0 && !dash:
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "-")
+}
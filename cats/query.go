@@ -0,0 +1,252 @@
+package cats
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"form_exer/forms"
+)
+
+// Query string parameter names for the /cats listing
+// Every filter lives in the URL, so any search can be bookmarked or shared
+const (
+	ParamText    = "q"
+	ParamBreed   = "breed"
+	ParamStatus  = "status"
+	ParamMinAge  = "min_age" // years
+	ParamMaxAge  = "max_age" // years
+	ParamKids    = "kids"
+	ParamPets    = "pets"
+	ParamSort    = "sort"
+	ParamPage    = "page"
+	AnyStatus    = "any" // ParamStatus value meaning "don't filter on status"
+	DefaultLimit = 12    // cats per page
+)
+
+// Sort orders offered on the listing page
+const (
+	SortName     = "name"      // A to Z (the default)
+	SortNameDesc = "name_desc" // Z to A
+	SortYoungest = "youngest"
+	SortOldest   = "oldest"
+)
+
+// SortOptions lists the sort orders in the order the dropdown shows them
+var SortOptions = []string{SortName, SortNameDesc, SortYoungest, SortOldest}
+
+// QuerySchema describes the listing's parameters, for the API documentation
+var QuerySchema = forms.Schema{
+	{Name: ParamText, Description: "words to look for in name, breed, temperament and description"},
+	{Name: ParamBreed},
+	{Name: ParamStatus, Enum: append(statusNames(), AnyStatus), Description: "default: available"},
+	{Name: ParamMinAge, Type: forms.Integer, Description: "years"},
+	{Name: ParamMaxAge, Type: forms.Integer, Description: "years"},
+	{Name: ParamKids, Type: forms.Boolean, Description: "only cats good with kids"},
+	{Name: ParamPets, Type: forms.Boolean, Description: "only cats good with other pets"},
+	{Name: ParamSort, Enum: SortOptions},
+	{Name: ParamPage, Type: forms.Integer, Description: "1-based"},
+}
+
+// Query describes one search of the catalog
+// The ZERO VALUE means "everything, sorted by name, first page"
+type Query struct {
+	Text   string // free text matched against name, breed, temperament and description
+	Breed  string // exact breed (case-insensitive)
+	Status string // a Status value, AnyStatus, or "" (treated as AnyStatus)
+
+	// Age range in whole years; nil POINTERS mean "no bound"
+	// (a plain int could not tell "no minimum" from "minimum 0")
+	MinAge *int
+	MaxAge *int
+
+	GoodWithKids bool
+	GoodWithPets bool
+
+	Sort string
+	Page int // 1-based
+}
+
+// ParseQuery reads a Query from request parameters
+// Bad numbers are ignored rather than reported - a mistyped URL should still show cats
+// When no status is given the listing defaults to cats that can be adopted now
+func ParseQuery(get forms.ValueFunc) Query {
+	q := Query{
+		Text:         strings.TrimSpace(get(ParamText)),
+		Breed:        strings.TrimSpace(get(ParamBreed)),
+		Status:       strings.TrimSpace(get(ParamStatus)),
+		MinAge:       optionalInt(get(ParamMinAge)),
+		MaxAge:       optionalInt(get(ParamMaxAge)),
+		GoodWithKids: get(ParamKids) != "",
+		GoodWithPets: get(ParamPets) != "",
+		Sort:         get(ParamSort),
+		Page:         1,
+	}
+	if q.Status == "" {
+		q.Status = string(StatusAvailable)
+	}
+	if n, err := strconv.Atoi(get(ParamPage)); err == nil && n > 1 {
+		q.Page = n
+	}
+	return q
+}
+
+// optionalInt parses a non-negative number, returning nil when s is blank or invalid
+func optionalInt(s string) *int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return nil
+	}
+	return &n
+}
+
+// Values turns the query back into URL parameters - the inverse of ParseQuery
+// Only non-default values are included, which keeps bookmarked URLs short
+func (q Query) Values() url.Values {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	set(ParamText, q.Text)
+	set(ParamBreed, q.Breed)
+	if q.Status != string(StatusAvailable) {
+		set(ParamStatus, q.Status)
+	}
+	if q.MinAge != nil {
+		set(ParamMinAge, strconv.Itoa(*q.MinAge))
+	}
+	if q.MaxAge != nil {
+		set(ParamMaxAge, strconv.Itoa(*q.MaxAge))
+	}
+	if q.GoodWithKids {
+		set(ParamKids, "1")
+	}
+	if q.GoodWithPets {
+		set(ParamPets, "1")
+	}
+	if q.Sort != SortName {
+		set(ParamSort, q.Sort)
+	}
+	if q.Page > 1 {
+		set(ParamPage, strconv.Itoa(q.Page))
+	}
+	return v
+}
+
+// WithPage returns a copy of the query pointing at another page
+// VALUE RECEIVER: q is already a copy, so changing it cannot affect the caller
+func (q Query) WithPage(page int) Query {
+	q.Page = page
+	return q
+}
+
+// Matches reports whether a single cat passes every filter in the query
+func (q Query) Matches(c Cat) bool {
+	if q.Status != "" && q.Status != AnyStatus && Status(q.Status) != c.Status {
+		return false
+	}
+	if q.Breed != "" && !strings.EqualFold(q.Breed, c.Breed) {
+		return false
+	}
+
+	years := c.AgeMonths / 12
+	if q.MinAge != nil && years < *q.MinAge {
+		return false
+	}
+	if q.MaxAge != nil && years > *q.MaxAge {
+		return false
+	}
+
+	if q.GoodWithKids && !c.GoodWithKids {
+		return false
+	}
+	if q.GoodWithPets && !c.GoodWithPets {
+		return false
+	}
+
+	// FREE TEXT: every word must appear somewhere, so "shy tabby" narrows rather than widens
+	haystack := strings.ToLower(strings.Join([]string{c.Name, c.Breed, c.Temperament, c.Description}, " "))
+	for _, word := range strings.Fields(strings.ToLower(q.Text)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Result is one page of search results plus what the pager needs to know
+type Result struct {
+	Cats  []Cat // just this page
+	Total int   // matches across all pages
+	Page  int
+	Pages int
+}
+
+// Search filters, sorts and paginates a list of cats
+// The input slice is never modified
+func Search(list []Cat, q Query) Result {
+	matches := make([]Cat, 0, len(list))
+	for _, c := range list {
+		if q.Matches(c) {
+			matches = append(matches, c)
+		}
+	}
+
+	// SORT.SLICESTABLE with a LESS FUNCTION (a closure over matches)
+	// Stable keeps equal ages in name order, so paging is deterministic
+	sort.SliceStable(matches, func(i, j int) bool {
+		return strings.ToLower(matches[i].Name) < strings.ToLower(matches[j].Name)
+	})
+	switch q.Sort {
+	case SortNameDesc:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return strings.ToLower(matches[i].Name) > strings.ToLower(matches[j].Name)
+		})
+	case SortYoungest:
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].AgeMonths < matches[j].AgeMonths })
+	case SortOldest:
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].AgeMonths > matches[j].AgeMonths })
+	}
+
+	// PAGINATION MATH: ceiling division, then clamp the requested page into range
+	r := Result{Total: len(matches), Page: q.Page}
+	r.Pages = (r.Total + DefaultLimit - 1) / DefaultLimit
+	if r.Pages == 0 {
+		r.Pages = 1
+	}
+	if r.Page < 1 {
+		r.Page = 1
+	}
+	if r.Page > r.Pages {
+		r.Page = r.Pages
+	}
+
+	start := (r.Page - 1) * DefaultLimit
+	end := min(start+DefaultLimit, r.Total)
+	r.Cats = matches[start:end]
+	return r
+}
+
+// Breeds returns the distinct breeds in a list, sorted, for the breed dropdown
+func Breeds(list []Cat) []string {
+	seen := map[string]bool{} // MAP AS A SET
+	var out []string
+	for _, c := range list {
+		if c.Breed != "" && !seen[c.Breed] {
+			seen[c.Breed] = true
+			out = append(out, c.Breed)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. POINTERS FOR OPTIONAL VALUES - nil means "not set", distinct from zero
+// 2. ROUND-TRIPPING - ParseQuery and Values convert between URL and struct
+// 3. SORT.SLICESTABLE - Sorting with a closure, keeping ties in order
+// 4. PAGINATION - Ceiling division and clamping
+// 5. MAPS AS SETS - Collecting distinct breeds
@@ -0,0 +1,106 @@
+package cats
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// intPtr is a *int for the optional bounds
+func intPtr(n int) *int { return &n }
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		minAge *int
+		maxAge *int
+		page   int
+		status string
+	}{
+		{"defaults", "", nil, nil, 1, "available"},
+		{"bounds and page", "min_age=2&max_age=5&page=3", intPtr(2), intPtr(5), 3, "available"},
+		{"zero is a bound", "min_age=0", intPtr(0), nil, 1, "available"},
+		{"spaces around numbers", "min_age=+2+&max_age=%205", intPtr(2), intPtr(5), 1, "available"},
+		// Bad numbers are dropped, not reported: the listing still shows cats
+		{"not numbers", "min_age=two&max_age=5y&page=last", nil, nil, 1, "available"},
+		{"negative", "min_age=-1&max_age=-3&page=-2", nil, nil, 1, "available"},
+		{"page zero", "page=0", nil, nil, 1, "available"},
+		{"too big", "max_age=99999999999999999999", nil, nil, 1, "available"},
+		// min > max is kept as given: it simply matches nothing (see TestSearch)
+		{"min above max", "min_age=9&max_age=1", intPtr(9), intPtr(1), 1, "available"},
+		{"any status", "status=any", nil, nil, 1, AnyStatus},
+		{"blank status", "status=+", nil, nil, 1, "available"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q := ParseQuery(values.Get) // METHOD VALUE as the forms.ValueFunc
+
+			if !sameBound(q.MinAge, tt.minAge) || !sameBound(q.MaxAge, tt.maxAge) {
+				t.Errorf("ages %s..%s, want %s..%s", bound(q.MinAge), bound(q.MaxAge), bound(tt.minAge), bound(tt.maxAge))
+			}
+			if q.Page != tt.page || q.Status != tt.status {
+				t.Errorf("page %d status %q, want %d %q", q.Page, q.Status, tt.page, tt.status)
+			}
+		})
+	}
+}
+
+func sameBound(a, b *int) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func bound(p *int) string {
+	if p == nil {
+		return "_"
+	}
+	return strconv.Itoa(*p)
+}
+
+func TestSearch(t *testing.T) {
+	// 30 available cats aged 0..29 years, plus one adopted: three pages of DefaultLimit (12)
+	var list []Cat
+	for i := range 30 {
+		list = append(list, Cat{Slug: "cat" + strconv.Itoa(i), Name: "Cat " + strconv.Itoa(100+i), AgeMonths: i * 12, Status: StatusAvailable})
+	}
+	list = append(list, Cat{Slug: "gone", Name: "Gone", AgeMonths: 24, Status: StatusAdopted})
+
+	tests := []struct {
+		name      string
+		q         Query
+		total     int
+		page      int
+		pages     int
+		onPage    int
+		firstSlug string
+	}{
+		{"first page", Query{Status: "available"}, 30, 1, 3, 12, "cat0"},
+		{"last page is short", Query{Status: "available", Page: 3}, 30, 3, 3, 6, "cat24"},
+		{"beyond the last page shows the last", Query{Status: "available", Page: 99}, 30, 3, 3, 6, "cat24"},
+		{"page zero shows the first", Query{Status: "available", Page: 0}, 30, 1, 3, 12, "cat0"},
+		{"age range", Query{Status: "available", MinAge: intPtr(2), MaxAge: intPtr(4)}, 3, 1, 1, 3, "cat2"},
+		{"min above max matches nothing", Query{Status: "available", MinAge: intPtr(9), MaxAge: intPtr(1)}, 0, 1, 1, 0, ""},
+		{"no matches is still page 1 of 1", Query{Status: "available", Text: "zebra", Page: 4}, 0, 1, 1, 0, ""},
+		{"any status", Query{Status: AnyStatus, MinAge: intPtr(2), MaxAge: intPtr(2)}, 2, 1, 1, 2, "cat2"},
+		{"oldest first", Query{Status: "available", Sort: SortOldest}, 30, 1, 3, 12, "cat29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Search(list, tt.q)
+			if r.Total != tt.total || r.Page != tt.page || r.Pages != tt.pages || len(r.Cats) != tt.onPage {
+				t.Fatalf("total %d page %d/%d with %d cats, want %d page %d/%d with %d",
+					r.Total, r.Page, r.Pages, len(r.Cats), tt.total, tt.page, tt.pages, tt.onPage)
+			}
+			if tt.firstSlug != "" && r.Cats[0].Slug != tt.firstSlug {
+				t.Errorf("first cat %s, want %s", r.Cats[0].Slug, tt.firstSlug)
+			}
+		})
+	}
+	if list[0].Slug != "cat0" || list[30].Slug != "gone" {
+		t.Error("Search reordered its input")
+	}
+}
@@ -0,0 +1,24 @@
+package cats
+
+import "errors"
+
+// SENTINEL ERROR: A package-level error value callers can compare against
+// Usage: if errors.Is(err, cats.ErrNotFound) { ... render a 404 ... }
+var ErrNotFound = errors.New("cat not found")
+
+// INTERFACE: Repository describes WHAT we can do with stored cats, not HOW
+// Pages and handlers depend only on this interface, so the storage can be
+// swapped (JSON file today, a database tomorrow) without touching them
+type Repository interface {
+	// List returns every cat, ordered by name
+	List() ([]Cat, error)
+
+	// Get returns the cat with the given slug, or ErrNotFound
+	Get(slug string) (Cat, error)
+
+	// Save inserts the cat, or replaces an existing cat with the same slug
+	Save(cat Cat) error
+
+	// Delete removes the cat with the given slug, or returns ErrNotFound
+	Delete(slug string) error
+}
@@ -0,0 +1,210 @@
+// Package cors lets pages on other origins call our API endpoints.
+//
+// Browsers stop a script on https://app.example.com from reading responses
+// from our site - the SAME-ORIGIN POLICY. CROSS-ORIGIN RESOURCE SHARING (CORS)
+// is how a server opts out for chosen origins: it answers with
+// Access-Control-Allow-* headers naming who may read the response.
+//
+// Requests that could change something in ways a plain HTML form couldn't
+// (a JSON body, a PUT, a custom header) are announced first with a PREFLIGHT:
+// an OPTIONS request asking "may I?". Only if the preflight answer allows the
+// method and headers does the browser send the real request.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/forms"
+)
+
+// Policy says which other origins may call a group of routes, and how
+type Policy struct {
+	// AllowedOrigins are full origins ("https://app.example.com"), wildcard
+	// subdomains ("https://*.example.com" - not example.com itself), or "*" for anyone
+	AllowedOrigins []string
+
+	// AllowedMethods may be used in cross-origin requests; empty means GET, HEAD and POST
+	AllowedMethods []string
+
+	// AllowedHeaders may be sent, e.g. "Content-Type" for JSON bodies; "*" allows any
+	AllowedHeaders []string
+
+	// ExposedHeaders may be read by the calling script, beyond the few basic ones
+	// (Content-Type, ...) browsers always expose - e.g. "X-Request-ID"
+	ExposedHeaders []string
+
+	// AllowCredentials lets requests carry cookies and HTTP auth
+	// Can't be combined with "*": credentials need a named origin
+	AllowCredentials bool
+
+	// MaxAge is how long a browser may cache a preflight answer (0 = the browser's default)
+	MaxAge time.Duration
+}
+
+// Validate reports configuration mistakes, so they fail at startup and not in a browser
+func (p Policy) Validate() error {
+	if len(p.AllowedOrigins) == 0 {
+		return errors.New("cors: no allowed origins")
+	}
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			if p.AllowCredentials {
+				return errors.New(`cors: "*" can't be used with AllowCredentials - list the origins`)
+			}
+			continue
+		}
+		scheme, host, ok := strings.Cut(o, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.ContainsAny(host, "/?#") {
+			return fmt.Errorf("cors: %q is not an origin like https://app.example.com", o)
+		}
+		if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return fmt.Errorf("cors: %q: a wildcard may only replace the leftmost subdomain", o)
+		}
+	}
+	return nil
+}
+
+// allows reports whether origin matches one of the allowed origins
+func (p Policy) allows(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.AllowedOrigins {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		// WILDCARD SUBDOMAIN: "https://*.example.com" becomes prefix "https://"
+		// and suffix ".example.com"; what's between must be hostname labels
+		prefix, suffix, ok := strings.Cut(pattern, "*")
+		if !ok || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		sub := origin[len(prefix) : len(origin)-len(suffix)]
+		if len(origin) > len(prefix)+len(suffix) && isHostLabels(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostLabels reports whether s is only letters, digits, '-' and '.'
+// so "https://evil.com/x.example.com" can't pass for a subdomain
+func isHostLabels(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// methods returns the allowed methods, with the default filled in
+func (p Policy) methods() []string {
+	if len(p.AllowedMethods) == 0 {
+		return []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	return p.AllowedMethods
+}
+
+// allowsHeader reports whether a request header may be sent (names are case-insensitive)
+func (p Policy) allowsHeader(name string) bool {
+	return slices.ContainsFunc(p.AllowedHeaders, func(h string) bool {
+		return h == "*" || strings.EqualFold(h, name)
+	})
+}
+
+// allowOrigin sets the headers every CORS response for an allowed origin gets
+func (p Policy) allowOrigin(resp rweb.Response, origin string) {
+	if slices.Contains(p.AllowedOrigins, "*") && !p.AllowCredentials {
+		resp.SetHeader("Access-Control-Allow-Origin", "*")
+	} else {
+		resp.SetHeader("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		resp.SetHeader("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// Route applies p to one route's handler, for the real (non-preflight) requests
+//
+// The headers are set before the handler runs, so error responses carry them
+// too - otherwise the calling script couldn't read why its request failed
+func Route(p Policy, next rweb.Handler) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		resp := ctx.Response()
+		// VARY: the answer depends on the Origin header, so caches must keep one copy per origin
+		resp.SetHeader("Vary", "Origin")
+
+		// strings.Clone: header values point into rweb's reusable request buffer
+		if origin := strings.Clone(forms.Header(ctx.Request(), "Origin")); origin != "" && p.allows(origin) {
+			p.allowOrigin(resp, origin)
+			if len(p.ExposedHeaders) > 0 {
+				resp.SetHeader("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+			}
+		}
+		// A disallowed origin still gets the response - the browser just won't
+		// let the script read it. CORS protects the visitor, not the server.
+		return next(ctx)
+	}
+}
+
+// Preflight answers the OPTIONS requests for a path
+//
+// A preflight that isn't allowed gets 204 without the Allow headers: the browser
+// then refuses to send the real request. allowed lists the path's methods, for
+// the Allow header of plain (non-CORS) OPTIONS requests.
+func Preflight(p Policy, allowed []string) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		req := ctx.Request()
+		resp := ctx.Response()
+		resp.SetHeader("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+		resp.SetStatus(http.StatusNoContent)
+
+		origin := strings.Clone(forms.Header(req, "Origin"))
+		method := forms.Header(req, "Access-Control-Request-Method")
+		if origin == "" || method == "" {
+			// Not a preflight, just someone asking what the path supports
+			resp.SetHeader("Allow", strings.Join(slices.Concat(allowed, []string{http.MethodOptions}), ", "))
+			return nil
+		}
+		if !p.allows(origin) || !slices.Contains(p.methods(), method) || !slices.Contains(allowed, method) {
+			return nil
+		}
+
+		// Access-Control-Request-Headers: "content-type, x-request-id"
+		var headers []string
+		for _, h := range strings.Split(forms.Header(req, "Access-Control-Request-Headers"), ",") {
+			if h = strings.TrimSpace(h); h == "" {
+				continue
+			}
+			if !p.allowsHeader(h) {
+				return nil
+			}
+			headers = append(headers, strings.Clone(h))
+		}
+
+		p.allowOrigin(resp, origin)
+		resp.SetHeader("Access-Control-Allow-Methods", strings.Join(p.methods(), ", "))
+		if len(headers) > 0 {
+			// Echo what was asked for: it has all been checked above
+			resp.SetHeader("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+		}
+		if p.MaxAge > 0 {
+			resp.SetHeader("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+		}
+		return nil
+	}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. SAME-ORIGIN POLICY - browsers isolate sites; CORS is the server's opt-out
+// 2. PREFLIGHT REQUESTS - OPTIONS asks before a request with side effects is sent
+// 3. VARY - telling caches which request headers the response depends on
+// 4. FAIL AT STARTUP - Validate catches bad configuration before any request
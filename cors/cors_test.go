@@ -0,0 +1,150 @@
+package cors
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+var testPolicy = Policy{
+	AllowedOrigins:   []string{"https://app.example.com", "https://*.cats.example"},
+	AllowedMethods:   []string{http.MethodPost},
+	AllowedHeaders:   []string{"Content-Type", "X-Request-ID"},
+	ExposedHeaders:   []string{"X-Request-ID"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true}, // origins are case-insensitive
+		{"http://app.example.com", false}, // a different scheme is a different origin
+		{"https://app.example.com:8443", false},
+		{"https://shop.cats.example", true},
+		{"https://eu.shop.cats.example", true},
+		{"https://cats.example", false}, // the wildcard needs a subdomain
+		{"https://evilcats.example", false},
+		{"https://evil.com/.cats.example", false},
+		{"null", false}, // sandboxed iframes and file:// pages
+	}
+	for _, tt := range tests {
+		if got := testPolicy.allows(tt.origin); got != tt.want {
+			t.Errorf("allows(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := testPolicy.Validate(); err != nil {
+		t.Errorf("valid policy: %v", err)
+	}
+	for _, bad := range []Policy{
+		{},
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"app.example.com"}},
+		{AllowedOrigins: []string{"https://app.example.com/"}},
+		{AllowedOrigins: []string{"https://app.*.example.com"}},
+	} {
+		if bad.Validate() == nil {
+			t.Errorf("Validate(%v) should fail", bad.AllowedOrigins)
+		}
+	}
+}
+
+// newCORSServer has one POST route with testPolicy and its preflight
+func newCORSServer(p Policy) *rweb.Server {
+	s := rweb.NewServer()
+	s.Post("/api", Route(p, func(ctx rweb.Context) error { return ctx.WriteString("ok") }))
+	s.Options("/api", Preflight(p, []string{http.MethodPost}))
+	return s
+}
+
+func request(s *rweb.Server, method string, headers ...string) rweb.Response {
+	var hs []rweb.Header
+	for i := 0; i+1 < len(headers); i += 2 {
+		hs = append(hs, rweb.Header{Key: headers[i], Value: headers[i+1]})
+	}
+	return s.Request(method, "/api", hs, nil)
+}
+
+func TestRoute(t *testing.T) {
+	s := newCORSServer(testPolicy)
+
+	resp := request(s, http.MethodPost, "Origin", "https://app.example.com")
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Expose-Headers":    "X-Request-ID",
+		"Vary":                             "Origin",
+	} {
+		if got := resp.Header(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	resp = request(s, http.MethodPost, "Origin", "https://evil.example")
+	if resp.Header("Access-Control-Allow-Origin") != "" {
+		t.Error("a disallowed origin must not be named")
+	}
+	if string(resp.Body()) != "ok" {
+		t.Error("the handler still runs; the browser hides the response")
+	}
+}
+
+func TestAnyOrigin(t *testing.T) {
+	s := newCORSServer(Policy{AllowedOrigins: []string{"*"}})
+	resp := request(s, http.MethodPost, "Origin", "https://anyone.example")
+	if got := resp.Header("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	s := newCORSServer(testPolicy)
+
+	resp := request(s, http.MethodOptions,
+		"Origin", "https://shop.cats.example",
+		"Access-Control-Request-Method", "POST",
+		"Access-Control-Request-Headers", "content-type, x-request-id")
+	if resp.Status() != http.StatusNoContent {
+		t.Errorf("status %d, want 204", resp.Status())
+	}
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://shop.cats.example",
+		"Access-Control-Allow-Methods": "POST",
+		"Access-Control-Allow-Headers": "content-type, x-request-id",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if got := resp.Header(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// Refused preflights get no Allow headers, so the browser doesn't send the request
+func TestPreflightRefused(t *testing.T) {
+	s := newCORSServer(testPolicy)
+
+	for name, headers := range map[string][]string{
+		"origin": {"Origin", "https://evil.example", "Access-Control-Request-Method", "POST"},
+		"method": {"Origin", "https://app.example.com", "Access-Control-Request-Method", "DELETE"},
+		"header": {"Origin", "https://app.example.com", "Access-Control-Request-Method", "POST",
+			"Access-Control-Request-Headers", "x-admin"},
+	} {
+		resp := request(s, http.MethodOptions, headers...)
+		if resp.Header("Access-Control-Allow-Origin") != "" || resp.Header("Access-Control-Allow-Methods") != "" {
+			t.Errorf("%s: preflight should be refused", name)
+		}
+	}
+
+	// A plain OPTIONS request learns which methods the path supports
+	if got := request(s, http.MethodOptions).Header("Allow"); got != "POST, OPTIONS" {
+		t.Errorf("Allow = %q", got)
+	}
+}
@@ -0,0 +1,124 @@
+package crash
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/logging"
+)
+
+// newPanicServer has one route that panics, behind request IDs and Middleware
+func newPanicServer(opts Options) *rweb.Server {
+	s := rweb.NewServer()
+	s.Use(logging.Middleware(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	s.Use(Middleware(opts))
+	s.Get("/upload", func(ctx rweb.Context) error {
+		var sizes map[string]int
+		sizes["photo.jpg"] = 1 // writing to a nil map panics
+		return nil
+	})
+	s.Get("/fine", func(ctx rweb.Context) error {
+		return ctx.WriteString("ok")
+	})
+	return s
+}
+
+func TestMiddlewareRecovers(t *testing.T) {
+	now = func() time.Time { return time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	dir := t.TempDir()
+	var reports []Report
+	s := newPanicServer(Options{Dir: dir, OnPanic: func(r Report) { reports = append(reports, r) }})
+
+	resp := s.Request(http.MethodGet, "/upload?draft=1", []rweb.Header{
+		{Key: "Accept", Value: "text/html"},
+		{Key: "Cookie", Value: "session=s3cret"},
+		{Key: "Authorization", Value: "Basic c2FtOndoaXNrZXJz"},
+		{Key: "X-Api-Key", Value: "k-123"},
+	}, nil)
+
+	// No route recovered it further up, so rweb's fallback answers 500
+	if resp.Status() != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", resp.Status())
+	}
+	if len(reports) != 1 {
+		t.Fatalf("OnPanic called %d times, want 1", len(reports))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if len(files) != 1 {
+		t.Fatalf("report files: %v", files)
+	}
+	if want := "crash-20250314T093000.000Z-" + reports[0].RequestID + ".txt"; filepath.Base(files[0]) != want {
+		t.Errorf("file name %s, want %s", filepath.Base(files[0]), want)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	for _, want := range []string{
+		"panic: assignment to entry in nil map",
+		"request:    GET /upload?draft=1",
+		"Accept: text/html",
+		"Cookie: [REDACTED]",
+		"Authorization: [REDACTED]",
+		"X-Api-Key: [REDACTED]",
+		"goroutine ",
+		"crash_test.go", // the stack reaches the panicking handler
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	for _, secret := range []string{"s3cret", "c2FtOndoaXNrZXJz", "k-123"} {
+		if strings.Contains(text, secret) {
+			t.Errorf("report leaks %q", secret)
+		}
+	}
+
+	// The server is still up
+	if resp := s.Request(http.MethodGet, "/fine", nil, nil); string(resp.Body()) != "ok" {
+		t.Errorf("after the panic: %q", resp.Body())
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	cause := errors.New("disk on fire")
+	err := error(&PanicError{Value: cause, ReportFile: "crashes/crash-1.txt"})
+
+	if got := err.Error(); got != "panic: disk on fire (crash report crashes/crash-1.txt)" {
+		t.Errorf("Error() = %q", got)
+	}
+	// panic(err) keeps err visible to errors.Is
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is should find the panic value")
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Authorization":       true,
+		"proxy-authorization": true,
+		"Cookie":              true,
+		"X-CSRF-Token":        true,
+		"X-Api-Key":           true,
+		"Accept":              false,
+		"User-Agent":          false,
+		"Content-Type":        false,
+	} {
+		if got := sensitive(name); got != want {
+			t.Errorf("sensitive(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
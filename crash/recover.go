@@ -0,0 +1,88 @@
+// Package crash keeps a panicking handler from taking the whole server down.
+//
+// Go programs crash when a panic reaches the top of a goroutine, and rweb does
+// not recover panics in handlers - so one nil pointer in one request would stop
+// the site for everyone. Middleware recovers the panic instead, writes a crash
+// report (the panic, the stack and the request, with secrets redacted) to a
+// file, and turns it into an ordinary error. The error handler then answers
+// with the usual 500 page; the visitor never sees the stack trace.
+package crash
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/logging"
+)
+
+// Options configure Middleware
+type Options struct {
+	// Dir receives one report file per panic; "" keeps reports out of files
+	Dir string
+
+	// OnPanic, if set, is called with every report - e.g. to count panics
+	OnPanic func(Report)
+}
+
+// PanicError is returned in place of a handler that panicked
+type PanicError struct {
+	Value      any    // what was passed to panic()
+	Stack      []byte // the panicking goroutine's stack
+	ReportFile string // where the report was written; "" if it wasn't
+}
+
+// Error is short on purpose: it goes into the log line, the stack goes into the report
+func (e *PanicError) Error() string {
+	if e.ReportFile != "" {
+		return fmt.Sprintf("panic: %v (crash report %s)", e.Value, e.ReportFile)
+	}
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap exposes the panic value when it was an error, e.g. panic(err)
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Middleware recovers panics in everything registered after it
+//
+// Register it right after the error handler: the *PanicError it returns then
+// goes to the error handler like any other error and gets the 500 page, in the
+// visitor's theme and language.
+func Middleware(opts Options) rweb.Handler {
+	// NAMED RESULT: the deferred function below can replace what we return
+	return func(ctx rweb.Context) (err error) {
+		// DEFER + RECOVER: recover() only stops a panic when called directly
+		// from a deferred function. It returns nil when nothing panicked.
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// The stack must be captured HERE, while the panicking frames are still on it
+			perr := &PanicError{Value: v, Stack: debug.Stack()}
+
+			report := newReport(ctx, perr)
+			if opts.Dir != "" {
+				file, werr := report.Save(opts.Dir)
+				if werr != nil {
+					logging.FromContext(ctx).Error("writing crash report failed", "err", werr)
+				}
+				perr.ReportFile = file
+			}
+			if opts.OnPanic != nil {
+				opts.OnPanic(report)
+			}
+			err = perr
+		}()
+		return ctx.Next()
+	}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. PANIC AND RECOVER - stopping a panic before it ends the program
+// 2. DEFERRED FUNCTIONS AND NAMED RESULTS - changing the return value after a panic
+// 3. PANICS AS ERRORS - one error path for everything that goes wrong
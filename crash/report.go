@@ -0,0 +1,140 @@
+package crash
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/logging"
+	"form_exer/metrics"
+)
+
+// Report is everything known about one panic
+type Report struct {
+	Time      time.Time
+	RequestID string
+	Method    string
+	Path      string
+	Query     string
+	Route     string        // the route pattern, e.g. /cats/:slug ("" if routing hadn't happened)
+	Headers   []rweb.Header // with Redacted in place of secret values
+	Value     any
+	Stack     []byte
+}
+
+// Redacted replaces the value of every sensitive header in a report
+const Redacted = "[REDACTED]"
+
+// now is a variable so tests can fix the report time
+var now = time.Now
+
+// newReport gathers the request details for a report
+//
+// strings.Clone on everything: the strings point into rweb's request buffer,
+// which is reused once this request is over
+func newReport(ctx rweb.Context, perr *PanicError) Report {
+	req := ctx.Request()
+	r := Report{
+		Time:      now().UTC(),
+		RequestID: logging.RequestID(ctx),
+		Method:    strings.Clone(req.Method()),
+		Path:      strings.Clone(req.Path()),
+		Query:     strings.Clone(req.Query()),
+		Route:     metrics.RoutePattern(ctx),
+		Value:     perr.Value,
+		Stack:     perr.Stack,
+	}
+	for _, h := range req.Headers() {
+		value := Redacted
+		if !sensitive(h.Key) {
+			value = strings.Clone(h.Value)
+		}
+		r.Headers = append(r.Headers, rweb.Header{Key: strings.Clone(h.Key), Value: value})
+	}
+	return r
+}
+
+// sensitive reports whether a header may carry credentials
+// Crash reports get copied around and attached to bug reports, so when in doubt, redact
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"auth", "cookie", "token", "secret", "password", "key", "session"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTo writes the report as text, laid out like Go's own crash output:
+//
+//	panic: assignment to entry in nil map
+//
+//	time:       2025-03-14T09:30:00Z
+//	request_id: 9f2c41d07a3be815
+//	request:    POST /upload?x=1
+//	route:      /upload
+//
+//	headers:
+//	  Accept: text/html
+//	  Cookie: [REDACTED]
+//
+//	goroutine 42 [running]:
+//	...
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic: %v\n\n", r.Value)
+	fmt.Fprintf(&b, "time:       %s\n", r.Time.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "request_id: %s\n", r.RequestID)
+	target := r.Path
+	if r.Query != "" {
+		target += "?" + r.Query
+	}
+	fmt.Fprintf(&b, "request:    %s %s\n", r.Method, target)
+	fmt.Fprintf(&b, "route:      %s\n", r.Route)
+	b.WriteString("\nheaders:\n")
+	for _, h := range r.Headers {
+		fmt.Fprintf(&b, "  %s: %s\n", h.Key, h.Value)
+	}
+	b.WriteString("\n")
+	b.Write(r.Stack)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Save writes the report to a new file in dir and returns its path
+// The name sorts by time and includes the request ID, to find it from a log line:
+//
+//	crash-20250314T093000.000Z-9f2c41d07a3be815.txt
+func (r Report) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	id := r.RequestID
+	if id == "" {
+		id = "norequest"
+	}
+	name := filepath.Join(dir, fmt.Sprintf("crash-%s-%s.txt", r.Time.Format("20060102T150405.000Z"), id))
+
+	// 0600 and O_EXCL: reports hold request details, and never overwrite an older one
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return name, f.Close()
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. IO.WRITERTO - the report writes itself to a file, a buffer or a test
+// 2. REDACTION - secrets never reach a file that gets shared
+// 3. EXCLUSIVE CREATE - O_EXCL fails instead of overwriting an existing file
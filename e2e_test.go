@@ -0,0 +1,1295 @@
+package main
+
+// END-TO-END TESTS: each test boots the real server (newServer from main.go) on a
+// free port with its own temporary data and upload directories, then talks to it
+// over HTTP exactly like a browser or curl would. Nothing is mocked - routing,
+// middleware, form parsing, storage and rendering all run for real.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"form_exer/adoption"
+	"form_exer/auth"
+	"form_exer/cors"
+	"form_exer/events"
+	"form_exer/health"
+	"form_exer/logging"
+	"form_exer/mail"
+	"form_exer/proxy"
+	"form_exer/storage"
+	"form_exer/tracing"
+	"form_exer/web/webtest"
+)
+
+// Staff account used by the admin tests
+const (
+	testAdmin    = "sam"
+	testPassword = "whiskers"
+)
+
+// testServer is one running server plus the directories it writes to
+type testServer struct {
+	t         *testing.T
+	URL       string // e.g. "http://127.0.0.1:53127"
+	DataDir   string
+	UploadDir string
+	Outbox    *mail.Outbox // every email the server sent
+	client    *http.Client
+}
+
+// startServer boots a fresh server for a single test
+// edit, if given, adjusts the configuration first (e.g. to add a tracer)
+// Every test gets its own copy of the catalog, so tests can't disturb each other
+// (or the real data/ directory)
+func startServer(t *testing.T, edit ...func(*serverConfig)) *testServer {
+	t.Helper()
+
+	// t.TempDir is removed automatically when the test finishes
+	dir := t.TempDir()
+	dataDir, uploadDir := filepath.Join(dir, "data"), filepath.Join(dir, "uploads")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := os.ReadFile(filepath.Join("data", "cats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "cats.json"), catalog, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// BUFFERED CHANNEL (cap 1) so the server never blocks telling us it's ready
+	ready := make(chan struct{}, 1)
+	outbox := &mail.Outbox{}
+	cfg := serverConfig{
+		Address:    "localhost:0", // port 0: the OS picks any free port
+		DataDir:    dataDir,
+		UploadDir:  uploadDir,
+		AdminUsers: auth.Users{testAdmin: testPassword},
+		ContactTo:  "staff@example.com",
+		Mailer:     outbox,
+		ReadyChan:  ready,
+		// Quiet logs: failures show up as test errors, not as log lines
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, f := range edit {
+		f(&cfg)
+	}
+	s, err := newServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run blocks until the process is interrupted, so it gets its own goroutine
+	// The listener lives until the test binary exits, which is fine for tests
+	go func() {
+		if err := s.Run(); err != nil {
+			t.Errorf("server stopped: %v", err)
+		}
+	}()
+
+	// SELECT with a TIMEOUT: don't hang forever if the server never starts
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not start within 5s")
+	}
+
+	return &testServer{
+		t:         t,
+		URL:       "http://" + s.GetListenAddr(),
+		DataDir:   dataDir,
+		UploadDir: uploadDir,
+		Outbox:    outbox,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// Return redirects as they are, so tests can check the 303 and its Location
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// response is an HTTP response with the body already read
+type response struct {
+	*http.Response // EMBEDDED: StatusCode, Header, ... are promoted
+	Body           string
+}
+
+// do sends req and reads the whole body
+func (ts *testServer) do(req *http.Request) response {
+	ts.t.Helper()
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		ts.t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return response{Response: resp, Body: string(body)}
+}
+
+// request builds a request for a path on the test server
+// headers are name/value pairs: "Accept-Language", "es"
+func (ts *testServer) request(method, path string, body io.Reader, headers ...string) *http.Request {
+	ts.t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, body)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	return req
+}
+
+func (ts *testServer) get(path string, headers ...string) response {
+	ts.t.Helper()
+	return ts.do(ts.request(http.MethodGet, path, nil, headers...))
+}
+
+// postForm sends fields URL-encoded, like a plain HTML form
+func (ts *testServer) postForm(path string, fields url.Values, headers ...string) response {
+	ts.t.Helper()
+	headers = append(headers, "Content-Type", "application/x-www-form-urlencoded")
+	return ts.do(ts.request(http.MethodPost, path, strings.NewReader(fields.Encode()), headers...))
+}
+
+// postJSON sends body as a JSON request body
+func (ts *testServer) postJSON(path, body string, headers ...string) response {
+	ts.t.Helper()
+	headers = append(headers, "Content-Type", "application/json")
+	return ts.do(ts.request(http.MethodPost, path, strings.NewReader(body), headers...))
+}
+
+// upload is one file in a multipart request
+type upload struct {
+	Field, Name string
+	Content     []byte
+}
+
+// postMultipart sends fields and files as multipart/form-data, like a form with
+// enctype="multipart/form-data" and <input type="file">
+func (ts *testServer) postMultipart(path string, fields url.Values, files []upload, headers ...string) response {
+	ts.t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, v := range values {
+			if err := mw.WriteField(name, v); err != nil {
+				ts.t.Fatal(err)
+			}
+		}
+	}
+	for _, f := range files {
+		w, err := mw.CreateFormFile(f.Field, f.Name)
+		if err != nil {
+			ts.t.Fatal(err)
+		}
+		if _, err := w.Write(f.Content); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil { // writes the closing boundary
+		ts.t.Fatal(err)
+	}
+
+	headers = append(headers, "Content-Type", mw.FormDataContentType())
+	return ts.do(ts.request(http.MethodPost, path, &body, headers...))
+}
+
+// asAdmin returns the header pair for HTTP Basic auth as the test staff member
+func asAdmin() []string {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(testAdmin, testPassword)
+	return []string{"Authorization", req.Header.Get("Authorization")}
+}
+
+// expectStatus stops the test when the status is wrong - later checks would only add noise
+func expectStatus(t *testing.T, r response, want int) {
+	t.Helper()
+	if r.StatusCode != want {
+		t.Fatalf("%s %s: status %d, want %d\n%s", r.Request.Method, r.Request.URL.Path, r.StatusCode, want, r.Body)
+	}
+}
+
+// expectHeader checks a single response header
+func expectHeader(t *testing.T, r response, name, want string) {
+	t.Helper()
+	if got := r.Header.Get(name); got != want {
+		t.Errorf("%s %s: header %s = %q, want %q", r.Request.Method, r.Request.URL.Path, name, got, want)
+	}
+}
+
+// html parses an HTML response body for selector assertions
+// Only the media type is checked: some handlers add "; charset=utf-8", others
+// rely on the page's <meta charset>
+func html(t *testing.T, r response) *webtest.Doc {
+	t.Helper()
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("%s %s: Content-Type = %q, want text/html", r.Request.Method, r.Request.URL.Path, ct)
+	}
+	return webtest.Parse(t, r.Body)
+}
+
+func TestHome(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/")
+	expectStatus(t, r, http.StatusOK)
+	expectHeader(t, r, "Content-Language", "en")
+
+	doc := html(t, r)
+	doc.AssertAttr("html", "lang", "en")
+	doc.AssertText("title", "My Website")
+	doc.AssertCount(".cat-card", 3) // every available cat in the catalog
+}
+
+// Every response carries a request ID; one sent by a proxy is kept
+func TestRequestID(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/")
+	if id := r.Header.Get(logging.HeaderRequestID); len(id) != 16 {
+		t.Errorf("generated request ID %q, want 16 hex characters", id)
+	}
+
+	r = ts.get("/", logging.HeaderRequestID, "edge-42")
+	expectHeader(t, r, logging.HeaderRequestID, "edge-42")
+}
+
+// The locale can come from the Accept-Language header or a URL prefix
+func TestHomeLocalized(t *testing.T) {
+	ts := startServer(t)
+
+	for _, r := range []response{
+		ts.get("/", "Accept-Language", "es-MX,es;q=0.9,en;q=0.5"),
+		ts.get("/es"),
+	} {
+		expectStatus(t, r, http.StatusOK)
+		expectHeader(t, r, "Content-Language", "es")
+		html(t, r).AssertAttr("html", "lang", "es")
+	}
+}
+
+func TestContactForm(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/contact")
+	expectStatus(t, r, http.StatusOK)
+
+	doc := html(t, r)
+	doc.AssertAttr("form", "action", "/contact")
+	doc.AssertAttr("form", "method", "POST")
+	for _, name := range []string{"name", "email", "message"} {
+		doc.AssertExists(`form [name="` + name + `"]`)
+	}
+	doc.AssertExists("form[data-enhance]")
+}
+
+// PROGRESSIVE ENHANCEMENT: the script the contact page links to is served,
+// and the page's CSP lets it run without a nonce ('self')
+func TestEnhanceScript(t *testing.T) {
+	ts := startServer(t)
+
+	page := ts.get("/contact")
+	src, _ := html(t, page).Attr("script", "src")
+	if !strings.Contains(page.Header.Get("Content-Security-Policy"), "script-src 'self'") {
+		t.Errorf("CSP %q doesn't allow same-origin scripts", page.Header.Get("Content-Security-Policy"))
+	}
+
+	r := ts.get(src)
+	expectStatus(t, r, http.StatusOK)
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/javascript") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(r.Body, "form[data-enhance]") {
+		t.Error("served script isn't enhance.js")
+	}
+
+	// Only the embedded files are reachable
+	expectStatus(t, ts.get("/assets/js/missing.js"), http.StatusNotFound)
+	expectStatus(t, ts.get("/assets/../main.go"), http.StatusNotFound)
+}
+
+func TestContactSubmit(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.postForm("/contact", url.Values{
+		"name":    {"Ann Lee"},
+		"email":   {"ann@example.com"},
+		"message": {"Is Luna good with dogs?"},
+	})
+	expectStatus(t, r, http.StatusOK)
+	html(t, r).AssertText("p", "Posted - name: Ann Lee, email: ann@example.com, message: Is Luna good with dogs?")
+	if vary := r.Header.Get("Vary"); !strings.Contains(vary, "Accept") {
+		t.Errorf("Vary = %q, want Accept in it: the reply depends on it", vary)
+	}
+
+	// What the visitor typed is shown as text, never run as markup
+	r = ts.postForm("/contact", url.Values{
+		"name":    {"<script>alert(1)</script>"},
+		"email":   {"ann@example.com"},
+		"message": {"<img src=x onerror=alert(2)>"},
+	})
+	expectStatus(t, r, http.StatusOK)
+	doc := html(t, r)
+	doc.AssertMissing("p script")
+	doc.AssertMissing("p img")
+	doc.AssertTextContains("p", "<script>alert(1)</script>")
+
+	// The staff got an email they can reply to
+	sent := ts.Outbox.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d emails, want 2", len(sent))
+	}
+	want := mail.Message{To: "staff@example.com", ReplyTo: "ann@example.com",
+		Subject: "Contact form: Ann Lee", Body: "Is Luna good with dogs?"}
+	if sent[0] != want {
+		t.Errorf("sent %+v\nwant %+v", sent[0], want)
+	}
+}
+
+// A submission staff couldn't answer is refused and never mailed
+func TestContactRejected(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.postForm("/contact", url.Values{"name": {"Ann Lee"}, "message": {"Hello?"}})
+	expectStatus(t, r, http.StatusBadRequest)
+	if sent := ts.Outbox.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d emails for an invalid submission", len(sent))
+	}
+}
+
+// JSON in, JSON out: the same endpoint serves scripts and apps
+func TestContactJSON(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.postJSON("/contact", `{"name": "Ann Lee", "email": "ann@example.com", "message": "Hi"}`,
+		"Accept", "application/json")
+	expectStatus(t, r, http.StatusOK)
+	var result map[string]string
+	if err := json.Unmarshal([]byte(r.Body), &result); err != nil {
+		t.Fatalf("reply %q: %v", r.Body, err)
+	}
+	if result["status"] != "accepted" || result["email"] != "ann@example.com" {
+		t.Errorf("reply = %v", result)
+	}
+	if sent := ts.Outbox.Sent(); len(sent) != 1 || sent[0].Body != "Hi" {
+		t.Errorf("sent %+v", sent)
+	}
+}
+
+// The same rules reject a bad submission however it is encoded, and API
+// clients learn which fields to fix, in their language
+func TestContactJSONRejected(t *testing.T) {
+	ts := startServer(t)
+
+	for name, r := range map[string]response{
+		"json": ts.postJSON("/contact", `{"name": "Ann", "email": "ann.example.com"}`,
+			"Accept", "application/json", "Accept-Language", "es"),
+		"form": ts.postForm("/contact", url.Values{"name": {"Ann"}, "email": {"ann.example.com"}},
+			"Accept", "application/json", "Accept-Language", "es"),
+	} {
+		expectStatus(t, r, http.StatusBadRequest)
+		expectHeader(t, r, "Content-Type", "application/problem+json")
+		var problem struct {
+			Errors map[string]string `json:"errors"`
+		}
+		if err := json.Unmarshal([]byte(r.Body), &problem); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := map[string]string{
+			"email":   "Esa dirección de correo no parece válida.",
+			"message": "Escribe un mensaje.",
+		}
+		if !reflect.DeepEqual(problem.Errors, want) {
+			t.Errorf("%s: errors = %v, want %v", name, problem.Errors, want)
+		}
+	}
+
+	// A body that isn't JSON at all is a bad request, not a crash
+	r := ts.postJSON("/contact", `{"name": `, "Accept", "application/json")
+	expectStatus(t, r, http.StatusBadRequest)
+	if sent := ts.Outbox.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d emails for invalid submissions", len(sent))
+	}
+}
+
+// The load balancer's probes: alive, and ready with every check passing
+func TestHealthProbes(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/healthz")
+	expectStatus(t, r, http.StatusOK)
+
+	r = ts.get("/readyz")
+	expectStatus(t, r, http.StatusOK)
+	var report health.Report
+	if err := json.Unmarshal([]byte(r.Body), &report); err != nil {
+		t.Fatalf("/readyz: %v\n%s", err, r.Body)
+	}
+	checked := map[string]string{}
+	for _, c := range report.Checks {
+		checked[c.Name] = c.Status
+	}
+	if report.Status != health.StatusOK || checked["storage"] != health.StatusOK || checked["database"] != health.StatusOK {
+		t.Errorf("readiness report %+v", report)
+	}
+}
+
+// A request carrying a traceparent joins the caller's trace, and the work done
+// for it shows up as child spans
+func TestTracing(t *testing.T) {
+	rec := &tracing.Recorder{}
+	ts := startServer(t, func(cfg *serverConfig) { cfg.Tracer = tracing.NewTracer(rec) })
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := ts.request(http.MethodPost, "/contact",
+		strings.NewReader(url.Values{"name": {"Ann"}, "email": {"ann@example.com"}, "message": {"Hi"}}.Encode()),
+		"Content-Type", "application/x-www-form-urlencoded",
+		"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	expectStatus(t, ts.do(req), http.StatusOK)
+	expectStatus(t, ts.get("/cats/luna"), http.StatusOK)
+
+	spans := map[string]tracing.SpanData{}
+	for _, s := range rec.Spans() {
+		spans[s.Name] = s
+	}
+	contact, mailSend := spans["POST /contact"], spans["mail.send"]
+	if contact.TraceID.String() != traceID {
+		t.Errorf("request span trace %s, want the caller's %s", contact.TraceID, traceID)
+	}
+	if mailSend.ParentSpanID != contact.SpanID {
+		t.Error("mail.send should be a child of the request span")
+	}
+	catPage, render := spans["GET /cats/:slug"], spans["render"]
+	if render.ParentSpanID != catPage.SpanID || catPage.TraceID.String() == traceID {
+		t.Errorf("the cat page should be its own trace with a render child: %+v %+v", catPage, render)
+	}
+}
+
+// After some traffic, /metrics reports it in the Prometheus text format
+func TestMetrics(t *testing.T) {
+	ts := startServer(t)
+
+	ts.get("/cats/luna")
+	ts.postForm("/contact", url.Values{"name": {"Ann"}, "email": {"ann@example.com"}, "message": {"Hi"}})
+	ts.postForm("/contact", url.Values{"name": {"Bot"}})
+	ts.postMultipart("/upload", url.Values{"vehicle": {"car"}},
+		[]upload{{Field: "file", Name: "notes.txt", Content: []byte("twelve bytes")}})
+
+	r := ts.get("/metrics")
+	expectStatus(t, r, http.StatusOK)
+	expectHeader(t, r, "Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/cats/:slug",status="200"} 1`,
+		`http_request_duration_seconds_count{method="POST",route="/contact",status="400"} 1`,
+		`contact_submissions_total{result="accepted"} 1`,
+		`contact_submissions_total{result="rejected"} 1`,
+		`uploads_total{kind="file",result="stored"} 1`,
+		`upload_bytes_total{kind="file"} 12`,
+		"\ngo_goroutines ",
+	} {
+		if !strings.Contains(r.Body, want) {
+			t.Errorf("/metrics is missing %s", want)
+		}
+	}
+}
+
+// TABLE-DRIVEN: the path parameter and the form fields both reach the handler
+func TestSecurityHeaders(t *testing.T) {
+	ts := startServer(t)
+
+	for _, path := range []string{"/", "/contact", "/no/such/page"} {
+		r := ts.get(path)
+		expectHeader(t, r, "X-Content-Type-Options", "nosniff")
+		expectHeader(t, r, "Referrer-Policy", "strict-origin-when-cross-origin")
+		expectHeader(t, r, "Cross-Origin-Opener-Policy", "same-origin")
+		if csp := r.Header.Get("Content-Security-Policy"); !strings.Contains(csp, "frame-ancestors 'none'") ||
+			!strings.Contains(csp, "report-uri /csp-report") {
+			t.Errorf("%s: CSP = %q", path, csp)
+		}
+	}
+
+	// The staff pages send no Referer at all
+	expectHeader(t, ts.get("/admin/cats", asAdmin()...), "Referrer-Policy", "no-referrer")
+
+	// The contact reply's inline <style> carries the nonce the CSP allows
+	r := ts.postForm("/contact", url.Values{"name": {"Ann"}, "email": {"ann@example.com"}, "message": {"Hi"}})
+	csp := r.Header.Get("Content-Security-Policy")
+	start := strings.Index(r.Body, `<style nonce="`)
+	if start < 0 {
+		t.Fatalf("no nonce'd <style> in %s", r.Body)
+	}
+	nonce, _, _ := strings.Cut(r.Body[start+len(`<style nonce="`):], `"`)
+	if !strings.Contains(csp, "style-src 'self' 'nonce-"+nonce+"'") {
+		t.Errorf("nonce %q not allowed by %s", nonce, csp)
+	}
+}
+
+func TestCSPReportOnly(t *testing.T) {
+	ts := startServer(t, func(cfg *serverConfig) { cfg.CSPReportOnly = true })
+
+	r := ts.get("/")
+	if r.Header.Get("Content-Security-Policy") != "" || r.Header.Get("Content-Security-Policy-Report-Only") == "" {
+		t.Errorf("report-only mode: CSP %q, report-only %q",
+			r.Header.Get("Content-Security-Policy"), r.Header.Get("Content-Security-Policy-Report-Only"))
+	}
+}
+
+func TestCSPReport(t *testing.T) {
+	ts := startServer(t)
+
+	report := `{"csp-report": {"document-uri": "http://localhost/", "blocked-uri": "inline",
+		"effective-directive": "script-src-elem", "disposition": "enforce"}}`
+	r := ts.do(ts.request(http.MethodPost, "/csp-report", strings.NewReader(report), "Content-Type", "application/csp-report"))
+	expectStatus(t, r, http.StatusNoContent)
+
+	r = ts.do(ts.request(http.MethodPost, "/csp-report", strings.NewReader("hello"), "Content-Type", "text/plain"))
+	expectStatus(t, r, http.StatusUnsupportedMediaType)
+
+	metrics := ts.get("/metrics").Body
+	if !strings.Contains(metrics, `csp_violations_total{directive="script-src-elem",disposition="enforce"} 1`) {
+		t.Error("the violation should be counted")
+	}
+}
+
+func TestPostFormData(t *testing.T) {
+	ts := startServer(t)
+
+	cases := []struct {
+		formID string
+		fields url.Values
+		want   string
+	}{
+		{"123", url.Values{"dept": {"support"}, "name": {"Sue"}}, "Posted - form_id: 123, dept: support, name: Sue"},
+		{"abc", url.Values{"dept": {"sales"}}, "Posted - form_id: abc, dept: sales, name: "},
+		{"7", url.Values{"name": {"Ana & Bo"}}, "Posted - form_id: 7, dept: , name: Ana & Bo"},
+	}
+	for _, tc := range cases {
+		r := ts.postForm("/post-form-data/"+tc.formID, tc.fields)
+		expectStatus(t, r, http.StatusOK)
+		if r.Body != tc.want {
+			t.Errorf("form %s: got %q, want %q", tc.formID, r.Body, tc.want)
+		}
+	}
+}
+
+func TestPostFormDataJSON(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.postJSON("/post-form-data/42", `{"dept": "sales", "name": "Sue"}`, "Accept", "application/json")
+	expectStatus(t, r, http.StatusOK)
+	if want := `{"form_id":"42","dept":"sales","name":"Sue"}`; strings.TrimSpace(r.Body) != want {
+		t.Errorf("got %s, want %s", r.Body, want)
+	}
+
+	// A JSON body with a plain-text reply, as before
+	r = ts.postJSON("/post-form-data/7", `{"dept": "sales"}`)
+	if r.Body != "Posted - form_id: 7, dept: sales, name: " {
+		t.Errorf("got %q", r.Body)
+	}
+}
+
+// CORS: a frontend on another origin may call the API endpoints, but not the pages
+func TestCORS(t *testing.T) {
+	ts := startServer(t, func(cfg *serverConfig) {
+		cfg.CORS = &cors.Policy{
+			AllowedOrigins: []string{"https://*.frontend.example"},
+			AllowedHeaders: []string{"Content-Type"},
+			MaxAge:         time.Minute,
+		}
+	})
+	const origin = "https://app.frontend.example"
+
+	// The browser's preflight before a POST with a JSON body
+	r := ts.do(ts.request(http.MethodOptions, "/post-form-data/42", nil,
+		"Origin", origin, "Access-Control-Request-Method", "POST", "Access-Control-Request-Headers", "content-type"))
+	expectStatus(t, r, http.StatusNoContent)
+	expectHeader(t, r, "Access-Control-Allow-Origin", origin)
+	expectHeader(t, r, "Access-Control-Allow-Headers", "content-type")
+	expectHeader(t, r, "Access-Control-Max-Age", "60")
+
+	r = ts.postForm("/post-form-data/42", url.Values{"name": {"Sue"}}, "Origin", origin)
+	expectStatus(t, r, http.StatusOK)
+	expectHeader(t, r, "Access-Control-Allow-Origin", origin)
+
+	// Errors carry the headers too, so the script can read what went wrong
+	r = ts.do(ts.request(http.MethodPost, "/upload", strings.NewReader("not multipart"),
+		"Origin", origin, "Content-Type", "text/plain"))
+	expectStatus(t, r, http.StatusBadRequest)
+	expectHeader(t, r, "Access-Control-Allow-Origin", origin)
+
+	// The pages stay same-origin only
+	r = ts.postForm("/contact", url.Values{"name": {"Sue"}}, "Origin", origin)
+	expectHeader(t, r, "Access-Control-Allow-Origin", "")
+}
+
+// MULTIPART UPLOAD, END TO END: upload a file, check the JSON reply, find the file
+// on disk and download it again from the URL the server handed back
+func TestUploadRoundTrip(t *testing.T) {
+	ts := startServer(t)
+
+	// Two uploads in a row: the second must not see the first one's file
+	// (rweb reuses request objects - see forms.Parse)
+	for _, content := range []string{"first upload\n", "the second file is longer\n"} {
+		r := ts.postMultipart("/upload", url.Values{"vehicle": {"car"}},
+			[]upload{{Field: "file", Name: "notes.txt", Content: []byte(content)}})
+		expectStatus(t, r, http.StatusOK)
+
+		var stored storage.File
+		if err := json.Unmarshal([]byte(r.Body), &stored); err != nil {
+			t.Fatalf("reply is not a stored file: %v\n%s", err, r.Body)
+		}
+		if stored.OriginalName != "notes.txt" || stored.Size != int64(len(content)) {
+			t.Errorf("stored = %+v, want notes.txt of %d bytes", stored, len(content))
+		}
+		if !strings.HasPrefix(stored.URL, "/uploads/") || stored.Name == "notes.txt" {
+			t.Errorf("stored under %q (%s), want a random name under /uploads/", stored.Name, stored.URL)
+		}
+
+		onDisk, err := os.ReadFile(filepath.Join(ts.UploadDir, stored.Name))
+		if err != nil || string(onDisk) != content {
+			t.Errorf("file on disk = %q, %v; want %q", onDisk, err, content)
+		}
+
+		dl := ts.get(stored.URL)
+		expectStatus(t, dl, http.StatusOK)
+		// Not an image, so a download rather than a page (see Uploads.Serve)
+		expectHeader(t, dl, "Content-Type", "application/octet-stream")
+		expectHeader(t, dl, "Content-Disposition", "attachment; filename="+stored.Name)
+		if dl.Body != content {
+			t.Errorf("downloaded %q, want %q", dl.Body, content)
+		}
+	}
+}
+
+// An uploaded web page must not become a page of the site
+func TestUploadedHTMLIsNotServedAsHTML(t *testing.T) {
+	ts := startServer(t)
+
+	page := []byte(`<!DOCTYPE html><html><script>alert(document.cookie)</script></html>`)
+	r := ts.postMultipart("/upload", nil, []upload{{Field: "file", Name: "login.html", Content: page}})
+	expectStatus(t, r, http.StatusOK)
+	var stored storage.File
+	if err := json.Unmarshal([]byte(r.Body), &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	dl := ts.get(stored.URL)
+	expectStatus(t, dl, http.StatusOK)
+	expectHeader(t, dl, "Content-Type", "application/octet-stream")
+	expectHeader(t, dl, "Content-Security-Policy", "sandbox")
+	if !strings.HasPrefix(dl.Header.Get("Content-Disposition"), "attachment") {
+		t.Errorf("Content-Disposition = %q, want an attachment", dl.Header.Get("Content-Disposition"))
+	}
+}
+
+func TestUploadedFileMissing(t *testing.T) {
+	ts := startServer(t)
+	expectStatus(t, ts.get("/uploads/nope.txt"), http.StatusNotFound)
+	expectStatus(t, ts.get("/uploads/..%2fdata%2fcats.json"), http.StatusNotFound)
+}
+
+func TestWellKnown(t *testing.T) {
+	ts := startServer(t)
+
+	want, err := os.ReadFile(filepath.Join(".well-known", "some-file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ts.get("/.well-known/some-file.txt")
+	expectStatus(t, r, http.StatusOK)
+	expectHeader(t, r, "Content-Disposition", "attachment; filename=some-file.txt")
+	if r.Body != string(want) {
+		t.Errorf("got %q, want %q", r.Body, want)
+	}
+}
+
+func TestCatPages(t *testing.T) {
+	ts := startServer(t)
+
+	list := ts.get("/cats?q=luna")
+	expectStatus(t, list, http.StatusOK)
+	html(t, list).AssertCount(".cat-card", 1)
+
+	detail := ts.get("/cats/luna")
+	expectStatus(t, detail, http.StatusOK)
+	html(t, detail).AssertText("title", "Meet Luna")
+
+	missing := ts.get("/cats/no-such-cat")
+	expectStatus(t, missing, http.StatusNotFound)
+	html(t, missing).AssertExists("a[href='/']")
+}
+
+// SEEDED PHOTOS under the default policy: every <img> on the public pages
+// comes from an origin img-src allows, and no embedder policy blocks them
+func TestSeededPhotosAllowed(t *testing.T) {
+	ts := startServer(t)
+
+	for _, path := range []string{"/", "/cats", "/cats/luna"} {
+		r := ts.get(path)
+		expectStatus(t, r, http.StatusOK)
+		if coep := r.Header.Get("Cross-Origin-Embedder-Policy"); coep == "require-corp" {
+			t.Errorf("%s: COEP require-corp blocks photos from hosts without CORP", path)
+		}
+		var imgSrc []string
+		for _, directive := range strings.Split(r.Header.Get("Content-Security-Policy"), ";") {
+			if fields := strings.Fields(directive); len(fields) > 0 && fields[0] == "img-src" {
+				imgSrc = fields[1:]
+			}
+		}
+
+		imgs := html(t, r).Find("img")
+		if len(imgs) == 0 {
+			t.Errorf("%s: no photos", path)
+		}
+		for _, img := range imgs {
+			for _, a := range img.Attr {
+				if a.Key != "src" {
+					continue
+				}
+				u, err := url.Parse(a.Val)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := "'self'"
+				if u.Host != "" {
+					want = u.Scheme + "://" + u.Host
+				}
+				if !slices.Contains(imgSrc, want) {
+					t.Errorf("%s: photo %s is blocked by img-src %v", path, a.Val, imgSrc)
+				}
+			}
+		}
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/theme.css?name=dark")
+	expectStatus(t, r, http.StatusOK)
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("Content-Type = %q, want text/css", ct)
+	}
+	if !strings.Contains(r.Body, "--color-") {
+		t.Error("stylesheet has no color tokens")
+	}
+}
+
+// PREFERENCE COOKIES: a theme and a language chosen by one request both stick,
+// and a request that already carries them gets nothing back
+func TestThemeAndLanguageCookies(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/es/cats?theme=dark")
+	expectStatus(t, r, http.StatusOK)
+	got := map[string]string{}
+	for _, c := range r.Cookies() {
+		got[c.Name] = c.Value
+	}
+	if got["theme"] != "dark" || got["lang"] != "es" {
+		t.Errorf("cookies = %v, want theme=dark and lang=es", got)
+	}
+
+	again := ts.get("/es/cats?theme=dark", "Cookie", "theme=dark; lang=es")
+	expectStatus(t, again, http.StatusOK)
+	if set := again.Header.Values("Set-Cookie"); len(set) != 0 {
+		t.Errorf("unchanged preferences were set again: %q", set)
+	}
+
+	// Switching keeps the search the visitor was looking at
+	r = ts.get("/cats?q=calm&min_age=1")
+	expectStatus(t, r, http.StatusOK)
+	html(t, r).AssertExists(`.site-footer a[href="?min_age=1&q=calm&theme=dark"]`)
+	html(t, r).AssertExists(`.site-footer a[href="?lang=es&min_age=1&q=calm"]`)
+}
+
+// LANGUAGE SWITCHER on a prefixed page: the ?lang= link beats the /es/ prefix
+func TestLanguageSwitchFromPrefixedPath(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/es/cats?q=calm")
+	expectStatus(t, r, http.StatusOK)
+	expectHeader(t, r, "Content-Language", "es")
+	href, _ := html(t, r).Attr(`.site-footer a[hreflang=en]`, "href")
+	if href != "?lang=en&q=calm" {
+		t.Fatalf("English link %q", href)
+	}
+
+	// Follow it as a browser would: relative to /es/cats
+	r = ts.get("/es/cats"+href, "Cookie", "lang=es")
+	expectStatus(t, r, http.StatusOK)
+	expectHeader(t, r, "Content-Language", "en")
+	html(t, r).AssertAttr(`html`, "lang", "en")
+	html(t, r).AssertExists(`.site-footer a.current[lang=en]`)
+	var saved string
+	for _, c := range r.Cookies() {
+		if c.Name == "lang" {
+			saved = c.Value
+		}
+	}
+	if saved != "en" {
+		t.Errorf("lang cookie %q, want the new choice saved", saved)
+	}
+}
+
+// API DOCUMENTATION: the generated document and its page, as a client sees them
+func TestAPIDocs(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/openapi.json")
+	expectStatus(t, r, http.StatusOK)
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(r.Body), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi %q", doc.OpenAPI)
+	}
+	for _, path := range []string{"/contact", "/cats/{slug}", "/admin/cats/{slug}/photos", "/upload"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("%s missing from /openapi.json", path)
+		}
+	}
+
+	r = ts.get("/docs")
+	expectStatus(t, r, http.StatusOK)
+	html(t, r).AssertText("#post_upload h3 code", "/upload")
+}
+
+func TestUnknownRoute(t *testing.T) {
+	ts := startServer(t)
+	r := ts.get("/no/such/page")
+	expectStatus(t, r, http.StatusNotFound)
+	if !strings.Contains(r.Body, `class="not-found"`) {
+		t.Error("unknown routes should get the not-found page")
+	}
+
+	// API clients get RFC 9457 problem details instead
+	r = ts.get("/no/such/page", "Accept", "application/json")
+	expectStatus(t, r, http.StatusNotFound)
+	if ct := r.Header.Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(r.Body, `"status":404`) {
+		t.Errorf("body = %s", r.Body)
+	}
+}
+
+func TestAdminRequiresAuth(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.get("/admin/cats")
+	expectStatus(t, r, http.StatusUnauthorized)
+	if !strings.HasPrefix(r.Header.Get("WWW-Authenticate"), "Basic ") {
+		t.Errorf("WWW-Authenticate = %q", r.Header.Get("WWW-Authenticate"))
+	}
+
+	r = ts.get("/admin/cats", asAdmin()...)
+	expectStatus(t, r, http.StatusOK)
+	html(t, r).AssertCount("tbody tr", 3)
+}
+
+// CSRF: the browser sends the staff member's saved credentials with a form
+// another site posts here too - such a post must not change anything
+func TestAdminRejectsCrossSitePosts(t *testing.T) {
+	ts := startServer(t)
+
+	r := ts.postForm("/admin/cats/luna/retire", nil, append(asAdmin(), "Origin", "https://evil.example")...)
+	expectStatus(t, r, http.StatusForbidden)
+	r = ts.postForm("/admin/cats/luna/retire", nil, append(asAdmin(), "Sec-Fetch-Site", "cross-site")...)
+	expectStatus(t, r, http.StatusForbidden)
+	expectStatus(t, ts.get("/cats/luna"), http.StatusOK) // still listed
+
+	// The site's own edit page posting is fine
+	r = ts.postForm("/admin/cats/luna/retire", nil, append(asAdmin(), "Origin", ts.URL, "Sec-Fetch-Site", "same-origin")...)
+	expectStatus(t, r, http.StatusSeeOther)
+}
+
+// Admin photo upload: multipart through auth, storage, the catalog and the audit log
+func TestAdminPhotoUpload(t *testing.T) {
+	ts := startServer(t)
+
+	// The PNG signature is enough for content sniffing to call it image/png
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	r := ts.postMultipart("/admin/cats/luna/photos", url.Values{"alt": {"Luna asleep"}},
+		[]upload{{Field: "photo", Name: "luna.png", Content: png}}, asAdmin()...)
+	expectStatus(t, r, http.StatusSeeOther)
+	expectHeader(t, r, "Location", "/admin/cats/luna/edit?saved=1")
+
+	detail := html(t, ts.get("/cats/luna"))
+	src, ok := detail.Attr(`img[alt="Luna asleep"]`, "src")
+	if !ok {
+		t.Fatalf("new photo is not on the cat's page\n%s", detail.HTML)
+	}
+	img := ts.get(src)
+	expectStatus(t, img, http.StatusOK)
+	expectHeader(t, img, "Content-Type", "image/png")
+
+	auditLog, err := os.ReadFile(filepath.Join(ts.DataDir, "audit.log"))
+	if err != nil || !strings.Contains(string(auditLog), `"cat.photo.add"`) {
+		t.Errorf("audit log = %q, %v; want a cat.photo.add entry", auditLog, err)
+	}
+
+	// Photos must be images
+	r = ts.postMultipart("/admin/cats/luna/photos", nil,
+		[]upload{{Field: "photo", Name: "notes.txt", Content: []byte("not a picture")}}, asAdmin()...)
+	expectStatus(t, r, http.StatusUnsupportedMediaType)
+	html(t, r).AssertText(".upload-form .field-error", "Only image files can be used as photos.")
+}
+
+// A body that doesn't parse is a bad request on every cat form, not a crash
+func TestAdminCatFormsRejectBadBodies(t *testing.T) {
+	ts := startServer(t)
+
+	for _, path := range []string{"/admin/cats", "/admin/cats/luna/edit", "/admin/cats/luna/photos"} {
+		expectStatus(t, ts.postJSON(path, `{"name": `, asAdmin()...), http.StatusBadRequest)
+	}
+}
+
+// POST-REDIRECT-GET through the first adoption step, then check the stored draft
+func TestAdoptionDraft(t *testing.T) {
+	ts := startServer(t)
+
+	// Following a link creates nothing; only the button's POST does
+	ts.get("/cats/luna/adopt")
+	if saved, _ := os.ReadFile(filepath.Join(ts.DataDir, "applications.json")); strings.Contains(string(saved), "luna") {
+		t.Errorf("a GET saved a draft: %s", saved)
+	}
+
+	start := ts.postForm("/cats/luna/adopt", nil)
+	expectStatus(t, start, http.StatusSeeOther)
+	step1 := start.Header.Get("Location")
+	if !strings.HasSuffix(step1, "/step/1") {
+		t.Fatalf("redirected to %q, want step 1", step1)
+	}
+
+	// Missing answers: 422 and the form again
+	r := ts.postForm(step1, url.Values{"action": {"next"}})
+	expectStatus(t, r, http.StatusUnprocessableEntity)
+	html(t, r).AssertExists(".field-error")
+
+	r = ts.postForm(step1, url.Values{
+		"action":              {"next"},
+		adoption.FieldName:    {"Jane Doe"},
+		adoption.FieldEmail:   {"jane@example.com"},
+		adoption.FieldPhone:   {"555-0100"},
+		adoption.FieldAddress: {"1 Main St"},
+	})
+	expectStatus(t, r, http.StatusSeeOther)
+	expectHeader(t, r, "Location", strings.TrimSuffix(step1, "1")+"2")
+
+	// A body that doesn't parse is a bad request, not a crash
+	expectStatus(t, ts.postJSON(step1, `{"action": `), http.StatusBadRequest)
+
+	saved, err := os.ReadFile(filepath.Join(ts.DataDir, "applications.json"))
+	if err != nil || !strings.Contains(string(saved), "jane@example.com") {
+		t.Errorf("applications.json = %q, %v; want Jane's draft", saved, err)
+	}
+}
+
+// STAFF REVIEW: the list links to each application, whose page holds the
+// answers, the history and the status form
+func TestAdminApplicationReview(t *testing.T) {
+	ts := startServer(t)
+
+	start := ts.postForm("/cats/luna/adopt", nil)
+	expectStatus(t, start, http.StatusSeeOther)
+	id := strings.Split(start.Header.Get("Location"), "/")[2] // /applications/<id>/step/1
+	page := "/admin/applications/" + id
+
+	list := ts.get("/admin/applications", asAdmin()...)
+	expectStatus(t, list, http.StatusOK)
+	html(t, list).AssertAttr("tbody td a", "href", page)
+
+	r := ts.get(page, asAdmin()...)
+	expectStatus(t, r, http.StatusOK)
+	doc := html(t, r)
+	doc.AssertCount(".answers dl", 4)
+	doc.AssertAttr("form.inline-form", "action", page+"/status")
+	// Only the applicant submits a draft, once it validates
+	doc.AssertMissing(`select[name=status] option[value=submitted]`)
+
+	// A move the workflow forbids is explained on the same page
+	for _, status := range []string{"approved", "submitted"} {
+		r = ts.postForm(page+"/status", url.Values{"status": {status}}, asAdmin()...)
+		expectStatus(t, r, http.StatusConflict)
+		html(t, r).AssertExists(".alert-error")
+	}
+
+	expectStatus(t, ts.postJSON(page+"/status", `{"status": `, asAdmin()...), http.StatusBadRequest)
+
+	r = ts.postForm(page+"/status", url.Values{"status": {"withdrawn"}, "note": {"Asked by phone"}}, asAdmin()...)
+	expectStatus(t, r, http.StatusSeeOther)
+	expectHeader(t, r, "Location", page)
+	r = ts.get(page, asAdmin()...)
+	html(t, r).AssertTextContains("table.history tbody", "Asked by phone")
+
+	expectStatus(t, ts.get("/admin/applications/nobody", asAdmin()...), http.StatusNotFound)
+}
+
+// sseEvent is one event read off a stream
+type sseEvent struct {
+	Type, ID, Data string
+}
+
+// openStream opens an event stream and returns a function reading its next event
+// It doesn't use ts.client: that one's timeout would cut the stream off
+func (ts *testServer) openStream(path string, headers ...string) (next func() sseEvent, closeStream func()) {
+	ts.t.Helper()
+	resp, err := http.DefaultClient.Do(ts.request(http.MethodGet, path, nil, headers...))
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		resp.Body.Close()
+		ts.t.Fatalf("GET %s: status %d, Content-Type %q", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// A GOROUTINE reads the stream so the test can give up waiting
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+
+	next = func() sseEvent {
+		ts.t.Helper()
+		var ev sseEvent
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return sseEvent{} // the stream ended
+				}
+				if line == "" {
+					return ev
+				}
+				field, value, _ := strings.Cut(line, ": ")
+				switch field {
+				case "event":
+					ev.Type = value
+				case "id":
+					ev.ID = value
+				case "data":
+					ev.Data = value
+				}
+			case <-time.After(5 * time.Second):
+				ts.t.Fatal("no event within 5s")
+			}
+		}
+	}
+	return next, func() { resp.Body.Close() }
+}
+
+// LIVE NOTIFICATIONS: a contact message reaches an open stream, a reconnect
+// with Last-Event-ID replays it, and requests after a stream still work
+func TestEventStream(t *testing.T) {
+	broker := events.New(events.Options{})
+	ts := startServer(t, func(c *serverConfig) { c.Events = broker })
+	defer broker.Close()
+
+	expectStatus(t, ts.get("/events"), http.StatusUnauthorized)
+	expectStatus(t, ts.get("/events?types=nope", asAdmin()...), http.StatusBadRequest)
+
+	next, closeStream := ts.openStream("/events?types=contact.message", asAdmin()...)
+	defer closeStream()
+
+	expectStatus(t, ts.postForm("/contact", url.Values{
+		"name": {"Ann"}, "email": {"ann@example.com"}, "message": {"Is Luna still here?"},
+	}), http.StatusOK)
+
+	ev := next()
+	if ev.Type != "contact.message" || ev.ID == "" {
+		t.Fatalf("event %+v", ev)
+	}
+	var got struct {
+		Data events.ContactNotice `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(ev.Data), &got); err != nil || got.Data.Name != "Ann" {
+		t.Errorf("data %q (%v)", ev.Data, err)
+	}
+
+	// RECONNECT: the browser sends the last ID it saw before the message
+	id, _ := strconv.ParseUint(ev.ID, 10, 64)
+	replay, closeReplay := ts.openStream("/events", append(asAdmin(), "Last-Event-ID", strconv.FormatUint(id-1, 10))...)
+	defer closeReplay()
+	if again := replay(); again.ID != ev.ID {
+		t.Errorf("replayed %+v, want id %s", again, ev.ID)
+	}
+
+	// SHUTDOWN ends every stream, telling the page to connect again
+	broker.Close()
+	if ev := next(); ev.Type != "reconnect" {
+		t.Errorf("got %+v after Close, want reconnect", ev)
+	}
+	closeStream()
+	closeReplay()
+	time.Sleep(50 * time.Millisecond) // until the server has put those contexts back
+
+	// The contexts that streamed go back to rweb's pool; every later
+	// response must still be an ordinary one (see events.ResetContext)
+	plain := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 5 * time.Second}
+	for range 200 {
+		resp, err := plain.Get(ts.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.ContentLength < 0 || resp.Header.Get("Content-Type") == "text/event-stream; charset=utf-8" ||
+			resp.Header.Get("Access-Control-Allow-Origin") != "" || !strings.Contains(string(body), "ok") {
+			t.Fatalf("after a stream: length %d, headers %v, body %q", resp.ContentLength, resp.Header, body)
+		}
+	}
+}
+
+// UPLOAD PROGRESS: a watcher subscribed before the upload hears it arrive
+// and end - with the stored file, or why it failed
+func TestUploadProgress(t *testing.T) {
+	ts := startServer(t)
+
+	expectStatus(t, ts.get("/upload/progress/bad"), http.StatusBadRequest)
+	r := ts.postMultipart("/upload?upload_id=no", nil, []upload{{Field: "file", Name: "a.txt", Content: []byte("hi")}})
+	expectStatus(t, r, http.StatusBadRequest)
+
+	const id = "e2e-upload-0001"
+	next, closeStream := ts.openStream("/upload/progress/" + id)
+	defer closeStream()
+
+	content := bytes.Repeat([]byte("cats "), 200<<10) // 1000 KiB
+	r = ts.postMultipart("/upload?upload_id="+id, nil, []upload{{Field: "file", Name: "big.txt", Content: content}})
+	expectStatus(t, r, http.StatusOK)
+
+	var phases []string
+	var final struct {
+		Phase  string       `json:"phase"`
+		Bytes  int64        `json:"bytes"`
+		Result storage.File `json:"result"`
+	}
+	for ev := next(); ; ev = next() {
+		if err := json.Unmarshal([]byte(ev.Data), &final); err != nil {
+			t.Fatalf("event %+v: %v", ev, err)
+		}
+		phases = append(phases, final.Phase)
+		if ev.Type != "upload.progress" {
+			if ev.Type != "upload.complete" {
+				t.Fatalf("final event %+v", ev)
+			}
+			break
+		}
+	}
+	if len(phases) != 2 || phases[0] != "received" || phases[1] != "done" {
+		t.Errorf("phases %v, want received, done", phases)
+	}
+	if final.Bytes != int64(len(content)) || final.Result.OriginalName != "big.txt" || final.Result.URL == "" {
+		t.Errorf("final %+v", final)
+	}
+
+	// A FAILURE is reported in the uploader's words
+	const failing = "e2e-upload-0002"
+	next, closeFailing := ts.openStream("/upload/progress/" + failing)
+	defer closeFailing()
+	expectStatus(t, ts.postMultipart("/upload?upload_id="+failing, url.Values{"vehicle": {"car"}}, nil), http.StatusBadRequest)
+	ev := next()
+	for ev.Type == "upload.progress" {
+		ev = next()
+	}
+	if ev.Type != "upload.failed" || !strings.Contains(ev.Data, `"error":"`) {
+		t.Errorf("got %+v, want upload.failed with a reason", ev)
+	}
+}
+
+// REVERSE PROXY: a prefix served by two local upstreams, through the whole
+// middleware stack - balanced between them, and failing over when one goes away
+func TestReverseProxy(t *testing.T) {
+	// Each upstream answers with its name and what it was sent
+	upstream := func(name string) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{
+				"name": name, "method": r.Method, "uri": r.RequestURI, "body": string(body),
+				"host": r.Header.Get("X-Forwarded-Host"), "prefix": r.Header.Get("X-Forwarded-Prefix"),
+			})
+		}))
+		t.Cleanup(s.Close)
+		return s
+	}
+	a, b := upstream("a"), upstream("b")
+	p, err := proxy.New(proxy.Route{Prefix: "/api/legacy", Upstreams: []string{a.URL, b.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	ts := startServer(t, func(cfg *serverConfig) { cfg.Proxies = []*proxy.Proxy{p} })
+
+	answer := func(r response) map[string]string {
+		t.Helper()
+		expectStatus(t, r, http.StatusOK)
+		var got map[string]string
+		if err := json.Unmarshal([]byte(r.Body), &got); err != nil {
+			t.Fatalf("%v in %q", err, r.Body)
+		}
+		return got
+	}
+
+	// ROUND-ROBIN: a, then b
+	first := answer(ts.get("/api/legacy/orders/7?full=1"))
+	second := answer(ts.get("/api/legacy/orders/7?full=1"))
+	if first["name"] == second["name"] {
+		t.Errorf("both requests went to %s", first["name"])
+	}
+	if first["uri"] != "/orders/7?full=1" || first["prefix"] != "/api/legacy" {
+		t.Errorf("upstream saw %+v", first)
+	}
+	if want := strings.TrimPrefix(ts.URL, "http://"); first["host"] != want {
+		t.Errorf("X-Forwarded-Host %q, want %q", first["host"], want)
+	}
+
+	got := answer(ts.postJSON("/api/legacy/orders", `{"cat":"tom"}`))
+	if got["method"] != http.MethodPost || got["body"] != `{"cat":"tom"}` {
+		t.Errorf("POST arrived as %+v", got)
+	}
+
+	// FAILOVER: with a gone, every GET is retried on b
+	a.Close()
+	for range 3 {
+		if got := answer(ts.get("/api/legacy/ping")); got["name"] != "b" {
+			t.Errorf("answered by %s", got["name"])
+		}
+	}
+
+	// Both gone: a gateway error, as problem+json for API clients
+	b.Close()
+	r := ts.get("/api/legacy/ping", "Accept", "application/json")
+	expectStatus(t, r, http.StatusBadGateway)
+	expectHeader(t, r, "Content-Type", "application/problem+json")
+}
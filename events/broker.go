@@ -0,0 +1,386 @@
+// Package events pushes live notifications to the staff pages as SERVER-SENT
+// EVENTS (SSE): one long-lived GET response that the server keeps writing
+// "event: ... / data: ..." blocks to, and that browsers read with EventSource.
+//
+// Handlers Publish typed events; the Broker FANS them OUT to every subscribed
+// stream whose filters match:
+//
+//	b := events.New(events.Options{})
+//	b.Publish(events.CatStatus, "", events.CatStatusChange{Slug: "tom", From: "available", To: "adopted"})
+//
+// Each event gets an increasing ID and is kept in a bounded history, so a
+// browser that reconnects with a Last-Event-ID header is sent what it missed.
+// When the history no longer reaches back that far, it is sent a "resync"
+// event instead, meaning "reload - some events are gone".
+//
+// A Tracker (progress.go) streams the progress of a single upload to the
+// client that sent it, rather than to the staff.
+package events
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Type names a kind of event; it is the SSE "event:" field, so browsers can
+// listen to each kind separately (source.addEventListener("cat.status", ...))
+type Type string
+
+// The events the site publishes
+const (
+	ContactMessage Type = "contact.message" // a visitor sent the contact form
+	UploadComplete Type = "upload.complete" // a file or cat photo was stored
+	CatStatus      Type = "cat.status"      // a cat became available, adopted, retired...
+)
+
+// AllTypes lists every published type, for validating ?types= filters
+var AllTypes = []Type{ContactMessage, UploadComplete, CatStatus}
+
+// PAYLOADS: the "data" of each published type
+
+// ContactNotice is a ContactMessage's data
+type ContactNotice struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Message string `json:"message"`
+}
+
+// UploadNotice is an UploadComplete's data; Cat is set for cat photos
+type UploadNotice struct {
+	Name string `json:"name"` // the stored file name
+	URL  string `json:"url"`
+	Size int64  `json:"size"`
+	Cat  string `json:"cat,omitempty"`
+}
+
+// CatStatusChange is a CatStatus's data; From is "" for a new cat
+type CatStatusChange struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+}
+
+// CONTROL EVENTS come from the broker itself, not from Publish; they reach
+// every stream whatever its filters
+const (
+	Heartbeat Type = "heartbeat" // keeps proxies from closing an idle stream
+	Resync    Type = "resync"    // events were missed; reload instead of replaying
+	Reconnect Type = "reconnect" // the stream ends here; open a new one
+)
+
+// Event is one notification, as it is stored for replay and sent as the
+// "data:" line (JSON):
+//
+//	{"id":1729250000000001,"type":"cat.status","time":"...","data":{"slug":"tom",...}}
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type Type            `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// User addresses the event to one staff member; "" means every subscriber
+	User string `json:"-"`
+
+	line string // the event as JSON, encoded once for every stream
+}
+
+// Options tune a Broker; zero values pick the defaults
+type Options struct {
+	History   int           // events kept for Last-Event-ID replay (default 256)
+	Buffer    int           // events queued per stream before it counts as stuck (default 32)
+	Heartbeat time.Duration // interval between heartbeats (default 15s)
+}
+
+// Broker fans published events out to the subscribed streams
+//
+// All of its state is guarded by one MUTEX: publishing and subscribing are
+// rare (a few per minute) and quick, so there is nothing to gain from more
+// elaborate locking
+type Broker struct {
+	opts Options
+
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event // oldest first, at most opts.History long
+	subs    map[*Subscription]struct{}
+	beating bool // the heartbeat goroutine is running
+	closed  bool
+}
+
+// New makes a broker; nothing runs until the first stream subscribes
+func New(opts Options) *Broker {
+	if opts.History <= 0 {
+		opts.History = 256
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 32
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = 15 * time.Second
+	}
+	return &Broker{
+		opts: opts,
+		// IDS FROM THE CLOCK: a restarted server starts numbering far above the
+		// IDs it handed out before, so a browser reconnecting with an old ID is
+		// told to resync instead of being replayed the wrong events
+		nextID: uint64(time.Now().UnixMicro()),
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription is one stream's place in the broker
+type Subscription struct {
+	// C delivers rweb.SSEvent values, ready for ctx.SetSSE; it is closed when
+	// the stream should end (the broker shut down, or the client stopped reading)
+	C chan any
+
+	user  string
+	types map[Type]bool // nil means every type
+	stale bool          // the queue was not drained since the last heartbeat
+}
+
+// wants reports whether e should go to s
+func (s *Subscription) wants(e Event) bool {
+	if e.User != "" && e.User != s.user {
+		return false
+	}
+	return s.types == nil || s.types[e.Type]
+}
+
+// Publish records an event and sends it to every matching stream
+// user "" addresses all staff; data is encoded as the event's JSON "data"
+//
+// NIL RECEIVER: a nil *Broker publishes nothing, so handlers built without
+// one (in tests, say) need no checks
+func (b *Broker) Publish(typ Type, user string, data any) (Event, error) {
+	if b == nil {
+		return Event{}, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := Event{ID: b.nextID, Type: typ, Time: time.Now().UTC(), Data: raw, User: user}
+	b.nextID++
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Event{}, err
+	}
+	e.line = string(line)
+
+	// BOUNDED HISTORY: drop the oldest event once it is full
+	if len(b.history) == b.opts.History {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:len(b.history)-1]
+	}
+	b.history = append(b.history, e)
+
+	for s := range b.subs {
+		if s.wants(e) {
+			b.send(s, e.sse())
+		}
+	}
+	return e, nil
+}
+
+// send queues an event without ever blocking the publisher
+// A stream whose queue is full is not keeping up (or is gone): it is dropped,
+// and the browser's reconnect replays what it missed from the history
+func (b *Broker) send(s *Subscription, ev rweb.SSEvent) {
+	select {
+	case s.C <- ev:
+	default:
+		b.drop(s)
+	}
+}
+
+// drop ends a subscription; b.mu must be held
+//
+// RWEB WORKAROUND: when the channel closes, rweb stops writing but leaves the
+// connection open - with no Content-Length, the browser can't tell that the
+// response is over and waits forever. So the last event on every stream is
+// a reconnect, telling the page's script to close it and open a new one.
+// Whatever is still queued is thrown away to make room: the new stream's
+// Last-Event-ID replays it.
+func (b *Broker) drop(s *Subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	for len(s.C) > 0 {
+		select {
+		case <-s.C:
+		default: // rweb took it first
+		}
+	}
+	s.C <- control(Reconnect, 0)
+	close(s.C)
+}
+
+// Subscribe opens a stream for a staff member
+//
+// types narrows the stream to some kinds of event (none means all). With
+// hasLast, lastID is the Last-Event-ID the browser reconnected with: the
+// events after it are queued first, or a resync event when they are no
+// longer all in the history.
+func (b *Broker) Subscribe(user string, types []Type, lastID uint64, hasLast bool) *Subscription {
+	s := &Subscription{C: make(chan any, b.opts.Buffer), user: user}
+	if len(types) > 0 {
+		s.types = map[Type]bool{}
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		s.C <- control(Reconnect, 0) // to another instance, hopefully
+		close(s.C)
+		return s
+	}
+
+	// REPLAY under the same lock as Publish, so no event can fall between the
+	// history and the live stream
+	if hasLast {
+		missed := b.replay(s, lastID)
+		if len(missed) > cap(s.C) {
+			// More missed events than fit in the queue: tell the client to reload
+			missed = []rweb.SSEvent{b.resync()}
+		}
+		for _, ev := range missed {
+			s.C <- ev
+		}
+	}
+
+	b.subs[s] = struct{}{}
+	if !b.beating {
+		b.beating = true
+		go b.heartbeat()
+	}
+	return s
+}
+
+// replay lists what a stream reconnecting after lastID missed; b.mu must be held
+func (b *Broker) replay(s *Subscription, lastID uint64) []rweb.SSEvent {
+	first := b.nextID // the oldest ID still known
+	if len(b.history) > 0 {
+		first = b.history[0].ID
+	}
+	// An ID we never issued (a previous run's), or older than the history
+	if lastID >= b.nextID || lastID+1 < first {
+		return []rweb.SSEvent{b.resync()}
+	}
+
+	var out []rweb.SSEvent
+	for _, e := range b.history {
+		if e.ID > lastID && s.wants(e) {
+			out = append(out, e.sse())
+		}
+	}
+	return out
+}
+
+// resync carries the newest ID, so the browser's next reconnect starts from here
+func (b *Broker) resync() rweb.SSEvent {
+	return control(Resync, b.nextID-1)
+}
+
+// Unsubscribe ends a subscription and closes its channel
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.drop(s)
+}
+
+// Subscribers counts the open streams
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// heartbeat runs while there are streams
+//
+// Besides keeping idle connections open, it finds streams whose client has
+// gone: rweb only notices a closed connection when a write fails, and stops
+// reading the channel then. A queue that still holds events two heartbeats
+// in a row belongs to such a stream, and is dropped.
+func (b *Broker) heartbeat() {
+	ticker := time.NewTicker(b.opts.Heartbeat)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.mu.Lock()
+		if b.closed || len(b.subs) == 0 {
+			b.beating = false
+			b.mu.Unlock()
+			return
+		}
+		for s := range b.subs {
+			if s.stale && len(s.C) > 0 {
+				b.drop(s)
+				continue
+			}
+			s.stale = len(s.C) > 0
+			b.send(s, control(Heartbeat, 0))
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Close ends every stream; later subscriptions are closed at once
+// Call it when the server shuts down, so open streams don't hold it up
+func (b *Broker) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.drop(s)
+	}
+}
+
+// sse is the event as rweb writes it
+func (e Event) sse() rweb.SSEvent {
+	return rweb.SSEvent{Type: withID(e.Type, e.ID), Data: e.line}
+}
+
+// control makes a broker event; id 0 leaves the browser's last ID alone
+func control(typ Type, id uint64) rweb.SSEvent {
+	t := string(typ)
+	if id != 0 {
+		t = withID(typ, id)
+	}
+	return rweb.SSEvent{Type: t, Data: `{"type":"` + string(typ) + `"}`}
+}
+
+// withID smuggles an "id:" line in after the event type
+//
+// RWEB WORKAROUND: rweb writes "event: <Type>\ndata: <Data>\n\n" and has no
+// field for the ID, so the ID rides along in the type:
+//
+//	event: cat.status
+//	id: 1729250000000001
+//	data: {...}
+func withID(typ Type, id uint64) string {
+	return string(typ) + "\nid: " + strconv.FormatUint(id, 10)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. FAN-OUT - one published event, a buffered channel per subscriber
+// 2. NON-BLOCKING SENDS - select with default never lets a slow client stall a handler
+// 3. BOUNDED HISTORY - the newest events, kept for Last-Event-ID replay
+// 4. MUTEX - one lock guards the history and the subscriber set together
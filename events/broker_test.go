@@ -0,0 +1,251 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// next takes the next queued event off s, failing if there is none
+func next(t *testing.T, s *Subscription) rweb.SSEvent {
+	t.Helper()
+	select {
+	case v, ok := <-s.C:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return v.(rweb.SSEvent)
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return rweb.SSEvent{}
+}
+
+// typeOf splits the event type from the smuggled id line
+func typeOf(ev rweb.SSEvent) (typ, id string) {
+	typ, id, _ = strings.Cut(ev.Type, "\nid: ")
+	return typ, id
+}
+
+func expectEmpty(t *testing.T, s *Subscription) {
+	t.Helper()
+	if n := len(s.C); n != 0 {
+		t.Fatalf("%d events queued, want none", n)
+	}
+}
+
+func TestPublishFansOut(t *testing.T) {
+	b := New(Options{})
+	defer b.Close()
+	s1 := b.Subscribe("ann", nil, 0, false)
+	s2 := b.Subscribe("bob", nil, 0, false)
+
+	e, err := b.Publish(CatStatus, "", CatStatusChange{Slug: "tom", Name: "Tom", From: "available", To: "adopted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*Subscription{s1, s2} {
+		ev := next(t, s)
+		typ, id := typeOf(ev)
+		if typ != string(CatStatus) || id != jsonNumber(e.ID) {
+			t.Errorf("event %q", ev.Type)
+		}
+		var got struct {
+			Type Type            `json:"type"`
+			Data CatStatusChange `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(ev.Data.(string)), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Type != CatStatus || got.Data.Slug != "tom" || got.Data.To != "adopted" {
+			t.Errorf("data %+v", got)
+		}
+	}
+}
+
+func jsonNumber(n uint64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestUserAndTypeFilters(t *testing.T) {
+	b := New(Options{})
+	defer b.Close()
+	ann := b.Subscribe("ann", nil, 0, false)
+	bob := b.Subscribe("bob", []Type{ContactMessage}, 0, false)
+
+	b.Publish(UploadComplete, "ann", UploadNotice{Name: "a.jpg"})
+	b.Publish(ContactMessage, "", ContactNotice{Name: "Sue"})
+
+	if typ, _ := typeOf(next(t, ann)); typ != string(UploadComplete) {
+		t.Errorf("ann got %q first", typ)
+	}
+	if typ, _ := typeOf(next(t, ann)); typ != string(ContactMessage) {
+		t.Errorf("ann got %q second", typ)
+	}
+	// bob sees neither ann's upload nor, by his filter, any upload at all
+	if typ, _ := typeOf(next(t, bob)); typ != string(ContactMessage) {
+		t.Errorf("bob got %q", typ)
+	}
+	expectEmpty(t, bob)
+}
+
+func TestReplayAfterLastEventID(t *testing.T) {
+	b := New(Options{})
+	defer b.Close()
+	first, _ := b.Publish(ContactMessage, "", ContactNotice{Name: "1"})
+	b.Publish(UploadComplete, "bob", UploadNotice{Name: "bobs.jpg"})
+	third, _ := b.Publish(ContactMessage, "", ContactNotice{Name: "3"})
+
+	s := b.Subscribe("ann", nil, first.ID, true)
+	// only the third: the second was bob's
+	if _, id := typeOf(next(t, s)); id != jsonNumber(third.ID) {
+		t.Errorf("replayed id %s, want %d", id, third.ID)
+	}
+	expectEmpty(t, s)
+
+	// Up to date: nothing to replay
+	s = b.Subscribe("ann", nil, third.ID, true)
+	expectEmpty(t, s)
+}
+
+func TestResync(t *testing.T) {
+	b := New(Options{History: 2})
+	defer b.Close()
+	first, _ := b.Publish(ContactMessage, "", ContactNotice{Name: "1"})
+	b.Publish(ContactMessage, "", ContactNotice{Name: "2"})
+	last, _ := b.Publish(ContactMessage, "", ContactNotice{Name: "3"})
+
+	tests := []struct {
+		name   string
+		lastID uint64
+	}{
+		{"fell out of the history", first.ID - 1},
+		{"from an earlier run", 12},
+		{"never issued", last.ID + 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := b.Subscribe("ann", nil, tt.lastID, true)
+			typ, id := typeOf(next(t, s))
+			if typ != string(Resync) || id != jsonNumber(last.ID) {
+				t.Errorf("got %q id %s, want resync at %d", typ, id, last.ID)
+			}
+			expectEmpty(t, s)
+		})
+	}
+
+	// Losing just the first is fine while the second is still known
+	s := b.Subscribe("ann", nil, first.ID, true)
+	if typ, _ := typeOf(next(t, s)); typ != string(ContactMessage) {
+		t.Errorf("got %q, want a replay", typ)
+	}
+}
+
+// A replay bigger than the queue is a resync too
+func TestResyncWhenReplayOverflows(t *testing.T) {
+	b := New(Options{Buffer: 2})
+	defer b.Close()
+	first, _ := b.Publish(ContactMessage, "", ContactNotice{})
+	for range 3 {
+		b.Publish(ContactMessage, "", ContactNotice{})
+	}
+	s := b.Subscribe("ann", nil, first.ID, true)
+	if typ, _ := typeOf(next(t, s)); typ != string(Resync) {
+		t.Errorf("got %q, want resync", typ)
+	}
+}
+
+// A stream that stops reading is dropped rather than blocking Publish
+func TestFullQueueDropsStream(t *testing.T) {
+	b := New(Options{Buffer: 1})
+	defer b.Close()
+	s := b.Subscribe("ann", nil, 0, false)
+	b.Publish(ContactMessage, "", ContactNotice{})
+	b.Publish(ContactMessage, "", ContactNotice{}) // no room
+
+	// The queued event makes way for the goodbye
+	if typ, _ := typeOf(next(t, s)); typ != string(Reconnect) {
+		t.Errorf("got %q, want reconnect", typ)
+	}
+	if _, ok := <-s.C; ok {
+		t.Error("stream still open")
+	}
+	if n := b.Subscribers(); n != 0 {
+		t.Errorf("%d subscribers, want 0", n)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	b := New(Options{Heartbeat: 10 * time.Millisecond})
+	defer b.Close()
+
+	live := b.Subscribe("ann", nil, 0, false)
+	gone := b.Subscribe("bob", nil, 0, false) // never read, like a closed connection
+
+	for range 3 {
+		if typ, _ := typeOf(next(t, live)); typ != string(Heartbeat) {
+			t.Fatalf("got %q, want heartbeat", typ)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for b.Subscribers() > 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := b.Subscribers(); n != 1 {
+		t.Fatalf("%d subscribers, want just the live one", n)
+	}
+	for range gone.C {
+		// drain the heartbeats it never read; the range ends once it is closed
+	}
+
+	// The goroutine stops with the last stream
+	b.Unsubscribe(live)
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		beating := b.beating
+		b.mu.Unlock()
+		if !beating {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("heartbeat still running without subscribers")
+}
+
+// Every stream ends with a reconnect event, then its channel closes
+func TestClose(t *testing.T) {
+	b := New(Options{})
+	s := b.Subscribe("ann", nil, 0, false)
+	b.Close()
+	for _, s := range []*Subscription{s, b.Subscribe("ann", nil, 0, false)} {
+		if typ, _ := typeOf(next(t, s)); typ != string(Reconnect) {
+			t.Errorf("got %q, want reconnect", typ)
+		}
+		if _, ok := <-s.C; ok {
+			t.Error("stream open after Close")
+		}
+	}
+}
+
+func TestNilBroker(t *testing.T) {
+	var b *Broker
+	if _, err := b.Publish(ContactMessage, "", nil); err != nil {
+		t.Error(err)
+	}
+	b.Close()
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := parseTypes("contact.message, cat.status")
+	if err != nil || len(types) != 2 || types[1] != CatStatus {
+		t.Errorf("got %v, %v", types, err)
+	}
+	if _, err := parseTypes("contact.message,nope"); err == nil {
+		t.Error("unknown type accepted")
+	}
+}
@@ -0,0 +1,294 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+)
+
+// ProgressPath is the stream of one upload's progress; UploadIDParam is the
+// query parameter naming the upload on the POST
+const (
+	ProgressPath  = "/upload/progress/:id"
+	UploadIDParam = "upload_id"
+)
+
+// Upload progress events; the last one on a stream is UploadComplete (with the
+// stored file as "result") or UploadFailed (with "error")
+const (
+	UploadProgress Type = "upload.progress"
+	UploadFailed   Type = "upload.failed"
+)
+
+// Phase is how far along an upload is
+type Phase string
+
+const (
+	PhaseReceived Phase = "received" // the whole request body has arrived
+	PhaseDone     Phase = "done"
+	PhaseFailed   Phase = "failed"
+)
+
+// Progress is the data of every upload progress event:
+//
+//	{"id":"k3j4...","phase":"received","bytes":2097152,"total":2097152}
+type Progress struct {
+	ID     string `json:"id"`
+	Phase  Phase  `json:"phase"`
+	Bytes  int64  `json:"bytes"`
+	Total  int64  `json:"total"`            // 0 when unknown
+	Result any    `json:"result,omitempty"` // the stored file, once done
+	Error  string `json:"error,omitempty"`  // why it failed, for the uploader
+}
+
+// Tracker follows uploads by an ID the CLIENT picks, so it can start watching
+// before it starts sending:
+//
+//	const id = crypto.randomUUID();
+//	const source = new EventSource("/upload/progress/" + id);
+//	source.addEventListener("upload.progress", e => show(JSON.parse(e.data)));
+//	source.addEventListener("upload.complete", e => { source.close(); done(JSON.parse(e.data).result); });
+//	source.addEventListener("upload.failed", e => { source.close(); failed(JSON.parse(e.data).error); });
+//	fetch("/upload?upload_id=" + id, {method: "POST", body: formData});
+//
+// RWEB LIMITATION: rweb reads the whole request body before any handler runs,
+// so the server can't see bytes arriving; its first event is "received" with
+// everything there. A progress bar for the transfer MUST come from the browser,
+// which measures its own sending - fetch can't, so use XMLHttpRequest:
+//
+//	xhr.upload.onprogress = e => bar(e.loaded, e.total);
+//
+// The stream adds what only the server knows: that the body arrived, and how
+// the upload ended - stored, or rejected and why.
+//
+// BOUNDED MEMORY: since anyone may watch any well-formed ID, a job exists for
+// every ID asked about, and each is kept for a while. At most MaxJobs are
+// remembered; the next one pushes out the upload heard of least recently,
+// whose watchers are told it isn't coming. A real upload is over within one
+// request, so it is never the oldest for long.
+// RWEB LIMITATION: handlers can't see the visitor's address, so there is no
+// per-client cap - the global one is what bounds a client that asks a lot
+type Tracker struct {
+	keep time.Duration
+	max  int // MaxJobs; tests lower it
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// MaxJobs is how many uploads a Tracker remembers at once
+const MaxJobs = 10000
+
+// job is one upload, known from its first update or its first watcher
+type job struct {
+	last     rweb.SSEvent // the newest event, sent first to a late watcher
+	started  bool
+	finished bool
+	watchers []chan any
+	expiry   *time.Timer
+	heard    time.Time // the last update or watcher: the oldest job goes first
+}
+
+// NewTracker keeps each upload - finished or not - for keep after it was last
+// heard of, so a watcher that connects late still learns how it ended
+// (0 means 10 minutes)
+func NewTracker(keep time.Duration) *Tracker {
+	if keep <= 0 {
+		keep = 10 * time.Minute
+	}
+	return &Tracker{keep: keep, max: MaxJobs, jobs: map[string]*job{}}
+}
+
+// ValidUploadID accepts 8-64 letters, digits, "-" and "_" - enough for a UUID,
+// too little to be abused as storage
+func ValidUploadID(id string) bool {
+	if len(id) < 8 || len(id) > 64 {
+		return false
+	}
+	return strings.Trim(id, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") == ""
+}
+
+// job finds or creates the upload's record and pushes its expiry back; t.mu must be held
+func (t *Tracker) job(id string) *job {
+	j, ok := t.jobs[id]
+	if !ok {
+		if len(t.jobs) >= t.max {
+			t.dropOldest()
+		}
+		j = &job{heard: time.Now()}
+		t.jobs[id] = j
+		// TIME.AFTERFUNC: no sweeper goroutine, each job forgets itself
+		j.expiry = time.AfterFunc(t.keep, func() { t.expire(id, j) })
+		return j
+	}
+	j.heard = time.Now()
+	j.expiry.Reset(t.keep)
+	return j
+}
+
+// expire forgets an upload nobody has heard of for a while
+func (t *Tracker) expire(id string, j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.jobs[id] != j {
+		return // already replaced
+	}
+	t.drop(id, j)
+}
+
+// dropOldest makes room for one more job; t.mu must be held
+// A LINEAR SCAN only runs with the tracker full, which takes many requests
+func (t *Tracker) dropOldest() {
+	var oldestID string
+	var oldest *job
+	for id, j := range t.jobs {
+		if oldest == nil || j.heard.Before(oldest.heard) {
+			oldestID, oldest = id, j
+		}
+	}
+	if oldest != nil {
+		t.drop(oldestID, oldest)
+	}
+}
+
+// drop forgets an upload; t.mu must be held
+// Watchers of one that never finished are told it isn't coming
+func (t *Tracker) drop(id string, j *job) {
+	j.expiry.Stop() // a no-op when it is the timer that called
+	delete(t.jobs, id)
+	if !j.finished {
+		t.end(j, progressEvent(UploadFailed, Progress{ID: id, Phase: PhaseFailed, Error: "The upload did not arrive in time."}))
+	}
+}
+
+// Update reports progress; NIL RECEIVER and an empty id report nothing, so
+// uploads nobody watches cost nothing. Neither does an id that isn't valid:
+// the client picks it, and it must not become a job unchecked
+func (t *Tracker) Update(id string, phase Phase, bytes, total int64) {
+	if t == nil || !ValidUploadID(id) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j := t.job(id)
+	if j.finished {
+		return
+	}
+	j.started = true
+	j.last = progressEvent(UploadProgress, Progress{ID: id, Phase: phase, Bytes: bytes, Total: total})
+	for _, w := range j.watchers {
+		// NON-BLOCKING: a watcher that is behind skips to a later update
+		select {
+		case w <- j.last:
+		default:
+		}
+	}
+}
+
+// Finish reports a stored upload and ends its streams
+func (t *Tracker) Finish(id string, size int64, result any) {
+	if t == nil || !ValidUploadID(id) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.end(t.job(id), progressEvent(UploadComplete, Progress{ID: id, Phase: PhaseDone, Bytes: size, Total: size, Result: result}))
+}
+
+// Fail reports a rejected upload with a reason for the uploader, and ends its streams
+func (t *Tracker) Fail(id, reason string) {
+	if t == nil || !ValidUploadID(id) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.end(t.job(id), progressEvent(UploadFailed, Progress{ID: id, Phase: PhaseFailed, Error: reason}))
+}
+
+// end sends the final event to every watcher and closes their streams; t.mu must be held
+func (t *Tracker) end(j *job, final rweb.SSEvent) {
+	if j.finished {
+		return
+	}
+	j.finished, j.started, j.last = true, true, final
+	for _, w := range j.watchers {
+		// The final event must arrive: when the queue is full, the oldest
+		// progress makes room
+		for sent := false; !sent; {
+			select {
+			case w <- final:
+				sent = true
+			default:
+				select {
+				case <-w:
+				default: // rweb took one meanwhile
+				}
+			}
+		}
+		close(w)
+	}
+	j.watchers = nil
+}
+
+// Watch returns a stream of the upload's events, starting with the newest one
+// For an upload that already ended, that is its final event and the stream ends
+func (t *Tracker) Watch(id string) <-chan any {
+	w := make(chan any, 8)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j := t.job(id)
+	if j.started {
+		w <- j.last
+	}
+	if j.finished {
+		close(w)
+		return w
+	}
+	j.watchers = append(j.watchers, w)
+	return w
+}
+
+// Watchers counts the open streams of an upload
+func (t *Tracker) Watchers(id string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if j, ok := t.jobs[id]; ok {
+		return len(j.watchers)
+	}
+	return 0
+}
+
+// Handler streams the progress of the upload named by the ":id" path parameter
+//
+// The stream ends after the final event; the client should close it then
+// (rweb leaves the connection open - see Broker.drop)
+func (t *Tracker) Handler(ctx rweb.Context) error {
+	id := ctx.Request().PathParam("id")
+	if !ValidUploadID(id) {
+		return apperr.BadRequest("error.bad_upload_id")
+	}
+	return serve(ctx, t.Watch(strings.Clone(id)))
+}
+
+// progressEvent encodes p for rweb
+// The upload's ID is no SSE "id:" - there is nothing to replay, only the newest state
+func progressEvent(typ Type, p Progress) rweb.SSEvent {
+	data, err := json.Marshal(p)
+	if err != nil {
+		// Only Result can fail to encode; report that rather than nothing
+		data, _ = json.Marshal(Progress{ID: p.ID, Phase: PhaseFailed, Error: err.Error()})
+		typ = UploadFailed
+	}
+	return rweb.SSEvent{Type: string(typ), Data: string(data)}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. CLIENT-CHOSEN IDS - the watcher can subscribe before the upload exists
+// 2. LATEST STATE, NOT A LOG - a late watcher gets the newest event, then live ones
+// 3. TIMERS - time.AfterFunc expires each job without a sweeper goroutine
+// 4. BOUNDED CACHES - a cap with least-recently-heard eviction keeps memory in check
@@ -0,0 +1,172 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// receive takes the next event off a watch stream
+func receive(t *testing.T, w <-chan any) (Type, Progress) {
+	t.Helper()
+	select {
+	case v, ok := <-w:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		ev := v.(rweb.SSEvent)
+		var p Progress
+		if err := json.Unmarshal([]byte(ev.Data.(string)), &p); err != nil {
+			t.Fatal(err)
+		}
+		return Type(ev.Type), p
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return "", Progress{}
+}
+
+func expectClosed(t *testing.T, w <-chan any) {
+	t.Helper()
+	select {
+	case _, ok := <-w:
+		if ok {
+			t.Error("stream still open")
+		}
+	case <-time.After(time.Second):
+		t.Error("stream still open")
+	}
+}
+
+func TestTrackerLifecycle(t *testing.T) {
+	tr := NewTracker(0)
+	w := tr.Watch("upload-1") // before the upload starts
+
+	tr.Update("upload-1", PhaseReceived, 100, 100)
+	tr.Finish("upload-1", 90, map[string]string{"name": "a.txt"})
+
+	if typ, p := receive(t, w); typ != UploadProgress || p.Phase != PhaseReceived || p.Bytes != 100 {
+		t.Errorf("first %s %+v", typ, p)
+	}
+	typ, p := receive(t, w)
+	if typ != UploadComplete || p.Phase != PhaseDone || p.Result.(map[string]any)["name"] != "a.txt" {
+		t.Errorf("final %s %+v", typ, p)
+	}
+	expectClosed(t, w)
+	if n := tr.Watchers("upload-1"); n != 0 {
+		t.Errorf("%d watchers after the end", n)
+	}
+
+	// Too late for the progress, just in time for the outcome
+	late := tr.Watch("upload-1")
+	if typ, _ := receive(t, late); typ != UploadComplete {
+		t.Errorf("late watcher got %s", typ)
+	}
+	expectClosed(t, late)
+}
+
+func TestTrackerFail(t *testing.T) {
+	tr := NewTracker(0)
+	w := tr.Watch("upload-2")
+	tr.Fail("upload-2", "Too big.")
+	tr.Update("upload-2", PhaseReceived, 1, 1) // too late: ignored
+
+	if typ, p := receive(t, w); typ != UploadFailed || p.Error != "Too big." {
+		t.Errorf("got %s %+v", typ, p)
+	}
+	expectClosed(t, w)
+}
+
+// A watcher that falls behind skips progress, never the outcome
+func TestTrackerSlowWatcher(t *testing.T) {
+	tr := NewTracker(0)
+	w := tr.Watch("upload-3")
+	for i := range 100 {
+		tr.Update("upload-3", PhaseReceived, int64(i), 100)
+	}
+	tr.Finish("upload-3", 100, nil)
+
+	var last Type
+	for v := range w {
+		last = Type(v.(rweb.SSEvent).Type)
+	}
+	if last != UploadComplete {
+		t.Errorf("last event %s, want %s", last, UploadComplete)
+	}
+}
+
+func TestTrackerExpiry(t *testing.T) {
+	tr := NewTracker(20 * time.Millisecond)
+	w := tr.Watch("never-sent")
+	if typ, p := receive(t, w); typ != UploadFailed || p.Error == "" {
+		t.Errorf("got %s %+v", typ, p)
+	}
+	expectClosed(t, w)
+}
+
+// Watching ever more IDs can't grow the tracker past its cap: the job heard
+// of least recently makes room, and its watcher is told
+func TestTrackerCap(t *testing.T) {
+	tr := NewTracker(0)
+	tr.max = 2
+	first := tr.Watch("watched-1")
+	tr.Watch("watched-2")
+	tr.Update("watched-1", PhaseReceived, 1, 1) // heard of again: now the newest
+
+	third := tr.Watch("watched-3")
+	if len(tr.jobs) != 2 || tr.jobs["watched-2"] != nil {
+		t.Errorf("jobs %v, want watched-2 dropped", tr.jobs)
+	}
+	if typ, _ := receive(t, first); typ != UploadProgress {
+		t.Errorf("watched-1 got %s", typ)
+	}
+
+	for i := range 50 {
+		tr.Watch(fmt.Sprintf("spam-%04d", i))
+	}
+	if len(tr.jobs) != 2 {
+		t.Errorf("%d jobs, want 2", len(tr.jobs))
+	}
+	if typ, p := receive(t, third); typ != UploadFailed || p.Error == "" {
+		t.Errorf("dropped watcher got %s %+v", typ, p)
+	}
+	expectClosed(t, third)
+}
+
+func TestTrackerNil(t *testing.T) {
+	var tr *Tracker
+	tr.Update("x", PhaseReceived, 1, 1)
+	tr.Finish("x", 1, nil)
+	tr.Fail("x", "")
+}
+
+// Reports under an ID that isn't valid - or none - create no job
+func TestTrackerIgnoresInvalidIDs(t *testing.T) {
+	tr := NewTracker(0)
+	for _, id := range []string{"", "short", "../../etc/passwd"} {
+		tr.Update(id, PhaseReceived, 1, 1)
+		tr.Finish(id, 1, nil)
+		tr.Fail(id, "Too big.")
+	}
+	if len(tr.jobs) != 0 {
+		t.Errorf("%d jobs for invalid ids", len(tr.jobs))
+	}
+}
+
+func TestValidUploadID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"0b5d4c9e-5a7f-4f7e-9a8e-2f0c1d2e3f4a": true,
+		"abc_DEF-123":                          true,
+		"short":                                false,
+		"has space in it":                      false,
+		"../../etc/passwd":                     false,
+		string(make([]byte, 65)):               false,
+	} {
+		if got := ValidUploadID(id); got != want {
+			t.Errorf("ValidUploadID(%q) = %v", id, got)
+		}
+	}
+}
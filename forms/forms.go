@@ -0,0 +1,140 @@
+// Package forms holds the small pieces every HTML form handler needs:
+// reading a submitted form safely, and reporting problems per field.
+// Domain packages (adoption, cats, ...) use FieldErrors and ValueFunc for
+// validation without depending on the web framework.
+package forms
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// FieldErrors maps a form field name to a message about what is wrong with it
+// A MAP TYPE with methods: named types can have methods even when they are maps
+type FieldErrors map[string]string
+
+// Add records the first problem found for a field (later ones are ignored,
+// so the visitor sees the most basic issue first)
+func (fe FieldErrors) Add(field, msg string) {
+	if _, exists := fe[field]; !exists {
+		fe[field] = msg
+	}
+}
+
+// Merge copies other's errors into fe
+func (fe FieldErrors) Merge(other FieldErrors) {
+	for k, v := range other {
+		fe.Add(k, v)
+	}
+}
+
+// FUNCTION TYPE: ValueFunc is any function that looks up a submitted form value by name
+// Taking a function instead of an rweb request keeps domain packages free of web framework
+// imports - a handler simply passes form.Value
+type ValueFunc func(key string) string
+
+// ErrNoFile is returned by Form.File when the field has no uploaded file
+var ErrNoFile = errors.New("no file uploaded")
+
+// maxMemory is how much of a multipart body is kept in memory; larger files spill to temp files
+const maxMemory = 32 << 20
+
+// Form is one parsed form submission (urlencoded, multipart or JSON)
+//
+// WHY NOT ctx.Request().FormValue()? Two rweb behaviours make it unsafe for
+// anything we keep past the end of the request:
+//  1. Urlencoded values are ZERO-COPY strings pointing into the request buffer,
+//     which rweb reuses for the next request on the connection.
+//  2. Parsed multipart forms are cached on pooled request objects and not reset,
+//     so a later request can see an earlier request's fields and files.
+//
+// Parse reads the raw body itself, producing independent strings every time.
+type Form struct {
+	values url.Values
+	files  map[string][]*multipart.FileHeader
+	mp     *multipart.Form // kept so Close can remove temp files
+}
+
+// Parse decodes the request body according to its Content-Type
+// Requests without a form body give an empty (but usable) Form
+func Parse(req rweb.ItfRequest) (*Form, error) {
+	f := &Form{values: url.Values{}}
+
+	mediaType, params, _ := mime.ParseMediaType(Header(req, "Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		// string(...) CONVERSION COPIES the bytes - the values no longer alias rweb's buffer
+		values, err := url.ParseQuery(string(req.Body()))
+		if err != nil {
+			return nil, err
+		}
+		f.values = values
+
+	case "multipart/form-data":
+		mr := multipart.NewReader(strings.NewReader(string(req.Body())), params["boundary"])
+		mp, err := mr.ReadForm(maxMemory)
+		if err != nil {
+			return nil, err
+		}
+		f.mp, f.values, f.files = mp, url.Values(mp.Value), mp.File
+
+	case "application/json":
+		// SCRIPTS AND APPS send JSON; it becomes the same values a form would give
+		values, err := parseJSON(req.Body())
+		if err != nil {
+			return nil, err
+		}
+		f.values = values
+	}
+	return f, nil
+}
+
+// Value returns the first value submitted for key, or ""
+func (f *Form) Value(key string) string {
+	return f.values.Get(key)
+}
+
+// Has reports whether the field was submitted at all (even if empty)
+func (f *Form) Has(key string) bool {
+	return f.values.Has(key)
+}
+
+// File opens the first file uploaded under key
+func (f *Form) File(key string) (multipart.File, *multipart.FileHeader, error) {
+	headers := f.files[key]
+	if len(headers) == 0 {
+		return nil, nil, ErrNoFile
+	}
+	file, err := headers[0].Open()
+	return file, headers[0], err
+}
+
+// Close removes any temporary files created for large multipart uploads
+// Safe to call on any Form; intended for use with defer
+func (f *Form) Close() {
+	if f.mp != nil {
+		_ = f.mp.RemoveAll()
+	}
+}
+
+// Header looks up a request header case-insensitively
+// (rweb's own Header() requires the exact capitalisation the client sent)
+func Header(req rweb.ItfRequest, key string) string {
+	for _, h := range req.Headers() {
+		if strings.EqualFold(h.Key, key) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. METHODS ON MAP TYPES - FieldErrors.Add / Merge
+// 2. METHOD VALUES - form.Value can be passed wherever a ValueFunc is expected
+// 3. DEFENSIVE COPYING - string(bytes) breaks aliasing with a reused buffer
+// 4. MIME PARSING - mime.ParseMediaType splits "multipart/form-data; boundary=..."
@@ -0,0 +1,77 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ErrJSONShape is returned for JSON bodies a form can't represent
+var ErrJSONShape = errors.New("forms: JSON body must be an object of strings, numbers, booleans or lists of them")
+
+// parseJSON turns a flat JSON object into form values, so a handler reads
+//
+//	{"name": "Ann", "age": 4, "tags": ["calm", "indoor"]}
+//
+// exactly as it would read name=Ann&age=4&tags=calm&tags=indoor - and the same
+// validation runs whichever way the data arrived
+//
+// null means "not submitted"; nested objects are rejected, because a form has
+// no way to express them
+func parseJSON(body []byte) (url.Values, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // keep 12345678901234567890 as written, not as a rounded float64
+
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("forms: decoding JSON body: %w", err)
+	}
+	if obj == nil || dec.More() {
+		return nil, ErrJSONShape // the body was null, or more than one value
+	}
+
+	values := url.Values{}
+	for key, v := range obj {
+		// TYPE SWITCH: JSON arrays decode as []any
+		switch v := v.(type) {
+		case nil:
+			continue
+		case []any:
+			for _, item := range v {
+				s, ok := scalarString(item)
+				if !ok {
+					return nil, ErrJSONShape
+				}
+				values.Add(key, s)
+			}
+		default:
+			s, ok := scalarString(v)
+			if !ok {
+				return nil, ErrJSONShape
+			}
+			values.Set(key, s)
+		}
+	}
+	return values, nil
+}
+
+// scalarString formats a JSON string, number or boolean as a form value
+func scalarString(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DECODING INTO any - maps, slices, strings, json.Number and bools
+// 2. ONE MODEL, MANY ENCODINGS - JSON becomes the same url.Values as a form
+// 3. json.Decoder.UseNumber - numbers keep their exact text
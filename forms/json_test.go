@@ -0,0 +1,47 @@
+package forms
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	got, err := parseJSON([]byte(`{
+		"name": "Ann",
+		"age": 12345678901234567890,
+		"indoor": true,
+		"tags": ["calm", 3],
+		"nickname": null
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"name":   {"Ann"},
+		"age":    {"12345678901234567890"}, // exactly as written, not rounded
+		"indoor": {"true"},
+		"tags":   {"calm", "3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestParseJSONRejects(t *testing.T) {
+	for _, body := range []string{
+		`null`,
+		`["a", "b"]`,
+		`{"address": {"city": "Leeds"}}`,
+		`{"tags": [["nested"]]}`,
+		`{"a": 1} {"b": 2}`,
+	} {
+		if _, err := parseJSON([]byte(body)); err == nil {
+			t.Errorf("%s: want an error", body)
+		}
+	}
+	if _, err := parseJSON([]byte(`{"a": {}}`)); !errors.Is(err, ErrJSONShape) {
+		t.Errorf("nested object: err = %v, want ErrJSONShape", err)
+	}
+}
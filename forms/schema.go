@@ -0,0 +1,41 @@
+package forms
+
+// FieldType is the kind of value a form field holds
+type FieldType string
+
+const (
+	Text    FieldType = ""        // free text (the zero value)
+	Integer FieldType = "integer" // a whole number, e.g. an age in months
+	Boolean FieldType = "boolean" // a checkbox: any value means checked, absent means not
+	File    FieldType = "file"    // an uploaded file; the form must be sent as multipart
+)
+
+// Field describes one form or query string field
+type Field struct {
+	Name        string
+	Type        FieldType
+	Required    bool
+	Enum        []string // the only accepted values, for choices such as a status
+	Description string
+}
+
+// Schema describes a form: its fields, in the order a page shows them
+//
+// Handlers read fields by name (form.Value), so a Schema doesn't drive parsing;
+// it DESCRIBES the form for generated API documentation (see package openapi),
+// next to the field name constants it uses
+type Schema []Field
+
+// HasFile reports whether the form uploads a file
+func (s Schema) HasFile() bool {
+	for _, f := range s {
+		if f.Type == File {
+			return true
+		}
+	}
+	return false
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DESCRIPTIVE DATA - a form's shape as a value other code can read
+// 2. NAMED STRING TYPES - FieldType constants instead of bare strings
@@ -2,9 +2,10 @@ module form_exer
 
 go 1.23.4
 
-require github.com/rohanthewiz/rweb v0.1.19-0.20250724033211-0709f777d0de
-
 require (
-	github.com/rohanthewiz/element v0.5.4 // indirect
-	github.com/rohanthewiz/serr v1.2.20 // indirect
+	github.com/rohanthewiz/element v0.5.4
+	github.com/rohanthewiz/rweb v0.1.19-0.20250724033211-0709f777d0de
+	golang.org/x/net v0.43.0
 )
+
+require github.com/rohanthewiz/serr v1.2.20 // indirect
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirWritable checks that files can be created in dir (uploads, data files)
+// It writes and removes a small probe file - the only sure test of permissions
+// and of a full or read-only disk
+func DirWritable(name, dir string) Check {
+	return Check{Name: name, Func: func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return err
+		}
+		_, werr := f.WriteString("ok")
+		cerr := f.Close()
+		rerr := os.Remove(f.Name())
+		// errors.Join returns nil when every error is nil
+		return errors.Join(werr, cerr, rerr)
+	}}
+}
+
+// TCP checks that something accepts connections at addr ("host:port")
+func TCP(name, addr string) Check {
+	return Check{Name: name, Func: func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}}
+}
+
+// SMTP checks that a mail server at addr answers with its "220" greeting
+// Connecting alone isn't enough: an overloaded server may accept and then
+// answer "421 try again later"
+func SMTP(name, addr string) Check {
+	return Check{Name: name, Func: func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetReadDeadline(deadline)
+		}
+
+		greeting, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("reading greeting: %w", err)
+		}
+		if !strings.HasPrefix(greeting, "220") {
+			return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(greeting))
+		}
+		_, _ = conn.Write([]byte("QUIT\r\n")) // polite, but we don't wait for the reply
+		return nil
+	}}
+}
+
+// CertWarnEvery limits how often CertFile reports a certificate that expires soon
+const CertWarnEvery = time.Hour
+
+// CertFile checks the PEM certificate at path: it must parse and be valid now.
+// A certificate with less than warnWithin left still passes - every instance
+// shares it, so failing would take the whole fleet out of rotation at once
+// while it still works - but warn is called with its expiry, at most once per
+// CertWarnEvery, so someone hears about it in time to renew
+func CertFile(name, path string, warnWithin time.Duration, warn func(notAfter time.Time)) Check {
+	var mu sync.Mutex
+	var warned time.Time
+	return Check{Name: name, Func: func(ctx context.Context) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// The first block is the server's own certificate; any others are its chain
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%s: no PEM certificate found", path)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch {
+		case now.Before(cert.NotBefore):
+			return fmt.Errorf("certificate is not valid until %s", cert.NotBefore.Format(time.RFC3339))
+		case now.After(cert.NotAfter):
+			return fmt.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+		case cert.NotAfter.Sub(now) < warnWithin && warn != nil:
+			// Readiness is probed every few seconds; one warning an hour is plenty
+			mu.Lock()
+			due := now.Sub(warned) >= CertWarnEvery
+			if due {
+				warned = now
+			}
+			mu.Unlock()
+			if due {
+				warn(cert.NotAfter)
+			}
+		}
+		return nil
+	}}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. FACTORY FUNCTIONS - each returns a Check closing over its settings
+// 2. CONTEXT-AWARE I/O - DialContext and read deadlines honour the timeout
+// 3. ERRORS.JOIN - several cleanup errors reported as one
+// 4. X.509 - reading a certificate's validity window
@@ -0,0 +1,156 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runOne(c Check) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return c.Func(ctx)
+}
+
+func TestDirWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := runOne(DirWritable("d", dir)); err != nil {
+		t.Errorf("temp dir: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("probe file left behind: %v", entries)
+	}
+	if err := runOne(DirWritable("d", filepath.Join(dir, "missing"))); err == nil {
+		t.Error("a missing directory should fail")
+	}
+}
+
+// fakeSMTP accepts one connection and sends greeting
+func fakeSMTP(t *testing.T, greeting string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(greeting))
+		conn.Read(make([]byte, 64)) // wait for QUIT
+	}()
+	return ln.Addr().String()
+}
+
+func TestSMTP(t *testing.T) {
+	if err := runOne(SMTP("smtp", fakeSMTP(t, "220 mail.example.com ESMTP\r\n"))); err != nil {
+		t.Errorf("ready server: %v", err)
+	}
+	err := runOne(SMTP("smtp", fakeSMTP(t, "421 too busy\r\n")))
+	if err == nil || !strings.Contains(err.Error(), "421") {
+		t.Errorf("busy server: %v", err)
+	}
+}
+
+func TestTCPRefused(t *testing.T) {
+	// Grab a free port, then close it so nothing is listening
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if err := runOne(TCP("db", addr)); err == nil {
+		t.Error("closed port should fail")
+	}
+}
+
+// writeCert creates a self-signed certificate valid between notBefore and notAfter
+func writeCert(t *testing.T, notBefore, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: notBefore, NotAfter: notAfter}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCertFile(t *testing.T) {
+	now := time.Now()
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		name          string
+		from, until   time.Time
+		wantErrSubstr string // "" = valid
+	}{
+		{"valid", now.Add(-time.Hour), now.Add(90 * 24 * time.Hour), ""},
+		// Expiring soon is a warning, not a failure: the certificate still works
+		{"expiring", now.Add(-time.Hour), now.Add(24 * time.Hour), ""},
+		{"expired", now.Add(-48 * time.Hour), now.Add(-time.Hour), "expired"},
+		{"not yet valid", now.Add(time.Hour), now.Add(90 * 24 * time.Hour), "not valid until"},
+	}
+	for _, tt := range tests {
+		err := runOne(CertFile("tls", writeCert(t, tt.from, tt.until), week, nil))
+		switch {
+		case tt.wantErrSubstr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErrSubstr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr)):
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.wantErrSubstr)
+		}
+	}
+
+	if err := runOne(CertFile("tls", filepath.Join(t.TempDir(), "none.pem"), week, nil)); err == nil {
+		t.Error("missing file should fail")
+	}
+}
+
+func TestCertFileWarns(t *testing.T) {
+	now := time.Now()
+	until := now.Add(24 * time.Hour)
+	var warnings []time.Time
+	check := CertFile("tls", writeCert(t, now.Add(-time.Hour), until), 7*24*time.Hour, func(notAfter time.Time) {
+		warnings = append(warnings, notAfter)
+	})
+
+	// Probed over and over, it passes every time and warns only once
+	for range 3 {
+		if err := runOne(check); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(warnings) != 1 || !warnings[0].Equal(until.Truncate(time.Second)) {
+		t.Errorf("warnings %v, want one for %v", warnings, until)
+	}
+
+	// Plenty of time left: no warning
+	warnings = nil
+	quiet := CertFile("tls", writeCert(t, now.Add(-time.Hour), now.Add(90*24*time.Hour)), 7*24*time.Hour, func(notAfter time.Time) {
+		warnings = append(warnings, notAfter)
+	})
+	if err := runOne(quiet); err != nil || len(warnings) != 0 {
+		t.Errorf("err %v, warnings %v", err, warnings)
+	}
+}
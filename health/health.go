@@ -0,0 +1,219 @@
+// Package health answers the two questions a load balancer or orchestrator asks:
+//
+//   - /healthz (LIVENESS): is the process running at all? If not, restart it.
+//   - /readyz (READINESS): can it serve traffic right now? If not, stop sending
+//     requests here for a while - but don't restart it.
+//
+// Readiness runs every registered Check (storage writable, data readable, mail
+// server reachable, certificate valid, ...) and reports each one's status and
+// latency as JSON. Once StartShutdown is called readiness fails straight away.
+// /readyz is public, so why a check failed (paths, addresses, certificate
+// details) goes to the log, not the response; and the checks touch the disk
+// and the network, so their results are reused for a few seconds.
+//
+// Failing readiness only helps while the server still accepts connections: the
+// load balancer has to probe /readyz, see the failure, and move traffic away
+// before the listener closes. So StartShutdown belongs to a PRE-STOP step that
+// comes before the stop signal (in main: SIGUSR1, then a wait, then SIGTERM) -
+// not to the stop itself.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// DefaultTimeout bounds each check, so one hung dependency can't hang /readyz
+const DefaultTimeout = 2 * time.Second
+
+// DefaultCacheFor is how long a report is reused: probes from every load
+// balancer (and anyone else) then cost at most one run of the checks per period
+const DefaultCacheFor = 5 * time.Second
+
+// Status values in the JSON reports
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check is one dependency test; Func returns nil when the dependency is usable
+// It should give up when ctx is done
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+// Health holds the readiness checks and the shutdown flag
+type Health struct {
+	Timeout  time.Duration // per check; 0 means DefaultTimeout
+	CacheFor time.Duration // how long Run reuses a report; 0 means DefaultCacheFor
+	Logger   *slog.Logger  // failed checks are logged here; nil means slog.Default()
+
+	mu     sync.Mutex
+	checks []Check
+
+	// runMu lets one Run at a time do the checks; the others wait for its report
+	runMu    sync.Mutex
+	last     Report
+	lastTime time.Time
+
+	// ATOMIC BOOL: set by the shutdown code, read by every /readyz request
+	// without needing the mutex
+	shuttingDown atomic.Bool
+}
+
+// New returns a Health with the given checks
+func New(checks ...Check) *Health {
+	return &Health{checks: checks}
+}
+
+// Add registers more checks
+func (h *Health) Add(checks ...Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, checks...)
+
+	h.runMu.Lock()
+	h.lastTime = time.Time{} // the next Run includes the new checks
+	h.runMu.Unlock()
+}
+
+// StartShutdown makes readiness fail from now on; liveness is unaffected
+func (h *Health) StartShutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether StartShutdown has been called
+func (h *Health) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// Result is the outcome of one check
+// STRUCT TAGS name the JSON fields; omitempty leaves out an empty error
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the /readyz response body
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Run performs every check at the same time and collects the results
+// in registration order; the report fails if any check fails.
+// A report younger than CacheFor is returned again instead, and each failure
+// is logged once per fresh report
+func (h *Health) Run(ctx context.Context) Report {
+	if h.ShuttingDown() {
+		return Report{Status: StatusShuttingDown, Checks: []Result{}}
+	}
+
+	cacheFor := h.CacheFor
+	if cacheFor == 0 {
+		cacheFor = DefaultCacheFor
+	}
+	h.runMu.Lock()
+	defer h.runMu.Unlock()
+	if !h.lastTime.IsZero() && time.Since(h.lastTime) < cacheFor {
+		return h.last
+	}
+
+	h.mu.Lock()
+	checks := append([]Check{}, h.checks...)
+	h.mu.Unlock()
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	// FAN-OUT: one goroutine per check, each writing only its own slot of results,
+	// so the slowest check (not the sum of all of them) sets the response time
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c, timeout)
+		}()
+	}
+	wg.Wait()
+
+	logger := h.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	report := Report{Status: StatusOK, Checks: results}
+	for _, r := range results {
+		if r.Status != StatusOK {
+			report.Status = StatusFail
+			logger.Warn("readiness check failed", "check", r.Name, "error", r.Error, "latency_ms", r.LatencyMS)
+		}
+	}
+	h.last, h.lastTime = report, time.Now()
+	return report
+}
+
+// runCheck runs one check under its own timeout and times it
+func runCheck(ctx context.Context, c Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Func(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err() // the check ignored its deadline but we still count the timeout
+	}
+
+	r := Result{Name: c.Name, Status: StatusOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		r.Status, r.Error = StatusFail, err.Error()
+	}
+	return r
+}
+
+// Live is the /healthz handler: answering at all proves the process is alive
+func (h *Health) Live(ctx rweb.Context) error {
+	ctx.Response().SetHeader("Cache-Control", "no-store")
+	return ctx.WriteJSON(map[string]string{"status": StatusOK})
+}
+
+// Ready is the /readyz handler: 200 with the report when every check passes,
+// 503 Service Unavailable (with the same report, to show what failed) otherwise.
+// The report says only which checks passed: anyone can fetch it, and the
+// errors, which can name files and hosts, are in the log (see Run)
+func (h *Health) Ready(ctx rweb.Context) error {
+	report := h.Run(context.Background())
+
+	// COPY before clearing: the cached report's slice is shared with later calls
+	public := Report{Status: report.Status, Checks: make([]Result, len(report.Checks))}
+	for i, r := range report.Checks {
+		r.Error = ""
+		public.Checks[i] = r
+	}
+
+	ctx.Response().SetHeader("Cache-Control", "no-store")
+	if public.Status != StatusOK {
+		ctx.Response().SetStatus(http.StatusServiceUnavailable)
+	}
+	return ctx.WriteJSON(public)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. LIVENESS VS READINESS - restart me vs. route around me
+// 2. CONTEXT TIMEOUTS - context.WithTimeout bounds every check
+// 3. FAN-OUT WITH WAITGROUP - checks run concurrently, results keep their order
+// 4. ATOMICS - a lock-free flag shared between the shutdown code and handlers
+// 5. CACHING - a short-lived report bounds the work unauthenticated probes can cause
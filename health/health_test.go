@@ -0,0 +1,164 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+func pass(name string) Check {
+	return Check{Name: name, Func: func(context.Context) error { return nil }}
+}
+
+func fail(name string) Check {
+	return Check{Name: name, Func: func(context.Context) error { return errors.New("broken") }}
+}
+
+// hang waits for its deadline, like a dependency that never answers
+func hang(name string) Check {
+	return Check{Name: name, Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+}
+
+func TestRunAllPass(t *testing.T) {
+	h := New(pass("a"), pass("b"))
+	r := h.Run(context.Background())
+	if r.Status != StatusOK || len(r.Checks) != 2 || r.Checks[0].Name != "a" || r.Checks[1].Name != "b" {
+		t.Errorf("report %+v", r)
+	}
+}
+
+func TestRunOneFails(t *testing.T) {
+	h := New(pass("a"), fail("b"))
+	r := h.Run(context.Background())
+	if r.Status != StatusFail {
+		t.Errorf("status %q, want fail", r.Status)
+	}
+	if b := r.Checks[1]; b.Status != StatusFail || b.Error != "broken" {
+		t.Errorf("check b = %+v", b)
+	}
+	if a := r.Checks[0]; a.Status != StatusOK || a.Error != "" {
+		t.Errorf("check a = %+v", a)
+	}
+}
+
+// Checks run concurrently, each under its own timeout
+func TestRunTimeout(t *testing.T) {
+	h := New(hang("slow1"), hang("slow2"), pass("fast"))
+	h.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	r := h.Run(context.Background())
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("took %v; hung checks should time out in parallel", took)
+	}
+	if r.Status != StatusFail || r.Checks[0].Status != StatusFail || r.Checks[2].Status != StatusOK {
+		t.Errorf("report %+v", r)
+	}
+}
+
+func TestShutdownFailsReadiness(t *testing.T) {
+	h := New(pass("a"))
+	h.StartShutdown()
+	if r := h.Run(context.Background()); r.Status != StatusShuttingDown {
+		t.Errorf("status %q, want shutting_down", r.Status)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	h := New(pass("storage"))
+	s := rweb.NewServer()
+	s.Get("/healthz", h.Live)
+	s.Get("/readyz", h.Ready)
+
+	if resp := s.Request(http.MethodGet, "/healthz", nil, nil); resp.Status() != http.StatusOK {
+		t.Errorf("/healthz: status %d", resp.Status())
+	}
+
+	resp := s.Request(http.MethodGet, "/readyz", nil, nil)
+	var report Report
+	if err := json.Unmarshal(resp.Body(), &report); err != nil {
+		t.Fatalf("/readyz body is not a report: %v\n%s", err, resp.Body())
+	}
+	if resp.Status() != http.StatusOK || report.Status != StatusOK || report.Checks[0].Name != "storage" {
+		t.Errorf("/readyz: status %d, report %+v", resp.Status(), report)
+	}
+
+	// Once shutting down, readiness answers 503 but the process is still alive
+	h.StartShutdown()
+	if resp := s.Request(http.MethodGet, "/readyz", nil, nil); resp.Status() != http.StatusServiceUnavailable {
+		t.Errorf("/readyz during shutdown: status %d, want 503", resp.Status())
+	}
+	if resp := s.Request(http.MethodGet, "/healthz", nil, nil); resp.Status() != http.StatusOK {
+		t.Errorf("/healthz during shutdown: status %d, want 200", resp.Status())
+	}
+}
+
+// /readyz is public: it says what failed, the log says why
+func TestReadyHidesErrors(t *testing.T) {
+	var logs bytes.Buffer
+	h := New(pass("storage"), fail("database"))
+	h.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	s := rweb.NewServer()
+	s.Get("/readyz", h.Ready)
+
+	resp := s.Request(http.MethodGet, "/readyz", nil, nil)
+	if resp.Status() != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", resp.Status())
+	}
+	var report Report
+	if err := json.Unmarshal(resp.Body(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if db := report.Checks[1]; db.Name != "database" || db.Status != StatusFail {
+		t.Errorf("database check %+v", db)
+	}
+	if bytes.Contains(resp.Body(), []byte("broken")) {
+		t.Errorf("the error reached the response: %s", resp.Body())
+	}
+	if !strings.Contains(logs.String(), "check=database error=broken") {
+		t.Errorf("log %q, want the database error", logs.String())
+	}
+	// The cached report keeps its detail for the next caller
+	if r := h.Run(context.Background()); r.Checks[1].Error != "broken" {
+		t.Errorf("cached report lost its error: %+v", r.Checks[1])
+	}
+}
+
+// Probes in quick succession share one run of the checks
+func TestRunCaches(t *testing.T) {
+	var runs atomic.Int32
+	counted := Check{Name: "disk", Func: func(context.Context) error { runs.Add(1); return nil }}
+	h := New(counted)
+	h.CacheFor = time.Hour
+
+	for range 3 {
+		h.Run(context.Background())
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("checks ran %d times, want once", n)
+	}
+
+	// A new check can't wait for the cache to expire
+	h.Add(pass("smtp"))
+	if r := h.Run(context.Background()); len(r.Checks) != 2 || runs.Load() != 2 {
+		t.Errorf("after Add: %d checks, %d runs", len(r.Checks), runs.Load())
+	}
+
+	// Nor can shutting down
+	h.StartShutdown()
+	if r := h.Run(context.Background()); r.Status != StatusShuttingDown {
+		t.Errorf("status %q, want shutting_down", r.Status)
+	}
+}
@@ -0,0 +1,159 @@
+// Package i18n translates the site's user-facing text.
+//
+// Messages live in one JSON file per locale (locales/en.json, locales/es.json, ...)
+// keyed by a dotted message ID such as "contact.heading". The files are EMBEDDED
+// into the binary, so a deployment can never ship code without its translations.
+//
+// A Translator looks messages up for one locale, falling back to the default
+// locale and finally to the key itself - which means literal text (an admin page
+// title, a cat's name) can be passed through a Translator unchanged.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is used when nothing better can be negotiated
+const DefaultLocale = "en"
+
+// EMBED DIRECTIVE: the compiler copies every matching file into the binary
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Message is one translatable text, possibly with plural forms
+// A plain string in the JSON file fills Other; an object such as
+// {"one": "%d cat", "other": "%d cats"} supplies the forms a language needs
+type Message struct {
+	Zero  string `json:"zero"`
+	One   string `json:"one"`
+	Few   string `json:"few"`
+	Many  string `json:"many"`
+	Other string `json:"other"`
+}
+
+// UnmarshalJSON accepts either a string or an object of plural forms
+// CUSTOM UNMARSHALER: encoding/json calls this method instead of its default decoding
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = Message{Other: s}
+		return nil
+	}
+	// TYPE CONVERSION to a local type without the method avoids infinite recursion
+	type plain Message
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// form returns the text for a plural category, falling back to Other
+func (m Message) form(category string) string {
+	var s string
+	switch category {
+	case "zero":
+		s = m.Zero
+	case "one":
+		s = m.One
+	case "few":
+		s = m.Few
+	case "many":
+		s = m.Many
+	}
+	if s == "" {
+		s = m.Other
+	}
+	return s
+}
+
+// Catalog holds the messages of every locale
+type Catalog struct {
+	messages map[string]map[string]Message // locale -> key -> message
+	locales  []string                      // sorted, default first
+}
+
+// Load reads every *.json file in dir of fsys; the file name is the locale ("es.json" -> "es")
+// Taking an fs.FS means tests can load catalogs from fstest.MapFS or a temp directory
+func Load(fsys fs.FS, dir string) (*Catalog, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{messages: map[string]map[string]Message{}}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var msgs map[string]Message
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", file, err)
+		}
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		c.messages[locale] = msgs
+		c.locales = append(c.locales, locale)
+	}
+	if _, ok := c.messages[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("i18n: no messages for the default locale %q", DefaultLocale)
+	}
+
+	// Default locale first, then alphabetical - the order language switchers show
+	sort.Slice(c.locales, func(i, j int) bool {
+		if c.locales[i] == DefaultLocale || c.locales[j] == DefaultLocale {
+			return c.locales[i] == DefaultLocale
+		}
+		return c.locales[i] < c.locales[j]
+	})
+	return c, nil
+}
+
+// Locales lists the locales the catalog can serve, default first
+func (c *Catalog) Locales() []string {
+	return c.locales
+}
+
+// Supports reports whether the catalog has messages for locale
+func (c *Catalog) Supports(locale string) bool {
+	_, ok := c.messages[locale]
+	return ok
+}
+
+// Translator returns a Translator for locale (unknown locales get the default)
+func (c *Catalog) Translator(locale string) Translator {
+	if !c.Supports(locale) {
+		locale = DefaultLocale
+	}
+	return Translator{locale: locale, catalog: c}
+}
+
+// lookup finds key in locale, then in the default locale
+func (c *Catalog) lookup(locale, key string) (Message, bool) {
+	if m, ok := c.messages[locale][key]; ok {
+		return m, true
+	}
+	m, ok := c.messages[DefaultLocale][key]
+	return m, ok
+}
+
+// Default is the catalog built from the embedded locale files
+// A broken locale file is a programming error, so it stops the program at startup
+var Default = mustLoad()
+
+func mustLoad() *Catalog {
+	c, err := Load(localeFiles, "locales")
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. EMBED - go:embed bakes the locale files into the binary
+// 2. CUSTOM JSON UNMARSHALING - A message can be a string or an object
+// 3. FS.FS - Loading from any file system (embedded, disk, in-memory)
+// 4. FALLBACK CHAINS - locale -> default locale -> key
@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/forms"
+	"form_exer/web/cookie"
+)
+
+// Ways a visitor can choose a language explicitly
+const (
+	QueryParam = "lang" // /contact?lang=es
+	CookieName = "lang" // remembers the last explicit choice
+)
+
+// contextKey is where Middleware leaves the request's Translator
+const contextKey = "i18n.translator"
+
+// oneYear is how long a language choice is remembered
+const oneYear = 365 * 24 * 60 * 60
+
+// Middleware picks the locale for each request, in this order:
+//  1. the ?lang= query parameter - the language switcher's links, which must
+//     work from a prefixed page too: "English" on /es/cats is /es/cats?lang=en
+//  2. a locale URL prefix, e.g. /es/cats (see LocalizedPaths)
+//  3. the lang cookie
+//  4. the browser's Accept-Language header
+//  5. DefaultLocale
+//
+// An explicit choice (1 or 2) is saved in the cookie, so links without a prefix
+// keep the visitor's language. Register it with s.Use(i18n.Middleware(i18n.Default))
+func Middleware(c *Catalog) rweb.Handler {
+	// CLOSURE: the returned handler remembers c
+	return func(ctx rweb.Context) error {
+		req := ctx.Request()
+
+		var locale string
+		if q := req.QueryParam(QueryParam); c.Supports(q) {
+			locale = strings.Clone(q) // QueryParam points into a reused buffer
+		} else {
+			locale = c.prefixLocale(req.Path())
+		}
+		saved := cookie.Get(req, CookieName)
+		if locale != "" && locale != saved {
+			// Only a new choice is saved: every /es/... page would otherwise resend it
+			_ = cookie.Set(ctx, http.Cookie{Name: CookieName, Value: locale, MaxAge: oneYear}) // a supported locale: always valid
+		}
+
+		if locale == "" && c.Supports(saved) {
+			locale = saved
+		}
+		if locale == "" {
+			locale = Negotiate(forms.Header(req, "Accept-Language"), c.Locales())
+		}
+
+		t := c.Translator(locale)
+		ctx.Set(contextKey, t)
+		ctx.Response().SetHeader("Content-Language", t.Locale())
+		return ctx.Next()
+	}
+}
+
+// FromContext returns the Translator chosen by Middleware
+// Without the middleware it returns the zero Translator (default locale)
+func FromContext(ctx rweb.Context) Translator {
+	t, _ := ctx.Get(contextKey).(Translator) // comma-ok: a missing value yields the zero Translator
+	return t
+}
+
+// prefixLocale returns the locale named by the first path segment ("/es/cats" -> "es")
+func (c *Catalog) prefixLocale(p string) string {
+	first, _, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	if c.Supports(first) {
+		return first
+	}
+	return ""
+}
+
+// LocalizedPaths returns path plus one copy per locale prefix:
+// "/cats" -> ["/cats", "/en/cats", "/es/cats"]; "/" -> ["/", "/en", "/es"]
+// The router needs every form registered; Middleware then reads the prefix
+func (c *Catalog) LocalizedPaths(p string) []string {
+	paths := []string{p}
+	for _, locale := range c.locales {
+		if p == "/" {
+			paths = append(paths, "/"+locale)
+		} else {
+			paths = append(paths, "/"+locale+p)
+		}
+	}
+	return paths
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. MIDDLEWARE FACTORIES - A function that returns a configured handler
+// 2. PRECEDENCE - Explicit choices beat remembered ones, which beat browser defaults;
+//    the most specific explicit choice (?lang=) beats the page's prefix
+// 3. REQUEST-SCOPED VALUES - ctx.Set/ctx.Get carry the Translator to handlers
@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate picks the best supported locale for an Accept-Language header
+// such as "es-MX,es;q=0.9,en;q=0.8". It returns "" when nothing matches.
+//
+// Matching is by language only: "es-MX" is served by "es"
+func Negotiate(acceptLanguage string, supported []string) string {
+	// ANONYMOUS STRUCT SLICE: each wanted language with its quality weight
+	type wanted struct {
+		lang string
+		q    float64
+	}
+	var prefs []wanted
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		// Each part is "tag" or "tag;q=0.8"
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue // malformed weight: ignore this entry
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue // q=0 means "not acceptable"
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		prefs = append(prefs, wanted{lang: lang, q: q})
+	}
+
+	// STABLE SORT by weight keeps the header's order for equal weights
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		for _, s := range supported {
+			if p.lang == s {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. STRINGS.CUT - Splitting on the first separator without index arithmetic
+// 2. STABLE SORTING - Ordering by preference weight
+// 3. CONTENT NEGOTIATION - Honouring the browser's language preferences
@@ -0,0 +1,119 @@
+package i18n
+
+import (
+	"fmt"
+
+	"form_exer/forms"
+)
+
+// Translator renders messages for one locale
+// The ZERO VALUE is ready to use and speaks the default locale, so a component
+// that was never given a Translator still renders sensible English
+type Translator struct {
+	locale  string
+	catalog *Catalog
+}
+
+// Locale returns the locale this translator renders
+func (t Translator) Locale() string {
+	if t.locale == "" {
+		return DefaultLocale
+	}
+	return t.locale
+}
+
+// cat returns the catalog, defaulting to the embedded one
+func (t Translator) cat() *Catalog {
+	if t.catalog == nil {
+		return Default
+	}
+	return t.catalog
+}
+
+// T translates key, formatting args into it with fmt.Sprintf verbs (%s, %d)
+// Unknown keys come back unchanged, so literal text can be passed through safely
+//
+// Translations are trusted HTML (they may contain entities like &copy;);
+// callers must still escape any user-provided args before passing them in
+func (t Translator) T(key string, args ...any) string {
+	m, ok := t.cat().lookup(t.Locale(), key)
+	if !ok {
+		return key
+	}
+	return format(m.Other, args)
+}
+
+// N translates a message with plural forms, choosing the form for count n
+// With no extra args, n itself fills the first verb: N("cats.matches", 3) -> "3 cats match your search"
+func (t Translator) N(key string, n int, args ...any) string {
+	m, ok := t.cat().lookup(t.Locale(), key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		args = []any{n}
+	}
+	return format(m.form(PluralCategory(t.Locale(), n)), args)
+}
+
+// Errors translates the messages in a set of field errors
+// Domain packages (e.g. adoption) report message keys; pages call this before rendering
+func (t Translator) Errors(errs forms.FieldErrors) forms.FieldErrors {
+	if errs == nil {
+		return nil
+	}
+	out := make(forms.FieldErrors, len(errs))
+	for field, msg := range errs {
+		out[field] = t.T(msg)
+	}
+	return out
+}
+
+// Language is a locale code with its name written in that language ("es", "Español")
+type Language struct {
+	Code string
+	Name string
+}
+
+// Languages lists every locale in the catalog, for language switchers
+// Each name comes from that locale's own "language.name" message
+func (t Translator) Languages() []Language {
+	c := t.cat()
+	out := make([]Language, 0, len(c.locales))
+	for _, code := range c.locales {
+		out = append(out, Language{Code: code, Name: c.Translator(code).T("language.name")})
+	}
+	return out
+}
+
+// format applies args only when there are some, so a literal "100%" survives T
+func format(s string, args []any) string {
+	if len(args) == 0 {
+		return s
+	}
+	return fmt.Sprintf(s, args...)
+}
+
+// PluralCategory returns the CLDR plural category ("one", "other", ...) for n
+// Only the rules for the languages we ship (and a few near neighbours) are included
+// See https://cldr.unicode.org/index/cldr-spec/plural-rules
+func PluralCategory(locale string, n int) string {
+	switch locale {
+	case "fr", "pt": // French counts 0 as singular: "0 chat"
+		if n == 0 || n == 1 {
+			return "one"
+		}
+	case "ja", "zh", "ko": // no grammatical plural
+	default: // English, Spanish, German, ...
+		if n == 1 {
+			return "one"
+		}
+	}
+	return "other"
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. USEFUL ZERO VALUES - Translator{} works without initialization
+// 2. VARIADIC ARGUMENTS - args ...any are forwarded to fmt.Sprintf
+// 3. PLURAL RULES - Languages disagree on what counts as "one"
+// 4. GRACEFUL FALLBACK - Missing keys render as themselves instead of failing
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/auth"
+	"form_exer/forms"
+)
+
+// AccessFormat is the layout of access log lines
+type AccessFormat string
+
+const (
+	// AccessCommon is the Common Log Format used by Apache and nginx, understood by most log tools:
+	//	203.0.113.9 - sam [14/Mar/2025:09:30:00 +0000] "GET /cats?q=luna HTTP/1.1" 200 5120
+	AccessCommon AccessFormat = "common"
+
+	// AccessJSON writes one JSON object per line with more detail (request ID, duration, ...)
+	AccessJSON AccessFormat = "json"
+)
+
+// ParseAccessFormat reads "common" (or "clf") and "json"; "" means common
+func ParseAccessFormat(s string) (AccessFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "common", "clf":
+		return AccessCommon, nil
+	case "json":
+		return AccessJSON, nil
+	}
+	return "", fmt.Errorf("unknown access log format %q (want common or json)", s)
+}
+
+// clfTime is the timestamp layout of the Common Log Format
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// now is the clock; tests replace it to get predictable timestamps
+var now = time.Now
+
+// accessEntry is everything recorded about one request
+// STRUCT TAGS name the JSON fields; omitempty leaves out blanks
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+	User      string    `json:"user,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query,omitempty"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Duration  float64   `json:"duration_ms"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// AccessLog writes one line to w for every request, after it has been handled
+// Register it right after Middleware so each line carries the request ID
+func AccessLog(w io.Writer, format AccessFormat) rweb.Handler {
+	// A MUTEX makes each line a single, uninterrupted write even when
+	// requests finish at the same moment
+	var mu sync.Mutex
+
+	return func(ctx rweb.Context) error {
+		start := now()
+		err := ctx.Next()
+
+		req := ctx.Request()
+		status := ctx.Response().Status()
+		if err != nil && (status == 0 || status == http.StatusOK) {
+			status = http.StatusInternalServerError // what rweb's error handler will send
+		}
+
+		// Everything is copied into the entry before the request's buffers are reused
+		e := accessEntry{
+			Time:      start,
+			RequestID: RequestID(ctx),
+			Remote:    clientAddr(req),
+			User:      auth.CurrentUser(ctx),
+			Method:    req.Method(),
+			Path:      req.Path(),
+			Query:     req.Query(),
+			Status:    status,
+			Bytes:     len(ctx.Response().Body()),
+			Duration:  float64(now().Sub(start).Microseconds()) / 1000,
+			Referer:   forms.Header(req, "Referer"),
+			UserAgent: forms.Header(req, "User-Agent"),
+		}
+
+		var line []byte
+		if format == AccessJSON {
+			line, _ = json.Marshal(e) // only strings and numbers - cannot fail
+			line = append(line, '\n')
+		} else {
+			line = []byte(e.common())
+		}
+
+		mu.Lock()
+		_, _ = w.Write(line) // a failing log must never fail the request
+		mu.Unlock()
+		return err
+	}
+}
+
+// common renders the entry in Common Log Format
+// host ident authuser [date] "request line" status bytes
+func (e accessEntry) common() string {
+	target := e.Path
+	if e.Query != "" {
+		target += "?" + e.Query
+	}
+	bytes := "-" // CLF writes "-" for an empty body
+	if e.Bytes > 0 {
+		bytes = strconv.Itoa(e.Bytes)
+	}
+	return fmt.Sprintf("%s - %s [%s] %q %d %s\n",
+		dash(e.Remote), dash(e.User), e.Time.Format(clfTime),
+		e.Method+" "+target+" HTTP/1.1", e.Status, bytes)
+}
+
+// clientAddr is the visitor's address as reported by a proxy in X-Forwarded-For
+// rweb doesn't expose the connection's own address to handlers
+func clientAddr(req rweb.ItfRequest) string {
+	first, _, _ := strings.Cut(forms.Header(req, "X-Forwarded-For"), ",")
+	return strings.TrimSpace(first)
+}
+
+// dash stands in for missing CLF fields; spaces would break the space-separated layout
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. AFTER-THE-FACT MIDDLEWARE - ctx.Next() first, then look at the response
+// 2. LOG FORMATS - the classic Common Log Format next to JSON lines
+// 3. REPLACEABLE CLOCK - a package-level now func makes timestamps testable
+// 4. MUTEXES - serializing writes from concurrent requests
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// fixClock stops the clock at t for the rest of the test
+func fixClock(t *testing.T, at time.Time) {
+	t.Helper()
+	saved := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = saved })
+}
+
+func newAccessServer(buf *bytes.Buffer, format AccessFormat) *rweb.Server {
+	s := rweb.NewServer()
+	s.Use(Middleware(New(Options{Output: &bytes.Buffer{}})))
+	s.Use(AccessLog(buf, format))
+	s.Get("/cats", func(ctx rweb.Context) error {
+		return ctx.WriteString("hello")
+	})
+	s.Get("/missing", func(ctx rweb.Context) error {
+		ctx.Response().SetStatus(http.StatusNotFound)
+		return nil
+	})
+	return s
+}
+
+func TestAccessLogCommon(t *testing.T) {
+	fixClock(t, time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC))
+	var buf bytes.Buffer
+	s := newAccessServer(&buf, AccessCommon)
+
+	s.Request(http.MethodGet, "/cats?q=luna", []rweb.Header{{Key: "X-Forwarded-For", Value: "203.0.113.9, 10.0.0.1"}}, nil)
+	s.Request(http.MethodGet, "/missing", nil, nil)
+
+	want := `203.0.113.9 - - [14/Mar/2025:09:30:00 +0000] "GET /cats?q=luna HTTP/1.1" 200 5` + "\n" +
+		`- - - [14/Mar/2025:09:30:00 +0000] "GET /missing HTTP/1.1" 404 -` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("access log:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	fixClock(t, time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC))
+	var buf bytes.Buffer
+	s := newAccessServer(&buf, AccessJSON)
+
+	resp := s.Request(http.MethodGet, "/cats?q=luna", []rweb.Header{{Key: "User-Agent", Value: "curl/8"}}, nil)
+
+	var e accessEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("not one JSON object: %v\n%s", err, buf.String())
+	}
+	if e.Method != "GET" || e.Path != "/cats" || e.Query != "q=luna" || e.Status != 200 || e.Bytes != 5 {
+		t.Errorf("entry %+v", e)
+	}
+	if e.UserAgent != "curl/8" {
+		t.Errorf("user agent %q", e.UserAgent)
+	}
+	if e.RequestID == "" || e.RequestID != resp.Header(HeaderRequestID) {
+		t.Errorf("request ID %q, response header %q", e.RequestID, resp.Header(HeaderRequestID))
+	}
+	if !strings.HasSuffix(buf.String(), "}\n") {
+		t.Error("each entry should end with a newline")
+	}
+}
+
+func TestParseAccessFormat(t *testing.T) {
+	for in, want := range map[string]AccessFormat{"": AccessCommon, "clf": AccessCommon, "JSON": AccessJSON} {
+		if got, err := ParseAccessFormat(in); err != nil || got != want {
+			t.Errorf("ParseAccessFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseAccessFormat("xml"); err == nil {
+		t.Error("xml should be rejected")
+	}
+}
@@ -0,0 +1,133 @@
+// Package logging gives the server structured, leveled logs built on log/slog.
+//
+// Three pieces work together:
+//   - New builds the application logger (text for people, JSON for log collectors)
+//   - Middleware gives every request an ID and a logger carrying it, so all lines
+//     written while handling one request can be found together
+//   - AccessLog (access.go) writes one line per request, optionally to a
+//     RotatingFile (rotate.go)
+//
+// Handlers log through FromContext:
+//
+//	logging.FromContext(ctx).Info("photo added", "cat", cat.Slug)
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/forms"
+)
+
+// HeaderRequestID carries the request ID; a proxy in front of us may already have set one
+const HeaderRequestID = "X-Request-ID"
+
+// Keys for request-scoped values (ctx.Set / ctx.Get)
+const (
+	requestIDKey = "logging.request_id"
+	loggerKey    = "logging.logger"
+)
+
+// Options configure New
+type Options struct {
+	Level  slog.Level // messages below this level are dropped
+	JSON   bool       // one JSON object per line instead of key=value text
+	Output io.Writer
+}
+
+// New builds a logger from opts
+func New(opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if opts.JSON {
+		return slog.New(slog.NewJSONHandler(opts.Output, handlerOpts))
+	}
+	return slog.New(slog.NewTextHandler(opts.Output, handlerOpts))
+}
+
+// ParseLevel reads "debug", "info", "warn" or "error" (any case); "" means info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	// slog.Level implements encoding.TextUnmarshaler - reuse its parser
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
+}
+
+// Middleware assigns each request an ID and a logger that includes it
+//
+// The ID is sent back in the X-Request-ID response header, so a visitor reporting
+// a problem can quote it. An ID set by a proxy in front of us is kept, as long as
+// it looks like an ID (and not like an attempt to inject text into our logs).
+//
+// Errors returned by the handlers are logged here, with the request ID attached.
+// Register it first, so everything after it can use FromContext
+func Middleware(base *slog.Logger) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		// strings.Clone: header values point into rweb's reusable request buffer
+		id := strings.Clone(forms.Header(ctx.Request(), HeaderRequestID))
+		if !validID(id) {
+			id = newID()
+		}
+
+		// slog.Logger.With returns a NEW logger; base is never modified
+		logger := base.With("request_id", id)
+		ctx.Set(requestIDKey, id)
+		ctx.Set(loggerKey, logger)
+		ctx.Response().SetHeader(HeaderRequestID, id)
+
+		err := ctx.Next()
+		if err != nil {
+			logger.Error("request failed",
+				"method", ctx.Request().Method(), "path", ctx.Request().Path(), "err", err)
+		}
+		return err // still returned: rweb's error handler writes the 500 page
+	}
+}
+
+// RequestID returns the current request's ID ("" outside Middleware)
+func RequestID(ctx rweb.Context) string {
+	id, _ := ctx.Get(requestIDKey).(string)
+	return id
+}
+
+// FromContext returns the request's logger, or slog.Default() outside Middleware
+func FromContext(ctx rweb.Context) *slog.Logger {
+	if l, ok := ctx.Get(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// newID returns 16 random hex characters - unique enough to find one request in the logs
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // crypto/rand.Read never fails on supported platforms
+	return hex.EncodeToString(b)
+}
+
+// validID accepts short IDs made of letters, digits and - _ .
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. STRUCTURED LOGGING - key/value pairs instead of formatted strings
+// 2. DERIVED LOGGERS - logger.With adds fields to every later line
+// 3. REQUEST-SCOPED VALUES - the logger travels with the request through ctx
+// 4. TEXTUNMARSHALER - slog.Level already knows how to parse "warn"
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// newTestServer registers Middleware with a logger writing into buf, plus two routes:
+// /log logs a line through FromContext and /fail returns an error
+func newTestServer(buf *bytes.Buffer) *rweb.Server {
+	s := rweb.NewServer()
+	s.Use(Middleware(New(Options{Output: buf, Level: slog.LevelDebug})))
+	s.Get("/log", func(ctx rweb.Context) error {
+		FromContext(ctx).Info("hello", "id_seen", RequestID(ctx))
+		return ctx.WriteString("ok")
+	})
+	s.Get("/fail", func(ctx rweb.Context) error {
+		return errors.New("disk full")
+	})
+	return s
+}
+
+func TestMiddlewareGeneratesID(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTestServer(&buf)
+
+	resp := s.Request(http.MethodGet, "/log", nil, nil)
+	id := resp.Header(HeaderRequestID)
+	if !validID(id) || len(id) != 16 {
+		t.Fatalf("request ID %q, want 16 hex characters", id)
+	}
+	// The handler's line carries the ID twice: once from the derived logger, once as RequestID(ctx)
+	if got := buf.String(); !strings.Contains(got, "request_id="+id) || !strings.Contains(got, "id_seen="+id) {
+		t.Errorf("log line %q does not carry request ID %s", got, id)
+	}
+
+	// A second request gets a different ID
+	if next := s.Request(http.MethodGet, "/log", nil, nil).Header(HeaderRequestID); next == id {
+		t.Error("two requests shared the same ID")
+	}
+}
+
+func TestMiddlewareKeepsIncomingID(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTestServer(&buf)
+
+	resp := s.Request(http.MethodGet, "/log", []rweb.Header{{Key: HeaderRequestID, Value: "edge-42"}}, nil)
+	if id := resp.Header(HeaderRequestID); id != "edge-42" {
+		t.Errorf("request ID %q, want the proxy's edge-42", id)
+	}
+}
+
+// An incoming ID that could forge log lines is replaced
+func TestMiddlewareRejectsBadID(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTestServer(&buf)
+
+	bad := `x" level=ERROR msg="forged`
+	resp := s.Request(http.MethodGet, "/log", []rweb.Header{{Key: HeaderRequestID, Value: bad}}, nil)
+	if id := resp.Header(HeaderRequestID); id == bad || !validID(id) {
+		t.Errorf("request ID %q should have been replaced", id)
+	}
+}
+
+func TestMiddlewareLogsErrors(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTestServer(&buf)
+
+	s.Request(http.MethodGet, "/fail", nil, nil)
+	got := buf.String()
+	for _, want := range []string{"level=ERROR", "path=/fail", `err="disk full"`, "request_id="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q is missing %s", got, want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+		ok   bool
+	}{
+		{"", slog.LevelInfo, true},
+		{"debug", slog.LevelDebug, true},
+		{"WARN", slog.LevelWarn, true},
+		{"error", slog.LevelError, true},
+		{"loud", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err == nil) != tt.ok || (tt.ok && got != tt.want) {
+			t.Errorf("ParseLevel(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	New(Options{JSON: true, Output: &buf}).Info("hi", "cat", "luna")
+	if got := buf.String(); !strings.Contains(got, `"msg":"hi"`) || !strings.Contains(got, `"cat":"luna"`) {
+		t.Errorf("JSON log line %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.Writer for log files that never grow without bound
+//
+// When the current file would pass MaxBytes, or has been open longer than MaxAge,
+// it is renamed with a timestamp (access.log -> access.log.20250314-093000) and a
+// fresh file is started. Only the newest MaxBackups old files are kept.
+type RotatingFile struct {
+	Path       string
+	MaxBytes   int64         // 0 = no size limit
+	MaxAge     time.Duration // 0 = no age limit
+	MaxBackups int           // 0 = keep every old file
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotatingFile opens (or creates) path for appending
+func OpenRotatingFile(path string, maxBytes int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{Path: path, MaxBytes: maxBytes, MaxAge: maxAge, MaxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p, rotating first if p would not fit
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	tooBig := r.MaxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxBytes
+	tooOld := r.MaxAge > 0 && now().Sub(r.opened) >= r.MaxAge
+	if tooBig || tooOld {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// open starts writing to r.Path, picking up its current size
+// An existing file counts as opened at its last modification, so a file left
+// over from long ago is rotated on the first write instead of growing forever
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size, r.opened = f, info.Size(), now()
+	if info.Size() > 0 {
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+// rotate renames the current file and opens a new one (r.mu must be held)
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+
+	backup := r.Path + "." + now().Format("20060102-150405.000")
+	if err := os.Rename(r.Path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotating %s: %w", r.Path, err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.opened = now() // a fresh file, whatever open() read from disk
+	return r.prune()
+}
+
+// prune removes the oldest backups beyond MaxBackups
+// The timestamp suffix sorts in time order, so a plain string sort is oldest-first
+func (r *RotatingFile) prune() error {
+	if r.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(r.Path + ".*")
+	if err != nil {
+		return err
+	}
+	sort.Strings(backups)
+	for len(backups) > r.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. IO.WRITER - anything that can Write can be a log destination
+// 2. LOG ROTATION - rename-and-reopen keeps each file small
+// 3. LEXICAL TIMESTAMPS - YYYYMMDD-hhmmss names sort in time order
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// backups lists the rotated copies of path
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	names, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestRotateBySize(t *testing.T) {
+	clock := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	now = func() time.Time { clock = clock.Add(time.Second); return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := OpenRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Each write is 6 bytes, so every second write starts a new file
+	for _, line := range []string{"one-1\n", "two-2\n", "thr-3\n", "fou-4\n", "fiv-5\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "fiv-5\n" {
+		t.Errorf("current file %q, want only the last line", got)
+	}
+	// Four rotations happened but only MaxBackups (2) copies are kept
+	if b := backups(t, path); len(b) != 2 {
+		t.Errorf("backups %v, want 2", b)
+	}
+}
+
+func TestRotateByAge(t *testing.T) {
+	clock := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := OpenRotatingFile(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("morning\n"))
+	clock = clock.Add(30 * time.Minute)
+	f.Write([]byte("still morning\n"))
+	if b := backups(t, path); len(b) != 0 {
+		t.Fatalf("rotated too early: %v", b)
+	}
+
+	clock = clock.Add(time.Hour)
+	f.Write([]byte("afternoon\n"))
+	b := backups(t, path)
+	if len(b) != 1 {
+		t.Fatalf("backups %v, want 1", b)
+	}
+	if old, _ := os.ReadFile(b[0]); string(old) != "morning\nstill morning\n" {
+		t.Errorf("backup holds %q", old)
+	}
+	if cur, _ := os.ReadFile(path); string(cur) != "afternoon\n" {
+		t.Errorf("current file holds %q", cur)
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "a.log"), 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Error("write after Close should fail")
+	}
+}
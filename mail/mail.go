@@ -0,0 +1,119 @@
+// Package mail sends email on the site's behalf - for now, contact form messages
+// to the shelter staff.
+// Handlers depend only on the Sender interface, so development can print messages
+// to the console, tests can collect them in an Outbox, and production can use SMTP.
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+// Message is one plain-text email
+type Message struct {
+	To      string
+	ReplyTo string // where answers go, e.g. the visitor who filled in the contact form
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(m Message) error
+}
+
+// COMPILE-TIME CHECKS that every sender below satisfies the interface
+var (
+	_ Sender = LogSender{}
+	_ Sender = SMTPSender{}
+	_ Sender = (*Outbox)(nil)
+)
+
+// ErrNoRecipient is returned for a message without a To address
+var ErrNoRecipient = errors.New("mail: message has no recipient")
+
+// LogSender writes messages to W instead of sending them - the default when
+// no mail server is configured, so nothing is silently lost during development
+type LogSender struct {
+	W io.Writer
+}
+
+func (s LogSender) Send(m Message) error {
+	if m.To == "" {
+		return ErrNoRecipient
+	}
+	_, err := fmt.Fprintf(s.W, "---- mail to %s ----\n%s\n", m.To, m.format())
+	return err
+}
+
+// SMTPSender delivers through an SMTP server such as "smtp.example.com:587"
+// Auth may be nil for servers that accept mail without signing in
+type SMTPSender struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func (s SMTPSender) Send(m Message) error {
+	if m.To == "" {
+		return ErrNoRecipient
+	}
+	msg := "From: " + s.From + "\r\n" + m.format()
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{m.To}, []byte(msg))
+}
+
+// format renders headers and body in the layout RFC 5322 expects
+// Header values have line breaks removed: a visitor typing "\r\nBcc: ..." into a
+// form field must not be able to add headers of their own (HEADER INJECTION)
+func (m Message) format() string {
+	var sb strings.Builder
+	header := func(name, value string) {
+		if value != "" {
+			sb.WriteString(name + ": " + oneLine(value) + "\r\n")
+		}
+	}
+	header("To", m.To)
+	header("Reply-To", m.ReplyTo)
+	header("Subject", m.Subject)
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	sb.WriteString(m.Body)
+	return sb.String()
+}
+
+// oneLine replaces CR and LF with spaces
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// Outbox keeps sent messages in memory - handy in tests
+type Outbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (o *Outbox) Send(m Message) error {
+	if m.To == "" {
+		return ErrNoRecipient
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, m)
+	return nil
+}
+
+// Sent returns a copy of every message sent so far
+func (o *Outbox) Sent() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. INTERFACES FOR SIDE EFFECTS - Swap real delivery for a fake without touching handlers
+// 2. COMPILE-TIME INTERFACE CHECKS - var _ Sender = ... fails the build if a method is missing
+// 3. HEADER INJECTION - Never let user input start a new header line
+// 4. MUTEX-GUARDED SLICES - Outbox is safe to use from concurrent requests
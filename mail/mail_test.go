@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	got := Message{To: "staff@example.com", ReplyTo: "ann@example.com", Subject: "Hi", Body: "Hello\nthere"}.format()
+	want := "To: staff@example.com\r\nReply-To: ann@example.com\r\nSubject: Hi\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n\r\nHello\nthere"
+	if got != want {
+		t.Errorf("format =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// A visitor can't smuggle extra headers in through a form field
+func TestFormatHeaderInjection(t *testing.T) {
+	got := Message{To: "staff@example.com", Subject: "Hi\r\nBcc: everyone@example.com"}.format()
+	if strings.Contains(got, "\r\nBcc:") {
+		t.Errorf("header injected:\n%q", got)
+	}
+}
+
+func TestLogSender(t *testing.T) {
+	var sb strings.Builder
+	if err := (LogSender{W: &sb}).Send(Message{To: "staff@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if out := sb.String(); !strings.Contains(out, "mail to staff@example.com") || !strings.HasSuffix(out, "Hello\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	var o Outbox
+	if err := o.Send(Message{To: "a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	sent := o.Sent()
+	if len(sent) != 1 || sent[0].To != "a@example.com" {
+		t.Fatalf("Sent() = %+v", sent)
+	}
+	sent[0].To = "changed" // Sent returns a copy
+	if o.Sent()[0].To != "a@example.com" {
+		t.Error("Sent should not expose the outbox's own slice")
+	}
+}
+
+func TestNoRecipient(t *testing.T) {
+	for _, s := range []Sender{LogSender{W: &strings.Builder{}}, SMTPSender{}, &Outbox{}} {
+		if err := s.Send(Message{Subject: "x"}); !errors.Is(err, ErrNoRecipient) {
+			t.Errorf("%T: err = %v, want ErrNoRecipient", s, err)
+		}
+	}
+}
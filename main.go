@@ -9,19 +9,34 @@ package main
 // Go organizes imports into groups (standard library, then third-party packages).
 import (
 	// Standard library imports (built into Go)
-	"fmt"    // Package for formatted I/O (printing, string formatting)
-	"io"     // Package for I/O primitives (reading, writing)
-	"log"    // Package for simple logging
-	"net/http" // Package for HTTP client and server implementations
-	"os"     // Package for operating system functionality (file operations)
-	"strings" // Package for string manipulation
+	"fmt"           // Package for formatted I/O (printing, string formatting)
+	"io"            // Package for the Reader/Writer interfaces
+	"log/slog"      // Package for structured, leveled logging
+	"net/http"      // Package for HTTP client and server implementations
+	"os"            // Package for operating system functionality (file operations)
+	"path/filepath" // Package for building file paths portably
+	"strconv"       // Package for converting strings to numbers
+	"strings"       // Package for string manipulation
+	"time"          // Package for durations and clocks
 
 	// Local package imports (from this module)
-	"form_exer/web/pages" // Our page components (HomePage, Contact, etc.)
+	"form_exer/adoption" // Adoption applications and their review workflow
+	"form_exer/app"      // Every route and handler of the site
+	"form_exer/audit"    // Append-only record of staff changes
+	"form_exer/auth"     // Basic auth for the admin pages
+	"form_exer/cats"     // Cat domain model and repository
+	"form_exer/cors"     // Cross-origin access to the API endpoints
+	"form_exer/events"   // Live notifications for the staff pages
+	"form_exer/health"   // Liveness and readiness probes
+	"form_exer/logging"  // Structured logs, request IDs and the access log
+	"form_exer/mail"     // Sending contact messages to staff
+	"form_exer/proxy"    // Forwarding path prefixes to other servers
+	"form_exer/security" // Security headers and the Content-Security-Policy
+	"form_exer/storage"  // Upload subsystem
+	"form_exer/tracing"  // Request tracing
 
 	// Third-party package imports (external dependencies defined in go.mod)
-	"github.com/rohanthewiz/element" // HTML element builder library
-	"github.com/rohanthewiz/rweb"    // Lightweight web framework
+	"github.com/rohanthewiz/rweb" // Lightweight web framework
 )
 
 // main() is the entry point of the program. Go automatically calls this function when the program starts.
@@ -31,35 +46,476 @@ func main() {
 	// Deferred functions execute in LIFO (Last In, First Out) order.
 	// Note: defer does not fire on CTRL-C because CTRL-C uses os.Exit() for immediate shutdown
 	defer func() {
-		fmt.Println("Exiting main()...")
+		slog.Info("exiting main()")
 	}() // The () at the end immediately invokes this anonymous function (but defer delays its execution)
 
+	// LOGGING comes first so that everything after it can report problems
+	// LOG_LEVEL=debug|info|warn|error, LOG_FORMAT=text|json
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fatal("bad LOG_LEVEL", err)
+	}
+	logger := logging.New(logging.Options{Level: level, JSON: os.Getenv("LOG_FORMAT") == "json", Output: os.Stderr})
+	// slog.SetDefault also sends the standard "log" package's output through our handler
+	slog.SetDefault(logger)
+
+	// ACCESS LOG: stdout by default, or a rotating file when ACCESS_LOG names one
+	accessLog, accessFormat, err := openAccessLog()
+	if err != nil {
+		fatal("cannot open access log", err)
+	}
+
+	// STAFF ACCOUNTS come from the environment, e.g. ADMIN_USERS="alice:s3cret,bob:hunter2"
+	// With no accounts configured every admin request is refused - a safe default
+	adminUsers := auth.ParseUsers(os.Getenv("ADMIN_USERS"))
+	if len(adminUsers) == 0 {
+		slog.Warn("ADMIN_USERS is not set - admin pages are disabled")
+	}
+
+	// MAIL: contact messages go to CONTACT_EMAIL through the SMTP server in SMTP_ADDR
+	// (e.g. "smtp.example.com:25"); without SMTP_ADDR they are printed instead
+	var mailer mail.Sender
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		mailer = mail.SMTPSender{Addr: addr, From: envOr("MAIL_FROM", "website@localhost")}
+	}
+
+	// TRACING: off unless TRACE_EXPORTER says where spans go
+	tracer, flushTraces, err := newTracer()
+	if err != nil {
+		fatal("bad tracing settings", err)
+	}
+	defer flushTraces() // send the last spans before exiting
+
+	// CORS: which other origins may call the API endpoints
+	corsPolicy, err := newCORSPolicy()
+	if err != nil {
+		fatal("bad CORS settings", err)
+	}
+
+	// READINESS: main keeps hold of the health checks so it can fail /readyz
+	// before shutting down
+	probes := health.New()
+
+	// PRE-STOP: rweb closes the listener the moment SIGTERM arrives, so a /readyz
+	// failing after that is never seen - the load balancer just finds the port
+	// closed. Readiness has to fail first, while we still answer. The
+	// orchestrator sends SIGUSR1, waits while the load balancer's probes notice
+	// and move traffic away, and only then sends SIGTERM. In Kubernetes that is
+	// a preStop hook longer than the readiness probe's failure window:
+	//
+	//	lifecycle: {preStop: {exec: {command: ["sh", "-c", "kill -USR1 1; sleep 15"]}}}
+	preStop := make(chan os.Signal, 1)
+	notifyPreStop(preStop)
+	go func() {
+		for sig := range preStop {
+			probes.StartShutdown()
+			slog.Info("readiness now fails, waiting for SIGTERM", "signal", sig.String())
+		}
+	}()
+
+	// LIVE NOTIFICATIONS: main owns the broker so it can end the open streams on shutdown
+	broker := events.New(events.Options{})
+
+	// REVERSE PROXY: path prefixes served by pools of other servers
+	proxies, err := newProxies()
+	if err != nil {
+		fatal("bad proxy settings", err)
+	}
+
+	// SHUTDOWN DRAIN: how long to keep finishing in-flight requests after SIGTERM
+	drain, err := time.ParseDuration(envOr("SHUTDOWN_DRAIN", "5s"))
+	if err != nil {
+		fatal("bad SHUTDOWN_DRAIN", err)
+	}
+
+	// CONFIGURATION lives in main(); everything else is built by newServer,
+	// which the end-to-end tests (e2e_test.go) call with their own temp directories
+	s, err := newServer(serverConfig{
+		// Format: ":port" listens on all network interfaces (0.0.0.0:8000)
+		// This is preferred over "localhost:8000" for Docker compatibility
+		Address:      ":8000",
+		DataDir:      "data",
+		UploadDir:    "uploads",
+		AdminUsers:   adminUsers,
+		ContactTo:    envOr("CONTACT_EMAIL", "staff@localhost"),
+		Mailer:       mailer,
+		Logger:       logger,
+		AccessLog:    accessLog,
+		AccessFormat: accessFormat,
+		Health:       probes,
+		Tracer:       tracer,
+		// HTTPS: with TLS_CERT and TLS_KEY set, Address speaks HTTPS instead of HTTP
+		TLSCertFile: os.Getenv("TLS_CERT"),
+		TLSKeyFile:  os.Getenv("TLS_KEY"),
+		// CRASH_DIR: where panic reports are written (default data/crashes)
+		CrashDir: os.Getenv("CRASH_DIR"),
+		// CSP_REPORT_ONLY=1: browsers report what the policy would block, but block nothing
+		CSPReportOnly: os.Getenv("CSP_REPORT_ONLY") == "1",
+		CORS:          corsPolicy,
+		Events:        broker,
+		Proxies:       proxies,
+	})
+	if err != nil {
+		// fatal logs the error and exits - the site is useless without its data
+		fatal("cannot start", err)
+	}
+
+	// SERVER STARTUP
+	// s.Run() starts the HTTP server and blocks until shutdown
+	// It returns an error if the server fails to start or crashes
+	// This is the last line of main() - the program waits here while serving requests
+	slog.Info("starting server", "address", ":8000")
+	if err := s.Run(); err != nil {
+		slog.Error("server stopped", "err", err)
+		return
+	}
+
+	// GRACEFUL SHUTDOWN: Run returns on CTRL-C or SIGTERM, after rweb has closed
+	// the listener (no new connections). The load balancer should have moved
+	// traffic away during the PRE-STOP wait (above); requests already being
+	// handled, and any still arriving on open keep-alive connections, get
+	// answers until the drain ends. Readiness fails here too, for a stop that
+	// came without a pre-stop - though then nothing can see it any more
+	probes.StartShutdown()
+	// Event streams never finish by themselves; closing them lets browsers
+	// reconnect (with Last-Event-ID) to another instance
+	broker.Close()
+	// Health checks stop; requests still being forwarded finish during the drain
+	for _, p := range proxies {
+		p.Close()
+	}
+	slog.Info("shutting down", "drain", drain)
+	time.Sleep(drain)
+}
+
+// openAccessLog reads the ACCESS_LOG settings from the environment:
+//
+//	ACCESS_LOG             file to write to (unset = stdout, "off" = no access log)
+//	ACCESS_LOG_FORMAT      common (the default) or json
+//	ACCESS_LOG_MAX_MB      rotate once the file reaches this size (default 100)
+//	ACCESS_LOG_MAX_AGE     rotate once the file is this old, e.g. 24h (default never)
+//	ACCESS_LOG_MAX_BACKUPS how many rotated files to keep (default 7)
+func openAccessLog() (io.Writer, logging.AccessFormat, error) {
+	format, err := logging.ParseAccessFormat(os.Getenv("ACCESS_LOG_FORMAT"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	path := os.Getenv("ACCESS_LOG")
+	switch path {
+	case "":
+		return os.Stdout, format, nil
+	case "off":
+		return nil, format, nil
+	}
+
+	maxMB, err := strconv.Atoi(envOr("ACCESS_LOG_MAX_MB", "100"))
+	if err != nil {
+		return nil, "", fmt.Errorf("ACCESS_LOG_MAX_MB: %w", err)
+	}
+	var maxAge time.Duration
+	if v := os.Getenv("ACCESS_LOG_MAX_AGE"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil {
+			return nil, "", fmt.Errorf("ACCESS_LOG_MAX_AGE: %w", err)
+		}
+	}
+	backups, err := strconv.Atoi(envOr("ACCESS_LOG_MAX_BACKUPS", "7"))
+	if err != nil {
+		return nil, "", fmt.Errorf("ACCESS_LOG_MAX_BACKUPS: %w", err)
+	}
+
+	// The file stays open for the life of the process, so it is never closed
+	f, err := logging.OpenRotatingFile(path, int64(maxMB)<<20, maxAge, backups)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, format, nil
+}
+
+// newTracer reads the tracing settings from the environment:
+//
+//	TRACE_EXPORTER                      "" (tracing off), "stdout" or "otlp"
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  collector URL (default http://localhost:4318/v1/traces)
+//	OTEL_SERVICE_NAME                   name shown in the trace viewer (default cat-adoption)
+//
+// flush sends any spans still waiting; call it before exiting
+func newTracer() (tracer *tracing.Tracer, flush func(), err error) {
+	switch exporter := os.Getenv("TRACE_EXPORTER"); exporter {
+	case "":
+		return nil, func() {}, nil
+	case "stdout":
+		return tracing.NewTracer(&tracing.StdoutExporter{W: os.Stdout}), func() {}, nil
+	case "otlp":
+		// BATCHING: spans are sent in groups from a background goroutine
+		batcher := tracing.NewBatcher(&tracing.OTLPExporter{
+			Endpoint:    envOr("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318/v1/traces"),
+			ServiceName: envOr("OTEL_SERVICE_NAME", "cat-adoption"),
+		}, 512, 5*time.Second)
+		return tracing.NewTracer(batcher), batcher.Shutdown, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown TRACE_EXPORTER %q (want stdout or otlp)", exporter)
+	}
+}
+
+// newCORSPolicy reads the CORS settings for the API endpoints from the environment:
+//
+//	CORS_ORIGINS      comma-separated origins, e.g. "https://app.example.com,https://*.example.com"
+//	                  ("" keeps the API same-origin only)
+//	CORS_CREDENTIALS  "1" lets requests carry cookies and HTTP auth
+//	CORS_MAX_AGE      how long browsers may cache a preflight answer (default 10m)
+func newCORSPolicy() (*cors.Policy, error) {
+	origins := os.Getenv("CORS_ORIGINS")
+	if origins == "" {
+		return nil, nil
+	}
+	maxAge, err := time.ParseDuration(envOr("CORS_MAX_AGE", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("CORS_MAX_AGE: %w", err)
+	}
+	p := &cors.Policy{
+		AllowedOrigins:   strings.Split(origins, ","),
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Content-Type", "Accept", logging.HeaderRequestID},
+		ExposedHeaders:   []string{logging.HeaderRequestID},
+		AllowCredentials: os.Getenv("CORS_CREDENTIALS") == "1",
+		MaxAge:           maxAge,
+	}
+	for i, o := range p.AllowedOrigins {
+		p.AllowedOrigins[i] = strings.TrimSpace(o)
+	}
+	return p, p.Validate()
+}
+
+// newProxies reads the reverse proxy settings from the environment:
+//
+//	PROXY_ROUTES           "prefix=upstream,upstream;prefix=upstream", e.g.
+//	                       "/api/legacy=http://10.0.0.5:8080,http://10.0.0.6:8080"
+//	                       ("" proxies nothing)
+//	PROXY_BALANCE          round-robin (the default) or least-connections
+//	PROXY_HEALTH_PATH      checked on every upstream, e.g. /healthz ("" = no checks)
+//	PROXY_HEALTH_INTERVAL  time between checks (default 10s)
+//	PROXY_TIMEOUT          limit for each attempt (default 30s)
+//	PROXY_ATTEMPTS         upstreams an idempotent request is tried on (default 2)
+//	PROXY_TRUST_FORWARDED  1 = pass on the X-Forwarded-* and Forwarded headers
+//	                       requests arrive with; only behind a proxy that sets them
+//
+// The settings apply to every route; the proxies' health checks start at once
+func newProxies() ([]*proxy.Proxy, error) {
+	routes, err := proxy.ParseRoutes(os.Getenv("PROXY_ROUTES"))
+	if err != nil || len(routes) == 0 {
+		return nil, err
+	}
+	interval, err := time.ParseDuration(envOr("PROXY_HEALTH_INTERVAL", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("PROXY_HEALTH_INTERVAL: %w", err)
+	}
+	timeout, err := time.ParseDuration(envOr("PROXY_TIMEOUT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("PROXY_TIMEOUT: %w", err)
+	}
+	attempts, err := strconv.Atoi(envOr("PROXY_ATTEMPTS", "2"))
+	if err != nil {
+		return nil, fmt.Errorf("PROXY_ATTEMPTS: %w", err)
+	}
+	proto := "http"
+	if os.Getenv("TLS_CERT") != "" {
+		proto = "https"
+	}
+
+	var proxies []*proxy.Proxy
+	for _, r := range routes {
+		r.Balance = proxy.Balance(os.Getenv("PROXY_BALANCE"))
+		r.HealthPath = os.Getenv("PROXY_HEALTH_PATH")
+		r.HealthInterval, r.Timeout, r.Attempts, r.Proto = interval, timeout, attempts, proto
+		r.TrustForwarded = os.Getenv("PROXY_TRUST_FORWARDED") == "1"
+		p, err := proxy.New(r)
+		if err != nil {
+			for _, started := range proxies {
+				started.Close()
+			}
+			return nil, err
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, nil
+}
+
+// fatal logs err and exits
+// slog has no Fatal level, so this plays the part of log.Fatal
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// serverConfig is everything about the server that differs between running
+// for real and running under test
+type serverConfig struct {
+	Address    string        // listen address; "localhost:0" picks a free port
+	DataDir    string        // cats.json, applications.json and audit.log live here
+	UploadDir  string        // uploaded files and cat photos
+	AdminUsers auth.Users    // staff accounts for the admin pages
+	ContactTo  string        // where contact form messages are mailed
+	Mailer     mail.Sender   // nil prints messages to the console
+	Verbose    bool          // rweb's own debug output for every request
+	ReadyChan  chan struct{} // signalled once the server is listening (buffered, cap 1)
+
+	Logger       *slog.Logger         // application logs; nil means slog.Default()
+	AccessLog    io.Writer            // one line per request; nil turns it off
+	AccessFormat logging.AccessFormat // common or json
+
+	Health      *health.Health  // readiness checks are added to it; nil makes a new one
+	Tracer      *tracing.Tracer // nil turns tracing off
+	TLSCertFile string          // PEM certificate; with TLSKeyFile, serve HTTPS on Address
+	TLSKeyFile  string
+	CrashDir    string // panic reports; "" means DataDir/crashes
+
+	// CSPReportOnly only reports Content-Security-Policy violations instead of
+	// blocking them - for trying out a policy change
+	CSPReportOnly bool
+
+	// CORS opens the API endpoints to other origins; nil keeps them same-origin only
+	CORS *cors.Policy
+
+	// Events carries live notifications to the staff pages; nil makes a private broker
+	Events *events.Broker
+
+	// Proxies forward path prefixes to other servers; the caller closes them
+	Proxies []*proxy.Proxy
+}
+
+// newServer builds a server with every middleware and route registered, ready to Run
+// Returning the server instead of running it lets tests start it however they like
+func newServer(cfg serverConfig) (*rweb.Server, error) {
 	// STRUCT LITERAL with NAMED FIELDS: Creating a new rweb server instance
 	// rweb.ServerOptions is a struct type, and we're creating an instance using a struct literal.
 	// Named fields (Address: value) make the code self-documenting and allow fields in any order.
 	s := rweb.NewServer(rweb.ServerOptions{
 		// Address specifies the TCP address for the server to listen on
-		// Format: ":port" listens on all network interfaces (0.0.0.0:8000)
-		// This is preferred over "localhost:8000" for Docker compatibility
-		Address: ":8000",
+		Address: cfg.Address,
 
 		// Verbose is a boolean field that enables detailed request/response logging
-		// Go's zero value for bool is false, so we explicitly set it to true
-		Verbose: true,
+		Verbose: cfg.Verbose,
 
 		// Debug is another boolean field for additional debugging information
 		// Explicitly setting to false for clarity (same as omitting it)
 		Debug: false,
+
+		// ReadyChan lets a caller wait until the listener is open
+		ReadyChan: cfg.ReadyChan,
+
+		// TLS: rweb listens on TLSAddr with the certificate instead of plain HTTP on Address
+		TLS: rweb.TLSCfg{
+			UseTLS:   cfg.TLSCertFile != "",
+			TLSAddr:  cfg.Address,
+			CertFile: cfg.TLSCertFile,
+			KeyFile:  cfg.TLSKeyFile,
+		},
 	})
 
 	// SHORT VARIABLE DECLARATION: The := operator declares and initializes a variable
 	// Go infers the type from the right-hand side (here: *rweb.Server)
 	// This is equivalent to: var s *rweb.Server = rweb.NewServer(...)
 
-	// METHOD CALL: Calling the Use() method on the server instance
-	// Use() registers middleware that runs before route handlers
-	// rweb.RequestInfo is a pre-built middleware function provided by the rweb package
-	s.Use(rweb.RequestInfo)
+	// DEPENDENCY SETUP: open every store before any routes need it
+	// catRepo has the INTERFACE type cats.Repository, so handlers don't
+	// care that the data happens to live in a JSON file
+	var catRepo cats.Repository
+	catRepo, err := cats.NewFileRepository(filepath.Join(cfg.DataDir, "cats.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Adoption applications live in their own file, next to the catalog
+	appStore, err := adoption.NewFileStore(filepath.Join(cfg.DataDir, "applications.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	// UPLOAD SUBSYSTEM: files are stored under random names in the upload dir
+	// and served back from /uploads/<name>
+	uploads, err := storage.New(cfg.UploadDir, "/uploads/", storage.Options{MaxBytes: 32 << 20})
+	if err != nil {
+		return nil, err
+	}
+
+	// AUDIT TRAIL of every staff change
+	auditLog, err := audit.NewFileLog(filepath.Join(cfg.DataDir, "audit.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a mail server, messages are printed to the console instead
+	mailer := cfg.Mailer
+	if mailer == nil {
+		mailer = mail.LogSender{W: os.Stdout}
+	}
+
+	// READINESS CHECKS: everything the site can't work without
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	probes := cfg.Health
+	if probes == nil {
+		probes = health.New()
+	}
+	if probes.Logger == nil {
+		probes.Logger = logger // why a check failed is logged, not shown on /readyz
+	}
+	probes.Add(
+		// Uploads and cat photos are written here
+		health.DirWritable("storage", cfg.UploadDir),
+		// The "database" is the JSON files in DataDir, rewritten on every save
+		health.DirWritable("database", cfg.DataDir),
+	)
+	// TYPE ASSERTION on an interface: only a real SMTP server has an address to check
+	if smtp, ok := mailer.(mail.SMTPSender); ok {
+		probes.Add(health.SMTP("smtp", smtp.Addr))
+	}
+	if cfg.TLSCertFile != "" {
+		// Only an expired certificate fails readiness; one expiring within a week
+		// is logged, while there's still time to renew
+		probes.Add(health.CertFile("tls_certificate", cfg.TLSCertFile, 7*24*time.Hour, func(notAfter time.Time) {
+			logger.Warn("TLS certificate expires soon", "path", cfg.TLSCertFile,
+				"expires", notAfter.Format(time.RFC3339), "seconds_left", int(time.Until(notAfter).Seconds()))
+		}))
+	}
+
+	// CRASH REPORTS go next to the data unless configured elsewhere
+	crashDir := cfg.CrashDir
+	if crashDir == "" {
+		crashDir = filepath.Join(cfg.DataDir, "crashes")
+	}
+
+	// SECURITY HEADERS for the public pages; the staff pages derive theirs from it
+	policy := security.DefaultPolicy()
+	policy.ReportURI = app.CSPReportPath
+	policy.ReportOnly = cfg.CSPReportOnly
+
+	var corsPolicies map[string]cors.Policy
+	if cfg.CORS != nil {
+		corsPolicies = map[string]cors.Policy{app.GroupAPI: *cfg.CORS}
+	}
+
+	// THE APPLICATION: every route, handler and middleware lives in package app
+	site := app.New(
+		app.Config{
+			AdminUsers:   cfg.AdminUsers,
+			ContactTo:    cfg.ContactTo,
+			Logger:       cfg.Logger,
+			AccessLog:    cfg.AccessLog,
+			AccessFormat: cfg.AccessFormat,
+			Tracer:       cfg.Tracer,
+			CrashDir:     crashDir,
+			Security:     map[string]security.Policy{app.GroupPages: policy},
+			CORS:         corsPolicies,
+		},
+		app.Deps{Cats: catRepo, Apps: appStore, Uploads: uploads, Audit: auditLog, Mailer: mailer, Health: probes,
+			Events: cfg.Events, Proxies: cfg.Proxies},
+	)
+	site.Register(s)
+
+	// ===== MORE rweb EXAMPLES (disabled, kept for reference) =====
 
 	/*	// MIDDLEWARE PATTERN: Middleware are functions that process requests before they reach handlers
 		// Middleware 1: Request logging middleware
@@ -90,11 +546,12 @@ func main() {
 	// var authMidWare rweb.Handler
 
 	_ = func(ctx rweb.Context) error {
-		fmt.Println("**-> Checking Auth...")
+		logger := logging.FromContext(ctx)
+		logger.Debug("checking auth")
 
 		reqPath := ctx.Request().Path()
 		if strings.Contains(reqPath, "roh") {
-			fmt.Println("**-> Auth OK")
+			logger.Debug("auth ok")
 			return ctx.Next()
 		}
 
@@ -122,38 +579,6 @@ func main() {
 		})
 	*/
 
-	// ===== HTTP ROUTE HANDLERS =====
-	// Routes map URL paths to handler functions
-	// The server's router (master handler) looks up which route matches the incoming request
-
-	// ROUTE HANDLER with ANONYMOUS FUNCTION
-	// s.Get() registers a handler for GET requests to the "/" path
-	// The second argument is an anonymous function (also called a function literal or lambda)
-	// FUNCTION SIGNATURE: func(ctx rweb.Context) error
-	//   - Takes one parameter: ctx (context with request/response data)
-	//   - Returns an error (nil means success, non-nil error is handled by the framework)
-	s.Get("/", func(ctx rweb.Context) error {
-		// METHOD CHAINING: ctx.Response() returns a response object, then we call SetHeader() on it
-		// SetHeader sets an HTTP response header (key-value pair)
-		ctx.Response().SetHeader("Content-Type", "text/html; charset=utf-8")
-
-		// CALLING METHODS ACROSS PACKAGES
-		// pages.HomePage is a struct instance from the pages package
-		// We call its Render() method, which returns an HTML string
-		// ctx.WriteHTML() sends that HTML back to the client
-		// The return statement returns the error (or nil) from WriteHTML
-		return ctx.WriteHTML(pages.HomePage.Render())
-	})
-
-	// Another GET route - same pattern as above
-	// This demonstrates that we can have multiple routes with different paths
-	// GET requests typically retrieve and display data (idempotent - safe to repeat)
-	s.Get("/contact", func(ctx rweb.Context) error {
-		ctx.Response().SetHeader("Content-Type", "text/html; charset=utf-8")
-		// pages.Contact is another page instance, similar to HomePage
-		return ctx.WriteHTML(pages.Contact.Render())
-	})
-
 	/*	s.Get("/roh", func(ctx rweb.Context) error {
 			ctx.Response().SetHeader("Content-Type", "text/plain; charset=utf-8")
 
@@ -171,132 +596,13 @@ func main() {
 		})
 	*/
 
-	// POST ROUTE with ROUTE PARAMETERS
-	// POST requests typically modify data on the server (non-idempotent - side effects)
-	// ROUTE PARAMETERS: ":form_id" is a URL parameter that captures any value in that position
-	// Example: /post-form-data/123 → form_id = "123"
-	// Example: /post-form-data/abc → form_id = "abc"
-	// Test with: curl -X POST http://localhost:8080/post-form-data/123 -d "dept=engineering&name=JohnDoe"
-	s.Post("/post-form-data/:form_id",
-		func(ctx rweb.Context) error {
-			// FORM DATA EXTRACTION: FormValue() retrieves data from POST request body
-			// These values come from the form data sent in the request
-			dept := ctx.Request().FormValue("dept")      // form field "dept=engineering"
-			formId := ctx.Request().PathParam("form_id") // URL path parameter "123"
-			name := ctx.Request().FormValue("name")      // form field "name=JohnDoe"
-
-			// STRING FORMATTING: fmt.Sprintf() works like printf - formats a string
-			// %s is a placeholder for string values
-			outStr := fmt.Sprintf("Posted - form_id: %s, dept: %s, name: %s", formId, dept, name)
-
-			// WriteString sends plain text back to the client
-			return ctx.WriteString(outStr)
-		})
-
-	// POST route for contact form submission
-	// This handles the form data from the contact page
-	s.Post("/contact",
-		func(ctx rweb.Context) error {
-			// Extract multiple form fields from the POST request
-			name := ctx.Request().FormValue("name")       // form field "name"
-			email := ctx.Request().FormValue("email")     // form field "email"
-			message := ctx.Request().FormValue("message") // form field "message"
-			outStr := fmt.Sprintf("Posted - name: %s, email: %s, message: %s", name, email, message)
-
-			// FLUENT API / METHOD CHAINING: Building HTML dynamically
-			// element.NewBuilder() creates a new HTML builder
-			b := element.NewBuilder()
-
-			// METHOD CHAINING with VARIADIC FUNCTIONS
-			// Body() creates a <body> tag with style attribute
-			// R() is a variadic function - it accepts any number of arguments (components)
-			// Each method returns the builder, allowing us to chain calls
-			b.Body("style", "background-color:darkgreen").R(
-				// H1() creates an <h1> tag, T() adds text content
-				b.H1("style", "color:maroon;background-color:#dfc673").T("Welcome"),
-				b.Hr(), // Hr() creates an <hr> horizontal rule tag
-				b.P().T(outStr), // P() creates a <p> paragraph tag
-			)
-
-			// String() converts the builder to an HTML string
-			return ctx.WriteHTML(b.String())
-		})
-
-	// STATIC FILE SERVING
-	// StaticFiles() serves files from the filesystem
-	// Parameters: (URL prefix, filesystem path, segments to strip)
-	// Example: Request to "/.well-known/some-file.txt" → serves file at "/some-file.txt"
-	s.StaticFiles("/.well-known/", "/", 0)
-
-	// FILE UPLOAD HANDLER
-	// Demonstrates handling multipart/form-data (file uploads + regular form fields)
-	// Test with: curl -X POST -F "vehicle=car" -F "file=@somefile.txt" http://localhost:8080/upload
-	s.Post("/upload", func(c rweb.Context) error {
-		// Get the request object for convenience
-		req := c.Request()
-
-		// MULTIPART FORM: Can contain both regular fields and file uploads
-		// Extract regular form field (not a file)
-		name := req.FormValue("vehicle")
-		fmt.Println("vehicle:", name)
-
-		// MULTIPLE RETURN VALUES: Go functions can return multiple values
-		// GetFormFile returns 3 values: (file, fileHeader, error)
-		// The BLANK IDENTIFIER (_) ignores the second return value (fileHeader)
-		// This is Go's way of explicitly discarding values we don't need
-		file, _, err := req.GetFormFile("file")
-
-		// ERROR HANDLING PATTERN: Check if err is not nil
-		// In Go, errors are values and must be explicitly checked
-		// If there's an error, return it immediately (early return pattern)
-		if err != nil {
-			return err
-		}
-
-		// DEFER for RESOURCE CLEANUP: Ensure file is closed when function exits
-		// This prevents resource leaks even if the function returns early or panics
-		// defer runs in LIFO order (Last In, First Out)
-		defer file.Close()
-
-		// Read the entire file content into a byte slice ([]byte)
-		// io.ReadAll reads until EOF (End Of File) or error
-		// Note: For large files, consider streaming to disk instead of loading into memory
-		data, err := io.ReadAll(file)
-		if err != nil {
-			return err
-		}
-
-		// OCTAL LITERAL: 0666 is an octal number (base 8) representing file permissions
-		// In Unix: 0666 = rw-rw-rw- (read/write for owner, group, others)
-		// os.WriteFile creates (or overwrites) a file with the given data and permissions
-		err = os.WriteFile("uploaded_file.txt", data, 0666)
-		if err != nil {
-			return err
-		}
-
-		// Returning nil indicates success (no error occurred)
-		// In Go, functions that return error typically return nil on success
-		return nil
-	})
-
-	// SERVER STARTUP
-	// s.Run() starts the HTTP server and blocks until shutdown
-	// It returns an error if the server fails to start or crashes
-	// log.Println() prints the error (if any) when the server stops
-	// This is the last line of main() - the program waits here while serving requests
-	log.Println(s.Run())
+	return s, nil
 }
 
-// ===== EXAMPLE TEST OUTPUT =====
-// The comments below show example curl commands and their outputs
-// These demonstrate how the API endpoints work in practice
-
-// Outputs
-// >curl -X POST -d "dept=support" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/123
-// Posted - form_id: 123%
-// >curl -X POST -d "dept=support" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/123
-// Posted - form_id: 123, dept: support%
-// >curl -X POST -d "dept=support" -d "name=Sue" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/123
-// Posted - form_id: 123, dept: support%
-// >curl -X POST -d "dept=support" -d "name=Sue" -H "Content-Type: application/x-www-form-urlencoded" http://localhost:8000/post-form-data/roh
-// Posted - form_id: 123, dept: support, name:Sue%
+// envOr reads an environment variable, falling back to def when it is unset or empty
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// routeKey is the request-scoped storage key holding the matched route pattern
+const routeKey = "metrics.route"
+
+// unmatched labels requests that no route claimed (404s, static files)
+const unmatched = "unmatched"
+
+// HTTPMetrics are the request counters and latencies recorded by Middleware
+type HTTPMetrics struct {
+	Requests *Counter   // http_requests_total{method,route,status}
+	Duration *Histogram // http_request_duration_seconds{method,route,status}
+	InFlight *Gauge     // http_requests_in_flight
+}
+
+// NewHTTPMetrics registers the request metrics on reg
+func NewHTTPMetrics(reg *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		Requests: reg.Counter("http_requests_total",
+			"HTTP requests served, by method, route and status.", "method", "route", "status"),
+		Duration: reg.Histogram("http_request_duration_seconds",
+			"Time to handle HTTP requests, by method, route and status.", DefBuckets, "method", "route", "status"),
+		InFlight: reg.Gauge("http_requests_in_flight",
+			"HTTP requests being handled right now."),
+	}
+}
+
+// Middleware counts and times every request
+//
+// The route label is the PATTERN the request matched ("/cats/:slug"), not the
+// raw path ("/cats/luna"): one series per route instead of one per cat. rweb
+// doesn't tell middleware which route matched, so each route handler is wrapped
+// with Route, which records its pattern for Middleware to read afterwards.
+func (m *HTTPMetrics) Middleware(ctx rweb.Context) error {
+	start := time.Now()
+	m.InFlight.Add(1)
+	defer m.InFlight.Add(-1)
+
+	err := ctx.Next()
+
+	status := ctx.Response().Status()
+	if err != nil && (status == 0 || status == http.StatusOK) {
+		status = http.StatusInternalServerError // what rweb's error handler will send
+	}
+	route := RoutePattern(ctx)
+	if route == "" {
+		route = unmatched
+	}
+	method := ctx.Request().Method()
+	code := strconv.Itoa(status)
+
+	m.Requests.Inc(method, route, code)
+	m.Duration.Observe(time.Since(start).Seconds(), method, route, code)
+	return err
+}
+
+// Route wraps a route's handler so Middleware can label the request with pattern
+func Route(pattern string, next rweb.Handler) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		ctx.Set(routeKey, pattern)
+		return next(ctx)
+	}
+}
+
+// RoutePattern returns the pattern of the route that handled the request,
+// or "" before routing or when no route matched
+// Other middleware (tracing) uses it to name requests the same way
+func RoutePattern(ctx rweb.Context) string {
+	route, _ := ctx.Get(routeKey).(string)
+	return route
+}
+
+// Handler serves the registry in the text exposition format - the /metrics page
+func Handler(reg *Registry) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		ctx.Response().SetHeader("Content-Type", ContentType)
+		ctx.Response().SetHeader("Cache-Control", "no-store")
+		// The registry streams into the buffer, which is then sent whole
+		var buf bytes.Buffer
+		if _, err := reg.WriteTo(&buf); err != nil {
+			return err
+		}
+		return ctx.Bytes(buf.Bytes())
+	}
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. AFTER-THE-FACT MIDDLEWARE - time ctx.Next(), then record the outcome
+// 2. HANDLER WRAPPING - Route tags a request with its pattern on the way in
+// 3. LABEL CARDINALITY - route patterns keep the number of series bounded
+// 4. METHOD VALUES - m.Middleware is an rweb.Handler bound to m
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+func TestMiddlewareLabelsByRoute(t *testing.T) {
+	reg := NewRegistry()
+	m := NewHTTPMetrics(reg)
+
+	s := rweb.NewServer()
+	s.Use(m.Middleware)
+	s.Get("/cats/:slug", Route("/cats/:slug", func(ctx rweb.Context) error {
+		return ctx.WriteString("a cat")
+	}))
+	s.Get("/boom", Route("/boom", func(ctx rweb.Context) error {
+		return errors.New("boom")
+	}))
+	s.Get("/metrics", Route("/metrics", Handler(reg)))
+
+	s.Request(http.MethodGet, "/cats/luna", nil, nil)
+	s.Request(http.MethodGet, "/cats/milo", nil, nil)
+	s.Request(http.MethodGet, "/boom", nil, nil)
+	s.Request(http.MethodGet, "/nowhere", nil, nil)
+
+	// Two different cats, one series
+	if v := m.Requests.Value("GET", "/cats/:slug", "200"); v != 2 {
+		t.Errorf("/cats/:slug 200 = %v, want 2", v)
+	}
+	if v := m.Requests.Value("GET", "/boom", "500"); v != 1 {
+		t.Errorf("/boom 500 = %v, want 1", v)
+	}
+	if v := m.Requests.Value("GET", unmatched, "404"); v != 1 {
+		t.Errorf("unmatched 404 = %v, want 1", v)
+	}
+	if n := m.Duration.Count("GET", "/cats/:slug", "200"); n != 2 {
+		t.Errorf("duration observations = %d, want 2", n)
+	}
+
+	resp := s.Request(http.MethodGet, "/metrics", nil, nil)
+	if ct := resp.Header("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type %q", ct)
+	}
+	body := string(resp.Body())
+	if !strings.Contains(body, `http_requests_total{method="GET",route="/cats/:slug",status="200"} 2`) {
+		t.Errorf("/metrics is missing the /cats/:slug series:\n%s", body)
+	}
+	// The scrape itself is in flight while it is being written
+	if !strings.Contains(body, "http_requests_in_flight 1\n") {
+		t.Error("in-flight gauge should count the scrape itself")
+	}
+}
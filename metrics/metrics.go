@@ -0,0 +1,368 @@
+// Package metrics counts what the server does and publishes the numbers in the
+// Prometheus text exposition format, so a Prometheus server (or anything that
+// speaks the format) can scrape /metrics and graph traffic over time.
+//
+// A Registry holds named metrics of three kinds:
+//   - Counter: only goes up (requests served, bytes uploaded)
+//   - Gauge: goes up and down (goroutines running, memory in use)
+//   - Histogram: counts observations into buckets (request durations)
+//
+// Each metric may have LABELS - named dimensions such as route or status - and
+// every distinct combination of label values is its own time series:
+//
+//	requests := reg.Counter("http_requests_total", "Requests served.", "route", "status")
+//	requests.Inc("/cats", "200")
+//
+// Keep label values to a small, known set (route patterns, not raw paths):
+// every new value is another series Prometheus stores forever.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are Prometheus' default histogram buckets, in seconds:
+// good for request latencies from a few milliseconds to ten seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry is a set of metrics that are written out together
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric // in registration order, which is also output order
+	names   map[string]bool
+	hooks   []func() // run before every scrape, e.g. to refresh runtime gauges
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+// metric is what every kind of metric can do: write its lines
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// register adds m under name; registering a name twice is a programming error
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: " + name + " is registered twice")
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// OnScrape runs f before every WriteTo, so gauges that mirror some outside
+// value (memory in use, queue length) are fresh when they are read
+func (r *Registry) OnScrape(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, f)
+}
+
+// WriteTo writes every metric in the text exposition format
+// It implements io.WriterTo
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	hooks := append([]func(){}, r.hooks...)
+	metrics := append([]metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	for _, f := range hooks {
+		f()
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ===== SERIES =====
+
+// family is the part shared by every kind of metric: a name, help text,
+// label names and one value per combination of label values (a SERIES)
+type family struct {
+	name   string
+	help   string
+	kind   string // "counter", "gauge" or "histogram"
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series // keyed by the joined label values
+}
+
+type series struct {
+	values []string // label values, in the order of family.labels
+	value  float64  // counters and gauges
+	counts []uint64 // histograms: one per bucket, not cumulative
+	sum    float64  // histograms: total of every observation
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{name: name, help: help, kind: kind, labels: labels, series: map[string]*series{}}
+}
+
+// get returns the series for values, creating it on first use (f.mu must be held)
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	// "\xff" never appears in valid UTF-8, so it can't be confused with a label value
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		// Label values often come from request buffers that get reused - keep copies
+		copied := make([]string, len(values))
+		for i, v := range values {
+			copied[i] = strings.Clone(v)
+		}
+		s = &series{values: copied}
+		f.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values, so output is stable (f.mu must be held)
+func (f *family) sorted() []*series {
+	out := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+// header writes the # HELP and # TYPE lines
+func (f *family) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// labelString renders {a="x",b="y"}, plus any extra pairs (a histogram's le)
+func (f *family) labelString(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range f.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, name, escapeLabel(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extra[i], escapeLabel(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// ===== COUNTER =====
+
+// Counter is a value that only increases
+type Counter struct{ family }
+
+// Counter registers a counter; by convention its name ends in _total
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{newFamily(name, help, "counter", labels)}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds one to the series with these label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter " + c.name + " cannot decrease")
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += v
+	c.mu.Unlock()
+}
+
+// Value returns the current value of one series (0 if it was never touched)
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(labelValues).value
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(s.values), formatFloat(s.value))
+	}
+}
+
+// ===== GAUGE =====
+
+// Gauge is a value that can go up and down
+type Gauge struct{ family }
+
+// Gauge registers a gauge
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newFamily(name, help, "gauge", labels)}
+	r.register(name, g)
+	return g
+}
+
+// Set replaces the value of the series with these label values
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = v
+	g.mu.Unlock()
+}
+
+// Add changes the value by v (negative to decrease)
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += v
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, s := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelString(s.values), formatFloat(s.value))
+	}
+}
+
+// ===== HISTOGRAM =====
+
+// Histogram counts observations into buckets, e.g. how many requests took
+// under 10ms, under 25ms, ... so Prometheus can estimate percentiles
+type Histogram struct {
+	family
+	buckets []float64 // upper bounds, ascending
+}
+
+// Histogram registers a histogram with the given bucket upper bounds (nil = DefBuckets)
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: histogram " + name + " buckets must be in ascending order")
+	}
+	h := &Histogram{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+// Observe records one value in the series with these label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets)+1) // the last one is +Inf
+	}
+	// sort.SearchFloat64s finds the first bucket whose bound is >= v
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+}
+
+// Count returns how many values one series has observed
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var n uint64
+	for _, c := range h.get(labelValues).counts {
+		n += c
+	}
+	return n
+}
+
+// write renders the buckets CUMULATIVELY, as the format requires: the le="0.1"
+// bucket counts every observation up to 0.1, including those in smaller buckets
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, s := range h.sorted() {
+		var cumulative uint64
+		for i, c := range s.counts {
+			cumulative += c
+			le := "+Inf"
+			if i < len(h.buckets) {
+				le = formatFloat(h.buckets[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.values, "le", le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(s.values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(s.values), cumulative)
+	}
+}
+
+// ===== FORMATTING =====
+
+// formatFloat writes numbers the way Prometheus reads them: shortest form, +Inf/-Inf/NaN
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Label values escape backslash, double quote and newline
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Help text escapes only backslash and newline
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// countingWriter counts bytes on their way to w, for WriteTo's return value
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. STRUCT EMBEDDING - Counter, Gauge and Histogram share family's fields and methods
+// 2. INTERFACES - the registry only needs each metric to write itself
+// 3. VARIADIC LABELS - Inc("/cats", "200") matches the label names given at registration
+// 4. MUTEXES - handlers on many goroutines update the same series
+// 5. IO.WRITERTO - the registry streams itself into any writer
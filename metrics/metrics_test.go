@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, reg *Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := reg.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+	return buf.String()
+}
+
+func TestCounterExposition(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.Counter("jobs_total", "Jobs run.", "queue", "result")
+	c.Inc("mail", "ok")
+	c.Inc("mail", "ok")
+	c.Add(0.5, "images", "failed")
+
+	want := `# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total{queue="images",result="failed"} 0.5
+jobs_total{queue="mail",result="ok"} 2
+`
+	if got := scrape(t, reg); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if v := c.Value("mail", "ok"); v != 2 {
+		t.Errorf("Value = %v", v)
+	}
+}
+
+func TestGaugeWithoutLabels(t *testing.T) {
+	reg := NewRegistry()
+	g := reg.Gauge("queue_length", "Items waiting.")
+	g.Set(5)
+	g.Add(-2)
+
+	if got := scrape(t, reg); !strings.HasSuffix(got, "\nqueue_length 3\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+// Buckets are written cumulatively and end with +Inf, followed by _sum and _count
+func TestHistogramExposition(t *testing.T) {
+	reg := NewRegistry()
+	h := reg.Histogram("wait_seconds", "Time waited.", []float64{0.1, 1}, "op")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v, "read")
+	}
+
+	want := `# HELP wait_seconds Time waited.
+# TYPE wait_seconds histogram
+wait_seconds_bucket{op="read",le="0.1"} 2
+wait_seconds_bucket{op="read",le="1"} 3
+wait_seconds_bucket{op="read",le="+Inf"} 4
+wait_seconds_sum{op="read"} 3.65
+wait_seconds_count{op="read"} 4
+`
+	if got := scrape(t, reg); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if n := h.Count("read"); n != 4 {
+		t.Errorf("Count = %d", n)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("odd_total", "Back\\slash and\nnewline.", "v").Inc("say \"hi\"\n\\")
+
+	got := scrape(t, reg)
+	for _, want := range []string{
+		`# HELP odd_total Back\\slash and\nnewline.`,
+		`odd_total{v="say \"hi\"\n\\"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+}
+
+func TestOnScrapeRunsBeforeWriting(t *testing.T) {
+	reg := NewRegistry()
+	g := reg.Gauge("scrapes", "Times scraped.")
+	n := 0
+	reg.OnScrape(func() { n++; g.Set(float64(n)) })
+
+	scrape(t, reg)
+	if got := scrape(t, reg); !strings.Contains(got, "scrapes 2\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestRuntimeMetrics(t *testing.T) {
+	reg := NewRegistry()
+	RegisterRuntime(reg)
+
+	got := scrape(t, reg)
+	for _, name := range []string{"go_goroutines ", "go_memstats_alloc_bytes ", "go_info{version=", "process_start_time_seconds "} {
+		if !strings.Contains(got, "\n"+name) {
+			t.Errorf("missing %s", name)
+		}
+	}
+}
+
+// Mistakes in how a metric is used are programming errors and panic early
+func TestMisusePanics(t *testing.T) {
+	expectPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: no panic", name)
+			}
+		}()
+		f()
+	}
+	reg := NewRegistry()
+	c := reg.Counter("a_total", "A.", "x")
+
+	expectPanic("duplicate name", func() { reg.Counter("a_total", "again") })
+	expectPanic("wrong label count", func() { c.Inc("1", "2") })
+	expectPanic("negative add", func() { c.Add(-1, "1") })
+	expectPanic("unsorted buckets", func() { reg.Histogram("h", "H.", []float64{1, 0.5}) })
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"form_exer/forms"
+)
+
+// Media types of request and response bodies
+const (
+	FormURLEncoded = "application/x-www-form-urlencoded"
+	Multipart      = "multipart/form-data"
+	JSON           = "application/json"
+	HTML           = "text/html"
+	ProblemJSON    = "application/problem+json"
+)
+
+// Route is what the generator needs to know about one route
+type Route struct {
+	Method  string
+	Path    string // rweb syntax: /cats/:slug
+	Summary string
+	Tags    []string
+
+	Query   forms.Schema // query string parameters
+	Headers forms.Schema // request headers that change the reply
+	Form    forms.Schema // request body fields; sent urlencoded, multipart or as JSON
+
+	// Reply is a value of the JSON reply's type, nil for routes that don't
+	// answer with JSON. With Produces also set, the route NEGOTIATES: JSON when
+	// the Accept header asks for it, Produces otherwise
+	Reply    any
+	Produces string // media type of a non-JSON reply; "" means an HTML page when Reply is nil
+	Status   int    // success status; 0 means 200
+
+	BasicAuth bool     // staff only: HTTP Basic authentication
+	AlsoAt    []string // other paths serving the same operation, e.g. /es/cats
+}
+
+// Generate builds the document describing routes
+//
+// Every operation also gets the error responses the central error handler
+// sends: a page, or RFC 9457 problem details (errorSchema) for JSON clients
+func Generate(info Info, routes []Route, errorSchema *Schema) Document {
+	doc := Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{"Problem": errorSchema},
+			SecuritySchemes: map[string]*SecurityScheme{"basicAuth": {Type: "http", Scheme: "basic"}},
+		},
+	}
+
+	for _, r := range routes {
+		path, params := pathTemplate(r.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		op := operation(r, params)
+		switch r.Method {
+		case http.MethodGet:
+			item.Get = op
+		case http.MethodPost:
+			item.Post = op
+		case http.MethodPut:
+			item.Put = op
+		case http.MethodPatch:
+			item.Patch = op
+		case http.MethodDelete:
+			item.Delete = op
+		case http.MethodOptions:
+			item.Options = op
+		}
+	}
+	return doc
+}
+
+// operation describes one route
+func operation(r Route, pathParams []string) *Operation {
+	op := &Operation{
+		Summary:     r.Summary,
+		OperationID: operationID(r.Method, r.Path),
+		Tags:        r.Tags,
+		Responses:   map[string]*Response{},
+	}
+	if len(r.AlsoAt) > 0 {
+		op.Description = "Also served at " + strings.Join(r.AlsoAt, ", ") + "."
+	}
+
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, in := range []struct {
+		where  string
+		fields forms.Schema
+	}{{"query", r.Query}, {"header", r.Headers}} {
+		for _, f := range in.fields {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: f.Name, In: in.where, Description: f.Description, Required: f.Required, Schema: fieldSchema(f),
+			})
+		}
+	}
+
+	if len(r.Form) > 0 {
+		schema := FormSchema(r.Form)
+		content := map[string]*MediaType{Multipart: {Schema: schema}}
+		if !r.Form.HasFile() {
+			// forms.Parse reads all three into the same values
+			content[FormURLEncoded] = &MediaType{Schema: schema}
+			content[JSON] = &MediaType{Schema: schema}
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if status != http.StatusNoContent && status/100 != 3 {
+		success.Content = map[string]*MediaType{}
+		if r.Reply != nil {
+			success.Content[JSON] = &MediaType{Schema: SchemaOf(r.Reply)}
+		}
+		if r.Produces != "" {
+			success.Content[r.Produces] = &MediaType{}
+		} else if r.Reply == nil {
+			success.Content[HTML] = &MediaType{}
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	if r.BasicAuth {
+		op.Security = []map[string][]string{{"basicAuth": {}}}
+		op.Responses["401"] = &Response{Description: "Staff login required"}
+	}
+	op.Responses["default"] = &Response{
+		Description: "Error: a page for browsers, problem details for JSON clients",
+		Content: map[string]*MediaType{
+			HTML:        {},
+			ProblemJSON: {Schema: &Schema{Ref: "#/components/schemas/Problem"}},
+		},
+	}
+	return op
+}
+
+// pathTemplate turns rweb's /cats/:slug into OpenAPI's /cats/{slug}
+// and returns the parameter names
+// A catch-all like /assets/*path becomes /assets/{path}; OpenAPI has no
+// syntax for "the rest of the path", so the description has to say so
+func pathTemplate(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+			name := seg[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID names an operation for client generators: "post_cats_slug_photos"
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.Trim(strings.TrimLeft(seg, ":*"), ".")
+		seg = strings.NewReplacer(".", "_", "-", "_").Replace(seg)
+		if seg != "" {
+			id += "_" + seg
+		}
+	}
+	return id
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. GENERATED DOCUMENTATION - derived from the code, so it can't fall behind
+// 2. PATH TEMPLATES - the same route written in two syntaxes
+// 3. CONTENT NEGOTIATION, DESCRIBED - one response, several media types
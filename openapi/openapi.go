@@ -0,0 +1,127 @@
+// Package openapi generates an OpenAPI 3.1 document - the standard,
+// machine-readable description of an HTTP API - from a list of routes.
+//
+// The document is built from the same route table the server registers and
+// the same form schemas the handlers' field names come from, so it can't
+// drift out of date the way a hand-written description does. Tools read it
+// to render documentation, generate client code, or test the API.
+package openapi
+
+// Version is the OpenAPI version the documents follow
+const Version = "3.1.0"
+
+// THE DOCUMENT MODEL below mirrors the OpenAPI specification, trimmed to the
+// parts we fill in. omitempty keeps unused parts out of the JSON.
+
+// Document is the root of an OpenAPI description
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"` // keyed by path template, e.g. "/cats/{slug}"
+	Components Components           `json:"components"`
+}
+
+// Info names and versions the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations on one path, one field per HTTP method
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+}
+
+// Operation describes one method on one path
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"` // keyed by status code, or "default"
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query string parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path" or "query"
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody lists the media types a body may be sent as
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes one possible answer
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType gives the schema of a body in one encoding (nil schema: any content)
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is a JSON Schema describing a value
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+}
+
+// Components holds definitions shared across operations
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme says how clients authenticate
+type SecurityScheme struct {
+	Type   string `json:"type"`             // "http"
+	Scheme string `json:"scheme,omitempty"` // "basic"
+}
+
+// Operations lists the path item's operations with their methods, in a fixed order
+func (p *PathItem) Operations() []MethodOperation {
+	var out []MethodOperation
+	for _, m := range []MethodOperation{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put},
+		{"PATCH", p.Patch}, {"DELETE", p.Delete}, {"OPTIONS", p.Options},
+	} {
+		if m.Operation != nil {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// MethodOperation pairs an operation with its HTTP method
+type MethodOperation struct {
+	Method    string
+	Operation *Operation
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. MODELLING A SPECIFICATION - Go structs whose JSON is an OpenAPI document
+// 2. POINTERS FOR OPTIONAL PARTS - nil operations and schemas are left out
+// 3. OMITEMPTY - only what is filled in appears in the output
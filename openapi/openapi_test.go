@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"form_exer/forms"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type person struct {
+	address                 // promoted: city appears on person itself
+	Name    string          `json:"name"`
+	Age     int             `json:"age,omitempty"`
+	Tags    []string        `json:"tags"`
+	Avatar  []byte          `json:"avatar,omitempty"`
+	Born    *time.Time      `json:"born,omitempty"`
+	Extra   map[string]bool `json:"extra,omitempty"`
+	Friend  *person         `json:"friend,omitempty"` // recursive
+	Secret  string          `json:"-"`
+	hidden  string
+	Plain   float64
+}
+
+func TestSchemaOf(t *testing.T) {
+	s := SchemaOf(person{})
+
+	if s.Type != "object" {
+		t.Fatalf("type %q", s.Type)
+	}
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"Plain", "age", "avatar", "born", "city", "extra", "friend", "name", "tags"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("properties %v, want %v", names, want)
+	}
+
+	checks := map[string]Schema{
+		"Plain":  {Type: "number"},
+		"age":    {Type: "integer"},
+		"avatar": {Type: "string", Format: "byte"},
+		"born":   {Type: "string", Format: "date-time"},
+		"city":   {Type: "string"},
+		"tags":   {Type: "array", Items: &Schema{Type: "string"}},
+		"extra":  {Type: "object", AdditionalProperties: &Schema{Type: "boolean"}},
+	}
+	for name, want := range checks {
+		if got := *s.Properties[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: %+v, want %+v", name, got, want)
+		}
+	}
+	// A type inside itself is cut short instead of recursing forever
+	if f := s.Properties["friend"]; f.Type != "object" || f.Properties != nil {
+		t.Errorf("friend: %+v", f)
+	}
+
+	// Without omitempty a field is always in the output
+	required := slices.Clone(s.Required)
+	slices.Sort(required)
+	if want := []string{"Plain", "city", "name", "tags"}; !reflect.DeepEqual(required, want) {
+		t.Errorf("required %v, want %v", required, want)
+	}
+}
+
+func TestFormSchema(t *testing.T) {
+	s := FormSchema(forms.Schema{
+		{Name: "name", Required: true},
+		{Name: "age", Type: forms.Integer},
+		{Name: "kids", Type: forms.Boolean},
+		{Name: "photo", Type: forms.File},
+		{Name: "status", Enum: []string{"a", "b"}, Description: "pick one"},
+	})
+
+	want := map[string]Schema{
+		"name":   {Type: "string"},
+		"age":    {Type: "integer"},
+		"kids":   {Type: "boolean"},
+		"photo":  {Type: "string", Format: "binary"},
+		"status": {Type: "string", Enum: []string{"a", "b"}, Description: "pick one"},
+	}
+	for name, w := range want {
+		if got := *s.Properties[name]; !reflect.DeepEqual(got, w) {
+			t.Errorf("%s: %+v, want %+v", name, got, w)
+		}
+	}
+	if !reflect.DeepEqual(s.Required, []string{"name"}) {
+		t.Errorf("required %v", s.Required)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	type reply struct {
+		OK bool `json:"ok"`
+	}
+	doc := Generate(Info{Title: "Test", Version: "1"}, []Route{
+		{Method: "GET", Path: "/cats/:slug", Summary: "A cat", AlsoAt: []string{"/es/cats/:slug"}},
+		{Method: "POST", Path: "/cats/:slug/photos", Summary: "Add a photo", BasicAuth: true, Status: 303,
+			Form: forms.Schema{{Name: "photo", Type: forms.File, Required: true}}},
+		{Method: "POST", Path: "/echo", Summary: "Echo", Reply: reply{}, Produces: "text/plain",
+			Form:    forms.Schema{{Name: "name"}},
+			Query:   forms.Schema{{Name: "loud", Type: forms.Boolean}},
+			Headers: forms.Schema{{Name: "X-Fragment", Enum: []string{"result"}}}},
+	}, &Schema{Type: "object"})
+
+	if doc.OpenAPI != Version {
+		t.Errorf("openapi %q", doc.OpenAPI)
+	}
+
+	// rweb's :slug becomes {slug}, with a path parameter
+	cat := doc.Paths["/cats/{slug}"].Get
+	if cat == nil {
+		t.Fatalf("paths: %v", doc.Paths)
+	}
+	if len(cat.Parameters) != 1 || cat.Parameters[0] != (Parameter{Name: "slug", In: "path", Required: true, Schema: cat.Parameters[0].Schema}) {
+		t.Errorf("parameters %+v", cat.Parameters)
+	}
+	if cat.OperationID != "get_cats_slug" || cat.Description != "Also served at /es/cats/:slug." {
+		t.Errorf("operation %+v", cat)
+	}
+	if _, ok := cat.Responses["200"].Content[HTML]; !ok {
+		t.Errorf("a route without a reply is a page: %+v", cat.Responses["200"])
+	}
+	if ref := cat.Responses["default"].Content[ProblemJSON].Schema.Ref; ref != "#/components/schemas/Problem" {
+		t.Errorf("error response refers to %q", ref)
+	}
+
+	// A file upload can only be sent as multipart; a redirect has no body;
+	// staff routes need Basic authentication
+	photos := doc.Paths["/cats/{slug}/photos"].Post
+	if types := slices.Sorted(maps.Keys(photos.RequestBody.Content)); !reflect.DeepEqual(types, []string{Multipart}) {
+		t.Errorf("upload body types %v", types)
+	}
+	if r := photos.Responses["303"]; r == nil || r.Content != nil {
+		t.Errorf("303 response %+v", r)
+	}
+	if photos.Responses["401"] == nil || len(photos.Security) != 1 {
+		t.Errorf("staff route: security %v, responses %v", photos.Security, photos.Responses)
+	}
+
+	// Plain fields may be posted any of three ways; a negotiated reply lists both types
+	echo := doc.Paths["/echo"].Post
+	if types := slices.Sorted(maps.Keys(echo.RequestBody.Content)); !reflect.DeepEqual(types, []string{JSON, FormURLEncoded, Multipart}) {
+		t.Errorf("form body types %v", types)
+	}
+	ok := echo.Responses["200"].Content
+	if ok[JSON] == nil || ok[JSON].Schema.Properties["ok"] == nil || ok["text/plain"] == nil {
+		t.Errorf("negotiated reply %+v", ok)
+	}
+	if len(echo.Parameters) != 2 || echo.Parameters[0].In != "query" || echo.Parameters[0].Schema.Type != "boolean" ||
+		echo.Parameters[1].In != "header" || echo.Parameters[1].Name != "X-Fragment" {
+		t.Errorf("query parameters %+v", echo.Parameters)
+	}
+
+	// The whole document must be valid JSON with the OpenAPI key names
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["paths"].(map[string]any)["/cats/{slug}/photos"]; !ok {
+		t.Errorf("JSON paths: %v", raw["paths"])
+	}
+}
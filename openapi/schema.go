@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"form_exer/forms"
+)
+
+// SchemaOf describes the JSON encoding/json produces for v's type
+//
+// REFLECTION: the reflect package reads a type's structure at run time - its
+// kind, its fields and their tags - which is exactly what encoding/json does
+// to encode a value, so following the same rules gives a matching schema
+func SchemaOf(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return schemaOfType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOfType does the work; seen holds the struct types being described
+// further up, so a RECURSIVE TYPE (a Schema holds Schemas) ends instead of
+// looping forever
+func schemaOfType(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"} // time.Time marshals as RFC 3339
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOfType(t.Elem(), seen)
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // []byte marshals as base64
+		}
+		return &Schema{Type: "array", Items: schemaOfType(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addFields(s, t, seen)
+		return s
+	}
+	return &Schema{} // interfaces and the like: any value
+}
+
+// addFields adds t's JSON fields to s, following encoding/json's rules:
+// unexported fields are skipped, `json:"-"` hides a field, `json:"name"`
+// renames it, and the fields of EMBEDDED structs are promoted into the parent
+func addFields(s *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, seen)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = schemaOfType(f.Type, seen)
+		// Fields without omitempty are always in the output
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// FormSchema describes a form as an object with one property per field
+// Form values are strings on the wire; the types say what the strings hold
+func FormSchema(fields forms.Schema) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, f := range fields {
+		s.Properties[f.Name] = fieldSchema(f)
+		if f.Required {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return s
+}
+
+// fieldSchema describes one form or query field
+func fieldSchema(f forms.Field) *Schema {
+	s := &Schema{Type: "string", Description: f.Description, Enum: f.Enum}
+	switch f.Type {
+	case forms.Integer:
+		s.Type = "integer"
+	case forms.Boolean:
+		s.Type = "boolean"
+	case forms.File:
+		s.Format = "binary" // OpenAPI's way of saying "a file upload"
+	}
+	return s
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. REFLECTION - reading a type's fields and struct tags at run time
+// 2. STRUCT TAGS - the json tag names the property, as encoding/json does
+// 3. RECURSION WITH A GUARD - seen stops self-referencing types from looping
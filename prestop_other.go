@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyPreStop does nothing: there is no SIGUSR1 on this platform, so
+// readiness only fails once the server is stopping (see main)
+func notifyPreStop(chan<- os.Signal) {}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyPreStop relays SIGUSR1, the PRE-STOP signal, to c (see main)
+func notifyPreStop(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// upstream is one server of the pool
+// ATOMICS rather than a mutex: every request reads them, and each is a single number
+type upstream struct {
+	url    *url.URL
+	base   string       // the URL as text, without a trailing slash
+	up     atomic.Bool  // in the pool; true until a check says otherwise
+	active atomic.Int64 // requests in flight
+}
+
+func newUpstream(u *url.URL) *upstream {
+	up := &upstream{url: u, base: u.String()}
+	up.up.Store(true)
+	return up
+}
+
+// target is the URL a request for path (what followed the prefix) goes to
+func (u *upstream) target(path, query string) string {
+	t := u.base + path
+	if u.url.Path == "" && path == "" {
+		t += "/"
+	}
+	if query != "" {
+		t += "?" + query
+	}
+	return t
+}
+
+// pick chooses the next upstream for a request: one that is in the pool and
+// hasn't been tried for it yet (tried is marked), or nil when none is left
+//
+// Both strategies start from the ROUND-ROBIN position, so least-connections
+// also takes turns among upstreams that are equally busy
+func (p *Proxy) pick(tried []bool) *upstream {
+	n := len(p.upstreams)
+	start := int((p.next.Add(1) - 1) % uint64(n))
+
+	best := -1
+	for i := range n {
+		j := (start + i) % n
+		u := p.upstreams[j]
+		if tried[j] || !u.up.Load() {
+			continue
+		}
+		if p.route.Balance == RoundRobin {
+			best = j
+			break
+		}
+		if best < 0 || u.active.Load() < p.upstreams[best].active.Load() {
+			best = j
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	tried[best] = true
+	return p.upstreams[best]
+}
+
+// setUp moves an upstream into or out of the pool, logging the change
+func (p *Proxy) setUp(u *upstream, up bool, err error) {
+	// Swap returns the old state: log only real changes, not every check
+	if u.up.Swap(up) != up {
+		p.logState(u, up, err)
+	}
+}
+
+// Healthy lists the upstreams currently in the pool, for tests and status pages
+func (p *Proxy) Healthy() []string {
+	var out []string
+	for _, u := range p.upstreams {
+		if u.up.Load() {
+			out = append(out, u.base)
+		}
+	}
+	return out
+}
+
+// checkHealth checks every upstream at once, then again each HealthInterval,
+// until Close
+func (p *Proxy) checkHealth() {
+	defer p.checking.Done()
+	ticker := time.NewTicker(p.route.HealthInterval)
+	defer ticker.Stop()
+
+	// Checks get their own deadline: a check still running when the next one
+	// is due says enough
+	client := &http.Client{
+		Transport:     p.client.Transport,
+		Timeout:       min(p.route.Timeout, p.route.HealthInterval),
+		CheckRedirect: p.client.CheckRedirect,
+	}
+	for {
+		var wg sync.WaitGroup
+		for _, u := range p.upstreams {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.check(client, u)
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check fetches one upstream's health path
+func (p *Proxy) check(client *http.Client, u *upstream) {
+	resp, err := client.Get(u.target(p.route.HealthPath, ""))
+	if err != nil {
+		p.setUp(u, false, err)
+		return
+	}
+	// Reading to the end lets the connection be used again
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		p.setUp(u, false, httpStatus(resp.StatusCode))
+		return
+	}
+	p.setUp(u, true, nil)
+}
+
+// httpStatus describes a failed check's status as an error for the log
+type httpStatus int
+
+func (s httpStatus) Error() string {
+	return "health check answered " + http.StatusText(int(s))
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. ATOMICS - lock-free counters and flags read on every request
+// 2. LOAD BALANCING - round-robin, or least connections with round-robin tie-breaking
+// 3. ACTIVE HEALTH CHECKS - a ticker goroutine, stopped by closing a channel
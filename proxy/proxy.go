@@ -0,0 +1,472 @@
+// Package proxy makes the site a REVERSE PROXY for other servers: requests
+// under a path prefix are forwarded to a POOL of upstreams, and their answers
+// are sent back as the site's own.
+//
+//	p, err := proxy.New(proxy.Route{
+//		Prefix:     "/api/legacy",
+//		Upstreams:  []string{"http://10.0.0.5:8080", "http://10.0.0.6:8080"},
+//		Balance:    proxy.LeastConnections,
+//		HealthPath: "/healthz",
+//	})
+//	defer p.Close()
+//
+// GET /api/legacy/orders/7?full=1 then goes to one of the upstreams as
+// GET /orders/7?full=1.
+//
+// rweb has a Server.Proxy of its own, but it sends everything to one address
+// through http.DefaultClient - no timeouts, no second server to fall back on,
+// and the hop-by-hop headers (Connection, Transfer-Encoding...) copied through.
+// This package adds, per Route:
+//   - LOAD BALANCING: round-robin, or the upstream with the fewest requests in flight
+//   - ACTIVE HEALTH CHECKS: a background GET of each upstream's health path;
+//     an upstream that fails is skipped until it passes again
+//   - RETRIES: an idempotent request (GET, PUT, DELETE...) that fails is sent
+//     to the next upstream; a POST is never sent twice
+//   - TIMEOUTS for every attempt, and X-Forwarded-* headers so the upstream
+//     knows who was really asked
+//   - A HEADER ALLOWLIST: the visitor's cookies and credentials are for this
+//     site, not for whatever runs behind it, so only DefaultPassHeaders (and
+//     the route's own PassHeaders) are forwarded
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+	"form_exer/forms"
+	"form_exer/logging"
+	"form_exer/tracing"
+)
+
+// Balance picks which upstream gets a request
+type Balance string
+
+const (
+	RoundRobin       Balance = "round-robin"       // each in turn
+	LeastConnections Balance = "least-connections" // the one with the fewest requests in flight
+)
+
+// Route is one forwarded prefix and the pool behind it; zero values pick the defaults
+type Route struct {
+	// Prefix is the path the site forwards, e.g. "/api/legacy"; it is removed
+	// before forwarding
+	Prefix string
+
+	// Upstreams are the pool's base URLs; a path in one ("http://old:8080/v1")
+	// is put in front of every forwarded path
+	Upstreams []string
+
+	Balance Balance // default RoundRobin
+
+	// HealthPath is fetched from every upstream each HealthInterval (default 10s);
+	// an error or a status of 400 or more takes it out of the pool until it passes
+	// again. "" turns the checks off: every upstream is then always in the pool
+	HealthPath     string
+	HealthInterval time.Duration
+
+	// Timeout limits each attempt, from connecting to reading the whole reply (default 30s)
+	Timeout time.Duration
+
+	// Attempts is how many upstreams an idempotent request is tried on before
+	// giving up (default 2); other requests are only ever tried once
+	Attempts int
+
+	// Proto is the X-Forwarded-Proto sent with every request; "" means "http".
+	// main sets "https" when serving TLS
+	Proto string
+
+	// TrustForwarded passes on the X-Forwarded-* and Forwarded headers a request
+	// arrives with. Only set it when the site sits behind a proxy of its own that
+	// replaces them: otherwise they are whatever the visitor chose to send, and
+	// are removed (see rewriteRequest)
+	TrustForwarded bool
+
+	// PassHeaders are request headers forwarded on top of DefaultPassHeaders,
+	// e.g. "X-Api-Key" for an upstream with keys of its own. Cookie and
+	// Authorization are only sent when listed here: by default an upstream
+	// never sees the visitor's session
+	PassHeaders []string
+}
+
+// DefaultPassHeaders are the request headers every route forwards: what the
+// visitor accepts, what the body is, and the conditional and range requests
+// that make caching work. Anything else stays on the site
+var DefaultPassHeaders = []string{
+	"Accept", "Accept-Encoding", "Accept-Language", "Cache-Control",
+	"Content-Encoding", "Content-Language", "Content-Type",
+	"If-Match", "If-Modified-Since", "If-None-Match", "If-Range", "If-Unmodified-Since",
+	"Pragma", "Range", "User-Agent",
+}
+
+// ParseRoutes reads "prefix=upstream,upstream;prefix=upstream" (the
+// PROXY_ROUTES environment variable format), e.g.
+//
+//	/api/legacy=http://10.0.0.5:8080,http://10.0.0.6:8080;/maps=http://maps:9000
+//
+// The other settings keep their zero values, for the caller to fill in
+func ParseRoutes(spec string) ([]Route, error) {
+	var routes []Route
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		prefix, upstreams, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("proxy route %q: want prefix=upstream,upstream", strings.TrimSpace(entry))
+		}
+		r := Route{Prefix: strings.TrimSpace(prefix)}
+		for _, u := range strings.Split(upstreams, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				r.Upstreams = append(r.Upstreams, u)
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// Proxy forwards one Route's requests
+type Proxy struct {
+	route     Route
+	pass      map[string]bool // canonical names of the headers forwarded
+	upstreams []*upstream
+	next      atomic.Uint64 // the round-robin position
+	client    *http.Client
+
+	stop      chan struct{} // closed by Close, ending the health checks
+	checking  sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// New checks the route, fills in its defaults and starts the health checks
+// Call Close when done with it
+func New(r Route) (*Proxy, error) {
+	r.Prefix = "/" + strings.Trim(r.Prefix, "/")
+	if r.Prefix == "/" {
+		return nil, errors.New("proxy: a route needs a prefix other than /")
+	}
+	if len(r.Upstreams) == 0 {
+		return nil, fmt.Errorf("proxy %s: no upstreams", r.Prefix)
+	}
+	switch r.Balance {
+	case "":
+		r.Balance = RoundRobin
+	case RoundRobin, LeastConnections:
+	default:
+		return nil, fmt.Errorf("proxy %s: unknown balance %q (want %s or %s)", r.Prefix, r.Balance, RoundRobin, LeastConnections)
+	}
+	if r.HealthInterval <= 0 {
+		r.HealthInterval = 10 * time.Second
+	}
+	if r.Timeout <= 0 {
+		r.Timeout = 30 * time.Second
+	}
+	if r.Attempts <= 0 {
+		r.Attempts = 2
+	}
+	if r.Proto == "" {
+		r.Proto = "http"
+	}
+	if r.HealthPath != "" && !strings.HasPrefix(r.HealthPath, "/") {
+		r.HealthPath = "/" + r.HealthPath
+	}
+
+	p := &Proxy{route: r, pass: make(map[string]bool), stop: make(chan struct{})}
+	for _, name := range slices.Concat(DefaultPassHeaders, r.PassHeaders) {
+		p.pass[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+	for _, raw := range r.Upstreams {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: upstream %q: %w", r.Prefix, raw, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("proxy %s: upstream %q is not an http(s) URL", r.Prefix, raw)
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		p.upstreams = append(p.upstreams, newUpstream(u))
+	}
+
+	// OWN TRANSPORT: the pool's connections are kept apart from every other
+	// client in the process, and replies are passed on exactly as sent
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 32
+	transport.DisableCompression = true // a gzipped reply stays gzipped for the visitor
+	p.client = &http.Client{
+		Transport: transport,
+		Timeout:   r.Timeout,
+		// A redirect is the upstream's answer to the visitor, not to us
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	if r.HealthPath != "" {
+		p.checking.Add(1)
+		go p.checkHealth()
+	}
+	return p, nil
+}
+
+// Prefix is the path the proxy forwards, without a trailing slash
+func (p *Proxy) Prefix() string { return p.route.Prefix }
+
+// Close stops the health checks and lets go of idle connections
+// Requests in flight still finish
+func (p *Proxy) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		p.checking.Wait()
+		p.client.CloseIdleConnections()
+	})
+}
+
+// reply is an upstream's answer, read in full
+// rweb sends a response only after the handler returns, so there is nothing
+// to gain from streaming it
+type reply struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// Handler forwards the request to the pool and writes back the answer
+//
+// An upstream that can't be reached, or answers 502, 503 or 504, is tried
+// again on the next one (idempotent methods only, up to Route.Attempts).
+// When every attempt failed the visitor gets a 502 (504 if the last one timed
+// out), or 503 when no upstream is in the pool at all.
+func (p *Proxy) Handler(ctx rweb.Context) error {
+	method := ctx.Request().Method()
+	attempts := 1
+	if idempotent(method) {
+		attempts = p.route.Attempts
+	}
+
+	tried := make([]bool, len(p.upstreams))
+	var answer *reply
+	var lastErr error
+	for range attempts {
+		u := p.pick(tried)
+		if u == nil {
+			break // nobody left to ask
+		}
+		a, err := p.forward(ctx, u)
+		if err != nil {
+			lastErr = err
+			logging.FromContext(ctx).Warn("upstream failed", "prefix", p.route.Prefix, "upstream", u.url.Host, "err", err)
+			continue
+		}
+		answer = a
+		if !retryStatus(a.status) {
+			break
+		}
+	}
+
+	switch {
+	case answer != nil:
+		// The best there is - possibly the last upstream's own 503
+		return p.write(ctx, answer)
+	case lastErr != nil && isTimeout(lastErr):
+		return apperr.Wrap(lastErr, http.StatusGatewayTimeout, "error.proxy_timeout")
+	case lastErr != nil:
+		return apperr.Wrap(lastErr, http.StatusBadGateway, "error.proxy_failed")
+	}
+	return apperr.New(http.StatusServiceUnavailable, "error.proxy_unavailable")
+}
+
+// forward sends the request to one upstream
+func (p *Proxy) forward(ctx rweb.Context, u *upstream) (*reply, error) {
+	// LEAST CONNECTIONS counts this request until its reply is read
+	u.active.Add(1)
+	defer u.active.Add(-1)
+
+	span := tracing.Start(ctx, "proxy.forward")
+	defer span.End()
+	span.SetAttr("proxy.upstream", u.url.Host)
+
+	req := ctx.Request()
+	// bytes.NewReader: rweb has read the whole body already, and a Reader over
+	// it can be sent again by a retry
+	out, err := http.NewRequest(req.Method(), u.target(strings.TrimPrefix(req.Path(), p.route.Prefix), req.Query()), bytes.NewReader(req.Body()))
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	p.rewriteRequest(req, out)
+	if sc := span.SpanContext(); sc.IsValid() {
+		// The upstream's spans join this trace, under this span
+		out.Header.Set("Traceparent", sc.Traceparent())
+	}
+
+	resp, err := p.client.Do(out)
+	if err != nil {
+		span.RecordError(err)
+		// PASSIVE HEALTH: no need to wait for the next check to notice an
+		// upstream that isn't there (without checks, nothing would bring it back)
+		if p.route.HealthPath != "" && !isTimeout(err) {
+			p.setUp(u, false, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// The client's Timeout covers reading the body too
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttr("http.status_code", resp.StatusCode)
+	return &reply{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
+
+// HOP-BY-HOP HEADERS describe one connection, not the request: a proxy must
+// not pass them on (RFC 9110, section 7.6.1)
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// rewriteRequest copies the visitor's allowed headers onto out (see
+// DefaultPassHeaders), minus the hop-by-hop ones, and adds the X-Forwarded-*
+// headers
+//
+// ALLOWLIST, NOT BLOCKLIST: the site's session cookie and the visitor's
+// Authorization would otherwise reach every upstream, and so would any
+// header invented later. A header nobody listed doesn't get through
+//
+// SPOOFING: an upstream may trust X-Forwarded-For for the visitor's address
+// and X-Forwarded-Proto for "was this HTTPS?", so the ones a request arrives
+// with are dropped, along with Forwarded - unless Route.TrustForwarded says a
+// proxy in front of the site wrote them. X-Forwarded-Proto always comes from
+// Route.Proto.
+//
+// RWEB LIMITATION: handlers can't see the connection's own address, so this
+// proxy can't add the visitor to X-Forwarded-For: without TrustForwarded the
+// upstream gets none
+func (p *Proxy) rewriteRequest(req rweb.ItfRequest, out *http.Request) {
+	in := http.Header{}
+	for _, h := range req.Headers() {
+		in.Add(h.Key, h.Value) // Add: a header may come more than once
+	}
+	// Before the allowlist: Connection may name an allowed header as hop-by-hop
+	removeHopHeaders(in)
+	for name, values := range in {
+		trusted := p.route.TrustForwarded && (name == "Forwarded" || strings.HasPrefix(name, "X-Forwarded-"))
+		if p.pass[name] || trusted {
+			out.Header[name] = values
+		}
+	}
+	// Go sets these from the request itself
+	out.Header.Del("Host")
+	out.Header.Del("Content-Length")
+
+	host := forms.Header(req, "Host")
+	if host == "" {
+		host = req.Host()
+	}
+	if host != "" {
+		out.Header.Set("X-Forwarded-Host", host)
+	}
+	out.Header.Set("X-Forwarded-Proto", p.route.Proto)
+	// Lets an upstream that builds links put them under the prefix
+	out.Header.Set("X-Forwarded-Prefix", p.route.Prefix)
+}
+
+// removeHopHeaders deletes the hop-by-hop headers, including any the
+// Connection header names
+func removeHopHeaders(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			h.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// write sends an upstream's reply as the response
+//
+// RWEB LIMITATION: a response holds one value per header, so repeated headers
+// are joined with commas - except Set-Cookie, where commas would break the
+// cookies: only the first one is passed on
+func (p *Proxy) write(ctx rweb.Context, a *reply) error {
+	removeHopHeaders(a.header)
+	a.header.Del("Content-Length") // rweb counts the body itself
+
+	resp := ctx.Response()
+	for name, values := range a.header {
+		value := strings.Join(values, ", ")
+		switch name {
+		case "Set-Cookie":
+			value = values[0]
+		case "Location":
+			value = p.rewriteLocation(value)
+		}
+		resp.SetHeader(name, value)
+	}
+	resp.SetStatus(a.status)
+	return ctx.Bytes(a.body)
+}
+
+// rewriteLocation points a redirect to an upstream's own address back
+// through the site: http://10.0.0.5:8080/orders/8 becomes /api/legacy/orders/8
+func (p *Proxy) rewriteLocation(loc string) string {
+	for _, u := range p.upstreams {
+		if rest, ok := strings.CutPrefix(loc, u.base); ok && (rest == "" || strings.ContainsAny(rest[:1], "/?#")) {
+			return p.route.Prefix + rest
+		}
+	}
+	return loc
+}
+
+// idempotent methods can be sent twice without doing anything twice
+// (RFC 9110, section 9.2.2) - the only ones worth retrying
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryStatus: the upstream (or a gateway in front of it) couldn't answer
+func retryStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+// isTimeout reports whether err is a deadline running out
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// logState reports an upstream entering or leaving the pool
+func (p *Proxy) logState(u *upstream, up bool, err error) {
+	if up {
+		slog.Info("upstream is back", "prefix", p.route.Prefix, "upstream", u.url.Host)
+		return
+	}
+	slog.Warn("upstream taken out of the pool", "prefix", p.route.Prefix, "upstream", u.url.Host, "err", err)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. REVERSE PROXY - the site answers with what another server said
+// 2. RETRIES - only idempotent requests, each time on a different upstream
+// 3. HOP-BY-HOP HEADERS - what belongs to a connection stays on it
+// 4. ALLOWLISTS - only headers someone chose to forward reach the upstream
@@ -0,0 +1,387 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+)
+
+// upstreamServer is a local upstream that answers with its name and counts requests
+type upstreamServer struct {
+	*httptest.Server
+	hits    atomic.Int64
+	healthy atomic.Bool
+
+	mu   sync.Mutex
+	last *http.Request // the newest request, apart from health checks
+	body string
+}
+
+func newUpstreamServer(t *testing.T, name string, handle func(w http.ResponseWriter, r *http.Request)) *upstreamServer {
+	t.Helper()
+	u := &upstreamServer{}
+	u.healthy.Store(true)
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			if !u.healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		u.hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		u.mu.Lock()
+		u.last, u.body = r, string(body)
+		u.mu.Unlock()
+		if handle != nil {
+			handle(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstreamServer) lastRequest() (*http.Request, string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.last, u.body
+}
+
+// newProxyServer serves p on an rweb server, the way app.Register does
+// Its errors are answered with their status, as the app's error handler would
+func newProxyServer(t *testing.T, r Route) (*Proxy, *rweb.Server) {
+	t.Helper()
+	p, err := New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+	s := rweb.NewServer(rweb.ServerOptions{})
+	s.Use(func(ctx rweb.Context) error {
+		if err := ctx.Next(); err != nil {
+			ctx.Response().SetStatus(apperr.StatusOf(err))
+		}
+		return nil
+	})
+	for _, path := range []string{p.Prefix(), p.Prefix() + "/*path"} {
+		for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+			s.AddMethod(m, path, p.Handler)
+		}
+	}
+	return p, s
+}
+
+func TestForwardsAndRewritesHeaders(t *testing.T) {
+	up := newUpstreamServer(t, "legacy", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Kept", "yes")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "made")
+	})
+	_, s := newProxyServer(t, Route{Prefix: "/api/legacy/", Upstreams: []string{up.URL + "/v1/"}, Proto: "https"})
+
+	resp := s.Request(http.MethodPut, "/api/legacy/orders/7?full=1", []rweb.Header{
+		{Key: "Host", Value: "cats.example.com"},
+		{Key: "X-Forwarded-For", Value: "203.0.113.9"},
+		{Key: "X-Forwarded-Proto", Value: "http"},
+		{Key: "Forwarded", Value: "for=203.0.113.9;proto=http"},
+		{Key: "Connection", Value: "keep-alive, X-Hop"},
+		{Key: "X-Hop", Value: "for the next hop only"},
+		{Key: "Content-Type", Value: "application/json"},
+		{Key: "Accept-Language", Value: "es"},
+		{Key: "Cookie", Value: "session=secret"},
+		{Key: "Authorization", Value: "Basic c2FtOndoaXNrZXJz"},
+		{Key: "X-Internal", Value: "not on the list"},
+	}, nil)
+	if resp.Status() != http.StatusCreated || string(resp.Body()) != "made" {
+		t.Fatalf("got %d %q", resp.Status(), resp.Body())
+	}
+	if resp.Header("X-Kept") != "yes" {
+		t.Errorf("response X-Kept %q", resp.Header("X-Kept"))
+	}
+
+	r, _ := up.lastRequest()
+	if r.Method != http.MethodPut || r.URL.Path != "/v1/orders/7" || r.URL.RawQuery != "full=1" {
+		t.Errorf("upstream got %s %s", r.Method, r.URL)
+	}
+	for name, want := range map[string]string{
+		"X-Forwarded-Host":   "cats.example.com",
+		"X-Forwarded-Proto":  "https",
+		"X-Forwarded-Prefix": "/api/legacy",
+		"X-Forwarded-For":    "", // the visitor's own; see rewriteRequest
+		"Forwarded":          "",
+		"Content-Type":       "application/json",
+		"Accept-Language":    "es",
+		"X-Hop":              "", // named in Connection
+		// ALLOWLIST: the visitor's session and credentials are the site's own
+		"Cookie":        "",
+		"Authorization": "",
+		"X-Internal":    "",
+	} {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("upstream %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// The prefix itself is the upstream's root
+	s.Request(http.MethodGet, "/api/legacy", nil, nil)
+	if r, _ := up.lastRequest(); r.URL.Path != "/v1" {
+		t.Errorf("prefix forwarded as %q", r.URL.Path)
+	}
+}
+
+// A route can forward more headers - even credentials, when it asks for them
+func TestPassHeaders(t *testing.T) {
+	up := newUpstreamServer(t, "legacy", nil)
+	_, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{up.URL}, PassHeaders: []string{"x-api-key", "Authorization"}})
+
+	s.Request(http.MethodGet, "/api/orders", []rweb.Header{
+		{Key: "X-Api-Key", Value: "k1"},
+		{Key: "Authorization", Value: "Bearer t1"},
+		{Key: "Cookie", Value: "session=secret"},
+		{Key: "Connection", Value: "X-Api-Key"}, // hop-by-hop wins over the list
+	}, nil)
+	r, _ := up.lastRequest()
+	for name, want := range map[string]string{
+		"X-Api-Key":     "",
+		"Authorization": "Bearer t1",
+		"Cookie":        "",
+	} {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("upstream %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// Behind a proxy of the site's own, its X-Forwarded-For and Forwarded are kept
+// - but the protocol is still the route's
+func TestTrustForwarded(t *testing.T) {
+	up := newUpstreamServer(t, "legacy", nil)
+	_, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{up.URL}, Proto: "https", TrustForwarded: true})
+
+	s.Request(http.MethodGet, "/api/orders", []rweb.Header{
+		{Key: "X-Forwarded-For", Value: "203.0.113.9, 10.0.0.2"},
+		{Key: "X-Forwarded-Proto", Value: "http"},
+		{Key: "Forwarded", Value: "for=203.0.113.9"},
+	}, nil)
+	r, _ := up.lastRequest()
+	for name, want := range map[string]string{
+		"X-Forwarded-For":   "203.0.113.9, 10.0.0.2",
+		"Forwarded":         "for=203.0.113.9",
+		"X-Forwarded-Proto": "https",
+	} {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("upstream %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// The in-memory s.Request sends no body, so bodies are checked over a real connection
+func TestForwardsBody(t *testing.T) {
+	up := newUpstreamServer(t, "legacy", nil)
+	p, err := New(Route{Prefix: "/api", Upstreams: []string{up.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	ready := make(chan struct{}, 1)
+	s := rweb.NewServer(rweb.ServerOptions{Address: "localhost:0", ReadyChan: ready})
+	s.Post("/api/*path", p.Handler)
+	go s.Run()
+	<-ready
+
+	resp, err := http.Post("http://"+s.GetListenAddr()+"/api/echo", "text/plain", strings.NewReader("hello upstream"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, body := up.lastRequest(); body != "hello upstream" {
+		t.Errorf("upstream got body %q", body)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	a, b := newUpstreamServer(t, "a", nil), newUpstreamServer(t, "b", nil)
+	_, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{a.URL, b.URL}})
+
+	var got []string
+	for range 4 {
+		got = append(got, string(s.Request(http.MethodGet, "/api/x", nil, nil).Body()))
+	}
+	if strings.Join(got, "") != "abab" {
+		t.Errorf("answered by %v, want a and b in turn", got)
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	release := make(chan struct{})
+	slow := newUpstreamServer(t, "slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, "slow")
+	})
+	fast := newUpstreamServer(t, "fast", nil)
+	_, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{slow.URL, fast.URL}, Balance: LeastConnections})
+
+	// The first request ties and goes to slow, where it stays in flight
+	done := make(chan string)
+	go func() { done <- string(s.Request(http.MethodGet, "/api/x", nil, nil).Body()) }()
+	for slow.hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// So everything else goes to fast, whatever the round-robin turn
+	for range 3 {
+		if got := string(s.Request(http.MethodGet, "/api/x", nil, nil).Body()); got != "fast" {
+			t.Errorf("answered by %s while slow was busy", got)
+		}
+	}
+	close(release)
+	if got := <-done; got != "slow" {
+		t.Errorf("first request answered by %s", got)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	a, b := newUpstreamServer(t, "a", nil), newUpstreamServer(t, "b", nil)
+	p, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{a.URL, b.URL},
+		HealthPath: "healthz", HealthInterval: 10 * time.Millisecond})
+
+	waitHealthy := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for len(p.Healthy()) != want && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if n := len(p.Healthy()); n != want {
+			t.Fatalf("%d healthy upstreams, want %d", n, want)
+		}
+	}
+
+	a.healthy.Store(false)
+	waitHealthy(1)
+	for range 3 {
+		if got := string(s.Request(http.MethodGet, "/api/x", nil, nil).Body()); got != "b" {
+			t.Errorf("answered by %s, which failed its check", got)
+		}
+	}
+
+	b.healthy.Store(false)
+	waitHealthy(0)
+	if resp := s.Request(http.MethodGet, "/api/x", nil, nil); resp.Status() != http.StatusServiceUnavailable {
+		t.Errorf("status %d with no upstream in the pool, want 503", resp.Status())
+	}
+
+	a.healthy.Store(true)
+	waitHealthy(1)
+	if got := string(s.Request(http.MethodGet, "/api/x", nil, nil).Body()); got != "a" {
+		t.Errorf("answered by %s after a came back", got)
+	}
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	failing := newUpstreamServer(t, "failing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	good := newUpstreamServer(t, "good", nil)
+	_, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{failing.URL, good.URL}})
+
+	// Round-robin starts with failing; the GET moves on to good
+	if resp := s.Request(http.MethodGet, "/api/x", nil, nil); resp.Status() != http.StatusOK || string(resp.Body()) != "good" {
+		t.Errorf("GET: %d %q, want good's answer", resp.Status(), resp.Body())
+	}
+	// The next turn is good's; then failing's, where a POST must stay
+	s.Request(http.MethodGet, "/api/x", nil, nil)
+	if resp := s.Request(http.MethodPost, "/api/x", nil, nil); resp.Status() != http.StatusBadGateway {
+		t.Errorf("POST status %d, want failing's 502 without a retry", resp.Status())
+	}
+	if n := good.hits.Load(); n != 2 {
+		t.Errorf("good got %d requests, want 2", n)
+	}
+}
+
+func TestUnreachableUpstream(t *testing.T) {
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close() // its port now refuses connections
+	good := newUpstreamServer(t, "good", nil)
+	_, s := newProxyServer(t, Route{Prefix: "/api", Upstreams: []string{gone.URL, good.URL}})
+
+	if resp := s.Request(http.MethodGet, "/api/x", nil, nil); string(resp.Body()) != "good" {
+		t.Errorf("GET: %d %q, want a retry on good", resp.Status(), resp.Body())
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+	stuck := newUpstreamServer(t, "stuck", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	tests := []struct {
+		name  string
+		route Route
+		want  int
+	}{
+		{"unreachable", Route{Upstreams: []string{gone.URL}}, http.StatusBadGateway},
+		{"too slow", Route{Upstreams: []string{stuck.URL}, Timeout: 20 * time.Millisecond, Attempts: 1}, http.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.route.Prefix = "/api"
+			_, s := newProxyServer(t, tt.route)
+			if status := s.Request(http.MethodGet, "/api/x", nil, nil).Status(); status != tt.want {
+				t.Errorf("status %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteLocation(t *testing.T) {
+	up := newUpstreamServer(t, "legacy", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+r.Host+"/orders/8", http.StatusSeeOther)
+	})
+	_, s := newProxyServer(t, Route{Prefix: "/api/legacy", Upstreams: []string{up.URL}})
+
+	resp := s.Request(http.MethodPost, "/api/legacy/orders", nil, nil)
+	if resp.Status() != http.StatusSeeOther || resp.Header("Location") != "/api/legacy/orders/8" {
+		t.Errorf("got %d to %q", resp.Status(), resp.Header("Location"))
+	}
+}
+
+func TestNewRejectsBadRoutes(t *testing.T) {
+	for name, r := range map[string]Route{
+		"no prefix":     {Upstreams: []string{"http://a"}},
+		"no upstreams":  {Prefix: "/api"},
+		"not http":      {Prefix: "/api", Upstreams: []string{"ftp://a"}},
+		"no host":       {Prefix: "/api", Upstreams: []string{"/just/a/path"}},
+		"bad balancing": {Prefix: "/api", Upstreams: []string{"http://a"}, Balance: "random"},
+	} {
+		if _, err := New(r); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(" /api/legacy = http://a:8080, http://b:8080 ;/maps=http://maps;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].Prefix != "/api/legacy" || len(routes[0].Upstreams) != 2 ||
+		routes[0].Upstreams[1] != "http://b:8080" || routes[1].Upstreams[0] != "http://maps" {
+		t.Errorf("got %+v", routes)
+	}
+	if _, err := ParseRoutes("/api"); err == nil {
+		t.Error("a route without upstreams was accepted")
+	}
+}
@@ -0,0 +1,205 @@
+// Package security sets the HTTP response headers that tell browsers to lock
+// a page down: where scripts and styles may come from, who may frame it, what
+// it may share in the Referer header, which device APIs it may use.
+//
+// The centrepiece is the CONTENT SECURITY POLICY (CSP). With it, a script
+// injected into a page (say, through a cat name nobody escaped) simply doesn't
+// run: the browser only executes scripts from our own origin, or inline ones
+// carrying this response's NONCE - a random value attackers can't guess:
+//
+//	b.Script("nonce", security.Nonce(ctx)).T(`...`)
+//
+// Policies can be enforced or, while trying one out, set to REPORT-ONLY: the
+// browser then runs everything but posts what it would have blocked to
+// ReportHandler, so a policy can be tightened without breaking the site.
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// CSP source keywords; the quotes are part of the syntax
+const (
+	Self = "'self'"
+	None = "'none'"
+
+	// NonceSource is a placeholder that becomes 'nonce-<this request's nonce>'
+	NonceSource = "'nonce'"
+)
+
+// CSP maps each directive to its allowed sources:
+//
+//	CSP{"default-src": {Self}, "img-src": {Self, "data:"}}
+type CSP map[string][]string
+
+// With returns a copy of the policy with one directive replaced
+// MAPS ARE REFERENCES: changing a shared CSP in place would change it for
+// every Policy holding it, so derived policies are built with With
+func (c CSP) With(directive string, sources ...string) CSP {
+	out := maps.Clone(c)
+	if out == nil {
+		out = CSP{}
+	}
+	out[directive] = sources
+	return out
+}
+
+// String renders the header value with the nonce filled in
+// default-src comes first, then the rest in alphabetical order, so the header
+// is the same on every request (maps have no order of their own)
+func (c CSP) String(nonce string) string {
+	names := slices.Sorted(maps.Keys(c))
+	if i := slices.Index(names, "default-src"); i > 0 {
+		names = append([]string{"default-src"}, slices.Delete(names, i, i+1)...)
+	}
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		sources := make([]string, 0, len(c[name]))
+		for _, src := range c[name] {
+			if src == NonceSource {
+				if nonce == "" {
+					continue
+				}
+				src = "'nonce-" + nonce + "'"
+			}
+			sources = append(sources, src)
+		}
+		parts = append(parts, strings.TrimSpace(name+" "+strings.Join(sources, " ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Policy is the set of security headers for a group of routes
+// Empty fields are left out of the response
+type Policy struct {
+	CSP CSP
+
+	// ReportOnly sends the CSP as Content-Security-Policy-Report-Only:
+	// nothing is blocked, violations are only reported
+	ReportOnly bool
+
+	// ReportURI is where browsers post violations, e.g. "/csp-report"
+	ReportURI string
+
+	ReferrerPolicy    string // Referrer-Policy, e.g. "strict-origin-when-cross-origin"
+	PermissionsPolicy string // Permissions-Policy, e.g. "camera=(), microphone=()"
+	OpenerPolicy      string // Cross-Origin-Opener-Policy, e.g. "same-origin"
+	EmbedderPolicy    string // Cross-Origin-Embedder-Policy, e.g. "require-corp"
+}
+
+// PhotoOrigin hosts the catalog's photos (see data/cats.json)
+const PhotoOrigin = "https://placekitten.com"
+
+// DefaultPolicy is a strict policy for a site that serves everything else itself:
+// scripts and styles from our origin or carrying the nonce, no plugins, no
+// framing, forms posting only back to us. Images may also come from PhotoOrigin
+func DefaultPolicy() Policy {
+	return Policy{
+		CSP: CSP{
+			"default-src":     {Self},
+			"script-src":      {Self, NonceSource},
+			"style-src":       {Self, NonceSource},
+			"img-src":         {Self, "data:", PhotoOrigin},
+			"object-src":      {None},
+			"base-uri":        {Self},
+			"form-action":     {Self},
+			"frame-ancestors": {None}, // CLICKJACKING: nobody may show our pages in a frame
+		},
+		ReferrerPolicy:    "strict-origin-when-cross-origin",
+		PermissionsPolicy: "camera=(), microphone=(), geolocation=(), payment=(), usb=()",
+		OpenerPolicy:      "same-origin",
+		// CREDENTIALLESS rather than require-corp: the photo host sends no
+		// Cross-Origin-Resource-Policy header, so require-corp would block every
+		// photo; credentialless loads them, just without our visitors' cookies
+		EmbedderPolicy: "credentialless",
+	}
+}
+
+// reportGroup names the report endpoint in the Reporting-Endpoints header
+const reportGroup = "csp-endpoint"
+
+// apply sets p's headers on the response
+func (p Policy) apply(resp rweb.Response, nonce string) {
+	set := func(name, value string) {
+		if value != "" {
+			resp.SetHeader(name, value)
+		}
+	}
+
+	// NOSNIFF is never optional: without it a browser may decide an uploaded
+	// "photo" is really HTML and run the script inside it
+	resp.SetHeader("X-Content-Type-Options", "nosniff")
+	set("Referrer-Policy", p.ReferrerPolicy)
+	set("Permissions-Policy", p.PermissionsPolicy)
+	set("Cross-Origin-Opener-Policy", p.OpenerPolicy)
+	set("Cross-Origin-Embedder-Policy", p.EmbedderPolicy)
+
+	if len(p.CSP) == 0 {
+		return
+	}
+	csp := p.CSP.String(nonce)
+	if p.ReportURI != "" {
+		// report-uri for older browsers, report-to (naming Reporting-Endpoints) for newer ones
+		csp += "; report-uri " + p.ReportURI + "; report-to " + reportGroup
+		resp.SetHeader("Reporting-Endpoints", reportGroup+`="`+p.ReportURI+`"`)
+	}
+	if p.ReportOnly {
+		resp.SetHeader("Content-Security-Policy-Report-Only", csp)
+	} else {
+		resp.SetHeader("Content-Security-Policy", csp)
+	}
+}
+
+// nonceKey is the request-scoped storage key holding the nonce
+const nonceKey = "security.nonce"
+
+// Middleware gives every request a fresh nonce and sets p's headers
+//
+// The headers are set BEFORE the handlers run, so every response has them -
+// error pages and 404s included. Routes needing a different policy wrap their
+// handler with Route, which replaces them.
+func Middleware(p Policy) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		nonce := newNonce()
+		ctx.Set(nonceKey, nonce)
+		p.apply(ctx.Response(), nonce)
+		return ctx.Next()
+	}
+}
+
+// Route applies p instead of the middleware's policy for one route's handler
+// The request keeps the nonce Middleware gave it
+func Route(p Policy, next rweb.Handler) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		p.apply(ctx.Response(), Nonce(ctx))
+		return next(ctx)
+	}
+}
+
+// Nonce returns this request's nonce for inline <script> and <style> tags
+// ("" outside Middleware - and an empty nonce attribute matches nothing)
+func Nonce(ctx rweb.Context) string {
+	nonce, _ := ctx.Get(nonceKey).(string)
+	return nonce
+}
+
+// newNonce returns 128 random bits, base64 encoded as CSP expects
+// A nonce must be new for every response - a fixed one is just a password
+// written into every page
+func newNonce() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never fails on supported platforms
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DEFENSE IN DEPTH - even if escaping fails somewhere, injected scripts don't run
+// 2. NONCES - a per-response secret that marks our own inline code
+// 3. MAPS ARE REFERENCES - With copies before changing, so policies don't share edits
+// 4. DETERMINISTIC OUTPUT - sorting map keys before rendering them
@@ -0,0 +1,119 @@
+package security
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+func TestCSPString(t *testing.T) {
+	csp := CSP{
+		"script-src":                {Self, NonceSource},
+		"default-src":               {Self},
+		"img-src":                   {Self, "data:"},
+		"upgrade-insecure-requests": nil, // a directive without sources
+	}
+
+	want := "default-src 'self'; img-src 'self' data:; script-src 'self' 'nonce-abc'; upgrade-insecure-requests"
+	if got := csp.String("abc"); got != want {
+		t.Errorf("String =\n  %s\nwant\n  %s", got, want)
+	}
+	// Without a nonce the placeholder is dropped rather than rendered empty
+	if got := csp.String(""); strings.Contains(got, "nonce") {
+		t.Errorf("String(\"\") = %s", got)
+	}
+}
+
+// With must leave the policy it was called on alone
+func TestCSPWith(t *testing.T) {
+	base := CSP{"img-src": {Self}}
+	derived := base.With("img-src", Self, "https://cdn.example.com")
+
+	if got := base.String(""); got != "img-src 'self'" {
+		t.Errorf("base changed: %s", got)
+	}
+	if got := derived.String(""); got != "img-src 'self' https://cdn.example.com" {
+		t.Errorf("derived = %s", got)
+	}
+}
+
+// newHeaderServer serves "/" with p, and "/strict" with a policy of its own
+func newHeaderServer(p Policy) *rweb.Server {
+	s := rweb.NewServer()
+	s.Use(Middleware(p))
+	page := func(ctx rweb.Context) error {
+		return ctx.WriteHTML(`<script nonce="` + Nonce(ctx) + `"></script>`)
+	}
+	s.Get("/", page)
+	strict := p
+	strict.ReferrerPolicy = "no-referrer"
+	strict.CSP = p.CSP.With("img-src", None)
+	s.Get("/strict", Route(strict, page))
+	return s
+}
+
+func TestMiddleware(t *testing.T) {
+	s := newHeaderServer(DefaultPolicy())
+
+	resp := s.Request(http.MethodGet, "/", nil, nil)
+	for name, want := range map[string]string{
+		"X-Content-Type-Options":       "nosniff",
+		"Referrer-Policy":              "strict-origin-when-cross-origin",
+		"Cross-Origin-Opener-Policy":   "same-origin",
+		"Cross-Origin-Embedder-Policy": "credentialless",
+	} {
+		if got := resp.Header(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	csp := resp.Header("Content-Security-Policy")
+	if !strings.Contains(csp, "frame-ancestors 'none'") {
+		t.Errorf("CSP should forbid framing: %s", csp)
+	}
+
+	// The nonce in the header is the one the handler put in the page
+	body := string(resp.Body())
+	nonce := strings.TrimSuffix(strings.TrimPrefix(body, `<script nonce="`), `"></script>`)
+	if len(nonce) < 16 || !strings.Contains(csp, "'nonce-"+nonce+"'") {
+		t.Errorf("nonce %q not in CSP %s", nonce, csp)
+	}
+
+	// ...and it is new for every response
+	again := s.Request(http.MethodGet, "/", nil, nil)
+	if again.Header("Content-Security-Policy") == csp {
+		t.Error("two responses shared a nonce")
+	}
+}
+
+func TestRouteOverrides(t *testing.T) {
+	s := newHeaderServer(DefaultPolicy())
+
+	resp := s.Request(http.MethodGet, "/strict", nil, nil)
+	if got := resp.Header("Referrer-Policy"); got != "no-referrer" {
+		t.Errorf("Referrer-Policy = %q", got)
+	}
+	csp := resp.Header("Content-Security-Policy")
+	if !strings.Contains(csp, "img-src 'none'") || !strings.Contains(csp, "'nonce-") {
+		t.Errorf("route policy not applied, or nonce lost: %s", csp)
+	}
+}
+
+func TestReportOnly(t *testing.T) {
+	p := DefaultPolicy()
+	p.ReportOnly = true
+	p.ReportURI = "/csp-report"
+	resp := newHeaderServer(p).Request(http.MethodGet, "/", nil, nil)
+
+	if resp.Header("Content-Security-Policy") != "" {
+		t.Error("report-only mode must not enforce")
+	}
+	csp := resp.Header("Content-Security-Policy-Report-Only")
+	if !strings.HasSuffix(csp, "; report-uri /csp-report; report-to csp-endpoint") {
+		t.Errorf("CSP-Report-Only = %s", csp)
+	}
+	if got := resp.Header("Reporting-Endpoints"); got != `csp-endpoint="/csp-report"` {
+		t.Errorf("Reporting-Endpoints = %s", got)
+	}
+}
@@ -0,0 +1,149 @@
+package security
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/apperr"
+	"form_exer/forms"
+)
+
+// Violation is one CSP violation as reported by a browser
+type Violation struct {
+	DocumentURI        string // the page where it happened
+	Referrer           string
+	BlockedURI         string // what was blocked: a URL, or "inline" / "eval"
+	EffectiveDirective string // the directive that blocked it, e.g. "script-src-elem"
+	Disposition        string // "enforce", or "report" under a report-only policy
+	SourceFile         string
+	LineNumber         int
+	ColumnNumber       int
+	Sample             string // the first characters of a blocked inline script or style
+}
+
+// maxReportBytes bounds a report body; real ones are a few hundred bytes
+const maxReportBytes = 64 << 10
+
+// ReportHandler is the /csp-report endpoint: it decodes the violations a
+// browser posts and passes each one to record
+//
+// Browsers use two formats:
+//   - application/csp-report, from report-uri: {"csp-report": {"document-uri": ...}}
+//   - application/reports+json, from report-to: [{"type": "csp-violation", "body": {"documentURL": ...}}]
+//
+// Anyone can post here, so record should only log and count - never trust a report
+func ReportHandler(record func(ctx rweb.Context, v Violation)) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		body := ctx.Request().Body()
+		if len(body) > maxReportBytes {
+			return apperr.TooLarge("error.too_large")
+		}
+		mediaType, _, _ := mime.ParseMediaType(forms.Header(ctx.Request(), "Content-Type"))
+
+		var violations []Violation
+		var err error
+		switch mediaType {
+		case "application/csp-report", "application/json":
+			violations, err = decodeLegacy(body)
+		case "application/reports+json":
+			violations, err = decodeReports(body)
+		default:
+			return apperr.New(http.StatusUnsupportedMediaType, "error.unsupported_type")
+		}
+		if err != nil {
+			return apperr.Wrap(err, http.StatusBadRequest, "error.bad_request")
+		}
+
+		for _, v := range violations {
+			record(ctx, v)
+		}
+		// 204 NO CONTENT: the browser doesn't look at the answer
+		ctx.Response().SetStatus(http.StatusNoContent)
+		return nil
+	}
+}
+
+// decodeLegacy reads the report-uri format
+// STRUCT TAGS name the kebab-case JSON keys
+func decodeLegacy(body []byte) ([]Violation, error) {
+	var doc struct {
+		Report struct {
+			DocumentURI        string `json:"document-uri"`
+			Referrer           string `json:"referrer"`
+			BlockedURI         string `json:"blocked-uri"`
+			ViolatedDirective  string `json:"violated-directive"`
+			EffectiveDirective string `json:"effective-directive"`
+			Disposition        string `json:"disposition"`
+			SourceFile         string `json:"source-file"`
+			LineNumber         int    `json:"line-number"`
+			ColumnNumber       int    `json:"column-number"`
+			Sample             string `json:"script-sample"`
+		} `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	r := doc.Report
+	if r.EffectiveDirective == "" {
+		r.EffectiveDirective = r.ViolatedDirective // older browsers send only this one
+	}
+	return []Violation{{
+		DocumentURI:        r.DocumentURI,
+		Referrer:           r.Referrer,
+		BlockedURI:         r.BlockedURI,
+		EffectiveDirective: r.EffectiveDirective,
+		Disposition:        r.Disposition,
+		SourceFile:         r.SourceFile,
+		LineNumber:         r.LineNumber,
+		ColumnNumber:       r.ColumnNumber,
+		Sample:             r.Sample,
+	}}, nil
+}
+
+// decodeReports reads the Reporting API format, which may batch several
+// reports of different types; only CSP violations are kept
+func decodeReports(body []byte) ([]Violation, error) {
+	var reports []struct {
+		Type string `json:"type"`
+		Body struct {
+			DocumentURL        string `json:"documentURL"`
+			Referrer           string `json:"referrer"`
+			BlockedURL         string `json:"blockedURL"`
+			EffectiveDirective string `json:"effectiveDirective"`
+			Disposition        string `json:"disposition"`
+			SourceFile         string `json:"sourceFile"`
+			LineNumber         int    `json:"lineNumber"`
+			ColumnNumber       int    `json:"columnNumber"`
+			Sample             string `json:"sample"`
+		} `json:"body"`
+	}
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil, err
+	}
+	var out []Violation
+	for _, r := range reports {
+		if r.Type != "csp-violation" {
+			continue
+		}
+		out = append(out, Violation{
+			DocumentURI:        r.Body.DocumentURL,
+			Referrer:           r.Body.Referrer,
+			BlockedURI:         r.Body.BlockedURL,
+			EffectiveDirective: r.Body.EffectiveDirective,
+			Disposition:        r.Body.Disposition,
+			SourceFile:         r.Body.SourceFile,
+			LineNumber:         r.Body.LineNumber,
+			ColumnNumber:       r.Body.ColumnNumber,
+			Sample:             r.Body.Sample,
+		})
+	}
+	return out, nil
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. ANONYMOUS STRUCTS - decoding shapes used in one place only
+// 2. ONE TYPE, TWO WIRE FORMATS - both report formats become a Violation
+// 3. UNTRUSTED INPUT - size limits, and reports are only logged, never acted on
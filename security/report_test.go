@@ -0,0 +1,50 @@
+package security
+
+import (
+	"testing"
+)
+
+// The handler itself needs a request body, which only a real connection
+// carries - see TestCSPReport in e2e_test.go. Here: the two decoders
+
+func TestDecodeLegacy(t *testing.T) {
+	got, err := decodeLegacy([]byte(`{"csp-report": {
+		"document-uri": "https://cats.example.com/contact",
+		"blocked-uri": "inline",
+		"violated-directive": "script-src",
+		"disposition": "enforce",
+		"line-number": 12}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Violation{
+		DocumentURI:        "https://cats.example.com/contact",
+		BlockedURI:         "inline",
+		EffectiveDirective: "script-src", // filled from violated-directive
+		Disposition:        "enforce",
+		LineNumber:         12,
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("decoded %+v", got)
+	}
+
+	if _, err := decodeLegacy([]byte(`{not json`)); err == nil {
+		t.Error("malformed JSON should fail")
+	}
+}
+
+func TestDecodeReports(t *testing.T) {
+	got, err := decodeReports([]byte(`[
+		{"type": "deprecation", "body": {"id": "old-api"}},
+		{"type": "csp-violation", "body": {
+			"documentURL": "https://cats.example.com/",
+			"blockedURL": "https://evil.example/x.js",
+			"effectiveDirective": "script-src-elem",
+			"disposition": "report"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].BlockedURI != "https://evil.example/x.js" || got[0].Disposition != "report" {
+		t.Errorf("decoded %+v - other report types should be skipped", got)
+	}
+}
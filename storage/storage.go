@@ -0,0 +1,219 @@
+// Package storage is the upload subsystem: it saves uploaded files to a directory
+// under unguessable names and records metadata about each one.
+// Both the public /upload endpoint and the admin cat photo uploads go through it.
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SENTINEL ERRORS callers can check with errors.Is
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrTypeNotAllowed  = errors.New("file type is not allowed")
+	ErrInvalidFileName = errors.New("invalid file name")
+)
+
+// File describes a stored upload
+type File struct {
+	Name         string    `json:"name"`          // stored name, e.g. "3f9a...e1.jpg"
+	OriginalName string    `json:"original_name"` // what the user's file was called
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	URL          string    `json:"url"` // where the file can be fetched from
+	UploadedAt   time.Time `json:"uploaded_at"`
+}
+
+// Options control what a Store accepts
+type Options struct {
+	// MaxBytes is the largest file accepted; 0 means no limit
+	MaxBytes int64
+
+	// AllowedTypes lists accepted content types (e.g. "image/jpeg") or
+	// prefixes ending in "/" (e.g. "image/"); empty means anything goes
+	AllowedTypes []string
+}
+
+// Store saves files into Dir and serves them under URLPrefix
+type Store struct {
+	Dir       string
+	URLPrefix string // e.g. "/uploads/"
+	Options   Options
+}
+
+// New creates the directory if needed and returns a Store
+func New(dir, urlPrefix string, opts Options) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating upload dir %q: %w", dir, err)
+	}
+	if !strings.HasSuffix(urlPrefix, "/") {
+		urlPrefix += "/"
+	}
+	return &Store{Dir: dir, URLPrefix: urlPrefix, Options: opts}, nil
+}
+
+// WithOptions returns a copy of the store with different acceptance rules
+// The copy shares the directory, so e.g. photo uploads can be limited to images
+// while general uploads are not
+func (st *Store) WithOptions(opts Options) *Store {
+	cp := *st // DEREFERENCE to copy the struct
+	cp.Options = opts
+	return &cp
+}
+
+// Save streams r to a new file and returns its metadata
+// The content type is detected from the first bytes (never trusted from the client)
+func (st *Store) Save(originalName string, r io.Reader) (File, error) {
+	// SNIFF the first 512 bytes - that's all http.DetectContentType looks at
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return File{}, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !st.allowed(contentType) {
+		return File{}, fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+
+	name := newName() + extFor(originalName, contentType)
+	path := filepath.Join(st.Dir, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return File{}, err
+	}
+
+	// IO.MULTIREADER stitches the sniffed bytes back in front of the rest of the stream
+	src := io.MultiReader(strings.NewReader(string(head)), r)
+	if st.Options.MaxBytes > 0 {
+		// Read one byte past the limit so we can tell "exactly at the limit" from "over it"
+		src = io.LimitReader(src, st.Options.MaxBytes+1)
+	}
+
+	size, err := io.Copy(f, src)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && st.Options.MaxBytes > 0 && size > st.Options.MaxBytes {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		_ = os.Remove(path) // don't leave partial files behind
+		return File{}, err
+	}
+
+	return File{
+		Name:         name,
+		OriginalName: filepath.Base(originalName),
+		ContentType:  contentType,
+		Size:         size,
+		URL:          st.URLPrefix + name,
+		UploadedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// Open returns a stored file for reading, along with its detected content type
+func (st *Store) Open(name string) (io.ReadCloser, string, error) {
+	path, err := st.path(name)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, "", err
+	}
+	return f, http.DetectContentType(head[:n]), nil
+}
+
+// Delete removes a stored file; deleting a file that's already gone is not an error
+func (st *Store) Delete(name string) error {
+	path, err := st.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// NameFromURL extracts the stored name from a URL this store produced
+// ok is false for URLs that point somewhere else (e.g. an external photo)
+func (st *Store) NameFromURL(url string) (name string, ok bool) {
+	return strings.CutPrefix(url, st.URLPrefix)
+}
+
+// path resolves a stored name to a file path, refusing anything that could
+// escape the upload directory (PATH TRAVERSAL protection: "../../etc/passwd")
+func (st *Store) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrInvalidFileName
+	}
+	return filepath.Join(st.Dir, name), nil
+}
+
+func (st *Store) allowed(contentType string) bool {
+	if len(st.Options.AllowedTypes) == 0 {
+		return true
+	}
+	// DetectContentType may add parameters: "text/plain; charset=utf-8"
+	base, _, _ := strings.Cut(contentType, ";")
+	for _, t := range st.Options.AllowedTypes {
+		if base == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(base, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// extFor picks an extension from the detected type, falling back to the client's
+// extension only for types we don't recognise (so "cat.html" that is really a JPEG becomes .jpg)
+func extFor(originalName, contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/jpeg"):
+		return ".jpg"
+	case strings.HasPrefix(contentType, "image/png"):
+		return ".png"
+	case strings.HasPrefix(contentType, "image/gif"):
+		return ".gif"
+	case strings.HasPrefix(contentType, "image/webp"):
+		return ".webp"
+	case strings.HasPrefix(contentType, "text/plain"):
+		return ".txt"
+	}
+	if ext := strings.ToLower(filepath.Ext(originalName)); ext != "" && len(ext) <= 6 {
+		return ext
+	}
+	return ".bin"
+}
+
+func newName() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. STREAMING - io.Copy writes uploads to disk without holding them all in memory
+// 2. CONTENT SNIFFING - trust the bytes, not the client's claimed type
+// 3. PATH TRAVERSAL PROTECTION - stored names must be plain file names
+// 4. IO COMPOSITION - MultiReader and LimitReader wrap the source stream
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// PATH TRAVERSAL: only plain names of files in the directory resolve
+func TestStorePath(t *testing.T) {
+	st := &Store{Dir: filepath.Join("var", "uploads")}
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"3f2a9c.jpg", true},
+		{"notes.txt", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{".hidden", false},
+		{"../secret.txt", false},
+		{"../../etc/passwd", false},
+		{"sub/file.jpg", false},
+		{"/etc/passwd", false},
+		{"a/../b.jpg", false},
+	}
+	for _, tt := range tests {
+		path, err := st.path(tt.name)
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidFileName) {
+				t.Errorf("path(%q) = %q, %v; want ErrInvalidFileName", tt.name, path, err)
+			}
+			continue
+		}
+		if err != nil || path != filepath.Join(st.Dir, tt.name) {
+			t.Errorf("path(%q) = %q, %v", tt.name, path, err)
+		}
+	}
+}
+
+func TestStoreAllowed(t *testing.T) {
+	tests := []struct {
+		allowed     []string
+		contentType string
+		want        bool
+	}{
+		{nil, "application/x-msdownload", true}, // no list: anything goes
+		{[]string{"image/jpeg"}, "image/jpeg", true},
+		{[]string{"image/jpeg"}, "image/png", false},
+		{[]string{"image/"}, "image/png", true}, // a prefix ending in "/"
+		{[]string{"image/"}, "text/html; charset=utf-8", false},
+		{[]string{"image"}, "image/png", false}, // without the "/" it's a whole type
+		{[]string{"text/plain"}, "text/plain; charset=utf-8", true},
+		{[]string{"text/plain"}, "text/plainx", false},
+		{[]string{"image/png", "text/plain"}, "text/plain; charset=utf-8", true},
+	}
+	for _, tt := range tests {
+		st := &Store{Options: Options{AllowedTypes: tt.allowed}}
+		if got := st.allowed(tt.contentType); got != tt.want {
+			t.Errorf("allowed %v, %q = %v, want %v", tt.allowed, tt.contentType, got, tt.want)
+		}
+	}
+}
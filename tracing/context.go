@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// TraceID names one whole trace: every span of one request, across services
+type TraceID [16]byte
+
+// SpanID names one span within a trace
+type SpanID [8]byte
+
+// IsValid reports whether the ID is set; all zeros means "none"
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+// String is the lowercase hex form used in headers and exports
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span that travels between services:
+// which trace it belongs to, which span is the parent, and whether it's recorded
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// HeaderTraceparent is the W3C Trace Context header
+const HeaderTraceparent = "traceparent"
+
+// Traceparent formats sc for the traceparent header of an outgoing request:
+//
+//	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+//	version-trace ID-parent span ID-flags (01 = sampled)
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent reads an incoming traceparent header
+// ok is false for anything malformed, in which case the request starts a new trace
+func ParseTraceparent(h string) (sc SpanContext, ok bool) {
+	h = strings.TrimSpace(h)
+	// 2 + 1 + 32 + 1 + 16 + 1 + 2 = 55 characters for version 00
+	if len(h) < 55 || h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return SpanContext{}, false
+	}
+	version := h[:2]
+	switch {
+	case version == "ff" || !isLowerHex(version):
+		return SpanContext{}, false // ff is forbidden by the spec
+	case version == "00" && len(h) != 55:
+		return SpanContext{}, false
+	case version != "00" && len(h) > 55 && h[55] != '-':
+		return SpanContext{}, false // later versions may only append fields
+	}
+
+	if !decodeHex(sc.TraceID[:], h[3:35]) || !decodeHex(sc.SpanID[:], h[36:52]) || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], h[53:55]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	return sc, true
+}
+
+// decodeHex fills dst from lowercase hex s (uppercase is invalid in traceparent)
+func decodeHex(dst []byte, s string) bool {
+	if !isLowerHex(s) {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func isLowerHex(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f')
+	}) < 0
+}
+
+// newTraceID and newSpanID return random, non-zero IDs
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. FIXED-SIZE ARRAYS - [16]byte IDs are comparable values, no allocation
+// 2. W3C TRACE CONTEXT - the traceparent header links spans across services
+// 3. DEFENSIVE PARSING - a malformed header starts a new trace rather than failing
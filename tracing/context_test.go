@@ -0,0 +1,44 @@
+package tracing
+
+import "testing"
+
+func TestParseTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, ok := ParseTraceparent(valid)
+	if !ok || !sc.Sampled {
+		t.Fatalf("ParseTraceparent(%q) = %+v, %v", valid, sc, ok)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("IDs %s %s", sc.TraceID, sc.SpanID)
+	}
+	// ROUND TRIP: formatting gives back the same header
+	if got := sc.Traceparent(); got != valid {
+		t.Errorf("Traceparent() = %q", got)
+	}
+
+	if sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"); !ok || sc.Sampled {
+		t.Errorf("flags 00 should parse as not sampled: %+v, %v", sc, ok)
+	}
+	// A future version may append fields
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Error("later versions with extra fields should parse")
+	}
+
+	for _, bad := range []string{
+		"",
+		"garbage",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",      // forbidden version
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",      // zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",      // zero span ID
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",      // uppercase
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x",    // version 00 has no extra fields
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",      // not hex
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",      // wrong separators
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01extra", // extra fields need a dash
+	} {
+		if sc, ok := ParseTraceparent(bad); ok {
+			t.Errorf("ParseTraceparent(%q) = %+v, want rejection", bad, sc)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter sends finished spans somewhere: the console, a collector, a test
+type Exporter interface {
+	Export(spans []SpanData) error
+}
+
+// ===== STDOUT =====
+
+// StdoutExporter writes each span as one JSON line - handy in development
+type StdoutExporter struct {
+	W io.Writer // usually os.Stdout
+
+	mu sync.Mutex
+}
+
+// stdoutSpan is the JSON layout of one span line
+type stdoutSpan struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_span_id,omitempty"`
+	Name       string         `json:"name"`
+	Start      time.Time      `json:"start"`
+	DurationMS float64        `json:"duration_ms"`
+	Attrs      map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+func (e *StdoutExporter) Export(spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	enc := json.NewEncoder(e.W) // Encode adds the newline
+	for _, s := range spans {
+		line := stdoutSpan{
+			TraceID:    s.TraceID.String(),
+			SpanID:     s.SpanID.String(),
+			Name:       s.Name,
+			Start:      s.Start,
+			DurationMS: float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			Error:      s.Err,
+		}
+		if s.ParentSpanID.IsValid() {
+			line.ParentID = s.ParentSpanID.String()
+		}
+		if len(s.Attrs) > 0 {
+			line.Attrs = map[string]any{}
+			for _, a := range s.Attrs {
+				line.Attrs[a.Key] = a.Value
+			}
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ===== OTLP OVER HTTP =====
+
+// OTLPExporter POSTs spans to an OpenTelemetry collector using OTLP/HTTP with
+// JSON encoding - the collector's default port is 4318:
+//
+//	http://localhost:4318/v1/traces
+//
+// Wrap it in a Batcher: one HTTP request per span would be far too many
+type OTLPExporter struct {
+	Endpoint    string            // full URL, ending in /v1/traces
+	ServiceName string            // how this service is named in the trace viewer
+	Headers     map[string]string // e.g. an API key for a hosted collector
+	Client      *http.Client      // nil = a client with a 10 second timeout
+}
+
+// ErrNoEndpoint is returned by an OTLPExporter without an Endpoint
+var ErrNoEndpoint = errors.New("tracing: OTLP exporter has no endpoint")
+
+func (e *OTLPExporter) Export(spans []SpanData) error {
+	if e.Endpoint == "" {
+		return ErrNoEndpoint
+	}
+	body, err := json.Marshal(otlpRequest(e.ServiceName, spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body) // read to the end so the connection can be reused
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("tracing: collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// The OTLP JSON types below mirror the protobuf messages of the OTLP spec,
+// trimmed to the fields we fill in. IDs are hex strings and 64-bit
+// timestamps are decimal strings, as the spec's JSON mapping requires.
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0 unset, 1 ok, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is a tagged union: exactly one field is set
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 travels as a string
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// otlpRequest builds the request body for one batch of spans
+func otlpRequest(service string, spans []SpanData) otlpTraces {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: 1},
+		}
+		if s.ParentSpanID.IsValid() {
+			span.ParentSpanID = s.ParentSpanID.String()
+		}
+		if s.Err != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.Err}
+		}
+		for _, a := range s.Attrs {
+			span.Attributes = append(span.Attributes, otlpKeyValue{Key: a.Key, Value: otlpValueOf(a.Value)})
+		}
+		out = append(out, span)
+	}
+
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpValueOf(service)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "form_exer/tracing"}, Spans: out}},
+	}}}
+}
+
+// otlpValueOf picks the union field for v's type; anything else becomes a string
+// TYPE SWITCH: each case binds v to that case's concrete type
+func otlpValueOf(v any) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// ===== BATCHING =====
+
+// Batcher collects spans and hands them to another exporter in batches, from a
+// background goroutine, so requests never wait on the network
+//
+// A batch is sent when it reaches MaxBatch spans or every Interval, whichever
+// comes first. If the collector falls far behind, new spans are dropped rather
+// than piling up in memory.
+type Batcher struct {
+	next     Exporter
+	maxBatch int
+	maxQueue int
+
+	mu      sync.Mutex
+	queue   []SpanData
+	dropped int
+
+	wake chan struct{} // a full batch is waiting
+	stop chan struct{} // Shutdown was called
+	done chan struct{} // the goroutine has finished
+}
+
+// NewBatcher starts a batcher in front of next
+func NewBatcher(next Exporter, maxBatch int, interval time.Duration) *Batcher {
+	b := &Batcher{
+		next:     next,
+		maxBatch: maxBatch,
+		maxQueue: maxBatch * 16,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.loop(interval)
+	return b
+}
+
+// Export queues spans; it never blocks on the network
+func (b *Batcher) Export(spans []SpanData) error {
+	b.mu.Lock()
+	room := b.maxQueue - len(b.queue)
+	if len(spans) > room {
+		b.dropped += len(spans) - room
+		spans = spans[:max(room, 0)]
+	}
+	b.queue = append(b.queue, spans...)
+	full := len(b.queue) >= b.maxBatch
+	b.mu.Unlock()
+
+	if full {
+		// NON-BLOCKING SEND: if a wake-up is already pending, one is enough
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// loop sends batches until Shutdown
+func (b *Batcher) loop(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.wake:
+		case <-b.stop:
+			b.flush()
+			return
+		}
+		b.flush()
+	}
+}
+
+// flush sends everything queued, MaxBatch spans at a time
+func (b *Batcher) flush() {
+	for {
+		b.mu.Lock()
+		n := min(len(b.queue), b.maxBatch)
+		batch := b.queue[:n:n] // full slice expression: appends can't reach the rest of the queue
+		b.queue = b.queue[n:]
+		dropped := b.dropped
+		b.dropped = 0
+		b.mu.Unlock()
+
+		if dropped > 0 {
+			slog.Warn("tracing queue full, spans dropped", "dropped", dropped)
+		}
+		if n == 0 {
+			return
+		}
+		if err := b.next.Export(batch); err != nil {
+			slog.Warn("exporting spans failed", "spans", n, "err", err)
+		}
+	}
+}
+
+// Shutdown sends whatever is still queued and stops the goroutine
+// Call it before the program exits, or the last spans are lost
+func (b *Batcher) Shutdown() {
+	close(b.stop)
+	<-b.done
+}
+
+// ===== IN MEMORY =====
+
+// Recorder keeps spans in memory, for tests
+type Recorder struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (r *Recorder) Export(spans []SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+// Spans returns a copy of every span recorded so far, in the order they ended
+func (r *Recorder) Spans() []SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SpanData(nil), r.spans...)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. INTERFACES - console, collector, batcher and test recorder are all Exporters
+// 2. DECORATOR - Batcher wraps another Exporter and adds batching
+// 3. BACKPRESSURE - a bounded queue drops spans instead of growing without limit
+// 4. SELECT LOOPS - one goroutine waits on a ticker, a wake-up and a stop signal
+// 5. JSON MAPPING OF A PROTOCOL - Go structs shaped like the OTLP messages
@@ -0,0 +1,200 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectorStub is a local stand-in for an OpenTelemetry collector:
+// it decodes every OTLP/HTTP JSON request it receives
+type collectorStub struct {
+	mu       sync.Mutex
+	requests []otlpTraces
+	headers  []http.Header
+}
+
+func (c *collectorStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var body otlpTraces
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, body)
+	c.headers = append(c.headers, r.Header.Clone())
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *collectorStub) spans() []otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []otlpSpan
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				out = append(out, ss.Spans...)
+			}
+		}
+	}
+	return out
+}
+
+func sampleSpan(name string, err string) SpanData {
+	start := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	return SpanData{
+		TraceID:      TraceID{0x4b, 0xf9, 15: 0x36},
+		SpanID:       SpanID{0x00, 0xf0, 7: 0xb7},
+		ParentSpanID: SpanID{1, 7: 2},
+		Name:         name,
+		Kind:         KindServer,
+		Start:        start,
+		End:          start.Add(1500 * time.Microsecond),
+		Attrs:        []Attr{{"http.method", "GET"}, {"http.status_code", 200}, {"cached", true}},
+		Err:          err,
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	stub := &collectorStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	exp := &OTLPExporter{Endpoint: srv.URL + "/v1/traces", ServiceName: "cats", Headers: map[string]string{"X-Api-Key": "k"}}
+	if err := exp.Export([]SpanData{sampleSpan("GET /cats", ""), sampleSpan("mail.send", "timeout")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stub.requests) != 1 {
+		t.Fatalf("collector got %d requests", len(stub.requests))
+	}
+	if got := stub.headers[0].Get("X-Api-Key"); got != "k" {
+		t.Errorf("X-Api-Key %q", got)
+	}
+	resource := stub.requests[0].ResourceSpans[0].Resource.Attributes[0]
+	if resource.Key != "service.name" || *resource.Value.StringValue != "cats" {
+		t.Errorf("resource %+v", resource)
+	}
+
+	spans := stub.spans()
+	s := spans[0]
+	if s.TraceID != "4bf90000000000000000000000000036" || s.SpanID != "00f00000000000b7" || s.ParentSpanID != "0100000000000002" {
+		t.Errorf("IDs must be hex: %+v", s)
+	}
+	if s.StartTimeUnixNano != "1741944600000000000" || s.EndTimeUnixNano != "1741944600001500000" {
+		t.Errorf("times %s %s", s.StartTimeUnixNano, s.EndTimeUnixNano)
+	}
+	if s.Status.Code != 1 || spans[1].Status.Code != 2 || spans[1].Status.Message != "timeout" {
+		t.Errorf("statuses %+v %+v", s.Status, spans[1].Status)
+	}
+	if a := s.Attributes[1]; a.Key != "http.status_code" || a.Value.IntValue == nil || *a.Value.IntValue != "200" {
+		t.Errorf("int attribute %+v", a)
+	}
+	if a := s.Attributes[2]; a.Value.BoolValue == nil || !*a.Value.BoolValue {
+		t.Errorf("bool attribute %+v", a)
+	}
+}
+
+func TestOTLPExporterCollectorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := (&OTLPExporter{Endpoint: srv.URL}).Export([]SpanData{sampleSpan("x", "")})
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("error %v, want the collector's 503", err)
+	}
+	if err := (&OTLPExporter{}).Export(nil); !errors.Is(err, ErrNoEndpoint) {
+		t.Errorf("no endpoint: %v", err)
+	}
+}
+
+// countingExporter counts batches and spans
+type countingExporter struct {
+	mu             sync.Mutex
+	batches, spans int
+}
+
+func (c *countingExporter) Export(spans []SpanData) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches++
+	c.spans += len(spans)
+	return nil
+}
+
+func TestBatcher(t *testing.T) {
+	next := &countingExporter{}
+	// A long interval: only a full batch or Shutdown sends anything
+	b := NewBatcher(next, 3, time.Hour)
+
+	for i := 0; i < 7; i++ {
+		b.Export([]SpanData{sampleSpan("s", "")})
+	}
+	b.Shutdown()
+
+	if next.spans != 7 {
+		t.Errorf("exported %d spans, want 7", next.spans)
+	}
+	// 7 spans in batches of at most 3 is at least 3 batches
+	if next.batches < 3 {
+		t.Errorf("%d batches, want at least 3", next.batches)
+	}
+}
+
+// A collector that can't keep up costs spans, not memory
+func TestBatcherDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	next := &blockingExporter{release: block}
+	b := NewBatcher(next, 1, time.Hour) // queue holds 16 spans
+
+	for i := 0; i < 100; i++ {
+		b.Export([]SpanData{sampleSpan("s", "")})
+	}
+	close(block)
+	b.Shutdown()
+
+	if next.spans > 17 { // 16 queued plus at most one already being sent
+		t.Errorf("exported %d spans; the queue should have been bounded", next.spans)
+	}
+}
+
+type blockingExporter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	spans   int
+}
+
+func (e *blockingExporter) Export(spans []SpanData) error {
+	<-e.release
+	e.mu.Lock()
+	e.spans += len(spans)
+	e.mu.Unlock()
+	return nil
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&StdoutExporter{W: &buf}).Export([]SpanData{sampleSpan("GET /cats", "")}); err != nil {
+		t.Fatal(err)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["name"] != "GET /cats" || line["duration_ms"] != 1.5 || line["trace_id"] != "4bf90000000000000000000000000036" {
+		t.Errorf("line %v", line)
+	}
+}
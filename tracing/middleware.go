@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/forms"
+	"form_exer/metrics"
+)
+
+// spanCtxKey is the request-scoped storage key holding the request's span
+const spanCtxKey = "tracing.span"
+
+// Middleware opens a SERVER span for every request
+//
+// If the request carries a valid traceparent header, the span joins that trace,
+// so this service's work appears inside the caller's trace. Otherwise a new
+// trace begins here.
+func Middleware(t *Tracer) rweb.Handler {
+	return func(ctx rweb.Context) error {
+		req := ctx.Request()
+		parent, _ := ParseTraceparent(forms.Header(req, HeaderTraceparent))
+
+		// strings.Clone: the method and path point into rweb's reusable request buffer
+		method := strings.Clone(req.Method())
+		span := t.Start(parent, method, KindServer)
+		span.SetAttr("http.method", method)
+		span.SetAttr("http.target", strings.Clone(req.Path()))
+		ctx.Set(spanCtxKey, span)
+
+		err := ctx.Next()
+
+		status := ctx.Response().Status()
+		if err != nil && (status == 0 || status == http.StatusOK) {
+			status = http.StatusInternalServerError // what rweb's error handler will send
+		}
+		// Name the span after the ROUTE PATTERN once routing has happened:
+		// "GET /cats/:slug" groups every cat page together in the trace viewer
+		if route := metrics.RoutePattern(ctx); route != "" {
+			span.SetName(method + " " + route)
+			span.SetAttr("http.route", route)
+		}
+		span.SetAttr("http.status_code", status)
+		span.RecordError(err)
+		if err == nil && status >= 500 {
+			span.RecordError(httpError(status))
+		}
+		span.End()
+		return err
+	}
+}
+
+// httpError describes a 5xx status as an error for the span
+type httpError int
+
+func (e httpError) Error() string {
+	return "HTTP " + http.StatusText(int(e))
+}
+
+// FromContext returns the request's span, or nil when tracing is off
+func FromContext(ctx rweb.Context) *Span {
+	span, _ := ctx.Get(spanCtxKey).(*Span)
+	return span
+}
+
+// Start begins a child of the request's span; remember to End it
+// With tracing off it returns nil, which every *Span method accepts
+func Start(ctx rweb.Context, name string) *Span {
+	return FromContext(ctx).StartChild(name)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. CONTEXT PROPAGATION - traceparent in, the same trace ID out to the collector
+// 2. AFTER-THE-FACT NAMING - the route is only known once the handler has run
+// 3. NAMED TYPES AS ERRORS - httpError is an int with an Error method
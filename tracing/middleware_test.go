@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/metrics"
+)
+
+// newTracedServer serves /cats/:slug (with a child span) and /fail behind Middleware
+func newTracedServer(rec *Recorder) *rweb.Server {
+	s := rweb.NewServer()
+	s.Use(Middleware(NewTracer(rec)))
+	s.Get("/cats/:slug", metrics.Route("/cats/:slug", func(ctx rweb.Context) error {
+		span := Start(ctx, "render")
+		span.SetAttr("page", "cat")
+		span.End()
+		return ctx.WriteString("a cat")
+	}))
+	s.Get("/fail", func(ctx rweb.Context) error {
+		return errors.New("disk full")
+	})
+	return s
+}
+
+// byName indexes spans by name
+func byName(spans []SpanData) map[string]SpanData {
+	m := map[string]SpanData{}
+	for _, s := range spans {
+		m[s.Name] = s
+	}
+	return m
+}
+
+func TestMiddlewareStartsTrace(t *testing.T) {
+	rec := &Recorder{}
+	s := newTracedServer(rec)
+
+	s.Request(http.MethodGet, "/cats/luna", nil, nil)
+
+	spans := byName(rec.Spans())
+	server, ok := spans["GET /cats/:slug"]
+	if !ok {
+		t.Fatalf("no span named after the route: %+v", rec.Spans())
+	}
+	render := spans["render"]
+	if server.ParentSpanID.IsValid() || server.Kind != KindServer {
+		t.Errorf("request span should be a root server span: %+v", server)
+	}
+	if render.TraceID != server.TraceID || render.ParentSpanID != server.SpanID {
+		t.Errorf("render span is not a child of the request span:\n%+v\n%+v", render, server)
+	}
+}
+
+func TestMiddlewareJoinsIncomingTrace(t *testing.T) {
+	rec := &Recorder{}
+	s := newTracedServer(rec)
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	s.Request(http.MethodGet, "/cats/luna", []rweb.Header{{Key: "Traceparent", Value: parent}}, nil)
+
+	server := byName(rec.Spans())["GET /cats/:slug"]
+	if server.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("span did not join the caller's trace: %+v", server)
+	}
+}
+
+// The caller decided not to record this trace, so nothing is exported
+func TestMiddlewareHonoursUnsampled(t *testing.T) {
+	rec := &Recorder{}
+	s := newTracedServer(rec)
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+	s.Request(http.MethodGet, "/cats/luna", []rweb.Header{{Key: "traceparent", Value: parent}}, nil)
+
+	if spans := rec.Spans(); len(spans) != 0 {
+		t.Errorf("exported %d spans of an unsampled trace", len(spans))
+	}
+}
+
+func TestMiddlewareRecordsErrors(t *testing.T) {
+	rec := &Recorder{}
+	s := newTracedServer(rec)
+
+	s.Request(http.MethodGet, "/fail", nil, nil)
+
+	spans := rec.Spans()
+	if len(spans) != 1 || spans[0].Err != "disk full" {
+		t.Fatalf("spans %+v", spans)
+	}
+	// No route pattern was recorded, so the span keeps the method as its name
+	if spans[0].Name != "GET" {
+		t.Errorf("name %q", spans[0].Name)
+	}
+}
+
+// With tracing off every call is a harmless no-op
+func TestNilSpan(t *testing.T) {
+	var span *Span
+	child := span.StartChild("x")
+	child.SetAttr("k", "v")
+	child.SetName("y")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	if child != nil || child.SpanContext().IsValid() {
+		t.Error("a nil span's child should be nil")
+	}
+}
+
+func TestEndExportsOnce(t *testing.T) {
+	rec := &Recorder{}
+	span := NewTracer(rec).Start(SpanContext{}, "job", KindInternal)
+	span.End()
+	span.End()
+	if n := len(rec.Spans()); n != 1 {
+		t.Errorf("exported %d times", n)
+	}
+}
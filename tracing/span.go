@@ -0,0 +1,196 @@
+// Package tracing records what happened during a request as a tree of timed
+// SPANS, in the style of OpenTelemetry, and sends them to a collector.
+//
+// Middleware opens a span for every request, continuing the caller's trace when
+// the request carries a W3C traceparent header. Work done on the request's behalf
+// (writing an upload, sending mail, rendering a page) opens CHILD spans:
+//
+//	span := tracing.Start(ctx, "mail.send")
+//	err := mailer.Send(msg)
+//	span.RecordError(err)
+//	span.End()
+//
+// A trace viewer then shows the request as a timeline: how long each step took,
+// which one failed, and how this service's part fits into a larger trace.
+//
+// Work that outlives the request (a background job) carries its span in a
+// context.Context instead - see ContextWithSpan and SpanFromContext.
+//
+// Every *Span method works on a nil span and does nothing, so code can always
+// call tracing.Start even when tracing is turned off.
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SpanKind says which side of a conversation a span is (values match OTLP)
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1 // work inside the service
+	KindServer   SpanKind = 2 // handling an incoming request
+	KindClient   SpanKind = 3 // making an outgoing request
+)
+
+// Attr is one key/value detail attached to a span, e.g. http.status_code=200
+type Attr struct {
+	Key   string
+	Value any // string, bool, int, int64 or float64
+}
+
+// SpanData is a finished span, as handed to an Exporter
+type SpanData struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID // zero for the root span of the trace (in this service)
+	Name         string
+	Kind         SpanKind
+	Start, End   time.Time
+	Attrs        []Attr
+	Err          string // "" = the operation succeeded
+}
+
+// Tracer creates spans and passes the finished ones to its Exporter
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer returns a tracer exporting to exp
+func NewTracer(exp Exporter) *Tracer {
+	return &Tracer{exporter: exp}
+}
+
+// Start begins a span; with a valid parent it joins the parent's trace,
+// otherwise it starts a new, sampled trace
+func (t *Tracer) Start(parent SpanContext, name string, kind SpanKind) *Span {
+	if t == nil {
+		return nil
+	}
+	sc := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		sc.TraceID, sc.Sampled = newTraceID(), true
+	}
+	return &Span{
+		tracer: t,
+		sc:     sc,
+		data: SpanData{
+			TraceID:      sc.TraceID,
+			SpanID:       sc.SpanID,
+			ParentSpanID: parent.SpanID,
+			Name:         name,
+			Kind:         kind,
+			Start:        time.Now(),
+		},
+	}
+}
+
+// Span is one timed operation in progress
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	// A span may be touched from several goroutines (a handler and a job it started)
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext identifies the span, e.g. for an outgoing traceparent header
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// StartChild begins a span for a step of this one
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.Start(s.sc, name, KindInternal)
+}
+
+// SetName renames the span, e.g. once the matched route is known
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttr attaches a detail to the span
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.ended { // the exported copy shares the Attrs array
+		s.data.Attrs = append(s.data.Attrs, Attr{Key: key, Value: value})
+	}
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed; a nil err is ignored, so the result
+// of an operation can be passed straight in
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Err = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and exports it (if its trace is sampled)
+// Calling End again does nothing
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if !s.sc.Sampled || s.tracer.exporter == nil {
+		return
+	}
+	// A failing exporter must never fail the request - note it and carry on
+	if err := s.tracer.exporter.Export([]SpanData{data}); err != nil {
+		slog.Warn("exporting span failed", "span", data.Name, "err", err)
+	}
+}
+
+// ===== SPANS IN A context.Context (for background jobs) =====
+
+// spanKey is an UNEXPORTED KEY TYPE: no other package can collide with it
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. NIL RECEIVERS - every Span method is safe on a nil span, so tracing is optional
+// 2. SPAN TREES - children share the trace ID and point at their parent
+// 3. CONTEXT VALUES - an unexported key type carries the span through context.Context
+// 4. IDEMPOTENT END - finishing twice exports once
@@ -0,0 +1,79 @@
+// Package cookie reads and writes HTTP cookies on rweb requests.
+// rweb has no cookie API of its own, so this package bridges to net/http's
+// well-tested parsing (http.ParseCookie) and formatting (http.Cookie.String).
+package cookie
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// Get returns the value of the named cookie, or "" when the request doesn't carry it
+// The value is copied, so it is safe to keep after the request ends
+func Get(req rweb.ItfRequest, name string) string {
+	for _, h := range req.Headers() {
+		// HEADER NAMES ARE CASE-INSENSITIVE: "cookie" and "Cookie" are the same header
+		if !strings.EqualFold(h.Key, "Cookie") {
+			continue
+		}
+		cookies, err := http.ParseCookie(h.Value)
+		if err != nil {
+			continue // a malformed header is treated like a missing cookie
+		}
+		for _, c := range cookies {
+			if c.Name == name {
+				return strings.Clone(c.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// Set adds a Set-Cookie header to the response
+// Path defaults to "/" and SameSite to Lax, which suit site-wide preference cookies
+//
+// RWEB WORKAROUND: rweb's SetHeader replaces a header with the same name and
+// there is no way to add a second one, so the theme and language cookies of
+// one response used to overwrite each other. rweb writes header values as they
+// are, though: a later cookie rides along on the first header, after a line
+// break of its own ("theme=dark\r\nSet-Cookie: lang=es"), and the browser gets
+// both. That line break is the only one allowed: a cookie that doesn't pass
+// http.Cookie.Valid, or whose formatted line still holds a CR or LF, is
+// refused with an error and nothing is sent, so no caller's value can add
+// headers of its own.
+func Set(ctx rweb.Context, c http.Cookie) error {
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	// VALIDATE, DON'T SANITIZE: String would quietly drop bad bytes and send
+	// a different cookie from the one asked for
+	if err := c.Valid(); err != nil {
+		return err
+	}
+	line := c.String()
+	if line == "" || strings.ContainsAny(line, "\r\n") {
+		return ErrInvalid
+	}
+	resp := ctx.Response()
+	if prev := resp.Header("Set-Cookie"); prev != "" {
+		line = prev + "\r\nSet-Cookie: " + line
+	}
+	resp.SetHeader("Set-Cookie", line)
+	return nil
+}
+
+// ErrInvalid is returned by Set for a cookie that can't be sent safely
+var ErrInvalid = errors.New("cookie: invalid cookie")
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. REUSING THE STANDARD LIBRARY - net/http parses and formats cookies for us
+// 2. VALUE PARAMETERS - Set receives a copy of the cookie, so defaults don't leak to the caller
+// 3. DEFENSIVE COPYING - strings.Clone detaches the value from the request buffer
+// 4. WORKING AROUND A LIBRARY LIMITATION - several cookies through one header,
+//    with every value validated so only Set itself can break a line
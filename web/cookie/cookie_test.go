@@ -0,0 +1,53 @@
+package cookie
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rohanthewiz/rweb"
+)
+
+// set runs Set for each cookie in one request and returns the Set-Cookie
+// header and Set's errors
+func set(t *testing.T, cookies ...http.Cookie) (header string, errs []error) {
+	t.Helper()
+	s := rweb.NewServer()
+	s.Get("/", func(ctx rweb.Context) error {
+		for _, c := range cookies {
+			errs = append(errs, Set(ctx, c))
+		}
+		return ctx.WriteText("ok")
+	})
+	return s.Request(http.MethodGet, "/", nil, nil).Header("Set-Cookie"), errs
+}
+
+func TestSetTwo(t *testing.T) {
+	header, errs := set(t, http.Cookie{Name: "theme", Value: "dark"}, http.Cookie{Name: "lang", Value: "es"})
+	if errors.Join(errs...) != nil {
+		t.Fatal(errs)
+	}
+	want := "theme=dark; Path=/; SameSite=Lax\r\nSet-Cookie: lang=es; Path=/; SameSite=Lax"
+	if header != want {
+		t.Errorf("Set-Cookie %q, want %q", header, want)
+	}
+}
+
+// HEADER INJECTION: the only line break is the one Set adds between cookies
+func TestSetRejectsLineBreaks(t *testing.T) {
+	for _, c := range []http.Cookie{
+		{Name: "lang", Value: "es\r\nX-Injected: 1"},
+		{Name: "lang\r\nX-Injected: 1", Value: "es"},
+		{Name: "lang", Value: "es", Path: "/\nX-Injected: 1"},
+		{Name: "", Value: "es"},
+	} {
+		header, errs := set(t, http.Cookie{Name: "theme", Value: "dark"}, c)
+		if errs[0] != nil || errs[1] == nil {
+			t.Errorf("%q=%q: errors %v, want only the second cookie refused", c.Name, c.Value, errs)
+		}
+		if strings.Contains(header, "Injected") || strings.Contains(header, "\n") {
+			t.Errorf("%q=%q: Set-Cookie %q", c.Name, c.Value, header)
+		}
+	}
+}
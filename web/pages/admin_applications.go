@@ -0,0 +1,321 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the staff pages for reviewing adoption applications.
+package pages
+
+import (
+	"html"
+	"strconv"
+
+	"form_exer/adoption"
+	"form_exer/assets"
+	"form_exer/cats"
+	"form_exer/events"
+	"form_exer/i18n"
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// AdminApplicationsPath is the staff list of applications
+const AdminApplicationsPath = "/admin/applications"
+
+// AdminApplicationPath is the staff page for one application: its answers,
+// its history and the status form
+func AdminApplicationPath(id string) string {
+	return AdminApplicationsPath + "/" + id
+}
+
+// AdminApplicationStatusPath is where a status change for one application is posted
+func AdminApplicationStatusPath(id string) string {
+	return AdminApplicationPath(id) + "/status"
+}
+
+// AdminApplicationsPage lists applications; each links to its review page
+type AdminApplicationsPage struct {
+	shared.Page
+	Apps   []adoption.Application
+	Filter adoption.Status // "" shows everything
+}
+
+// AdminApplications is the base instance; handlers copy it and fill in Apps
+var AdminApplications = AdminApplicationsPage{
+	Page: shared.Page{Title: "Adoption Applications"},
+}
+
+func (p AdminApplicationsPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html().R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel").R(
+				AdminNav{}.Render(b),
+				LiveEvents{}.Render(b),
+				StatusFilter{Current: p.Filter}.Render(b),
+				b.Wrap(func() {
+					if len(p.Apps) == 0 {
+						b.P("class", "note").T("No applications to show.")
+						return
+					}
+					b.Table("class", "data-table").R(
+						b.THead().R(
+							b.Tr().R(
+								b.Th().T("Applicant"), b.Th().T("Cat"), b.Th().T("Status"), b.Th().T("Updated"),
+							),
+						),
+						b.TBody().R(
+							b.Wrap(func() {
+								for _, a := range p.Apps {
+									ApplicationRow{App: a}.Render(b)
+								}
+							}),
+						),
+					)
+				}),
+			),
+			element.RenderComponents(b, p.Footer()),
+			// LIVE UPDATES: the list below the nav fills in as things happen
+			b.Script("src", assets.LiveScript, "defer", "defer").R(),
+		),
+	)
+	return b.String()
+}
+
+// LiveEvents is the list of live notifications (see assets/js/live.js)
+// It stays hidden until the first one arrives, and is never shown without
+// the script; aria-live makes screen readers announce each new entry
+type LiveEvents struct{}
+
+func (LiveEvents) Render(b *element.Builder) (dontCare any) {
+	b.Ul("class", "live-events", "data-events", events.Path, "aria-live", "polite", "hidden", "hidden").R()
+	return
+}
+
+// StatusFilter is a row of links, one per status, to narrow the list
+// Plain links (GET with a query string) keep the filtered view bookmarkable
+type StatusFilter struct {
+	Current adoption.Status
+}
+
+func (sf StatusFilter) Render(b *element.Builder) (dontCare any) {
+	link := func(label string, status adoption.Status) {
+		href := AdminApplicationsPath
+		if status != "" {
+			href += "?status=" + string(status)
+		}
+		attrs := []string{"href", href}
+		if status == sf.Current {
+			attrs = append(attrs, "class", "current")
+		}
+		b.A(attrs...).T(label)
+	}
+
+	b.P("class", "filter-links").R(
+		b.Wrap(func() {
+			link("All", "")
+			for _, s := range adoption.AllStatuses {
+				link(s.Label(), s)
+			}
+		}),
+	)
+	return
+}
+
+// ApplicationRow is one line of the admin table
+// The applicant's name opens the application: a decision needs the answers,
+// so the status form lives there rather than here
+type ApplicationRow struct {
+	App adoption.Application
+}
+
+func (r ApplicationRow) Render(b *element.Builder) (dontCare any) {
+	a := r.App
+
+	name := a.Applicant.Name
+	if name == "" {
+		name = "(no name yet)" // a draft that stopped before step 1
+	}
+	b.Tr().R(
+		b.Td().R(
+			b.A("href", AdminApplicationPath(a.ID)).R(b.Strong().T(html.EscapeString(name))),
+			b.Br(),
+			b.Small().T(html.EscapeString(a.Applicant.Email)),
+		),
+		b.Td().R(b.A("href", CatDetailPath(a.CatSlug)).T(html.EscapeString(a.CatSlug))),
+		b.Td().T(a.Status.Label()),
+		b.Td().T(a.UpdatedAt.Format("2006-01-02 15:04")),
+	)
+	return
+}
+
+// AdminApplicationPage is one application as staff review it: every answer,
+// the history of its status, and the form that moves it on
+type AdminApplicationPage struct {
+	shared.Page
+	App   adoption.Application
+	Cat   cats.Cat
+	Error string // e.g. a rejected transition
+}
+
+func NewAdminApplicationPage(app adoption.Application, cat cats.Cat) AdminApplicationPage {
+	return AdminApplicationPage{
+		Page: shared.Page{Title: "Application for " + html.EscapeString(cat.Name)},
+		App:  app,
+		Cat:  cat,
+	}
+}
+
+func (p AdminApplicationPage) Render() (out string) {
+	b := element.NewBuilder()
+	a := p.App
+
+	b.Html().R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel panel-medium").R(
+				AdminNav{}.Render(b),
+				b.P().R(b.A("href", AdminApplicationsPath).T("&larr; All applications")),
+				b.Wrap(func() {
+					if p.Error != "" {
+						b.P("class", "alert alert-error").T(html.EscapeString(p.Error))
+					}
+				}),
+				b.H2().T(html.EscapeString(a.Applicant.Name), " &ndash; ", a.Status.Label()),
+				b.P("class", "muted").R(
+					b.T("For "),
+					b.A("href", CatDetailPath(p.Cat.Slug)).T(html.EscapeString(p.Cat.Name)),
+					b.T(", started ", a.CreatedAt.Format("2006-01-02 15:04")),
+				),
+				ApplicationStatusForm{App: a}.Render(b),
+				ApplicationAnswers{App: a, Tr: p.I18n}.Render(b),
+				ApplicationHistory{History: a.History}.Render(b),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// ApplicationStatusForm offers the moves staff may make from the current status
+type ApplicationStatusForm struct {
+	App adoption.Application
+}
+
+func (sf ApplicationStatusForm) Render(b *element.Builder) (dontCare any) {
+	next := sf.App.Status.StaffNext()
+	if len(next) == 0 {
+		b.P("class", "muted").T("This decision is final.")
+		return
+	}
+	b.Form("action", AdminApplicationStatusPath(sf.App.ID), "method", "POST", "class", "inline-form").R(
+		b.Select("name", "status").R(
+			b.Wrap(func() {
+				for _, s := range next {
+					b.Option("value", string(s)).T(s.Label())
+				}
+			}),
+		),
+		b.Input("type", "text", "name", "note", "placeholder", "Note (optional)"),
+		b.Button("type", "submit").T("Update"),
+	)
+	return
+}
+
+// ApplicationAnswers shows what the applicant told us, one step per section
+// DEFINITION LISTS (dl/dt/dd) pair each question with its answer
+type ApplicationAnswers struct {
+	App adoption.Application
+	Tr  i18n.Translator // for yes/no
+}
+
+func (aa ApplicationAnswers) Render(b *element.Builder) (dontCare any) {
+	a, tr := aa.App, aa.Tr
+	// answer adds one question and its (escaped) answer to the current list
+	answer := func(question, value string) {
+		if value == "" {
+			value = "&mdash;"
+		} else {
+			value = html.EscapeString(value)
+		}
+		b.Dt().T(question)
+		b.Dd().T(value)
+	}
+
+	b.Section("class", "answers").R(
+		b.H3().T("Applicant"),
+		b.Dl().R(
+			b.Wrap(func() {
+				answer("Name", a.Applicant.Name)
+				answer("Email", a.Applicant.Email)
+				answer("Phone", a.Applicant.Phone)
+				answer("Address", a.Applicant.Address)
+			}),
+		),
+		b.H3().T("Home environment"),
+		b.Dl().R(
+			b.Wrap(func() {
+				h := a.Home
+				answer("Housing", h.HousingType)
+				answer("Owns the home", yesNo(tr, h.OwnsHome))
+				if !h.OwnsHome {
+					answer("Landlord allows pets", yesNo(tr, h.LandlordAllowsPets))
+				}
+				answer("Adults", strconv.Itoa(h.Adults))
+				answer("Children", strconv.Itoa(h.Children))
+				answer("Other pets", h.OtherPets)
+				answer("Hours alone per day", strconv.Itoa(h.HoursAlone))
+			}),
+		),
+		b.H3().T("References"),
+		b.Dl().R(
+			b.Wrap(func() {
+				for i, ref := range a.References {
+					answer("Reference "+strconv.Itoa(i+1), ref.Name)
+					answer("Phone", ref.Phone)
+					answer("Relationship", ref.Relationship)
+				}
+			}),
+		),
+		b.H3().T("Agreement"),
+		b.Dl().R(
+			b.Wrap(func() {
+				answer("Accepts the adoption terms", yesNo(tr, a.Agreement.AcceptsTerms))
+				answer("Accepts a home visit", yesNo(tr, a.Agreement.AcceptsHomeVisit))
+				answer("Signature", a.Agreement.Signature)
+			}),
+		),
+	)
+	return
+}
+
+// ApplicationHistory lists every status change, oldest first
+type ApplicationHistory struct {
+	History []adoption.StatusChange
+}
+
+func (ah ApplicationHistory) Render(b *element.Builder) (dontCare any) {
+	b.H3().T("History")
+	if len(ah.History) == 0 {
+		b.P("class", "note").T("Not submitted yet.")
+		return
+	}
+	b.Table("class", "data-table history").R(
+		b.THead().R(
+			b.Tr().R(b.Th().T("When"), b.Th().T("Change"), b.Th().T("By"), b.Th().T("Note")),
+		),
+		b.TBody().R(
+			b.Wrap(func() {
+				for _, ch := range ah.History {
+					b.Tr().R(
+						b.Td().T(ch.At.Format("2006-01-02 15:04")),
+						b.Td().T(ch.From.Label(), " &rarr; ", ch.To.Label()),
+						b.Td().T(html.EscapeString(ch.By)),
+						b.Td().T(html.EscapeString(ch.Note)),
+					)
+				}
+			}),
+		),
+	)
+	return
+}
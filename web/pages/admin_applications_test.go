@@ -0,0 +1,115 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/adoption"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestAdminApplicationsPage(t *testing.T) {
+	submitted := draftApp()
+	submitted.Status = adoption.StatusSubmitted
+
+	page := pages.AdminApplications
+	page.Apps = []adoption.Application{submitted}
+	page.Filter = adoption.StatusSubmitted
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Adoption Applications")
+	doc.AssertCount("tbody tr", 1)
+	doc.AssertText(".filter-links a.current", "Submitted")
+	// Live updates: the list the script fills, and the script
+	doc.AssertAttr("ul.live-events", "data-events", "/events")
+	doc.AssertAttr("script", "src", "/assets/js/live.js")
+
+	doc.Golden("admin_applications")
+}
+
+func TestAdminApplicationsPageEmpty(t *testing.T) {
+	doc := webtest.RenderPage(t, pages.AdminApplications)
+
+	doc.AssertText("p.note", "No applications to show.")
+	doc.AssertMissing("table")
+}
+
+func TestStatusFilter(t *testing.T) {
+	doc := webtest.Render(t, pages.StatusFilter{})
+
+	doc.AssertCount("a", len(adoption.AllStatuses)+1)
+	doc.AssertText("a.current", "All")
+	doc.AssertAttr("a.current", "href", pages.AdminApplicationsPath)
+}
+
+// A row links to the application; deciding happens there
+func TestApplicationRow(t *testing.T) {
+	app := draftApp()
+	app.Status = adoption.StatusSubmitted
+	doc := webtest.Render(t, pages.ApplicationRow{App: app})
+
+	doc.AssertText("td a strong", "Jane Doe")
+	doc.AssertAttr("td a", "href", "/admin/applications/abc123")
+	doc.AssertMissing("form")
+}
+
+// Staff see every answer, the history, and the status form
+func TestAdminApplicationPage(t *testing.T) {
+	app := draftApp()
+	app.Home = adoption.Home{HousingType: "apartment", LandlordAllowsPets: true, Adults: 2, OtherPets: "<b>a dog</b>", HoursAlone: 4}
+	app.References = []adoption.Reference{
+		{Name: "Ann Lee", Phone: "555-0101", Relationship: "Friend"},
+		{Name: "Bo Park", Phone: "555-0102", Relationship: "Vet"},
+	}
+	app.Agreement = adoption.Agreement{AcceptsTerms: true, Signature: "Jane Doe"}
+	if err := app.Transition(adoption.StatusSubmitted, "applicant", ""); err != nil {
+		t.Fatal(err)
+	}
+	app.History[0].At = fixedTime
+	if err := app.Transition(adoption.StatusUnderReview, "sam", "Calling references"); err != nil {
+		t.Fatal(err)
+	}
+	app.History[1].At = fixedTime
+	app.UpdatedAt, app.SubmittedAt = fixedTime, fixedTime
+
+	page := pages.NewAdminApplicationPage(app, luna())
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Application for Luna")
+	doc.AssertText("h2", "Jane Doe – Under review")
+	doc.AssertCount(".answers dl", 4)
+	doc.AssertCount(".answers dt", 4+7+3*adoption.ReferencesRequired+3)
+	doc.AssertTextContains(".answers", "<b>a dog</b>") // escaped, so shown as text
+	doc.AssertMissing(".answers b")
+	doc.AssertCount("table.history tbody tr", 2)
+	doc.AssertAttr("form.inline-form", "action", "/admin/applications/abc123/status")
+	doc.AssertMissing(".alert-error")
+
+	doc.Golden("admin_application")
+}
+
+// The status dropdown only offers moves the workflow allows
+func TestApplicationStatusForm(t *testing.T) {
+	app := draftApp()
+	app.Status = adoption.StatusSubmitted
+	doc := webtest.Render(t, pages.ApplicationStatusForm{App: app})
+
+	doc.AssertCount("select[name=status] option", len(adoption.StatusSubmitted.Next()))
+	doc.AssertExists(`option[value=under_review]`)
+	doc.AssertMissing(`option[value=approved]`)
+
+	app.Status = adoption.StatusRejected
+	final := webtest.Render(t, pages.ApplicationStatusForm{App: app})
+	final.AssertMissing("form")
+	final.AssertText("p.muted", "This decision is final.")
+}
+
+// A rejected move is explained above the application
+func TestAdminApplicationPageError(t *testing.T) {
+	page := pages.NewAdminApplicationPage(draftApp(), luna())
+	page.Error = `cannot move "x"`
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText(".alert-error", `cannot move "x"`)
+	doc.AssertText("p.note", "Not submitted yet.")
+}
@@ -0,0 +1,278 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the staff pages for managing the cat catalog and viewing the audit trail.
+package pages
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"form_exer/audit"
+	"form_exer/cats"
+	"form_exer/forms"
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// Admin URL helpers - one place to build every admin link
+const (
+	AdminCatsPath   = "/admin/cats"
+	AdminNewCatPath = "/admin/cats/new"
+	AdminAuditPath  = "/admin/audit"
+)
+
+func AdminCatPath(slug string) string { return AdminCatsPath + "/" + slug }
+
+func AdminCatEditPath(slug string) string { return AdminCatPath(slug) + "/edit" }
+
+func AdminCatPhotosPath(slug string) string { return AdminCatPath(slug) + "/photos" }
+
+func AdminCatPhotoDeletePath(slug string, index int) string {
+	return AdminCatPhotosPath(slug) + "/" + strconv.Itoa(index) + "/delete"
+}
+
+// AdminNav links the admin pages together
+type AdminNav struct{}
+
+func (AdminNav) Render(b *element.Builder) (dontCare any) {
+	b.Nav("class", "admin-nav").R(
+		b.A("href", AdminCatsPath).T("Cats"),
+		b.A("href", AdminApplicationsPath).T("Applications"),
+		b.A("href", AdminAuditPath).T("Audit log"),
+	)
+	return
+}
+
+// AdminCatsPage lists every cat (whatever its status) with edit controls
+type AdminCatsPage struct {
+	shared.Page
+	Cats []cats.Cat
+}
+
+var AdminCats = AdminCatsPage{Page: shared.Page{Title: "Manage Cats"}}
+
+func (p AdminCatsPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html().R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel").R(
+				AdminNav{}.Render(b),
+				b.P().R(b.A("href", AdminNewCatPath, "class", "btn btn-primary").T("+ Add a cat")),
+				b.Table("class", "data-table").R(
+					b.THead().R(
+						b.Tr().R(
+							b.Th().T("Name"), b.Th().T("Age"), b.Th().T("Status"), b.Th().T("Photos"), b.Th().T(""),
+						),
+					),
+					b.TBody().R(
+						b.Wrap(func() {
+							for _, c := range p.Cats {
+								AdminCatRow{Cat: c}.Render(b)
+							}
+						}),
+					),
+				),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// AdminCatRow is one cat in the admin table, with retire/delete buttons
+// Destructive actions are POST forms, never plain links - a link can be
+// followed by a crawler or a browser prefetch
+type AdminCatRow struct {
+	Cat cats.Cat
+}
+
+func (r AdminCatRow) Render(b *element.Builder) (dontCare any) {
+	c := r.Cat
+	b.Tr().R(
+		b.Td().R(b.A("href", AdminCatEditPath(c.Slug)).T(html.EscapeString(c.Name))),
+		b.Td().T(c.AgeLabel()),
+		b.Td().T(string(c.Status)),
+		b.Td().T(strconv.Itoa(len(c.Photos))),
+		b.Td("class", "inline-form").R(
+			b.Wrap(func() {
+				if c.Status != cats.StatusRetired {
+					b.Form("action", AdminCatPath(c.Slug)+"/retire", "method", "POST").R(
+						b.Button("type", "submit").T("Retire"),
+					)
+				}
+			}),
+			b.Form("action", AdminCatPath(c.Slug)+"/delete", "method", "POST").R(
+				b.Button("type", "submit", "class", "text-danger").T("Delete"),
+			),
+		),
+	)
+	return
+}
+
+// AdminCatFormPage creates (IsNew) or edits a cat
+type AdminCatFormPage struct {
+	shared.Page
+	Cat    cats.Cat
+	IsNew  bool
+	Errors forms.FieldErrors
+	Notice string
+}
+
+func NewAdminCatFormPage(cat cats.Cat, isNew bool) AdminCatFormPage {
+	title := "Add a cat"
+	if !isNew {
+		title = "Edit " + html.EscapeString(cat.Name)
+	}
+	return AdminCatFormPage{Page: shared.Page{Title: title}, Cat: cat, IsNew: isNew}
+}
+
+func (p AdminCatFormPage) Render() (out string) {
+	b := element.NewBuilder()
+	c, errs := p.Cat, p.Errors
+
+	action := AdminCatsPath // create
+	if !p.IsNew {
+		action = AdminCatEditPath(c.Slug) // update
+	}
+
+	status := string(c.Status)
+	statuses := make([]string, 0, len(cats.AllStatuses))
+	for _, s := range cats.AllStatuses {
+		statuses = append(statuses, string(s))
+	}
+
+	b.Html().R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel panel-medium").R(
+				AdminNav{}.Render(b),
+				b.Wrap(func() {
+					if p.Notice != "" {
+						b.P("class", "alert alert-success").T(html.EscapeString(p.Notice))
+					}
+				}),
+				b.Form("action", action, "method", "POST").R(
+					FormField{Label: "Name", Name: cats.FieldName, Value: c.Name, Error: errs[cats.FieldName]}.Render(b),
+					b.Wrap(func() {
+						// The slug is part of every link to the cat, so it's only chosen once
+						if p.IsNew {
+							FormField{Label: "URL slug (leave blank to derive from the name)", Name: cats.FieldSlug, Value: c.Slug, Error: errs[cats.FieldSlug]}.Render(b)
+						}
+					}),
+					element.RenderComponents(b,
+						FormField{Label: "Age in months", Name: cats.FieldAgeMonths, Type: "number", Value: strconv.Itoa(c.AgeMonths), Error: errs[cats.FieldAgeMonths]},
+						FormField{Label: "Breed", Name: cats.FieldBreed, Value: c.Breed, Error: errs[cats.FieldBreed]},
+						TextAreaField{Label: "Temperament (shown on cards)", Name: cats.FieldTemperament, Value: c.Temperament, Error: errs[cats.FieldTemperament]},
+						TextAreaField{Label: "Full description", Name: cats.FieldDescription, Value: c.Description, Error: errs[cats.FieldDescription]},
+						SelectField{Label: "Status", Name: cats.FieldStatus, Options: statuses, Value: status, Error: errs[cats.FieldStatus]},
+						FormField{Label: "Vaccinations (comma separated)", Name: cats.FieldVaccinations, Value: strings.Join(c.Health.Vaccinations, ", ")},
+						CheckboxField{Label: "Spayed/neutered", Name: cats.FieldSpayed, Checked: c.Health.SpayedNeutered},
+						CheckboxField{Label: "Microchipped", Name: cats.FieldMicrochipped, Checked: c.Health.Microchipped},
+						CheckboxField{Label: "Good with kids", Name: cats.FieldGoodWithKids, Checked: c.GoodWithKids},
+						CheckboxField{Label: "Good with other pets", Name: cats.FieldGoodWithPets, Checked: c.GoodWithPets},
+						TextAreaField{Label: "Health notes", Name: cats.FieldHealthNotes, Value: c.Health.Notes},
+					),
+					b.Button("type", "submit", "class", "btn btn-primary").T("Save"),
+				),
+				b.Wrap(func() {
+					if !p.IsNew { // photos attach to an existing cat
+						AdminPhotoManager{Cat: c, Error: errs["photo"]}.Render(b)
+					}
+				}),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// AdminPhotoManager lists a cat's photos with remove buttons and an upload form
+type AdminPhotoManager struct {
+	Cat   cats.Cat
+	Error string
+}
+
+func (pm AdminPhotoManager) Render(b *element.Builder) (dontCare any) {
+	slug := pm.Cat.Slug
+
+	b.Section("class", "photo-manager").R(
+		b.H3().T("Photos"),
+		b.Div("class", "photo-list").R(
+			b.Wrap(func() {
+				// RANGE with INDEX: the index identifies which photo to remove
+				for i, photo := range pm.Cat.Photos {
+					b.Div("class", "photo-tile").R(
+						b.Img("src", html.EscapeString(photo.URL), "alt", html.EscapeString(photo.Alt)),
+						b.Form("action", AdminCatPhotoDeletePath(slug, i), "method", "POST").R(
+							b.Button("type", "submit").T("Remove"),
+						),
+					)
+				}
+			}),
+		),
+		// ENCTYPE multipart/form-data is required for file inputs -
+		// the default urlencoded format can't carry file contents
+		b.Form("action", AdminCatPhotosPath(slug), "method", "POST", "enctype", "multipart/form-data", "class", "upload-form").R(
+			b.Input("type", "file", "name", "photo", "accept", "image/*"),
+			b.Input("type", "text", "name", "alt", "placeholder", "Describe the photo (alt text)"),
+			b.Button("type", "submit").T("Upload"),
+			FieldError{Message: pm.Error}.Render(b),
+		),
+	)
+	return
+}
+
+// AdminAuditPage shows the most recent audit entries
+type AdminAuditPage struct {
+	shared.Page
+	Entries []audit.Entry
+}
+
+var AdminAudit = AdminAuditPage{Page: shared.Page{Title: "Audit Log"}}
+
+func (p AdminAuditPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html().R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel").R(
+				AdminNav{}.Render(b),
+				b.Table("class", "data-table").R(
+					b.THead().R(
+						b.Tr().R(
+							b.Th().T("When"), b.Th().T("Who"), b.Th().T("Action"), b.Th().T("Target"), b.Th().T("Changes"),
+						),
+					),
+					b.TBody().R(
+						b.Wrap(func() {
+							for _, e := range p.Entries {
+								b.Tr().R(
+									b.Td().T(e.At.Format("2006-01-02 15:04:05")),
+									b.Td().T(html.EscapeString(e.Actor)),
+									b.Td().T(html.EscapeString(e.Action)),
+									b.Td().T(html.EscapeString(e.Target)),
+									b.Td().R(
+										b.Wrap(func() {
+											for _, ch := range e.Changes {
+												b.Div().T(html.EscapeString(ch.Field), ": ",
+													html.EscapeString(ch.From), " &rarr; ", html.EscapeString(ch.To))
+											}
+										}),
+									),
+								)
+							}
+						}),
+					),
+				),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
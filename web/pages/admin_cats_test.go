@@ -0,0 +1,100 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/audit"
+	"form_exer/cats"
+	"form_exer/forms"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestAdminNav(t *testing.T) {
+	doc := webtest.Render(t, pages.AdminNav{})
+
+	doc.AssertCount("nav.admin-nav a", 3)
+	doc.AssertExists(`a[href="/admin/cats"]`)
+	doc.AssertExists(`a[href="/admin/applications"]`)
+	doc.AssertExists(`a[href="/admin/audit"]`)
+}
+
+func TestAdminCatsPage(t *testing.T) {
+	page := pages.AdminCats
+	page.Cats = []cats.Cat{luna(), shadow()}
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Manage Cats")
+	doc.AssertCount("tbody tr", 2)
+	doc.AssertAttr(".panel p a.btn", "href", pages.AdminNewCatPath)
+
+	doc.Golden("admin_cats")
+}
+
+// Destructive actions are POST forms; retired cats can't be retired again
+func TestAdminCatRow(t *testing.T) {
+	doc := webtest.Render(t, pages.AdminCatRow{Cat: luna()})
+	doc.AssertAttr(`form[action="/admin/cats/luna/retire"]`, "method", "POST")
+	doc.AssertAttr(`form[action="/admin/cats/luna/delete"]`, "method", "POST")
+	doc.AssertAttr("td a", "href", "/admin/cats/luna/edit")
+
+	retired := luna()
+	retired.Status = cats.StatusRetired
+	doc = webtest.Render(t, pages.AdminCatRow{Cat: retired})
+	doc.AssertMissing(`form[action="/admin/cats/luna/retire"]`)
+}
+
+func TestAdminCatFormPageNew(t *testing.T) {
+	doc := webtest.RenderPage(t, pages.NewAdminCatFormPage(cats.Cat{Status: cats.StatusAvailable}, true))
+
+	doc.AssertText("title", "Add a cat")
+	doc.AssertAttr(".panel form", "action", pages.AdminCatsPath)
+	doc.AssertExists(`input[name="` + cats.FieldSlug + `"]`)
+	doc.AssertMissing(".photo-manager") // photos need an existing cat
+}
+
+func TestAdminCatFormPageEdit(t *testing.T) {
+	page := pages.NewAdminCatFormPage(luna(), false)
+	page.Errors = forms.FieldErrors{cats.FieldName: "Name is required."}
+	page.Notice = "Saved."
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Edit Luna")
+	doc.AssertAttr(".panel > form", "action", "/admin/cats/luna/edit")
+	doc.AssertMissing(`input[name="` + cats.FieldSlug + `"]`) // slugs are fixed once chosen
+	doc.AssertText(".alert-success", "Saved.")
+	doc.AssertText(".field-error", "Name is required.")
+	doc.AssertText(`select[name="`+cats.FieldStatus+`"] option[selected]`, "available")
+	doc.AssertExists(`input[name="` + cats.FieldSpayed + `"][checked]`)
+	doc.AssertExists(".photo-manager")
+
+	doc.Golden("admin_cat_form")
+}
+
+func TestAdminPhotoManager(t *testing.T) {
+	doc := webtest.Render(t, pages.AdminPhotoManager{Cat: luna(), Error: "Not an image."})
+
+	doc.AssertCount(".photo-tile", 2)
+	doc.AssertExists(`form[action="/admin/cats/luna/photos/1/delete"]`)
+	doc.AssertAttr(".upload-form", "enctype", "multipart/form-data")
+	doc.AssertAttr(`.upload-form input[type=file]`, "name", "photo")
+	doc.AssertText(".upload-form .field-error", "Not an image.")
+}
+
+func TestAdminAuditPage(t *testing.T) {
+	page := pages.AdminAudit
+	page.Entries = []audit.Entry{{
+		At:      fixedTime,
+		Actor:   "sam",
+		Action:  "cat.update",
+		Target:  "luna",
+		Changes: []audit.Change{{Field: "status", From: "available", To: "pending"}},
+	}}
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertCount("tbody tr", 1)
+	doc.AssertText("tbody td", "2025-03-14 09:30:00")
+	doc.AssertText("tbody td div", "status: available → pending")
+
+	doc.Golden("admin_audit")
+}
@@ -0,0 +1,247 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the multi-step adoption application form and its status page.
+package pages
+
+import (
+	"html"
+	"strconv"
+
+	"form_exer/adoption"
+	"form_exer/cats"
+	"form_exer/forms"
+	"form_exer/i18n"
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// AdoptPath starts a new application for a cat (POST)
+func AdoptPath(catSlug string) string {
+	return CatDetailPath(catSlug) + "/adopt"
+}
+
+// ApplicationPath is the applicant's status page
+func ApplicationPath(id string) string {
+	return "/applications/" + id
+}
+
+// ApplicationStepPath is where a step of the form is shown (GET) and posted (POST)
+// Same ":param in the path" posting style as /post-form-data/:form_id
+func ApplicationStepPath(id string, step int) string {
+	return ApplicationPath(id) + "/step/" + strconv.Itoa(step)
+}
+
+// AdoptionFormPage shows one step of the application
+type AdoptionFormPage struct {
+	shared.Page
+	App    adoption.Application
+	Cat    cats.Cat
+	Step   adoption.Step
+	Errors forms.FieldErrors // field name -> message key; nil when there is nothing to report
+	Notice string            // message key, e.g. "adoption.notice.saved"
+}
+
+// NewAdoptionFormPage builds the page for one step of an application
+func NewAdoptionFormPage(app adoption.Application, cat cats.Cat, step adoption.Step) AdoptionFormPage {
+	return AdoptionFormPage{
+		Page: shared.Page{Title: "adoption.title", TitleArgs: []any{html.EscapeString(cat.Name)}},
+		App:  app,
+		Cat:  cat,
+		Step: step,
+	}
+}
+
+func (p AdoptionFormPage) Render() (out string) {
+	b := element.NewBuilder()
+	tr := p.I18n
+
+	b.Html("lang", tr.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel panel-narrow").R(
+				StepIndicator{Current: p.Step.Number, Tr: tr}.Render(b),
+				b.H2().T(tr.T(p.Step.Title)),
+
+				b.Wrap(func() {
+					if p.Notice != "" {
+						b.P("class", "alert alert-success").T(tr.T(p.Notice))
+					}
+					if len(p.Errors) > 0 {
+						b.P("class", "alert alert-error").T(tr.T("adoption.fix_fields"))
+					}
+				}),
+
+				// FORM ELEMENT: posts back to the same step URL
+				// The adoption package reports message keys; Errors turns them into sentences
+				b.Form("action", ApplicationStepPath(p.App.ID, p.Step.Number), "method", "POST").R(
+					AdoptionStepFields{App: p.App, Step: p.Step.Number, Errors: tr.Errors(p.Errors), Tr: tr}.Render(b),
+					StepButtons{AppID: p.App.ID, Step: p.Step.Number, Tr: tr}.Render(b),
+				),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// StepIndicator shows "1 About you > 2 Your home > ..." with the current step highlighted
+type StepIndicator struct {
+	Current int
+	Tr      i18n.Translator
+}
+
+func (si StepIndicator) Render(b *element.Builder) (dontCare any) {
+	b.Ol("class", "steps").R(
+		b.Wrap(func() {
+			for _, s := range adoption.Steps {
+				attrs := []string{}
+				if s.Number == si.Current {
+					attrs = append(attrs, "class", "current", "aria-current", "step")
+				}
+				b.Li(attrs...).T(strconv.Itoa(s.Number), ". ", si.Tr.T(s.Title))
+			}
+		}),
+	)
+	return
+}
+
+// StepButtons renders Back / Save draft / Next (or Submit on the last step)
+// Both submit buttons share name="action"; the value tells the handler which was clicked
+type StepButtons struct {
+	AppID string
+	Step  int
+	Tr    i18n.Translator
+}
+
+func (sb StepButtons) Render(b *element.Builder) (dontCare any) {
+	nextLabel := sb.Tr.T("adoption.next")
+	if sb.Step == len(adoption.Steps) {
+		nextLabel = sb.Tr.T("adoption.submit")
+	}
+
+	b.Div("class", "form-actions").R(
+		b.Wrap(func() {
+			if sb.Step > 1 {
+				b.A("href", ApplicationStepPath(sb.AppID, sb.Step-1)).T(sb.Tr.T("adoption.back"))
+			}
+		}),
+		b.Button("type", "submit", "name", "action", "value", "save", "class", "btn btn-secondary").T(sb.Tr.T("adoption.save_draft")),
+		b.Button("type", "submit", "name", "action", "value", "next", "class", "btn btn-primary").T(nextLabel),
+	)
+	return
+}
+
+// AdoptionStepFields renders the inputs for a single step, pre-filled from the draft
+type AdoptionStepFields struct {
+	App    adoption.Application
+	Step   int
+	Errors forms.FieldErrors // already translated
+	Tr     i18n.Translator
+}
+
+func (sf AdoptionStepFields) Render(b *element.Builder) (dontCare any) {
+	a, errs := sf.App, sf.Errors // reading from a nil map is fine in Go - it just returns ""
+	t := sf.Tr.T                 // METHOD VALUE: a short name for the labels below
+
+	// SWITCH on the step number picks which group of fields to render
+	switch sf.Step {
+	case 1:
+		element.RenderComponents(b,
+			FormField{Label: t("adoption.field.name"), Name: adoption.FieldName, Value: a.Applicant.Name, Error: errs[adoption.FieldName]},
+			FormField{Label: t("adoption.field.email"), Name: adoption.FieldEmail, Type: "email", Value: a.Applicant.Email, Error: errs[adoption.FieldEmail]},
+			FormField{Label: t("adoption.field.phone"), Name: adoption.FieldPhone, Type: "tel", Value: a.Applicant.Phone, Error: errs[adoption.FieldPhone]},
+			TextAreaField{Label: t("adoption.field.address"), Name: adoption.FieldAddress, Value: a.Applicant.Address, Error: errs[adoption.FieldAddress]},
+		)
+	case 2:
+		element.RenderComponents(b,
+			SelectField{Label: t("adoption.field.housing_type"), Name: adoption.FieldHousingType, Options: adoption.HousingTypes, Value: a.Home.HousingType, Error: errs[adoption.FieldHousingType], Prompt: t("adoption.choose")},
+			CheckboxField{Label: t("adoption.field.owns_home"), Name: adoption.FieldOwnsHome, Checked: a.Home.OwnsHome, Error: errs[adoption.FieldOwnsHome]},
+			CheckboxField{Label: t("adoption.field.landlord_ok"), Name: adoption.FieldLandlordOK, Checked: a.Home.LandlordAllowsPets, Error: errs[adoption.FieldLandlordOK]},
+			FormField{Label: t("adoption.field.adults"), Name: adoption.FieldAdults, Type: "number", Value: itoa(a.Home.Adults), Error: errs[adoption.FieldAdults]},
+			FormField{Label: t("adoption.field.children"), Name: adoption.FieldChildren, Type: "number", Value: itoa(a.Home.Children), Error: errs[adoption.FieldChildren]},
+			TextAreaField{Label: t("adoption.field.other_pets"), Name: adoption.FieldOtherPets, Value: a.Home.OtherPets, Error: errs[adoption.FieldOtherPets]},
+			FormField{Label: t("adoption.field.hours_alone"), Name: adoption.FieldHoursAlone, Type: "number", Value: itoa(a.Home.HoursAlone), Error: errs[adoption.FieldHoursAlone]},
+		)
+	case 3:
+		for i := 0; i < adoption.ReferencesRequired; i++ {
+			var ref adoption.Reference
+			if i < len(a.References) {
+				ref = a.References[i]
+			}
+			nameF, phoneF, relF := adoption.RefField(i, "name"), adoption.RefField(i, "phone"), adoption.RefField(i, "relationship")
+
+			b.FieldSet("class", "fieldset").R(
+				b.Legend().T(t("adoption.field.reference", i+1)),
+				element.RenderComponents(b,
+					FormField{Label: t("adoption.field.ref_name"), Name: nameF, Value: ref.Name, Error: errs[nameF]},
+					FormField{Label: t("adoption.field.ref_phone"), Name: phoneF, Type: "tel", Value: ref.Phone, Error: errs[phoneF]},
+					FormField{Label: t("adoption.field.ref_relationship"), Name: relF, Value: ref.Relationship, Error: errs[relF]},
+				),
+			)
+		}
+	case 4:
+		b.P("class", "prose").T(t("adoption.terms"))
+		element.RenderComponents(b,
+			CheckboxField{Label: t("adoption.field.accepts_terms"), Name: adoption.FieldAcceptsTerms, Checked: a.Agreement.AcceptsTerms, Error: errs[adoption.FieldAcceptsTerms]},
+			CheckboxField{Label: t("adoption.field.accepts_visit"), Name: adoption.FieldAcceptsVisit, Checked: a.Agreement.AcceptsHomeVisit, Error: errs[adoption.FieldAcceptsVisit]},
+			FormField{Label: t("adoption.field.signature"), Name: adoption.FieldSignature, Value: a.Agreement.Signature, Error: errs[adoption.FieldSignature]},
+		)
+	}
+	return
+}
+
+// AdoptionStatusPage lets an applicant check on (or withdraw) their application
+type AdoptionStatusPage struct {
+	shared.Page
+	App adoption.Application
+	Cat cats.Cat
+}
+
+func NewAdoptionStatusPage(app adoption.Application, cat cats.Cat) AdoptionStatusPage {
+	return AdoptionStatusPage{
+		Page: shared.Page{Title: "adoption.status_title", TitleArgs: []any{html.EscapeString(cat.Name)}},
+		App:  app,
+		Cat:  cat,
+	}
+}
+
+func (p AdoptionStatusPage) Render() (out string) {
+	b := element.NewBuilder()
+	a, tr := p.App, p.I18n
+
+	b.Html("lang", tr.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel panel-narrow").R(
+				b.H2().T(tr.T("adoption.status", tr.T("adoption.status."+string(a.Status)))),
+				b.Wrap(func() {
+					switch {
+					case a.Editable():
+						b.P().T(tr.T("adoption.not_submitted"), " ")
+						b.A("href", ApplicationStepPath(a.ID, 1)).T(tr.T("adoption.continue"))
+					case a.Status == adoption.StatusSubmitted || a.Status == adoption.StatusUnderReview:
+						b.P().T(tr.T("adoption.thanks", html.EscapeString(a.Applicant.Email)))
+					}
+
+					// Withdrawal is offered for as long as the workflow allows it
+					if a.Status.CanTransition(adoption.StatusWithdrawn) {
+						b.Form("action", ApplicationPath(a.ID)+"/withdraw", "method", "POST").R(
+							b.Button("type", "submit", "class", "btn btn-secondary").T(tr.T("adoption.withdraw")),
+						)
+					}
+				}),
+				b.P("class", "muted").T(tr.T("adoption.bookmark", ApplicationPath(a.ID))),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. MULTI-STEP FORMS - one URL per step, all posting back to themselves
+// 2. NAMED SUBMIT BUTTONS - name="action" distinguishes "save" from "next"
+// 3. NIL MAPS - reading errs[...] from a nil map safely returns ""
+// 4. COMPONENT COMPOSITION - FormField, CheckboxField, etc. reused across steps
+// 5. METHOD VALUES - t := sf.Tr.T keeps dozens of translated labels readable
@@ -0,0 +1,143 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/adoption"
+	"form_exer/forms"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestAdoptionPaths(t *testing.T) {
+	cases := map[string]string{
+		pages.AdoptPath("luna"):                 "/cats/luna/adopt",
+		pages.ApplicationPath("abc123"):         "/applications/abc123",
+		pages.ApplicationStepPath("abc123", 3):  "/applications/abc123/step/3",
+		pages.AdminApplicationStatusPath("abc"): "/admin/applications/abc/status",
+	}
+	for got, want := range cases {
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestAdoptionFormPageStep1(t *testing.T) {
+	step, _ := adoption.StepByNumber(1)
+	doc := webtest.RenderPage(t, pages.NewAdoptionFormPage(draftApp(), luna(), step))
+
+	doc.AssertText("title", "Adopt Luna")
+	doc.AssertText(".panel h2", "About you")
+	doc.AssertAttr("form", "action", "/applications/abc123/step/1")
+	doc.AssertAttr(`input[name=applicant_name]`, "value", "Jane Doe")
+	doc.AssertMissing(".alert")
+	doc.AssertMissing(".form-actions a") // no Back on the first step
+
+	doc.Golden("adoption_step1")
+}
+
+// Validation errors arrive as message keys and are shown translated next to their fields
+func TestAdoptionFormPageErrors(t *testing.T) {
+	step, _ := adoption.StepByNumber(1)
+	app := draftApp()
+	app.Applicant = adoption.Applicant{}
+
+	page := pages.NewAdoptionFormPage(app, luna(), step)
+	page.Errors = app.ValidateStep(1)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText(".alert-error", "Please fix the highlighted fields below.")
+	doc.AssertCount(".field-error", 4)
+	doc.AssertText(".field-error", "Please tell us your name.")
+
+	page.Apply(spanish)
+	es := webtest.RenderPage(t, page)
+	es.AssertText(".alert-error", "Corrige los campos marcados a continuación.")
+	es.AssertText(".field-error", "Dinos tu nombre.")
+}
+
+func TestAdoptionFormPageNotice(t *testing.T) {
+	step, _ := adoption.StepByNumber(2)
+	page := pages.NewAdoptionFormPage(draftApp(), luna(), step)
+	page.Notice = "adoption.notice.saved"
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertTextContains(".alert-success", "Draft saved.")
+	doc.AssertAttr(".form-actions a", "href", "/applications/abc123/step/1")
+}
+
+func TestStepIndicator(t *testing.T) {
+	doc := webtest.Render(t, pages.StepIndicator{Current: 3})
+
+	doc.AssertCount("ol.steps > li", len(adoption.Steps))
+	doc.AssertText("li.current", "3. References")
+	doc.AssertAttr("li.current", "aria-current", "step")
+}
+
+func TestStepButtons(t *testing.T) {
+	middle := webtest.Render(t, pages.StepButtons{AppID: "abc123", Step: 2})
+	middle.AssertText(`button[value=save]`, "Save draft")
+	middle.AssertText(`button[value=next]`, "Next")
+	middle.AssertAttr(`button[value=next]`, "name", "action")
+
+	last := webtest.Render(t, pages.StepButtons{AppID: "abc123", Step: len(adoption.Steps)})
+	last.AssertText(`button[value=next]`, "Submit application")
+}
+
+// Every step renders its own fields, and each field shows its own error
+func TestAdoptionStepFields(t *testing.T) {
+	cases := []struct {
+		step   int
+		fields []string
+	}{
+		{1, []string{adoption.FieldName, adoption.FieldEmail, adoption.FieldPhone, adoption.FieldAddress}},
+		{2, []string{adoption.FieldHousingType, adoption.FieldOwnsHome, adoption.FieldLandlordOK, adoption.FieldAdults,
+			adoption.FieldChildren, adoption.FieldOtherPets, adoption.FieldHoursAlone}},
+		{3, []string{adoption.RefField(0, "name"), adoption.RefField(1, "phone"), adoption.RefField(1, "relationship")}},
+		{4, []string{adoption.FieldAcceptsTerms, adoption.FieldAcceptsVisit, adoption.FieldSignature}},
+	}
+	for _, tc := range cases {
+		doc := webtest.Render(t, pages.AdoptionStepFields{App: draftApp(), Step: tc.step})
+		for _, name := range tc.fields {
+			doc.AssertExists(`[name="` + name + `"]`)
+		}
+	}
+
+	errs := forms.FieldErrors{adoption.FieldAdults: "Need an adult."}
+	doc := webtest.Render(t, pages.AdoptionStepFields{App: draftApp(), Step: 2, Errors: errs})
+	doc.AssertCount(".field-error", 1)
+	doc.AssertText(".field-error", "Need an adult.")
+	doc.AssertCount("fieldset", 0)
+
+	refs := webtest.Render(t, pages.AdoptionStepFields{App: draftApp(), Step: 3})
+	refs.AssertCount("fieldset", adoption.ReferencesRequired)
+	refs.AssertText("fieldset legend", "Reference 1")
+}
+
+func TestAdoptionStatusPage(t *testing.T) {
+	doc := webtest.RenderPage(t, pages.NewAdoptionStatusPage(draftApp(), luna()))
+
+	doc.AssertText("title", "Your application for Luna")
+	doc.AssertText(".panel h2", "Status: Draft")
+	doc.AssertAttr(".panel a", "href", "/applications/abc123/step/1")
+	doc.AssertAttr("form", "action", "/applications/abc123/withdraw")
+
+	doc.Golden("adoption_status")
+}
+
+func TestAdoptionStatusPageSubmitted(t *testing.T) {
+	app := draftApp()
+	app.Status = adoption.StatusUnderReview
+	page := pages.NewAdoptionStatusPage(app, luna())
+	page.Apply(spanish)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText(".panel h2", "Estado: En revisión")
+	doc.AssertTextContains(".panel p", "jane@example.com")
+	doc.AssertExists("form") // still withdrawable
+
+	app.Status = adoption.StatusApproved
+	final := webtest.RenderPage(t, pages.NewAdoptionStatusPage(app, luna()))
+	final.AssertMissing("form") // approved is final
+}
@@ -0,0 +1,168 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the API documentation page, rendered from the OpenAPI document.
+package pages
+
+import (
+	"html"
+	"maps"
+	"slices"
+	"strings"
+
+	"form_exer/openapi"
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// APIDocsPath is the human-readable API documentation; OpenAPIPath the machine-readable one
+const (
+	APIDocsPath = "/docs"
+	OpenAPIPath = "/openapi.json"
+)
+
+// APIDocsPage lists every operation of an OpenAPI document
+// It is the same document /openapi.json serves, so the two can't disagree
+type APIDocsPage struct {
+	shared.Page
+	Doc openapi.Document
+}
+
+// APIDocs is the base instance; the handler copies it and sets Doc
+var APIDocs = APIDocsPage{
+	Page: shared.Page{Title: "API Documentation"},
+}
+
+func (p APIDocsPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html("lang", p.I18n.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "panel api-docs").R(
+				b.H2().T(html.EscapeString(p.Doc.Info.Title)+" "+html.EscapeString(p.Doc.Info.Version)),
+				b.P().T(html.EscapeString(p.Doc.Info.Description)),
+				b.P().R(
+					b.T("Machine-readable: "),
+					b.A("href", OpenAPIPath).T(OpenAPIPath),
+					b.T(" (OpenAPI "+openapi.Version+")"),
+				),
+				b.P("class", "muted").T("Fields marked * are required."),
+				b.Wrap(func() {
+					// SORTED KEYS: map order is random, the page shouldn't be
+					for _, path := range slices.Sorted(maps.Keys(p.Doc.Paths)) {
+						for _, mo := range p.Doc.Paths[path].Operations() {
+							OperationDoc{Method: mo.Method, Path: path, Op: mo.Operation}.Render(b)
+						}
+					}
+				}),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// OperationDoc documents one method on one path
+type OperationDoc struct {
+	Method string
+	Path   string
+	Op     *openapi.Operation
+}
+
+func (o OperationDoc) Render(b *element.Builder) (dontCare any) {
+	op := o.Op
+
+	b.Section("class", "operation", "id", op.OperationID).R(
+		b.H3().R(
+			b.Span("class", "method method-"+strings.ToLower(o.Method)).T(o.Method),
+			b.T(" "),
+			b.Code().T(html.EscapeString(o.Path)),
+		),
+		b.P().T(html.EscapeString(op.Summary)),
+		b.Wrap(func() {
+			if op.Description != "" {
+				b.P("class", "muted").T(html.EscapeString(op.Description))
+			}
+			if len(op.Security) > 0 {
+				b.P("class", "note").T("Staff only: HTTP Basic authentication.")
+			}
+
+			if len(op.Parameters) > 0 {
+				b.H4().T("Parameters")
+				b.Table("class", "data-table").R(
+					b.THead().R(b.Tr().R(b.Th().T("Name"), b.Th().T("In"), b.Th().T("Type"), b.Th().T("Description"))),
+					b.TBody().R(b.Wrap(func() {
+						for _, prm := range op.Parameters {
+							fieldRow(b, prm.Name, prm.In, prm.Required, prm.Schema)
+						}
+					})),
+				)
+			}
+
+			if op.RequestBody != nil {
+				types := slices.Sorted(maps.Keys(op.RequestBody.Content))
+				b.H4().T("Body")
+				b.P("class", "muted").T(strings.Join(types, ", "))
+				// Every media type carries the same fields; show them once
+				if schema := op.RequestBody.Content[types[0]].Schema; schema != nil {
+					b.Table("class", "data-table").R(
+						b.THead().R(b.Tr().R(b.Th().T("Field"), b.Th().T("Type"), b.Th().T("Description"))),
+						b.TBody().R(b.Wrap(func() {
+							for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+								fieldRow(b, name, "", slices.Contains(schema.Required, name), schema.Properties[name])
+							}
+						})),
+					)
+				}
+			}
+
+			b.H4().T("Responses")
+			b.Ul("class", "responses").R(b.Wrap(func() {
+				for _, status := range slices.Sorted(maps.Keys(op.Responses)) {
+					resp := op.Responses[status]
+					b.Li().R(
+						b.Strong().T(status),
+						b.T(" "+html.EscapeString(resp.Description)),
+						b.Wrap(func() {
+							if len(resp.Content) > 0 {
+								b.Span("class", "muted").T(" - " + strings.Join(slices.Sorted(maps.Keys(resp.Content)), ", "))
+							}
+						}),
+					)
+				}
+			}))
+		}),
+	)
+	return
+}
+
+// fieldRow is one line of a parameter or body field table; in is left out when ""
+func fieldRow(b *element.Builder, name, in string, required bool, s *openapi.Schema) {
+	typ := s.Type
+	if s.Format != "" {
+		typ += " (" + s.Format + ")"
+	}
+	desc := s.Description
+	if len(s.Enum) > 0 {
+		desc = strings.TrimSpace("one of: " + strings.Join(s.Enum, ", ") + ". " + desc)
+	}
+	if required {
+		name += " *"
+	}
+
+	b.Tr().R(
+		b.Td().R(b.Code().T(html.EscapeString(name))),
+		b.Wrap(func() {
+			if in != "" {
+				b.Td().T(in)
+			}
+		}),
+		b.Td().T(typ),
+		b.Td().T(html.EscapeString(desc)),
+	)
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. ONE SOURCE, TWO VIEWS - the page and /openapi.json come from the same document
+// 2. SORTED MAP KEYS - slices.Sorted(maps.Keys(m)) gives a stable order
+// 3. SMALL RENDER HELPERS - fieldRow serves both the parameter and body tables
@@ -0,0 +1,32 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/forms"
+	"form_exer/openapi"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestAPIDocsPage(t *testing.T) {
+	page := pages.APIDocs
+	page.Doc = openapi.Generate(openapi.Info{Title: "Cats", Version: "1.0", Description: "Test API."}, []openapi.Route{
+		{Method: "GET", Path: "/cats", Summary: "Search the cats",
+			Query: forms.Schema{{Name: "sort", Enum: []string{"name", "oldest"}}}},
+		{Method: "POST", Path: "/admin/cats/:slug/photos", Summary: "Add a photo", BasicAuth: true, Status: 303,
+			Form: forms.Schema{{Name: "photo", Type: forms.File, Required: true}, {Name: "alt"}}},
+	}, &openapi.Schema{Type: "object"})
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "API Documentation")
+	doc.AssertText(".api-docs h2", "Cats 1.0")
+	doc.AssertAttr(".api-docs p a", "href", "/openapi.json")
+	// Sorted by path: /admin/... before /cats
+	doc.AssertCount(".operation", 2)
+	doc.AssertAttr(".operation", "id", "post_admin_cats_slug_photos")
+	doc.AssertText("#post_admin_cats_slug_photos .note", "Staff only: HTTP Basic authentication.")
+	doc.AssertText("#get_cats td", "sort")
+
+	doc.Golden("api_docs")
+}
@@ -0,0 +1,180 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the cat detail page reached from the "Meet <name>" links.
+package pages
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	"form_exer/cats"
+	"form_exer/i18n"
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// CatDetailPath builds the URL for a cat's detail page
+// Keeping URL construction in ONE function means the route and every link
+// to it can't drift apart. PathEscape protects against odd characters in slugs.
+func CatDetailPath(slug string) string {
+	return "/cats/" + url.PathEscape(slug)
+}
+
+// CatDetailPage shows everything we know about one cat
+// Like Home and ContactPage it embeds shared.Page for the Banner/Footer mixin
+type CatDetailPage struct {
+	shared.Page
+	Cat cats.Cat
+}
+
+// NewCatDetailPage is a CONSTRUCTOR FUNCTION - Go has no constructors,
+// so by convention a NewXxx function builds a ready-to-use value
+// Here it derives the page title from the cat's name
+func NewCatDetailPage(cat cats.Cat) CatDetailPage {
+	return CatDetailPage{
+		Page: shared.Page{Title: "card.meet", TitleArgs: []any{html.EscapeString(cat.Name)}},
+		Cat:  cat,
+	}
+}
+
+// Render returns the full HTML for the page
+func (p CatDetailPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html("lang", p.I18n.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b,
+				p.Banner(),
+				CatProfile{Cat: p.Cat, Tr: p.I18n},
+				p.Footer(),
+			),
+		),
+	)
+	return b.String()
+}
+
+// CatProfile is the main content of the detail page: gallery, story, health and call-to-action
+type CatProfile struct {
+	Cat cats.Cat
+	Tr  i18n.Translator
+}
+
+func (cp CatProfile) Render(b *element.Builder) (dontCare any) {
+	cat, tr := cp.Cat, cp.Tr
+	name := html.EscapeString(cat.Name)
+
+	b.Div("class", "panel panel-profile").R(
+		// Breadcrumb back to the list of cats
+		b.A("href", CatListPath, "class", "back-link").T(tr.T("detail.back")),
+
+		b.H2("class", "cat-name").T(name),
+		b.P("class", "cat-meta").T(
+			html.EscapeString(cat.Breed), " &middot; ", ageLabel(tr, cat.AgeMonths),
+		),
+
+		CatGallery{Name: cat.Name, Photos: cat.Photos, Tr: tr}.Render(b),
+
+		b.H3().T(tr.T("detail.about", name)),
+		b.P("class", "prose").T(html.EscapeString(cat.FullDescription())),
+
+		CatHealth{Health: cat.Health, Tr: tr}.Render(b),
+
+		b.Ul("class", "facts").R(
+			b.Li().T(tr.T("detail.good_with_kids"), " ", yesNo(tr, cat.GoodWithKids)),
+			b.Li().T(tr.T("detail.good_with_pets"), " ", yesNo(tr, cat.GoodWithPets)),
+		),
+
+		// CALL TO ACTION: Only offer adoption when the cat is actually available
+		b.Wrap(func() {
+			if !cat.IsAvailable() {
+				b.P("class", "note").T(tr.T("detail.unavailable", name))
+				return
+			}
+			// A FORM, not a link: starting creates an application, and links get
+			// followed by crawlers and prefetched by browsers
+			b.Form("action", AdoptPath(cat.Slug), "method", "POST", "class", "adopt-form").R(
+				b.Button("type", "submit", "class", "btn btn-primary btn-large").T(tr.T("detail.adopt")),
+			)
+		}),
+	)
+	return
+}
+
+// CatGallery shows a large main photo followed by thumbnails of the rest
+type CatGallery struct {
+	Name   string
+	Photos []cats.Photo
+	Tr     i18n.Translator
+}
+
+func (g CatGallery) Render(b *element.Builder) (dontCare any) {
+	if len(g.Photos) == 0 {
+		b.P("class", "note").T(g.Tr.T("detail.no_photos"))
+		return
+	}
+
+	// SLICE EXPRESSIONS: Photos[0] is the hero image, Photos[1:] are the rest
+	main, rest := g.Photos[0], g.Photos[1:]
+
+	b.Div("class", "gallery").R(
+		b.Img("src", html.EscapeString(main.URL), "alt", html.EscapeString(main.Alt),
+			"class", "gallery-main"),
+		b.Div("class", "gallery-thumbs").R(
+			b.Wrap(func() {
+				for _, photo := range rest {
+					// Thumbnails link to the full-size image
+					b.A("href", html.EscapeString(photo.URL)).R(
+						b.Img("src", html.EscapeString(photo.URL), "alt", html.EscapeString(photo.Alt)),
+					)
+				}
+			}),
+		),
+	)
+	return
+}
+
+// CatHealth lists vaccinations and other medical details
+type CatHealth struct {
+	Health cats.Health
+	Tr     i18n.Translator
+}
+
+func (ch CatHealth) Render(b *element.Builder) (dontCare any) {
+	h, tr := ch.Health, ch.Tr
+
+	vaccinations := tr.T("health.none")
+	if len(h.Vaccinations) > 0 {
+		vaccinations = html.EscapeString(strings.Join(h.Vaccinations, ", "))
+	}
+
+	b.Div("class", "info-box").R(
+		b.H3().T(tr.T("health.heading")),
+		b.Ul("class", "facts").R(
+			b.Li().T(tr.T("health.vaccinations"), " ", vaccinations),
+			b.Li().T(tr.T("health.spayed"), " ", yesNo(tr, h.SpayedNeutered)),
+			b.Li().T(tr.T("health.microchipped"), " ", yesNo(tr, h.Microchipped)),
+			b.Wrap(func() {
+				if h.Notes != "" {
+					b.Li().T(tr.T("health.notes"), " ", html.EscapeString(h.Notes))
+				}
+			}),
+		),
+	)
+	return
+}
+
+// yesNo turns a bool into display text in the visitor's language
+func yesNo(tr i18n.Translator, v bool) string {
+	if v {
+		return tr.T("common.yes")
+	}
+	return tr.T("common.no")
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. CONSTRUCTOR FUNCTIONS - NewCatDetailPage builds a page from domain data
+// 2. SINGLE SOURCE OF TRUTH - CatDetailPath is the only place the URL is built
+// 3. SMALL COMPONENTS - Gallery and health info are separate, reusable pieces
+// 4. SLICE EXPRESSIONS - Splitting the first photo from the rest
+// 5. CONDITIONAL RENDERING - b.Wrap() with if statements inside the tree
@@ -0,0 +1,95 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/cats"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestCatDetailPath(t *testing.T) {
+	if got := pages.CatDetailPath("luna"); got != "/cats/luna" {
+		t.Errorf("CatDetailPath = %q", got)
+	}
+	// PathEscape keeps odd slugs from breaking out of the path segment
+	if got := pages.CatDetailPath("a/b c"); got != "/cats/a%2Fb%20c" {
+		t.Errorf("CatDetailPath escaped = %q", got)
+	}
+}
+
+func TestCatDetailPage(t *testing.T) {
+	doc := webtest.RenderPage(t, pages.NewCatDetailPage(luna()))
+
+	doc.AssertText("title", "Meet Luna")
+	doc.AssertText(".cat-name", "Luna")
+	doc.AssertText(".cat-meta", "Domestic Shorthair · 4 years")
+	doc.AssertAttr(".back-link", "href", pages.CatListPath)
+	doc.AssertAttr("form.adopt-form", "action", pages.AdoptPath("luna"))
+	doc.AssertAttr("form.adopt-form", "method", "POST")
+
+	doc.Golden("cat_detail")
+}
+
+func TestCatDetailPageSpanish(t *testing.T) {
+	page := pages.NewCatDetailPage(luna())
+	page.Apply(spanish)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Conoce a Luna")
+	doc.AssertText(".panel h3", "Sobre Luna")
+	doc.AssertText("button.btn-large", "Iniciar adopción")
+}
+
+func TestCatProfileUnavailable(t *testing.T) {
+	doc := webtest.Render(t, pages.CatProfile{Cat: shadow()})
+
+	doc.AssertMissing("a.btn-large")
+	doc.AssertText("p.note", "Shadow is not available for adoption right now.")
+	// Shadow has no description, so the temperament stands in
+	doc.AssertText("p.prose", "Playful kitten.")
+
+	// The compatibility list follows the health box: its facts come last
+	facts := doc.Texts("ul.facts li")
+	if got := facts[len(facts)-2:]; got[0] != "Good with kids: Yes" || got[1] != "Good with other pets: Yes" {
+		t.Errorf("compatibility facts = %q", got)
+	}
+}
+
+func TestCatGallery(t *testing.T) {
+	doc := webtest.Render(t, pages.CatGallery{Name: "Luna", Photos: luna().Photos})
+
+	doc.AssertAttr("img.gallery-main", "src", "/img/luna1.jpg")
+	doc.AssertCount(".gallery-thumbs a", 1)
+	doc.AssertAttr(".gallery-thumbs a", "href", "/img/luna2.jpg")
+}
+
+func TestCatGalleryEmpty(t *testing.T) {
+	doc := webtest.Render(t, pages.CatGallery{Name: "Luna"})
+
+	doc.AssertMissing("img")
+	doc.AssertText("p.note", "No photos yet.")
+}
+
+func TestCatHealth(t *testing.T) {
+	doc := webtest.Render(t, pages.CatHealth{Health: luna().Health})
+
+	doc.AssertText(".info-box h3", "Health & vaccinations")
+	items := doc.Texts("li")
+	want := []string{"Vaccinations: FVRCP, Rabies", "Spayed/neutered: Yes", "Microchipped: Yes", "Notes: Senior dental diet."}
+	if len(items) != len(want) {
+		t.Fatalf("items = %q, want %q", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d = %q, want %q", i, items[i], want[i])
+		}
+	}
+}
+
+func TestCatHealthNothingRecorded(t *testing.T) {
+	doc := webtest.Render(t, pages.CatHealth{Health: cats.Health{}})
+
+	doc.AssertText("li", "Vaccinations: None recorded")
+	doc.AssertCount("li", 3) // no Notes line
+}
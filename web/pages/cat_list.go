@@ -0,0 +1,260 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the searchable /cats catalog listing.
+package pages
+
+import (
+	"html"
+	"strconv"
+
+	"form_exer/cats"
+	"form_exer/i18n"
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// CatListPath is the catalog listing; filters are added as query parameters
+const CatListPath = "/cats"
+
+// CatListURL builds a bookmarkable listing URL for a query
+func CatListURL(q cats.Query) string {
+	if encoded := q.Values().Encode(); encoded != "" {
+		return CatListPath + "?" + encoded
+	}
+	return CatListPath
+}
+
+// FragmentCatResults names the results as a fragment, and is their element id
+const FragmentCatResults = "cat-results"
+
+// CatListPage shows one page of search results with the filter form above it
+type CatListPage struct {
+	shared.Page
+	Query  cats.Query
+	Result cats.Result
+	Breeds []string // choices for the breed dropdown
+}
+
+// NewCatListPage is a CONSTRUCTOR that fills in the shared page title
+func NewCatListPage(q cats.Query, r cats.Result, breeds []string) CatListPage {
+	return CatListPage{
+		Page:   shared.Page{Title: "cats.title"},
+		Query:  q,
+		Result: r,
+		Breeds: breeds,
+	}
+}
+
+func (p CatListPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html("lang", p.I18n.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "container").R(
+				CatFilters{Query: p.Query, Breeds: p.Breeds, Tr: p.I18n}.Render(b),
+				CatResults{Query: p.Query, Result: p.Result, Tr: p.I18n}.Render(b),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
+
+// Fragment renders just the results - the match count, the cards and the pager -
+// so a script can update them as the filters change (see shared.Fragmenter)
+func (p CatListPage) Fragment(name string) (element.Component, bool) {
+	if name == FragmentCatResults {
+		return CatResults{Query: p.Query, Result: p.Result, Tr: p.I18n}, true
+	}
+	return nil, false
+}
+
+// option is one choice in a filter dropdown: the URL value and what the visitor reads
+type option struct {
+	Value, Label string
+}
+
+// CatFilters is a GET form, so submitting it simply produces a new bookmarkable URL
+type CatFilters struct {
+	Query  cats.Query
+	Breeds []string
+	Tr     i18n.Translator
+}
+
+func (f CatFilters) Render(b *element.Builder) (dontCare any) {
+	q, t := f.Query, f.Tr.T
+
+	breeds := []option{{"", t("filter.any_breed")}}
+	for _, breed := range f.Breeds {
+		breeds = append(breeds, option{breed, breed})
+	}
+
+	// ageValue shows an optional bound, or nothing when it isn't set
+	ageValue := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+
+	b.Form("method", "GET", "action", CatListPath, "class", "filters").R(
+		filterInput{Label: t("filter.search"), Name: cats.ParamText, Type: "search", Value: q.Text, Placeholder: t("filter.search_hint")}.Render(b),
+		filterSelect{Label: t("filter.breed"), Name: cats.ParamBreed, Value: q.Breed, Options: breeds}.Render(b),
+		filterSelect{Label: t("filter.status"), Name: cats.ParamStatus, Value: q.Status, Options: []option{
+			{string(cats.StatusAvailable), t("filter.status.available")},
+			{string(cats.StatusPending), t("filter.status.pending")},
+			{string(cats.StatusAdopted), t("filter.status.adopted")},
+			{cats.AnyStatus, t("filter.status.any")},
+		}}.Render(b),
+		filterInput{Label: t("filter.min_age"), Name: cats.ParamMinAge, Type: "number", Value: ageValue(q.MinAge)}.Render(b),
+		filterInput{Label: t("filter.max_age"), Name: cats.ParamMaxAge, Type: "number", Value: ageValue(q.MaxAge)}.Render(b),
+		filterCheckbox{Label: t("filter.kids"), Name: cats.ParamKids, Checked: q.GoodWithKids}.Render(b),
+		filterCheckbox{Label: t("filter.pets"), Name: cats.ParamPets, Checked: q.GoodWithPets}.Render(b),
+		filterSelect{Label: t("filter.sort"), Name: cats.ParamSort, Value: q.Sort, Options: []option{
+			{cats.SortName, t("filter.sort.name")},
+			{cats.SortNameDesc, t("filter.sort.name_desc")},
+			{cats.SortYoungest, t("filter.sort.youngest")},
+			{cats.SortOldest, t("filter.sort.oldest")},
+		}}.Render(b),
+		b.Button("type", "submit", "class", "btn btn-primary").T(t("filter.submit")),
+		b.A("href", CatListPath, "class", "filter-check").T(t("filter.clear")),
+	)
+	return
+}
+
+// filterInput is a compact labelled input for the filter bar
+type filterInput struct {
+	Label, Name, Type, Value, Placeholder string
+}
+
+func (fi filterInput) Render(b *element.Builder) (dontCare any) {
+	attrs := []string{"type", fi.Type, "id", fi.Name, "name", fi.Name, "value", html.EscapeString(fi.Value)}
+	if fi.Type == "number" {
+		attrs = append(attrs, "min", "0", "max", "30")
+	}
+	if fi.Placeholder != "" {
+		attrs = append(attrs, "placeholder", fi.Placeholder)
+	}
+
+	b.Div("class", "filter").R(
+		b.Label("for", fi.Name).T(fi.Label),
+		b.Input(attrs...),
+	)
+	return
+}
+
+// filterSelect renders a dropdown whose options have separate values and labels
+type filterSelect struct {
+	Label, Name, Value string
+	Options            []option
+}
+
+func (fs filterSelect) Render(b *element.Builder) (dontCare any) {
+	b.Div("class", "filter").R(
+		b.Label("for", fs.Name).T(fs.Label),
+		b.Select("id", fs.Name, "name", fs.Name).R(
+			b.Wrap(func() {
+				for _, opt := range fs.Options {
+					attrs := []string{"value", html.EscapeString(opt.Value)}
+					if opt.Value == fs.Value {
+						attrs = append(attrs, "selected", "selected")
+					}
+					b.Option(attrs...).T(html.EscapeString(opt.Label))
+				}
+			}),
+		),
+	)
+	return
+}
+
+// filterCheckbox is a single on/off filter
+type filterCheckbox struct {
+	Label, Name string
+	Checked     bool
+}
+
+func (fc filterCheckbox) Render(b *element.Builder) (dontCare any) {
+	attrs := []string{"type", "checkbox", "name", fc.Name, "value", "1"}
+	if fc.Checked {
+		attrs = append(attrs, "checked", "checked")
+	}
+	b.Label("class", "filter-check").R(
+		b.Input(attrs...),
+		b.T(" ", fc.Label),
+	)
+	return
+}
+
+// CatResults shows the match count, the grid of cards and the pager
+type CatResults struct {
+	Query  cats.Query
+	Result cats.Result
+	Tr     i18n.Translator
+}
+
+func (cr CatResults) Render(b *element.Builder) (dontCare any) {
+	r, tr := cr.Result, cr.Tr
+
+	b.Div("class", "results", "id", FragmentCatResults).R(
+		b.Wrap(func() {
+			// EMPTY STATE: suggest a way out instead of a blank page
+			if r.Total == 0 {
+				b.P("class", "empty-state").R(
+					b.T(tr.T("cats.no_matches"), " "),
+					b.A("href", CatListPath).T(tr.T("cats.show_all")),
+				)
+				return
+			}
+
+			// PLURALIZATION: "1 cat matches", "2 cats match" - and whatever the locale needs
+			b.P().T(tr.N("cats.matches", r.Total))
+
+			CatGrid{Cats: r.Cats, Tr: tr}.Render(b)
+			Pagination{Query: cr.Query, Page: r.Page, Pages: r.Pages, Tr: tr}.Render(b)
+		}),
+	)
+	return
+}
+
+// Pagination links to the previous/next pages and to every page number
+// Each link keeps the current filters, so paging never loses the search
+type Pagination struct {
+	Query cats.Query
+	Page  int
+	Pages int
+	Tr    i18n.Translator
+}
+
+func (pg Pagination) Render(b *element.Builder) (dontCare any) {
+	if pg.Pages <= 1 {
+		return // nothing to page through
+	}
+
+	b.Nav("aria-label", pg.Tr.T("pagination.label"), "class", "pagination").R(
+		b.Wrap(func() {
+			if pg.Page > 1 {
+				b.A("href", html.EscapeString(CatListURL(pg.Query.WithPage(pg.Page-1))), "rel", "prev").T(pg.Tr.T("pagination.prev"))
+			}
+			for n := 1; n <= pg.Pages; n++ {
+				if n == pg.Page {
+					b.Span("aria-current", "page", "class", "current").T(strconv.Itoa(n))
+					continue
+				}
+				b.A("href", html.EscapeString(CatListURL(pg.Query.WithPage(n)))).T(strconv.Itoa(n))
+			}
+			if pg.Page < pg.Pages {
+				b.A("href", html.EscapeString(CatListURL(pg.Query.WithPage(pg.Page+1))), "rel", "next").T(pg.Tr.T("pagination.next"))
+			}
+		}),
+	)
+	return
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. GET FORMS - Filters live in the URL, so results are bookmarkable and shareable
+// 2. UNEXPORTED COMPONENTS - filterInput/filterSelect are private helpers of this page
+// 3. CLOSURES - ageValue formats optional bounds inline
+// 4. PAGINATION - Links rebuilt from the query with WithPage
+// 5. EMPTY STATES - A helpful message and a reset link when nothing matches
+// 6. PLURALIZATION - The result count uses the locale's plural rules
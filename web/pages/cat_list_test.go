@@ -0,0 +1,104 @@
+package pages_test
+
+import (
+	"net/url"
+	"testing"
+
+	"form_exer/cats"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+// queryFrom parses a query string the way the /cats handler does
+func queryFrom(raw string) cats.Query {
+	v, _ := url.ParseQuery(raw)
+	return cats.ParseQuery(v.Get)
+}
+
+func TestCatListURL(t *testing.T) {
+	if got := pages.CatListURL(queryFrom("")); got != "/cats" {
+		t.Errorf("default query URL = %q, want /cats", got)
+	}
+	if got := pages.CatListURL(queryFrom("q=shy&kids=1")); got != "/cats?kids=1&q=shy" {
+		t.Errorf("URL = %q", got)
+	}
+}
+
+func TestCatListPage(t *testing.T) {
+	list := []cats.Cat{luna(), shadow()}
+	q := queryFrom("status=any")
+	page := pages.NewCatListPage(q, cats.Search(list, q), cats.Breeds(list))
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Our Cats")
+	doc.AssertText(".results > p", "2 cats match your search")
+	doc.AssertCount(".cat-card", 2)
+	doc.AssertMissing(".pagination") // one page only
+
+	doc.Golden("cat_list")
+}
+
+func TestCatFiltersKeepQuery(t *testing.T) {
+	q := queryFrom("q=gentle&breed=Bombay&status=pending&min_age=1&max_age=5&kids=1&sort=oldest")
+	doc := webtest.Render(t, pages.CatFilters{Query: q, Breeds: []string{"Bombay", "Siamese"}})
+
+	doc.AssertAttr("form.filters", "method", "GET")
+	doc.AssertAttr("form.filters", "action", pages.CatListPath)
+	doc.AssertAttr(`input[name=q]`, "value", "gentle")
+	doc.AssertText(`select[name=breed] option[selected]`, "Bombay")
+	doc.AssertCount(`select[name=breed] option`, 3) // "Any breed" + 2
+	doc.AssertText(`select[name=status] option[selected]`, "Adoption pending")
+	doc.AssertAttr(`input[name=min_age]`, "value", "1")
+	doc.AssertAttr(`input[name=max_age]`, "value", "5")
+	doc.AssertExists(`input[name=kids][checked]`)
+	doc.AssertMissing(`input[name=pets][checked]`)
+	doc.AssertText(`select[name=sort] option[selected]`, "Oldest first")
+}
+
+// Search text is typed by visitors - it must come back escaped
+func TestCatFiltersEscapeSearch(t *testing.T) {
+	doc := webtest.Render(t, pages.CatFilters{Query: cats.Query{Text: `"><script>x</script>`}})
+
+	doc.AssertMissing("script")
+	doc.AssertAttr(`input[name=q]`, "value", `"><script>x</script>`)
+}
+
+func TestCatResultsEmpty(t *testing.T) {
+	doc := webtest.Render(t, pages.CatResults{Result: cats.Result{Page: 1, Pages: 1}})
+
+	doc.AssertTextContains(".empty-state", "No cats match your search.")
+	doc.AssertAttr(".empty-state a", "href", pages.CatListPath)
+	doc.AssertMissing(".cat-grid")
+}
+
+func TestCatResultsSingular(t *testing.T) {
+	r := cats.Result{Cats: []cats.Cat{luna()}, Total: 1, Page: 1, Pages: 1}
+	doc := webtest.Render(t, pages.CatResults{Result: r})
+	doc.AssertText(".results > p", "1 cat matches your search")
+}
+
+func TestPagination(t *testing.T) {
+	q := queryFrom("q=cat&page=2")
+	doc := webtest.Render(t, pages.Pagination{Query: q, Page: 2, Pages: 3})
+
+	doc.AssertAttr("a[rel=prev]", "href", "/cats?q=cat")
+	doc.AssertAttr("a[rel=next]", "href", "/cats?page=3&q=cat")
+	doc.AssertText(".current", "2")
+	doc.AssertAttr(".current", "aria-current", "page")
+	doc.AssertCount("nav.pagination a", 4) // prev, 1, 3, next
+}
+
+func TestPaginationSinglePage(t *testing.T) {
+	doc := webtest.Render(t, pages.Pagination{Page: 1, Pages: 1})
+	doc.AssertMissing("nav")
+}
+
+func TestPaginationEnds(t *testing.T) {
+	first := webtest.Render(t, pages.Pagination{Page: 1, Pages: 2})
+	first.AssertMissing("a[rel=prev]")
+	first.AssertExists("a[rel=next]")
+
+	last := webtest.Render(t, pages.Pagination{Page: 2, Pages: 2})
+	last.AssertExists("a[rel=prev]")
+	last.AssertMissing("a[rel=next]")
+}
@@ -3,7 +3,9 @@
 package pages
 
 import (
-	"form_exer/web/shared" // Local package with shared components
+	"form_exer/assets"               // Local package with the embedded scripts
+	"form_exer/i18n"                 // Local package with the message catalog
+	"form_exer/web/shared"           // Local package with shared components
 	"github.com/rohanthewiz/element" // Third-party HTML builder library
 )
 
@@ -24,10 +26,11 @@ type ContactPage struct {
 // EXPORTED: Capital 'C' makes it accessible from other packages
 var Contact = ContactPage{
 	// NESTED STRUCT LITERAL: Initializing the embedded Page field
-	Page: shared.Page{Title: "Contact Us"},
+	// Title and Heading are MESSAGE KEYS, translated when the page renders
+	Page: shared.Page{Title: "contact.title"},
 
 	// Initialize the Heading field specific to this page
-	Heading: "Get in Touch",
+	Heading: "contact.heading",
 }
 
 // METHOD with VALUE RECEIVER and NAMED RETURN VALUE
@@ -40,37 +43,58 @@ func (c ContactPage) Render() (out string) {
 
 	// METHOD CHAINING: Build the page structure
 	// The pattern is: body → components (banner, form, footer) → heading
-	b.Body("style", "background-color:tan").R(
-		// COMPOSITE PATTERN: Render multiple components together
-		// element.RenderComponents takes a builder and multiple components
-		element.RenderComponents(b,
-			// METHOD from EMBEDDED FIELD: c.Banner() works due to embedding
-			// Equivalent to c.Page.Banner() but Go allows the shorthand
-			c.Banner(), // Renders the page banner at the top
-
-			// EMPTY STRUCT LITERAL: Creating ContactForm instance inline
-			// ContactForm{} creates a zero-value instance
-			ContactForm{}, // Renders the contact form
-
-			// Another method from the embedded Page
-			c.Footer(), // Renders the page footer at the bottom
+	b.Html("lang", c.I18n.Locale()).R(
+		element.RenderComponents(b, c.Head()),
+		b.Body().R(
+			// COMPOSITE PATTERN: Render multiple components together
+			// element.RenderComponents takes a builder and multiple components
+			element.RenderComponents(b,
+				// METHOD from EMBEDDED FIELD: c.Banner() works due to embedding
+				// Equivalent to c.Page.Banner() but Go allows the shorthand
+				c.Banner(), // Renders the page banner at the top
+
+				// STRUCT LITERAL: Creating ContactForm instance inline
+				// The form gets the page's translator for its placeholders and button
+				ContactForm{Tr: c.I18n}, // Renders the contact form
+
+				// Another method from the embedded Page
+				c.Footer(), // Renders the page footer at the bottom
+			),
+			// Add the page heading after the components
+			// c.Heading holds a message key; I18n.T turns it into the visitor's language
+			b.H1("class", "page-heading").T(c.I18n.T(c.Heading)),
+
+			// PROGRESSIVE ENHANCEMENT: the form works without this script;
+			// with it, the form is sent in the background (see assets/js/enhance.js)
+			// defer runs it once the page is parsed, so the form exists by then
+			b.Script("src", assets.EnhanceScript, "defer", "defer").R(),
 		),
-		// Add the page heading after the components
-		// c.Heading accesses the ContactPage's Heading field
-		b.H1("style", "color:maroon;background-color:#dfc673").T(c.Heading),
 	)
 
 	// Convert the builder to an HTML string and return it
 	return b.String()
 }
 
-// EMPTY STRUCT for FORM COMPONENT
-// ContactForm is a stateless component - no data fields needed
+// FragmentContactForm names the form as a fragment, and is its element id
+const FragmentContactForm = "contact-form"
+
+// Fragment renders just the form, e.g. to reset it after a background submit
+func (c ContactPage) Fragment(name string) (element.Component, bool) {
+	if name == FragmentContactForm {
+		return ContactForm{Tr: c.I18n}, true
+	}
+	return nil, false
+}
+
+// FORM COMPONENT
+// ContactForm holds no form data - only the translator for its visible text
 // All form structure and attributes are defined in the Render method
-type ContactForm struct{}
+type ContactForm struct {
+	Tr i18n.Translator // the zero value renders English
+}
 
 // METHOD with POINTER PARAMETER and NAMED RETURN
-// (cf ContactForm) - value receiver for the empty struct
+// (cf ContactForm) - value receiver
 // (b *element.Builder) - POINTER parameter to avoid copying the builder
 // (dontCare any) - named return with 'any' type (we return nil via naked return)
 func (cf ContactForm) Render(b *element.Builder) (dontCare any) {
@@ -78,30 +102,33 @@ func (cf ContactForm) Render(b *element.Builder) (dontCare any) {
 	// ATTRIBUTE PAIRS: "name", "value", "name", "value" pattern
 	// action="/contact" - where to send form data (POST request to /contact endpoint)
 	// method="POST" - HTTP method for form submission (POST for data modification)
-	b.Form("action", "/contact", "method", "POST").R(
+	// data-enhance opts the form into the fetch-based submit; data-success and
+	// data-failure are the translated messages the script shows
+	b.Form("action", "/contact", "method", "POST", "class", "contact-form", "id", FragmentContactForm,
+		"data-enhance", "", "data-success", cf.Tr.T("contact.sent"), "data-failure", cf.Tr.T("contact.failed")).R(
 		// INPUT ELEMENT: Text input field
 		// MULTIPLE ATTRIBUTES demonstrated:
 		//   type="text" - standard text input (single line)
 		//   name="name" - field name used when submitting form data
-		//   placeholder="Name" - hint text shown when field is empty
-		b.Input("type", "text", "name", "name", "placeholder", "Name"),
+		//   placeholder - hint text shown when field is empty, in the visitor's language
+		b.Input("type", "text", "name", "name", "placeholder", cf.Tr.T("contact.name")),
 
 		// INPUT ELEMENT: Email input field
 		// type="email" - HTML5 input type that validates email format
 		// Browser will enforce basic email validation before submission
-		b.Input("type", "email", "name", "email", "placeholder", "Email"),
+		b.Input("type", "email", "name", "email", "placeholder", cf.Tr.T("contact.email")),
 
 		// TEXTAREA ELEMENT: Multi-line text input
 		// name="message" - field identifier for form submission
 		// .R() with no arguments creates an empty textarea (no child elements)
 		// TextArea is different from Input - it's a paired tag (<textarea></textarea>)
-		b.TextArea("name", "message", "placeholder", "Message").R(),
+		b.TextArea("name", "message", "placeholder", cf.Tr.T("contact.message")).R(),
 
 		// BUTTON ELEMENT: Submit button
 		// type="submit" - clicking this button submits the form
-		// .T("Send") adds text content to the button
+		// .T(...) adds text content to the button ("Send", "Enviar", ...)
 		// When clicked, browser sends POST request to /contact with form data
-		b.Button("type", "submit").T("Send"),
+		b.Button("type", "submit").T(cf.Tr.T("contact.send")),
 	)
 
 	// NAKED RETURN: Returns the named value 'dontCare' (which is nil by default)
@@ -115,8 +142,9 @@ func (cf ContactForm) Render(b *element.Builder) (dontCare any) {
 // 3. NAMED RETURN VALUES - Enable naked returns and self-documentation
 // 4. VALUE RECEIVERS - Methods receive copies of structs
 // 5. POINTER PARAMETERS - Avoid copying large structs (builder)
-// 6. EMPTY STRUCTS - Zero-size structs for stateless components
+// 6. LOCALIZED TEXT - Placeholders and labels come from the message catalog
 // 7. FORM ELEMENTS - Input, TextArea, Button with proper attributes
 // 8. HTML5 INPUT TYPES - email type with built-in validation
 // 9. FORM SUBMISSION - POST method to server endpoint
 // 10. CONSISTENT PATTERNS - Similar structure to Home page for maintainability
+// 11. PROGRESSIVE ENHANCEMENT - data-* attributes configure an optional script
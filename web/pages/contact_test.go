@@ -0,0 +1,52 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/i18n"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestContactPage(t *testing.T) {
+	doc := webtest.RenderPage(t, pages.Contact)
+
+	doc.AssertText("title", "Contact Us")
+	doc.AssertText("header h1", "Contact Us")
+	doc.AssertText("h1.page-heading", "Get in Touch")
+	doc.AssertCount("form.contact-form", 1)
+	doc.AssertAttr("script", "src", "/assets/js/enhance.js")
+
+	doc.Golden("contact")
+}
+
+func TestContactPageSpanish(t *testing.T) {
+	page := pages.Contact
+	page.Apply(spanish)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertAttr("html", "lang", "es")
+	doc.AssertText("h1.page-heading", "Ponte en contacto")
+	doc.AssertText("form button", "Enviar")
+}
+
+func TestContactForm(t *testing.T) {
+	doc := webtest.Render(t, pages.ContactForm{})
+
+	doc.AssertAttr("form", "method", "POST")
+	doc.AssertAttr("form", "action", "/contact")
+	doc.AssertAttr(`input[name=name]`, "type", "text")
+	doc.AssertAttr(`input[name=email]`, "type", "email")
+	doc.AssertAttr(`input[name=email]`, "placeholder", "Email")
+	doc.AssertExists(`textarea[name=message]`)
+	doc.AssertAttr("button", "type", "submit")
+	doc.AssertText("button", "Send")
+}
+
+func TestContactFormTranslated(t *testing.T) {
+	doc := webtest.Render(t, pages.ContactForm{Tr: i18n.Default.Translator("es")})
+
+	doc.AssertAttr(`input[name=name]`, "placeholder", "Nombre")
+	doc.AssertAttr(`textarea[name=message]`, "placeholder", "Mensaje")
+	doc.AssertAttr("form", "data-success", "¡Gracias! Tu mensaje está en camino.")
+}
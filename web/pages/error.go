@@ -0,0 +1,50 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the page shown when a request fails for a reason other than 404.
+package pages
+
+import (
+	"strconv"
+
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// ErrorPage is rendered by the central error handler for 400, 403, 413, 500, ...
+// (404 has its own NotFoundPage)
+type ErrorPage struct {
+	shared.Page
+	Status    int    // HTTP status, shown as the heading
+	Message   string // what went wrong, in plain words (a message key or literal text)
+	RequestID string // quoted as a reference for support, when set
+}
+
+// Error is the default instance - copy it and set Status, Message and RequestID
+var Error = ErrorPage{
+	Page:    shared.Page{Title: "error.title"},
+	Status:  500,
+	Message: "error.internal",
+}
+
+func (p ErrorPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html("lang", p.I18n.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "error-page").R(
+				b.H2().T(strconv.Itoa(p.Status)),
+				b.P().T(p.I18n.T(p.Message)),
+				// CONDITIONAL RENDERING: the reference line only when there is an ID
+				b.Wrap(func() {
+					if p.RequestID != "" {
+						b.P("class", "reference").T(p.I18n.T("error.reference", p.RequestID))
+					}
+				}),
+				b.A("href", "/").T(p.I18n.T("notfound.home_link")),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
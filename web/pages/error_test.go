@@ -0,0 +1,34 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestErrorPage(t *testing.T) {
+	page := pages.Error
+	page.RequestID = "9f2c41d07a3be815"
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText("title", "Something Went Wrong")
+	doc.AssertText(".error-page h2", "500")
+	doc.AssertText(".error-page .reference", "If you contact us about this, please quote reference 9f2c41d07a3be815.")
+	doc.AssertAttr(".error-page a", "href", "/")
+
+	doc.Golden("error")
+}
+
+// Without a request ID there is nothing to quote, so no reference line
+func TestErrorPageWithoutReference(t *testing.T) {
+	page := pages.Error
+	page.Status = 413
+	page.Message = "error.too_large"
+	page.Apply(spanish)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText(".error-page h2", "413")
+	doc.AssertText(".error-page p", "Ese archivo es demasiado grande.")
+	doc.AssertCount(".reference", 0)
+}
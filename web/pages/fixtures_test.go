@@ -0,0 +1,70 @@
+package pages_test
+
+import (
+	"time"
+
+	"form_exer/adoption"
+	"form_exer/cats"
+	"form_exer/i18n"
+	"form_exer/web/shared"
+)
+
+// FIXTURES shared by the tests in this package
+// Built in code rather than read from data/cats.json, so editing the live
+// catalog never breaks a test
+
+func luna() cats.Cat {
+	return cats.Cat{
+		Slug:        "luna",
+		Name:        "Luna",
+		AgeMonths:   48,
+		Breed:       "Domestic Shorthair",
+		Temperament: "Calm and gentle.",
+		Description: "Luna is a true lap cat.",
+		Photos: []cats.Photo{
+			{URL: "/img/luna1.jpg", Alt: "Gray cat"},
+			{URL: "/img/luna2.jpg", Alt: "Luna on a windowsill"},
+		},
+		Health: cats.Health{
+			Vaccinations:   []string{"FVRCP", "Rabies"},
+			SpayedNeutered: true,
+			Microchipped:   true,
+			Notes:          "Senior dental diet.",
+		},
+		Status:       cats.StatusAvailable,
+		GoodWithKids: true,
+	}
+}
+
+func shadow() cats.Cat {
+	return cats.Cat{
+		Slug:         "shadow",
+		Name:         "Shadow",
+		AgeMonths:    8,
+		Breed:        "Bombay",
+		Temperament:  "Playful kitten.",
+		Photos:       []cats.Photo{{URL: "/img/shadow.jpg", Alt: "Black cat"}},
+		Status:       cats.StatusPending,
+		GoodWithKids: true,
+		GoodWithPets: true,
+	}
+}
+
+// fixedTime keeps dates in golden files stable
+var fixedTime = time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+
+// draftApp is an application on its first step
+func draftApp() adoption.Application {
+	return adoption.Application{
+		ID:         "abc123",
+		CatSlug:    "luna",
+		Status:     adoption.StatusDraft,
+		Applicant:  adoption.Applicant{Name: "Jane Doe", Email: "jane@example.com"},
+		References: make([]adoption.Reference, adoption.ReferencesRequired),
+		CreatedAt:  fixedTime,
+		UpdatedAt:  fixedTime,
+	}
+}
+
+// spanish is the settings a visitor who chose Spanish gets
+var spanish = shared.Settings{I18n: i18n.Default.Translator("es")}
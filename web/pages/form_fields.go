@@ -0,0 +1,136 @@
+// Package pages contains all page component definitions for the application.
+// This file holds small, reusable form field components used by the larger forms.
+package pages
+
+import (
+	"html"
+	"strconv"
+
+	"github.com/rohanthewiz/element"
+)
+
+// FormField renders a labelled <input> with an optional error message underneath
+// It follows the same b.Input("name", value, ...) attribute-pair pattern as ContactForm,
+// adding the label and error plumbing every multi-field form needs
+type FormField struct {
+	Label string
+	Name  string
+	Type  string // "text", "email", "tel", "number"... defaults to "text"
+	Value string
+	Error string
+}
+
+func (f FormField) Render(b *element.Builder) (dontCare any) {
+	typ := f.Type
+	if typ == "" {
+		typ = "text"
+	}
+
+	b.Div("class", "field").R(
+		b.Label("for", f.Name, "class", "field-label").T(f.Label),
+		b.Input("type", typ, "id", f.Name, "name", f.Name, "value", html.EscapeString(f.Value),
+			"class", "field-input"),
+		FieldError{Message: f.Error}.Render(b),
+	)
+	return
+}
+
+// TextAreaField is the multi-line version of FormField
+type TextAreaField struct {
+	Label string
+	Name  string
+	Value string
+	Error string
+}
+
+func (f TextAreaField) Render(b *element.Builder) (dontCare any) {
+	b.Div("class", "field").R(
+		b.Label("for", f.Name, "class", "field-label").T(f.Label),
+		b.TextArea("id", f.Name, "name", f.Name, "rows", "3", "class", "field-input").T(
+			html.EscapeString(f.Value),
+		),
+		FieldError{Message: f.Error}.Render(b),
+	)
+	return
+}
+
+// CheckboxField renders a checkbox with its label to the right
+type CheckboxField struct {
+	Label   string
+	Name    string
+	Checked bool
+	Error   string
+}
+
+func (f CheckboxField) Render(b *element.Builder) (dontCare any) {
+	// VARIADIC ARGUMENTS built up in a slice, then expanded with "..."
+	// "checked" is a boolean HTML attribute - it is only added when true
+	attrs := []string{"type", "checkbox", "id", f.Name, "name", f.Name, "value", "on"}
+	if f.Checked {
+		attrs = append(attrs, "checked", "checked")
+	}
+
+	b.Div("class", "field").R(
+		b.Label("class", "check-label").R(
+			b.Input(attrs...),
+			b.T(" ", f.Label),
+		),
+		FieldError{Message: f.Error}.Render(b),
+	)
+	return
+}
+
+// SelectField renders a <select> of options, pre-selecting the current value
+type SelectField struct {
+	Label   string
+	Name    string
+	Options []string
+	Value   string
+	Error   string
+	Prompt  string // text of the empty first option; "" means "Choose..."
+}
+
+func (f SelectField) Render(b *element.Builder) (dontCare any) {
+	prompt := f.Prompt
+	if prompt == "" {
+		prompt = "Choose..."
+	}
+
+	b.Div("class", "field").R(
+		b.Label("for", f.Name, "class", "field-label").T(f.Label),
+		b.Select("id", f.Name, "name", f.Name).R(
+			b.Option("value", "").T(prompt),
+			b.Wrap(func() {
+				for _, opt := range f.Options {
+					attrs := []string{"value", html.EscapeString(opt)}
+					if opt == f.Value {
+						attrs = append(attrs, "selected", "selected")
+					}
+					b.Option(attrs...).T(html.EscapeString(opt))
+				}
+			}),
+		),
+		FieldError{Message: f.Error}.Render(b),
+	)
+	return
+}
+
+// FieldError shows a validation message in red, or nothing at all when Message is empty
+type FieldError struct {
+	Message string
+}
+
+func (fe FieldError) Render(b *element.Builder) (dontCare any) {
+	if fe.Message != "" {
+		b.P("class", "field-error").T(html.EscapeString(fe.Message))
+	}
+	return
+}
+
+// itoa renders a number for a form value, leaving zero blank so empty fields stay empty
+func itoa(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
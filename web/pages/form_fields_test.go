@@ -0,0 +1,57 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestFormField(t *testing.T) {
+	doc := webtest.Render(t, pages.FormField{Label: "Email", Name: "email", Type: "email", Value: `a"b@x.com`, Error: "Bad <email>"})
+
+	doc.AssertAttr("label", "for", "email")
+	doc.AssertText("label", "Email")
+	doc.AssertAttr("input#email", "type", "email")
+	doc.AssertAttr("input#email", "value", `a"b@x.com`)
+	doc.AssertText(".field-error", "Bad <email>")
+}
+
+func TestFormFieldDefaults(t *testing.T) {
+	doc := webtest.Render(t, pages.FormField{Label: "Name", Name: "name"})
+
+	doc.AssertAttr("input", "type", "text")
+	doc.AssertMissing(".field-error")
+}
+
+func TestTextAreaField(t *testing.T) {
+	doc := webtest.Render(t, pages.TextAreaField{Label: "Notes", Name: "notes", Value: "</textarea><b>x</b>"})
+
+	doc.AssertText("textarea#notes", "</textarea><b>x</b>")
+	doc.AssertMissing("b")
+}
+
+func TestCheckboxField(t *testing.T) {
+	on := webtest.Render(t, pages.CheckboxField{Label: "Agree", Name: "agree", Checked: true})
+	on.AssertExists(`input[type=checkbox][checked]`)
+	on.AssertText("label.check-label", "Agree")
+
+	off := webtest.Render(t, pages.CheckboxField{Label: "Agree", Name: "agree"})
+	off.AssertMissing("input[checked]")
+}
+
+func TestSelectField(t *testing.T) {
+	doc := webtest.Render(t, pages.SelectField{Label: "Home", Name: "home", Options: []string{"house", "condo"}, Value: "condo"})
+
+	doc.AssertCount("select#home option", 3)
+	doc.AssertText("option", "Choose...")
+	doc.AssertText("option[selected]", "condo")
+
+	custom := webtest.Render(t, pages.SelectField{Name: "home", Prompt: "Elige..."})
+	custom.AssertText("option", "Elige...")
+}
+
+func TestFieldError(t *testing.T) {
+	webtest.Render(t, pages.FieldError{}).AssertMissing("p")
+	webtest.Render(t, pages.FieldError{Message: "Oops"}).AssertText("p.field-error", "Oops")
+}
@@ -0,0 +1,45 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/cats"
+	"form_exer/web/pages"
+	"form_exer/web/shared"
+	"form_exer/web/webtest"
+)
+
+// Every fragment a page offers renders on its own, and its root element
+// carries the fragment's name as its id - that is where a client swaps it in
+func TestFragments(t *testing.T) {
+	home := pages.HomePage
+	home.Cats = []cats.Cat{luna(), shadow()}
+	list := pages.NewCatListPage(cats.Query{}, cats.Search([]cats.Cat{luna(), shadow()}, cats.Query{Status: cats.AnyStatus}), nil)
+	contact := pages.Contact
+	contact.Apply(spanish)
+
+	tests := []struct {
+		page  shared.Fragmenter
+		name  string
+		check func(*webtest.Doc)
+	}{
+		{home, pages.FragmentCatGrid, func(d *webtest.Doc) { d.AssertCount(".cat-card", 2) }},
+		{list, pages.FragmentCatResults, func(d *webtest.Doc) { d.AssertExists("#cat-grid .cat-card") }},
+		{contact, pages.FragmentContactForm, func(d *webtest.Doc) { d.AssertText("button", "Enviar") }},
+	}
+	for _, tt := range tests {
+		c, ok := tt.page.Fragment(tt.name)
+		if !ok {
+			t.Errorf("%T has no fragment %q", tt.page, tt.name)
+			continue
+		}
+		doc := webtest.Render(t, c)
+		doc.AssertExists("#" + tt.name)
+		doc.AssertMissing("html")
+		tt.check(doc)
+
+		if _, ok := tt.page.Fragment("no-such-part"); ok {
+			t.Errorf("%T renders an unknown fragment", tt.page)
+		}
+	}
+}
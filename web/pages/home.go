@@ -15,11 +15,13 @@ var HomePage = Home{
 	// EMBEDDED FIELD: Page is embedded (no field name, just the type)
 	// This gives Home access to all Page fields and methods
 	// We initialize it with a nested struct literal
-	Page: shared.Page{Title: "My Website"},
+	// MESSAGE KEYS, not text: the title and heading are looked up in the visitor's
+	// language when the page renders (see the i18n package and locales/*.json)
+	Page: shared.Page{Title: "home.title"},
 
 	// Regular field: Heading is a specific field of the Home struct
 	// This is different from Page.Title - Heading is used for page content
-	Heading: "Home Page",
+	Heading: "home.heading",
 }
 
 // KEY CONCEPTS demonstrated in this file:
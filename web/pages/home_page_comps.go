@@ -3,7 +3,11 @@
 package pages
 
 import (
-	"form_exer/web/shared" // Local package with shared components (Banner, Footer, Page)
+	"html" // Standard library - escaping data before it goes into the page
+
+	"form_exer/cats"                 // Local package with the cat domain model
+	"form_exer/i18n"                 // Local package with the message catalog
+	"form_exer/web/shared"           // Local package with shared components (Banner, Footer, Page)
 	"github.com/rohanthewiz/element" // Third-party HTML builder library
 )
 
@@ -18,40 +22,49 @@ type Home struct {
 	// Additional field specific to Home page
 	// This demonstrates extending the base Page with page-specific data
 	Heading string
+
+	// Cats holds the adoptable cats to feature, loaded from the cat repository
+	// by the route handler. The HomePage singleton leaves it empty - handlers
+	// copy the value and fill this in per request (value semantics make that cheap)
+	Cats []cats.Cat
 }
 
 // METHOD with VALUE RECEIVER and NAMED RETURN VALUE
 // (h Home) - value receiver, method belongs to Home type
 // (out string) - NAMED RETURN VALUE: the return variable is declared in the signature
-//   This creates a variable 'out' that's automatically returned (though we don't use it here)
-//   Named returns make code self-documenting and enable "naked returns"
+//
+//	This creates a variable 'out' that's automatically returned (though we don't use it here)
+//	Named returns make code self-documenting and enable "naked returns"
 func (h Home) Render() (out string) {
 	// Create a new HTML builder instance
 	// element.NewBuilder() returns a pointer to a Builder
 	b := element.NewBuilder()
 
 	// METHOD CHAINING: Build the HTML structure
-	// b.Body() creates a <body> tag with inline CSS
+	// b.Html() creates the <html> tag; lang tells browsers and screen readers the page's language
 	// .R() is a VARIADIC METHOD - accepts any number of arguments
-	b.Body("style", "background-color:tan").R(
-		// FUNCTION CALL: element.RenderComponents is a helper function
-		// It takes a builder and multiple components, renders each component
-		// This demonstrates the COMPOSITE PATTERN - combining multiple components
-		element.RenderComponents(b,
-			// METHOD CALL on EMBEDDED FIELD: h.Banner() works because Page is embedded
-			// This is equivalent to h.Page.Banner(), but Go allows the shorthand
-			h.Banner(), // Returns Banner struct from the embedded Page
-
-			// STRUCT LITERAL: Creating a CatAdoptionHero instance inline
-			// Since CatAdoptionHero is empty, we use {}
-			CatAdoptionHero{},
-
-			// Another method from the embedded Page
-			h.Footer(), // Returns Footer struct
+	b.Html("lang", h.I18n.Locale()).R(
+		element.RenderComponents(b, h.Head()),
+		b.Body().R(
+			// FUNCTION CALL: element.RenderComponents is a helper function
+			// It takes a builder and multiple components, renders each component
+			// This demonstrates the COMPOSITE PATTERN - combining multiple components
+			element.RenderComponents(b,
+				// METHOD CALL on EMBEDDED FIELD: h.Banner() works because Page is embedded
+				// This is equivalent to h.Page.Banner(), but Go allows the shorthand
+				h.Banner(), // Returns Banner struct from the embedded Page
+
+				// STRUCT LITERAL: Creating a CatAdoptionHero instance inline
+				// The cats and the visitor's translator flow down from the page into the component
+				CatAdoptionHero{Cats: h.Cats, Tr: h.I18n},
+
+				// Another method from the embedded Page
+				h.Footer(), // Returns Footer struct
+			),
+			// Add a heading after the components
+			// h.Heading is a message key; h.I18n (from the embedded Page) translates it
+			b.H1("class", "page-heading").T(h.I18n.T(h.Heading)),
 		),
-		// Add a heading after the components
-		// h.Heading accesses the Home struct's Heading field
-		b.H1("style", "color:maroon;background-color:#dfc673").T(h.Heading),
 	)
 
 	// METHOD CALL: b.String() converts the builder to an HTML string
@@ -59,75 +72,46 @@ func (h Home) Render() (out string) {
 	return b.String()
 }
 
-// EMPTY STRUCT as a Component
-// CatAdoptionHero has no fields but provides rendering behavior
-// This is a stateless component - all its data is hardcoded in the Render method
-type CatAdoptionHero struct{}
+// DATA-DRIVEN COMPONENT
+// CatAdoptionHero renders whatever cats it is given - it knows nothing about
+// where they came from (file, database, ...). That is the repository's job.
+type CatAdoptionHero struct {
+	Cats []cats.Cat
+	Tr   i18n.Translator // the zero value renders English
+}
 
 // METHOD with NAMED RETURN VALUE and 'any' TYPE
-// (c CatAdoptionHero) - value receiver for the empty struct
+// (c CatAdoptionHero) - value receiver
 // (dontCare any) - NAMED RETURN with type 'any' (alias for interface{})
-//   The name "dontCare" documents that we ignore the return value
-//   'any' can hold any type - maximum flexibility
+//
+//	The name "dontCare" documents that we ignore the return value
+//	'any' can hold any type - maximum flexibility
 func (c CatAdoptionHero) Render(b *element.Builder) (dontCare any) {
 	// CONTAINER DIV with responsive design
-	// max-width limits content width on large screens
-	// margin:0 auto centers the container horizontally
-	b.Div("style", "max-width:1200px; margin:0 auto; padding:40px 20px").R(
+	// "container" limits content width on large screens and centers it
+	b.Div("class", "container").R(
 		// H2 heading - centered with custom styling
-		b.H2("style", "text-align:center; color:#2c3e50; font-size:2.5em; margin-bottom:20px").T("Find Your Purr-fect Companion"),
+		// Every visible sentence is a MESSAGE KEY looked up in the visitor's language
+		b.H2("class", "hero-title").T(c.Tr.T("hero.title")),
 
 		// P paragraph - .T() adds text content
-		// Text can be a single string or multiple strings concatenated
-		b.P("style", "text-align:center; color:#555; font-size:1.2em; margin-bottom:40px").T(
-			"Give a loving cat a forever home. Browse our adoptable cats and kittens waiting to meet you!",
-		),
-
-		// CSS GRID LAYOUT: Modern, responsive card layout
-		// display:grid creates a grid container
-		// grid-template-columns: repeat(auto-fit, minmax(300px, 1fr)) creates responsive columns:
-		//   - auto-fit: automatically fits as many columns as possible
-		//   - minmax(300px, 1fr): each column is min 300px, max 1 fraction of available space
-		// gap:30px adds space between grid items
-		b.Div("style", "display:grid; grid-template-columns:repeat(auto-fit, minmax(300px, 1fr)); gap:30px; margin-top:40px").R(
-			// CARD COMPONENT PATTERN: Each cat is a card (Div) with image, text, and button
-			// These 3 cards demonstrate repeating patterns - in real apps, use a loop with data
-
-			// Cat Card 1 - Demonstrating the card structure
-			b.Div("style", "background:white; border-radius:10px; box-shadow:0 4px 6px rgba(0,0,0,0.1); padding:20px").R(
-				// IMG TAG with multiple attributes
-				// Attributes are pairs: "name", "value", "name", "value"
-				// This is a VARIADIC FUNCTION pattern - accepts any number of string pairs
-				b.Img("src", "https://placekitten.com/400/300", "alt", "Orange tabby cat", "style", "width:100%; border-radius:8px; margin-bottom:15px"),
-
-				// H3 heading for the cat's name
-				b.H3("style", "color:#2c3e50; margin:10px 0").T("Whiskers"),
-
-				// P paragraph with description
-				// line-height:1.6 improves readability with proper spacing
-				b.P("style", "color:#666; line-height:1.6").T("A friendly orange tabby who loves to play and cuddle. Great with kids and other pets. Age: 2 years."),
-
-				// BUTTON element - demonstrates form controls
-				// cursor:pointer changes cursor on hover (UX improvement)
-				b.Button("style", "background-color:#e67e22; color:white; border:none; padding:10px 20px; border-radius:5px; cursor:pointer; font-size:1em; margin-top:10px").T("Meet Whiskers"),
-			),
-
-			// Cat 2
-			b.Div("style", "background:white; border-radius:10px; box-shadow:0 4px 6px rgba(0,0,0,0.1); padding:20px").R(
-				b.Img("src", "https://placekitten.com/401/300", "alt", "Gray and white cat", "style", "width:100%; border-radius:8px; margin-bottom:15px"),
-				b.H3("style", "color:#2c3e50; margin:10px 0").T("Luna"),
-				b.P("style", "color:#666; line-height:1.6").T("A calm and gentle gray beauty who enjoys quiet afternoons. Perfect for apartment living. Age: 4 years."),
-				b.Button("style", "background-color:#e67e22; color:white; border:none; padding:10px 20px; border-radius:5px; cursor:pointer; font-size:1em; margin-top:10px").T("Meet Luna"),
-			),
-
-			// Cat 3
-			b.Div("style", "background:white; border-radius:10px; box-shadow:0 4px 6px rgba(0,0,0,0.1); padding:20px").R(
-				b.Img("src", "https://placekitten.com/402/300", "alt", "Black cat", "style", "width:100%; border-radius:8px; margin-bottom:15px"),
-				b.H3("style", "color:#2c3e50; margin:10px 0").T("Shadow"),
-				b.P("style", "color:#666; line-height:1.6").T("A playful black kitten full of energy and curiosity. Loves interactive toys and exploring. Age: 8 months."),
-				b.Button("style", "background-color:#e67e22; color:white; border:none; padding:10px 20px; border-radius:5px; cursor:pointer; font-size:1em; margin-top:10px").T("Meet Shadow"),
-			),
-		),
+		b.P("class", "hero-lead").T(c.Tr.T("hero.lead")),
+
+		// WRAP lets us run ordinary Go logic (if/for) in the middle of a render tree
+		b.Wrap(func() {
+			// EMPTY STATE: Always tell the visitor something rather than showing a blank area
+			if len(c.Cats) == 0 {
+				b.P("class", "empty-state").T(c.Tr.T("hero.empty"))
+				return
+			}
+
+			CatGrid{Cats: c.Cats, Tr: c.Tr}.Render(b)
+
+			// The home page only features a few cats - the full catalog has search and filters
+			b.P("class", "browse-all").R(
+				b.A("href", CatListPath).T(c.Tr.T("hero.browse_all")),
+			)
+		}),
 	)
 
 	// NAKED RETURN: Just "return" without a value
@@ -137,6 +121,82 @@ func (c CatAdoptionHero) Render(b *element.Builder) (dontCare any) {
 	return
 }
 
+// FragmentCatGrid names the grid as a fragment, and is its element id
+const FragmentCatGrid = "cat-grid"
+
+// Fragment renders just the featured cats (see shared.Fragmenter)
+func (h Home) Fragment(name string) (element.Component, bool) {
+	if name == FragmentCatGrid {
+		return CatGrid{Cats: h.Cats, Tr: h.I18n}, true
+	}
+	return nil, false
+}
+
+// CatGrid lays out a card per cat; shared by the home page and the /cats listing
+type CatGrid struct {
+	Cats []cats.Cat
+	Tr   i18n.Translator
+}
+
+func (g CatGrid) Render(b *element.Builder) (dontCare any) {
+	// CSS GRID LAYOUT: Modern, responsive card layout
+	// The "cat-grid" class (web/theme) uses repeat(auto-fit, minmax(300px, 1fr)):
+	//   - auto-fit: automatically fits as many columns as possible
+	//   - minmax(300px, 1fr): each column is min 300px, max 1 fraction of available space
+	b.Div("class", "cat-grid", "id", FragmentCatGrid).R(
+		b.Wrap(func() {
+			// RANGE LOOP: One card per cat - the data decides how many cards there are
+			for _, cat := range g.Cats {
+				CatCard{Cat: cat, Tr: g.Tr}.Render(b)
+			}
+		}),
+	)
+	return
+}
+
+// CARD COMPONENT: CatCard renders a single cat with image, text, and button
+// Pulling the card out into its own component keeps CatAdoptionHero small
+// and lets other pages reuse the exact same card
+type CatCard struct {
+	Cat cats.Cat
+	Tr  i18n.Translator
+}
+
+func (cc CatCard) Render(b *element.Builder) (dontCare any) {
+	// ESCAPING: element writes text and attributes verbatim, so any value that
+	// comes from stored data is escaped to keep stray "<" or quotes from breaking the page
+	name := html.EscapeString(cc.Cat.Name)
+	photo := cc.Cat.MainPhoto()
+
+	b.Div("class", "cat-card").R(
+		// IMG TAG with multiple attributes
+		// Attributes are pairs: "name", "value", "name", "value"
+		b.Img("src", html.EscapeString(photo.URL), "alt", html.EscapeString(photo.Alt)),
+
+		// H3 heading for the cat's name
+		b.H3().T(name),
+
+		// P paragraph with description
+		b.P().T(
+			html.EscapeString(cc.Cat.Temperament), " ", cc.Tr.T("card.age", ageLabel(cc.Tr, cc.Cat.AgeMonths)),
+		),
+
+		// LINK STYLED AS A BUTTON: Navigation belongs in an <a> tag (it works without JavaScript,
+		// can be opened in a new tab, etc.) - the "btn" classes make it look like a button
+		b.A("href", CatDetailPath(cc.Cat.Slug), "class", "btn btn-primary").T(cc.Tr.T("card.meet", name)),
+	)
+	return
+}
+
+// ageLabel is the translated form of cats.Cat.AgeLabel: "8 months", "1 año", "4 ans"
+// PLURALIZATION: N picks the right form for the count in the visitor's language
+func ageLabel(tr i18n.Translator, months int) string {
+	if months < 12 {
+		return tr.N("age.months", months)
+	}
+	return tr.N("age.years", months/12)
+}
+
 // KEY CONCEPTS demonstrated in this file:
 // 1. STRUCT EMBEDDING - Home embeds shared.Page for the mixin pattern
 // 2. NAMED RETURN VALUES - Enables naked returns and self-documenting code
@@ -144,8 +204,8 @@ func (c CatAdoptionHero) Render(b *element.Builder) (dontCare any) {
 // 4. METHOD CHAINING - Fluent API for building HTML
 // 5. VARIADIC FUNCTIONS - Functions accepting any number of arguments
 // 6. COMPOSITE PATTERN - Combining multiple components into pages
-// 7. CSS GRID - Modern responsive layout directly in Go code
-// 8. EMPTY STRUCTS - Zero-size structs for stateless components
-// 9. 'any' TYPE - Go's universal type (interface{}) for maximum flexibility
+// 7. CSS GRID - Responsive layout from a theme class
+// 8. DATA-DRIVEN COMPONENTS - Cards are generated from repository data in a loop
+// 9. EMPTY STATES - Rendering a friendly message when there is no data
 // 10. NAKED RETURNS - Returning named values without explicit specification
-
+// 11. TRANSLATORS AS DATA - Components receive an i18n.Translator like any other field
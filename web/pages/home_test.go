@@ -0,0 +1,116 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/cats"
+	"form_exer/i18n"
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestHomePage(t *testing.T) {
+	page := pages.HomePage
+	page.Cats = []cats.Cat{luna(), shadow()}
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertAttr("html", "lang", "en")
+	doc.AssertText("title", "My Website")
+	doc.AssertText("header.site-header h1", "My Website")
+	doc.AssertText("h1.page-heading", "Home Page")
+	doc.AssertText(".hero-title", "Find Your Purr-fect Companion")
+	doc.AssertCount(".cat-card", 2)
+	doc.AssertAttr(".browse-all a", "href", pages.CatListPath)
+	doc.AssertExists("footer.site-footer")
+
+	doc.Golden("home")
+}
+
+func TestHomePageSpanish(t *testing.T) {
+	page := pages.HomePage
+	page.Cats = []cats.Cat{luna()}
+	page.Apply(spanish)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertAttr("html", "lang", "es")
+	doc.AssertText("title", "Mi sitio web")
+	doc.AssertText(".hero-title", "Encuentra a tu compañero ideal")
+	doc.AssertText(".cat-card a.btn", "Conoce a Luna")
+
+	doc.Golden("home_es")
+}
+
+// The singleton must stay empty - handlers copy it and fill in the cats
+func TestHomePageSingletonUntouched(t *testing.T) {
+	page := pages.HomePage
+	page.Cats = []cats.Cat{luna()}
+	if len(pages.HomePage.Cats) != 0 {
+		t.Fatal("filling a copy changed pages.HomePage")
+	}
+}
+
+func TestCatAdoptionHeroEmpty(t *testing.T) {
+	doc := webtest.Render(t, pages.CatAdoptionHero{})
+
+	doc.AssertText(".empty-state", "All of our cats have found homes for now. Please check back soon!")
+	doc.AssertMissing(".cat-grid")
+	doc.AssertMissing(".browse-all")
+}
+
+func TestCatGrid(t *testing.T) {
+	doc := webtest.Render(t, pages.CatGrid{Cats: []cats.Cat{luna(), shadow()}})
+
+	doc.AssertCount(".cat-grid > .cat-card", 2)
+	names := doc.Texts(".cat-card h3")
+	if len(names) != 2 || names[0] != "Luna" || names[1] != "Shadow" {
+		t.Errorf("card order = %q, want [Luna Shadow]", names)
+	}
+}
+
+func TestCatCard(t *testing.T) {
+	doc := webtest.Render(t, pages.CatCard{Cat: luna()})
+
+	doc.AssertAttr("img", "src", "/img/luna1.jpg")
+	doc.AssertAttr("img", "alt", "Gray cat")
+	doc.AssertText("h3", "Luna")
+	doc.AssertText("p", "Calm and gentle. Age: 4 years.")
+	doc.AssertAttr("a.btn.btn-primary", "href", "/cats/luna")
+	doc.AssertText("a.btn", "Meet Luna")
+}
+
+// Names and photo text come from staff input, so they must be escaped
+func TestCatCardEscapes(t *testing.T) {
+	c := luna()
+	c.Name = `<script>alert("x")</script>`
+	c.Photos[0].Alt = `"quoted"`
+	doc := webtest.Render(t, pages.CatCard{Cat: c})
+
+	doc.AssertMissing("script")
+	doc.AssertText("h3", c.Name)
+	doc.AssertAttr("img", "alt", `"quoted"`)
+}
+
+// Ages use the locale's plural forms: months under a year, years after
+func TestCatCardAgePlurals(t *testing.T) {
+	es := i18n.Default.Translator("es")
+	cases := []struct {
+		months int
+		tr     i18n.Translator
+		want   string
+	}{
+		{1, i18n.Translator{}, "Age: 1 month."},
+		{8, i18n.Translator{}, "Age: 8 months."},
+		{12, i18n.Translator{}, "Age: 1 year."},
+		{30, i18n.Translator{}, "Age: 2 years."},
+		{1, es, "Edad: 1 mes."},
+		{12, es, "Edad: 1 año."},
+		{36, es, "Edad: 3 años."},
+	}
+	for _, tc := range cases {
+		c := luna()
+		c.Temperament = ""
+		c.AgeMonths = tc.months
+		doc := webtest.Render(t, pages.CatCard{Cat: c, Tr: tc.tr})
+		doc.AssertText("p", tc.want)
+	}
+}
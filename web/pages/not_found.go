@@ -0,0 +1,40 @@
+// Package pages contains all page component definitions for the application.
+// This file defines the page shown when a requested resource does not exist.
+package pages
+
+import (
+	"form_exer/web/shared"
+	"github.com/rohanthewiz/element"
+)
+
+// NotFoundPage is rendered with a 404 status when a lookup fails
+// (for example /cats/<slug> for a slug that isn't in the catalog)
+type NotFoundPage struct {
+	shared.Page
+	Message string // what couldn't be found, in plain words
+}
+
+// NotFound is the default instance - copy it and set Message for specifics
+// Title and Message are message keys (a literal Message also works - it passes through unchanged)
+var NotFound = NotFoundPage{
+	Page:    shared.Page{Title: "notfound.title"},
+	Message: "notfound.message",
+}
+
+func (p NotFoundPage) Render() (out string) {
+	b := element.NewBuilder()
+
+	b.Html("lang", p.I18n.Locale()).R(
+		element.RenderComponents(b, p.Head()),
+		b.Body().R(
+			element.RenderComponents(b, p.Banner()),
+			b.Div("class", "not-found").R(
+				b.H2().T("404"),
+				b.P().T(p.I18n.T(p.Message)),
+				b.A("href", "/").T(p.I18n.T("notfound.home_link")),
+			),
+			element.RenderComponents(b, p.Footer()),
+		),
+	)
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package pages_test
+
+import (
+	"testing"
+
+	"form_exer/web/pages"
+	"form_exer/web/webtest"
+)
+
+func TestNotFoundPage(t *testing.T) {
+	doc := webtest.RenderPage(t, pages.NotFound)
+
+	doc.AssertText("title", "Page Not Found")
+	doc.AssertText(".not-found h2", "404")
+	doc.AssertText(".not-found p", "Sorry, we couldn't find what you were looking for.")
+	doc.AssertAttr(".not-found a", "href", "/")
+
+	doc.Golden("not_found")
+}
+
+// Handlers swap in a more specific message key
+func TestNotFoundPageMessage(t *testing.T) {
+	page := pages.NotFound
+	page.Message = "notfound.cat_gone"
+	page.Apply(spanish)
+	doc := webtest.RenderPage(t, page)
+
+	doc.AssertText(".not-found p", "No encontramos ese gato. ¡Puede que ya haya encontrado un hogar!")
+	doc.AssertText(".not-found a", "Volver a la página de inicio")
+}
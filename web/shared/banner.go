@@ -24,9 +24,11 @@ type Banner struct {
 // The element package uses 'any' for flexibility, though we return nil here
 func (b Banner) Render(builder *element.Builder) any {
 	// METHOD CHAINING: Each method returns the builder for chaining calls
-	// builder.Header() creates a <header> tag with inline CSS styles
+	// builder.Header() creates a <header> tag
+	// CSS CLASS instead of inline styles: "site-header" is defined by the theme
+	// stylesheet (web/theme), so its colors follow the visitor's chosen theme
 	// .R() is a variadic method that accepts child elements (components or tags)
-	builder.Header("style", "background-color:#2c3e50; color:white; padding:20px").R(
+	builder.Header("class", "site-header").R(
 		// builder.H1() creates an <h1> tag
 		// .T() adds text content (T = Text)
 		// b.Title accesses the Title field from the Banner struct
@@ -43,5 +45,5 @@ func (b Banner) Render(builder *element.Builder) any {
 // 2. METHOD RECEIVERS - (b Banner) attaches Render to the Banner type
 // 3. POINTER PARAMETERS - *element.Builder avoids copying large structs
 // 4. METHOD CHAINING - Builder pattern for fluent API
-// 5. INLINE CSS - Styling HTML elements programmatically
+// 5. CSS CLASSES - Styling comes from the theme stylesheet, not literals
 // 6. 'any' TYPE - Go's alias for interface{} (accepts any type)
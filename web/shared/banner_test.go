@@ -0,0 +1,21 @@
+package shared_test
+
+import (
+	"testing"
+
+	"form_exer/web/shared"
+	"form_exer/web/webtest"
+)
+
+func TestBanner(t *testing.T) {
+	doc := webtest.Render(t, shared.Banner{Title: "Cat Adoption"})
+
+	doc.AssertCount("header.site-header", 1)
+	doc.AssertText("header.site-header > h1", "Cat Adoption")
+}
+
+// Banner writes its title verbatim - callers escape anything that came from users
+func TestBannerKeepsEntities(t *testing.T) {
+	doc := webtest.Render(t, shared.Banner{Title: "Cats &amp; Kittens"})
+	doc.AssertText("h1", "Cats & Kittens")
+}
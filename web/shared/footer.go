@@ -1,37 +1,107 @@
 package shared
 
-import "github.com/rohanthewiz/element"
+import (
+	"html"
+	"net/url"
 
-// EMPTY STRUCT: Footer is a component with no data fields
-// Empty structs (struct{}) have zero size in memory - very efficient!
-// They're useful for components that only provide behavior (methods), not data
-// Even though Footer has no fields, it can still have methods
-type Footer struct{}
+	"github.com/rohanthewiz/element"
+
+	"form_exer/i18n"
+	"form_exer/web/theme"
+)
+
+// Footer is the bottom of every page: the copyright line and the theme and language switchers
+// It carries the current theme and translator so the switchers can mark the active choices
+type Footer struct {
+	Theme string
+	Tr    i18n.Translator // renders the footer's text in the visitor's language
+	Query string          // the page's query string, which the switchers' links keep
+}
 
 // INTERFACE IMPLEMENTATION: Implements element.Component interface
-// This is the same interface as Banner, but Footer has no data to store
+// This is the same interface as Banner
 //
 // METHOD with VALUE RECEIVER
-// (f Footer) - even though Footer is empty, we still use a receiver for consistency
+// (f Footer) - value receiver, the method works on a copy of the footer
 // (b *element.Builder) - pointer to the builder (note: parameter name is 'b' not 'builder')
-//   Different parameter names are fine - the type is what matters for the interface
+//
+//	Different parameter names are fine - the type is what matters for the interface
 func (f Footer) Render(b *element.Builder) any {
 	// METHOD CHAINING to build HTML structure
-	// b.Div() creates a <div> tag with inline CSS styling
-	b.Div("style", "background-color:lightgray").R(
+	// b.Footer() creates a <footer> tag; "site-footer" is styled by the theme stylesheet
+	b.Footer("class", "site-footer").R(
 		// b.P() creates a <p> paragraph tag
-		// &copy; is an HTML entity for the copyright symbol ©
-		b.P("style", "color:gray").T("Copyright &copy; 2025"),
+		// The copyright message contains &copy;, an HTML entity for the symbol ©
+		b.P().T(f.Tr.T("footer.copyright")),
+		ThemeSwitcher{Current: f.Theme, Tr: f.Tr, Query: f.Query}.Render(b),
+		LanguageSwitcher{Tr: f.Tr, Query: f.Query}.Render(b),
 	)
 
 	// Return nil - no error or special value to return
 	return nil
 }
 
+// ThemeSwitcher offers a plain link per theme
+// "?theme=dark" is a RELATIVE URL: it reloads the current page with the new theme,
+// and the server remembers the choice in a cookie - no JavaScript needed.
+// The rest of the page's query stays, so a filtered list stays filtered
+type ThemeSwitcher struct {
+	Current string
+	Tr      i18n.Translator
+	Query   string // the page's query string, without the "?"
+}
+
+func (ts ThemeSwitcher) Render(b *element.Builder) any {
+	b.P("class", "theme-switcher").R(
+		b.T(ts.Tr.T("footer.theme"), " "),
+		b.Wrap(func() {
+			for _, name := range theme.Names {
+				attrs := []string{"href", switchHref(ts.Query, theme.QueryParam, name)}
+				if name == ts.Current {
+					attrs = append(attrs, "class", "current", "aria-current", "true")
+				}
+				b.A(attrs...).T(ts.Tr.T("theme." + name))
+			}
+		}),
+	)
+	return nil
+}
+
+// LanguageSwitcher links to the current page in each available language
+// Each language is named in that language ("Español", not "Spanish"),
+// so visitors can find theirs even when they can't read the current one
+type LanguageSwitcher struct {
+	Tr    i18n.Translator
+	Query string // the page's query string, without the "?"
+}
+
+func (ls LanguageSwitcher) Render(b *element.Builder) any {
+	b.P("class", "theme-switcher").R(
+		b.Wrap(func() {
+			for _, lang := range ls.Tr.Languages() {
+				attrs := []string{"href", switchHref(ls.Query, i18n.QueryParam, lang.Code), "lang", lang.Code, "hreflang", lang.Code}
+				if lang.Code == ls.Tr.Locale() {
+					attrs = append(attrs, "class", "current", "aria-current", "true")
+				}
+				b.A(attrs...).T(lang.Name)
+			}
+		}),
+	)
+	return nil
+}
+
+// switchHref is a relative link to the current page with one query parameter
+// replaced: query with param set to value, escaped for an href attribute
+// A query that doesn't parse keeps what it could - a link is better than none
+func switchHref(query, param, value string) string {
+	v, _ := url.ParseQuery(query)
+	v.Set(param, value)
+	return html.EscapeString("?" + v.Encode())
+}
+
 // KEY CONCEPTS demonstrated in this file:
-// 1. EMPTY STRUCT - struct{} has zero size, used for stateless components
-// 2. METHODS ON EMPTY STRUCTS - Even empty structs can have methods
-// 3. INTERFACE CONSISTENCY - Same Render signature as Banner (duck typing)
-// 4. HTML ENTITIES - &copy; is rendered as © in HTML
-// 5. SHORT PARAMETER NAMES - 'b' instead of 'builder' is idiomatic Go (short, clear)
-// 6. STATELESS COMPONENTS - Footer doesn't need data, just behavior
+// 1. DATA FROM THE PAGE - Footer receives the theme and translator from the embedding page
+// 2. COMPONENT COMPOSITION - Footer renders the switchers inside itself
+// 3. HTML ENTITIES - &copy; in the message is rendered as © in HTML
+// 4. PROGRESSIVE ENHANCEMENT - Plain links work without any JavaScript
+// 5. LOCALIZED TEXT - Every visible string comes from the message catalog
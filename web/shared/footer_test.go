@@ -0,0 +1,57 @@
+package shared_test
+
+import (
+	"testing"
+
+	"form_exer/i18n"
+	"form_exer/web/shared"
+	"form_exer/web/theme"
+	"form_exer/web/webtest"
+)
+
+func TestFooter(t *testing.T) {
+	doc := webtest.Render(t, shared.Footer{Theme: theme.NameLight})
+
+	doc.AssertExists("footer.site-footer")
+	doc.AssertText("footer > p", "Copyright © 2025")
+	doc.AssertCount("footer .theme-switcher", 2) // themes and languages
+	doc.AssertExists(`footer a[href="?theme=dark"]`)
+	doc.AssertExists(`footer a[href="?lang=es"]`)
+}
+
+func TestThemeSwitcherMarksCurrent(t *testing.T) {
+	doc := webtest.Render(t, shared.ThemeSwitcher{Current: theme.NameDark})
+
+	doc.AssertCount("a", len(theme.Names))
+	doc.AssertTextContains("p", "Theme:")
+	doc.AssertCount("a.current", 1)
+	doc.AssertAttr("a.current", "href", "?theme=dark")
+	doc.AssertAttr("a.current", "aria-current", "true")
+	doc.AssertText("a.current", "Dark")
+}
+
+func TestThemeSwitcherTranslated(t *testing.T) {
+	doc := webtest.Render(t, shared.ThemeSwitcher{Current: theme.NameLight, Tr: i18n.Default.Translator("es")})
+
+	doc.AssertTextContains("p", "Tema:")
+	doc.AssertText("a.current", "Claro")
+}
+
+// The switchers change one parameter and keep the rest of the page's query
+func TestSwitchersKeepQuery(t *testing.T) {
+	doc := webtest.Render(t, shared.Footer{Theme: theme.NameLight, Query: "q=tabby&page=2&theme=light&lang=en"})
+
+	doc.AssertAttr(`footer a[hreflang=es]`, "href", "?lang=es&page=2&q=tabby&theme=light")
+	doc.AssertAttr(`footer a.current[aria-current]`, "href", "?lang=en&page=2&q=tabby&theme=light")
+	doc.AssertExists(`footer a[href="?lang=en&page=2&q=tabby&theme=dark"]`)
+}
+
+func TestLanguageSwitcher(t *testing.T) {
+	doc := webtest.Render(t, shared.LanguageSwitcher{Tr: i18n.Default.Translator("es")})
+
+	doc.AssertCount("a", len(i18n.Default.Locales()))
+	// Each language is named in itself, and marked with lang= so screen readers pronounce it
+	doc.AssertText(`a[lang=en]`, "English")
+	doc.AssertText(`a[lang=es]`, "Español")
+	doc.AssertAttr("a.current", "hreflang", "es")
+}
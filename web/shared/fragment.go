@@ -0,0 +1,29 @@
+package shared
+
+import "github.com/rohanthewiz/element"
+
+// FragmentHeader names the part of a page a request wants instead of the whole page
+//
+// PARTIAL RENDERING: a script (or HTMX, via hx-headers) asks for one component,
+// e.g. "X-Fragment: cat-results", gets just that component's HTML back and swaps
+// it into the page in place of the element with the same id - no full reload,
+// and no second copy of the markup in JavaScript
+const FragmentHeader = "X-Fragment"
+
+// Fragmenter is implemented by pages that can render parts of themselves
+// The name is also the id of the fragment's root element, so a client knows
+// where the HTML it gets back belongs
+type Fragmenter interface {
+	Fragment(name string) (element.Component, bool)
+}
+
+// RenderFragment renders one component on its own, without <html>, <head> or <body>
+func RenderFragment(c element.Component) string {
+	b := element.NewBuilder()
+	c.Render(b)
+	return b.String()
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. OPTIONAL INTERFACES - pages opt in to partial rendering by adding a method
+// 2. COMPONENTS AS UNITS - the same component renders inside a page or alone
@@ -0,0 +1,30 @@
+package shared
+
+import (
+	"github.com/rohanthewiz/element"
+
+	"form_exer/web/theme"
+)
+
+// Head renders the document <head>: encoding, viewport, title and the theme stylesheet
+// Every page renders it first, so styles are loaded before the body is drawn
+type Head struct {
+	Title string
+	Theme string
+}
+
+func (h Head) Render(b *element.Builder) any {
+	b.Head().R(
+		b.Meta("charset", "utf-8"),
+		// VIEWPORT META: without it phones render the page zoomed out
+		b.Meta("name", "viewport", "content", "width=device-width, initial-scale=1"),
+		b.Title().T(h.Title),
+		// The theme name is part of the URL, so each theme's CSS can be cached separately
+		b.Link("rel", "stylesheet", "href", theme.StylesheetURL(h.Theme)),
+	)
+	return nil
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DOCUMENT METADATA - charset, viewport and title belong in <head>
+// 2. EXTERNAL STYLESHEETS - One cacheable CSS file replaces per-element styles
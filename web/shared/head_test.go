@@ -0,0 +1,24 @@
+package shared_test
+
+import (
+	"testing"
+
+	"form_exer/web/shared"
+	"form_exer/web/theme"
+	"form_exer/web/webtest"
+)
+
+func TestHead(t *testing.T) {
+	doc := webtest.Render(t, shared.Head{Title: "Our Cats", Theme: theme.NameDark})
+
+	doc.AssertText("head > title", "Our Cats")
+	doc.AssertAttr("meta[charset]", "charset", "utf-8")
+	doc.AssertAttr("meta[name=viewport]", "content", "width=device-width, initial-scale=1")
+	doc.AssertAttr("link[rel=stylesheet]", "href", theme.StylesheetURL(theme.NameDark))
+}
+
+// An unknown or empty theme still links a stylesheet - the automatic one
+func TestHeadDefaultTheme(t *testing.T) {
+	doc := webtest.Render(t, shared.Head{Title: "x"})
+	doc.AssertAttr("link[rel=stylesheet]", "href", theme.StylesheetURL(theme.NameAuto))
+}
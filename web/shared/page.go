@@ -2,19 +2,57 @@
 // Package names are typically short, lowercase, and describe their purpose.
 package shared
 
+import "form_exer/i18n"
+
 // STRUCT DEFINITION: Page is a struct type that can be embedded in other structs
 // This implements the "MIXIN PATTERN" - providing shared functionality through composition
 // Any struct that embeds Page will inherit its fields and methods
 //
 // Example usage:
-//   type Home struct {
-//       shared.Page  // Embedded field - Home now has Title and all Page methods
-//       Heading string
-//   }
+//
+//	type Home struct {
+//	    shared.Page  // Embedded field - Home now has Title and all Page methods
+//	    Heading string
+//	}
 type Page struct {
 	// Title is an exported field (starts with capital letter)
 	// Exported fields are accessible from other packages
 	Title string
+
+	// TitleArgs fill the verbs in a translated title, e.g. "Adopt %s" -> "Adopt Luna"
+	TitleArgs []any
+
+	// Settings hold the visitor's per-request choices (theme, ...)
+	// Page singletons leave them at their zero value; handlers fill them in with Apply
+	Settings
+}
+
+// Settings are choices that belong to the visitor rather than the page,
+// so they change from one request to the next
+type Settings struct {
+	Theme string          // a theme name from web/theme; "" means the default
+	I18n  i18n.Translator // the visitor's language; the zero value renders English
+
+	// Nonce is this response's Content-Security-Policy nonce: inline <script>
+	// and <style> tags only run when they carry it, e.g.
+	//	b.Script("nonce", p.Nonce).T(`...`)
+	Nonce string
+
+	// Query is the request's query string (without "?"), so links that change
+	// one setting - the theme, the language - can keep the rest
+	Query string
+}
+
+// POINTER RECEIVER: Apply must change the page it is called on, not a copy
+// Because Page is embedded, Apply is PROMOTED - given page := pages.Contact,
+// calling page.Apply(...) sets the settings on that copy of the contact page
+func (p *Page) Apply(s Settings) {
+	p.Settings = s
+}
+
+// Head returns the <head> component (title and theme stylesheet)
+func (p Page) Head() Head {
+	return Head{Title: p.I18n.T(p.Title, p.TitleArgs...), Theme: p.Theme}
 }
 
 // METHOD with VALUE RECEIVER
@@ -28,17 +66,16 @@ func (p Page) Banner() Banner {
 	// STRUCT LITERAL: Creating a new Banner instance
 	// We pass p.Title to initialize the Banner's Title field
 	// This shows how mixins can share data with components they create
-	return Banner{Title: p.Title}
+	// TRANSLATE THE TITLE: page singletons hold a message key such as "home.title";
+	// literal titles (e.g. admin pages) have no catalog entry and pass through unchanged
+	return Banner{Title: p.I18n.T(p.Title, p.TitleArgs...)}
 }
 
 // Another method with value receiver
 // This demonstrates that structs can have multiple methods
-// EMPTY STRUCT LITERAL: Footer{} creates a Footer with all zero values
-// Since Footer has no fields, the empty literal is sufficient
+// The footer's switchers need to know the active theme and language
 func (p Page) Footer() Footer {
-	// Returning an empty Footer struct
-	// Even though Footer is empty, it has a Render() method defined elsewhere
-	return Footer{}
+	return Footer{Theme: p.Theme, Tr: p.I18n, Query: p.Query}
 }
 
 // KEY CONCEPTS demonstrated in this file:
@@ -48,3 +85,4 @@ func (p Page) Footer() Footer {
 // 4. VALUE RECEIVER - Method receives a copy, doesn't modify original
 // 5. MIXIN PATTERN - Embedding this struct provides shared functionality
 // 6. STRUCT LITERALS - Creating instances with {Field: Value} syntax
+// 7. POINTER RECEIVER - Apply modifies the page instead of a copy
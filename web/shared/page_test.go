@@ -0,0 +1,41 @@
+package shared_test
+
+import (
+	"testing"
+
+	"form_exer/i18n"
+	"form_exer/web/shared"
+	"form_exer/web/theme"
+	"form_exer/web/webtest"
+)
+
+// Page translates its title key for both the banner and the <title>
+func TestPageTranslatesTitle(t *testing.T) {
+	p := shared.Page{Title: "contact.title"}
+
+	webtest.Render(t, p.Banner()).AssertText("h1", "Contact Us")
+	webtest.Render(t, p.Head()).AssertText("title", "Contact Us")
+
+	p.Apply(shared.Settings{Theme: theme.NameDark, I18n: i18n.Default.Translator("es")})
+	webtest.Render(t, p.Banner()).AssertText("h1", "Contáctanos")
+	webtest.Render(t, p.Head()).AssertText("title", "Contáctanos")
+}
+
+// Titles with arguments, and literal titles with no catalog entry
+func TestPageTitleArgs(t *testing.T) {
+	p := shared.Page{Title: "adoption.title", TitleArgs: []any{"Luna"}}
+	webtest.Render(t, p.Banner()).AssertText("h1", "Adopt Luna")
+
+	literal := shared.Page{Title: "Admin: Cats"}
+	webtest.Render(t, literal.Banner()).AssertText("h1", "Admin: Cats")
+}
+
+// Apply must change the page it is called on (pointer receiver), including the footer's switchers
+func TestPageApply(t *testing.T) {
+	var p shared.Page
+	p.Apply(shared.Settings{Theme: theme.NameDark, I18n: i18n.Default.Translator("es")})
+
+	doc := webtest.Render(t, p.Footer())
+	doc.AssertText(`a.current[href="?theme=dark"]`, "Oscuro")
+	doc.AssertText(`a.current[lang=es]`, "Español")
+}
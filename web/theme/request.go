@@ -0,0 +1,89 @@
+package theme
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/rohanthewiz/rweb"
+
+	"form_exer/web/cookie"
+)
+
+// QueryParam switches theme on any page: /cats?theme=dark
+// CookieName remembers the choice for later requests
+const (
+	QueryParam = "theme"
+	CookieName = "theme"
+)
+
+// stylesheetParam names the theme in StylesheetURL. It is deliberately not
+// QueryParam, so fetching the CSS never re-saves the cookie on a cacheable response
+const stylesheetParam = "name"
+
+// contextKey is where Middleware leaves the theme for this request
+const contextKey = "theme.name"
+
+// oneYear is how long a theme choice is remembered
+const oneYear = 365 * 24 * 60 * 60
+
+// StylesheetURL is the href pages use for a theme's stylesheet
+func StylesheetURL(name string) string {
+	if !Valid(name) {
+		name = NameAuto
+	}
+	return StylesheetPath + "?" + stylesheetParam + "=" + url.QueryEscape(name)
+}
+
+// Middleware decides the theme for each request and makes it available via Current
+// A valid ?theme= value wins and is saved in a cookie; otherwise the cookie is used
+// Register it with s.Use(theme.Middleware)
+func Middleware(ctx rweb.Context) error {
+	name := ctx.Request().QueryParam(QueryParam)
+	saved := cookie.Get(ctx.Request(), CookieName)
+	if !Valid(name) {
+		name = saved
+	} else if name != saved {
+		// Only a new choice is saved (see cookie.Set for sharing the response with the lang cookie)
+		_ = cookie.Set(ctx, http.Cookie{Name: CookieName, Value: name, MaxAge: oneYear}) // a known theme name: always valid
+	}
+	if Valid(name) {
+		// string(...) of a fresh []byte: QueryParam's result points into a reused buffer
+		ctx.Set(contextKey, string([]byte(name)))
+	}
+	return ctx.Next()
+}
+
+// Current returns the theme chosen for this request, or NameAuto
+// TYPE ASSERTION with comma-ok: a missing value gives "" instead of a panic
+func Current(ctx rweb.Context) string {
+	if name, ok := ctx.Get(contextKey).(string); ok && name != "" {
+		return name
+	}
+	return NameAuto
+}
+
+// ServeStylesheet is the handler for StylesheetPath
+// The theme comes from the URL, so the response can be cached publicly;
+// the ETag lets browsers revalidate with a cheap 304 after a deploy
+func ServeStylesheet(ctx rweb.Context) error {
+	name := ctx.Request().QueryParam(stylesheetParam)
+	etag := ETag(name)
+
+	res := ctx.Response()
+	res.SetHeader("Content-Type", "text/css; charset=utf-8")
+	res.SetHeader("Cache-Control", "public, max-age="+strconv.Itoa(24*60*60))
+	res.SetHeader("ETag", etag)
+
+	if ctx.Request().Header("If-None-Match") == etag {
+		res.SetStatus(http.StatusNotModified)
+		return nil
+	}
+	return ctx.WriteString(Stylesheet(name))
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. MIDDLEWARE - Work done for every request before the handler runs
+// 2. REQUEST-SCOPED DATA - ctx.Set/ctx.Get carry the theme to handlers
+// 3. HTTP CACHING - Cache-Control, ETag and 304 Not Modified
+// 4. TYPE ASSERTIONS - Safely getting a string back out of an 'any'
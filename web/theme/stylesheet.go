@@ -0,0 +1,223 @@
+package theme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// StylesheetPath is where pages link the generated stylesheet from
+const StylesheetPath = "/theme.css"
+
+// variables lists every token as a CSS CUSTOM PROPERTY (name, value) pair
+// Written out by hand rather than with reflection so each name is easy to find
+func (t Tokens) variables() [][2]string {
+	c := t.Color
+	return [][2]string{
+		{"--color-page", c.Page},
+		{"--color-surface", c.Surface},
+		{"--color-surface-alt", c.SurfaceAlt},
+		{"--color-text", c.Text},
+		{"--color-text-muted", c.TextMuted},
+		{"--color-heading", c.Heading},
+		{"--color-primary", c.Primary},
+		{"--color-on-primary", c.OnPrimary},
+		{"--color-secondary", c.Secondary},
+		{"--color-on-secondary", c.OnSecondary},
+		{"--color-header", c.Header},
+		{"--color-on-header", c.OnHeader},
+		{"--color-footer", c.Footer},
+		{"--color-on-footer", c.OnFooter},
+		{"--color-highlight", c.Highlight},
+		{"--color-on-highlight", c.OnHighlight},
+		{"--color-border", c.Border},
+		{"--color-danger", c.Danger},
+		{"--color-danger-bg", c.DangerBg},
+		{"--color-success", c.Success},
+		{"--color-success-bg", c.SuccessBg},
+		{"--space-xs", t.Space.XS},
+		{"--space-s", t.Space.S},
+		{"--space-m", t.Space.M},
+		{"--space-l", t.Space.L},
+		{"--space-xl", t.Space.XL},
+		{"--font-family", t.Type.FontFamily},
+		{"--line-height", t.Type.LineHeight},
+		{"--size-small", t.Type.SizeSmall},
+		{"--size-base", t.Type.SizeBase},
+		{"--size-large", t.Type.SizeLarge},
+		{"--size-xl", t.Type.SizeXL},
+		{"--size-hero", t.Type.SizeHero},
+		{"--radius-s", t.Radius.S},
+		{"--radius-m", t.Radius.M},
+		{"--radius-l", t.Radius.L},
+		{"--shadow", t.Shadow},
+	}
+}
+
+// rootBlock renders the tokens as a ":root { --name: value; ... }" rule
+func (t Tokens) rootBlock() string {
+	var sb strings.Builder
+	sb.WriteString(":root {\n")
+	for _, v := range t.variables() {
+		sb.WriteString("  " + v[0] + ": " + v[1] + ";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Stylesheet returns the complete CSS for a theme name
+// Unknown names fall back to "auto" (light, or dark when the OS asks for it)
+func Stylesheet(name string) string {
+	if css, ok := stylesheets[name]; ok {
+		return css
+	}
+	return stylesheets[NameAuto]
+}
+
+// ETag identifies one version of a theme's stylesheet, so browsers can revalidate cheaply
+func ETag(name string) string {
+	sum := sha256.Sum256([]byte(Stylesheet(name)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// stylesheets is built once at startup - the output never changes while the server runs
+// IMMEDIATELY INVOKED FUNCTION LITERAL initialises a package-level map
+var stylesheets = func() map[string]string {
+	auto := Light.Tokens.rootBlock() +
+		// MEDIA QUERY: the browser applies dark tokens when the OS is in dark mode
+		"@media (prefers-color-scheme: dark) {\n" + Dark.Tokens.rootBlock() + "}\n"
+
+	return map[string]string{
+		NameLight: Light.Tokens.rootBlock() + rules,
+		NameDark:  Dark.Tokens.rootBlock() + rules,
+		NameAuto:  auto + rules,
+	}
+}()
+
+// rules are the component classes. They only ever refer to tokens through var(--...),
+// so the same rules serve every theme.
+const rules = `
+body { margin: 0; background: var(--color-page); color: var(--color-text); font-family: var(--font-family); line-height: var(--line-height); }
+h1, h2, h3 { color: var(--color-heading); }
+a { color: var(--color-primary); }
+
+/* ----- site chrome ----- */
+.site-header { background: var(--color-header); color: var(--color-on-header); padding: var(--space-m); }
+.site-header h1 { color: var(--color-on-header); margin: 0; }
+.site-footer { background: var(--color-footer); color: var(--color-on-footer); padding: var(--space-xs) var(--space-m); }
+.site-footer p { margin: var(--space-xs) 0; }
+.theme-switcher a { color: var(--color-on-footer); margin-right: var(--space-s); }
+.theme-switcher a.current { font-weight: bold; text-decoration: none; }
+.page-heading { color: var(--color-on-highlight); background: var(--color-highlight); margin: 0; padding: var(--space-xs) var(--space-m); }
+
+/* ----- layout ----- */
+.container { max-width: 1200px; margin: 0 auto; padding: var(--space-l) var(--space-m); }
+.panel { max-width: 1100px; margin: 0 auto; padding: var(--space-l) var(--space-m); background: var(--color-surface); }
+.panel-medium { max-width: 800px; }
+.panel-narrow { max-width: 700px; border-radius: var(--radius-l); }
+.panel-profile { max-width: 900px; padding: var(--space-xl) var(--space-m); border-radius: var(--radius-l); }
+.center { text-align: center; }
+
+/* ----- text ----- */
+.hero-title { text-align: center; font-size: var(--size-hero); margin-bottom: var(--space-m); }
+.hero-lead { text-align: center; font-size: var(--size-large); margin-bottom: var(--space-xl); }
+.empty-state { text-align: center; color: var(--color-text-muted); font-style: italic; margin-top: var(--space-xl); }
+.note { color: var(--color-text-muted); font-style: italic; }
+.muted { color: var(--color-text-muted); }
+.prose { line-height: 1.7; }
+.facts { line-height: 1.8; }
+.cat-name { font-size: var(--size-xl); margin: var(--space-m) 0 var(--space-xs); }
+.cat-meta { color: var(--color-text-muted); margin-top: 0; }
+.back-link { text-decoration: none; }
+.browse-all { text-align: center; margin-top: var(--space-l); font-size: 1.1em; }
+.not-found, .error-page { max-width: 700px; margin: 60px auto; text-align: center; }
+.not-found h2, .error-page h2 { font-size: 2em; }
+.not-found p, .error-page p { font-size: var(--size-large); }
+.error-page .reference { font-size: var(--size-base); color: var(--color-text-muted); }
+
+/* ----- buttons ----- */
+.btn { display: inline-block; border: none; padding: var(--space-s) var(--space-m); border-radius: var(--radius-s); cursor: pointer; font-size: var(--size-base); text-decoration: none; margin-right: var(--space-s); }
+.btn-primary { background: var(--color-primary); color: var(--color-on-primary); }
+.btn-secondary { background: var(--color-secondary); color: var(--color-on-secondary); }
+.btn-large { padding: 14px 28px; font-size: 1.1em; margin-top: var(--space-l); }
+.text-danger { color: var(--color-danger); }
+
+/* ----- cat cards ----- */
+.cat-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(300px, 1fr)); gap: var(--space-l); margin-top: var(--space-xl); }
+.cat-card { background: var(--color-surface); border-radius: var(--radius-l); box-shadow: var(--shadow); padding: var(--space-m); }
+.cat-card img { width: 100%; border-radius: var(--radius-m); margin-bottom: 15px; }
+.cat-card h3 { margin: var(--space-s) 0; }
+.cat-card .btn { margin-top: var(--space-s); }
+
+/* ----- cat detail ----- */
+.gallery { margin: var(--space-m) 0; }
+.gallery-main { width: 100%; max-height: 500px; object-fit: cover; border-radius: var(--radius-m); }
+.gallery-thumbs { display: flex; gap: var(--space-s); margin-top: var(--space-s); flex-wrap: wrap; }
+.gallery-thumbs img { width: 120px; height: 90px; object-fit: cover; border-radius: var(--radius-s); }
+.info-box { background: var(--color-surface-alt); border-radius: var(--radius-m); padding: 15px var(--space-m); margin-top: var(--space-m); }
+.info-box h3 { margin-top: 0; }
+
+/* ----- forms ----- */
+.field { margin-bottom: 15px; }
+.field-label { display: block; font-weight: bold; color: var(--color-heading); margin-bottom: var(--space-xs); }
+.field-input { width: 100%; padding: 8px; box-sizing: border-box; }
+.check-label { color: var(--color-heading); }
+.field-error { color: var(--color-danger); margin: 4px 0 0; font-size: var(--size-small); }
+select { padding: 8px; }
+.fieldset { border: 1px solid var(--color-border); border-radius: var(--radius-s); margin-bottom: 15px; }
+.form-actions { margin-top: 25px; }
+.form-actions a { margin-right: 15px; }
+.alert { padding: var(--space-s); border-radius: var(--radius-s); }
+.alert-error { background: var(--color-danger-bg); color: var(--color-danger); }
+.alert-success { background: var(--color-success-bg); color: var(--color-success); }
+.inline-form { display: flex; gap: var(--space-xs); }
+.live-events { background: var(--color-surface); border-radius: var(--radius-s); padding: var(--space-s) var(--space-m); list-style: none; }
+.live-events li + li { margin-top: var(--space-xs); }
+.contact-form { max-width: 500px; margin: var(--space-l) auto; display: flex; flex-direction: column; gap: var(--space-s); background: var(--color-surface); padding: var(--space-m); border-radius: var(--radius-l); }
+.contact-form input, .contact-form textarea { padding: 8px; }
+.contact-form button { align-self: flex-start; }
+
+/* ----- adoption steps ----- */
+.steps { display: flex; gap: var(--space-m); list-style: none; padding: 0; color: var(--color-text-muted); }
+.steps .current { color: var(--color-primary); font-weight: bold; }
+
+/* ----- admin ----- */
+.admin-nav { margin-bottom: var(--space-m); }
+.admin-nav a, .filter-links a { margin-right: 15px; }
+.filter-links a.current { font-weight: bold; }
+.answers dl { display: grid; grid-template-columns: max-content 1fr; gap: var(--space-xs) var(--space-m); }
+.answers dt { font-weight: bold; color: var(--color-heading); }
+.answers dd { margin: 0; }
+.data-table { width: 100%; border-collapse: collapse; }
+.data-table thead tr { text-align: left; border-bottom: 2px solid var(--color-heading); }
+.data-table tbody tr { border-bottom: 1px solid var(--color-border); vertical-align: top; }
+.photo-manager { margin-top: var(--space-xl); border-top: 1px solid var(--color-border); padding-top: var(--space-m); }
+.photo-list { display: flex; gap: 15px; flex-wrap: wrap; }
+.photo-tile { text-align: center; }
+.photo-tile img { width: 150px; height: 110px; object-fit: cover; }
+.upload-form { margin-top: var(--space-m); }
+
+/* ----- API docs ----- */
+.api-docs .operation { border-top: 1px solid var(--color-border); padding: var(--space-s) 0; }
+.api-docs .method { display: inline-block; min-width: 4em; padding: 2px var(--space-xs); border-radius: var(--radius-s); background: var(--color-secondary); color: var(--color-on-secondary); font-size: var(--size-small); text-align: center; }
+.api-docs .method-get { background: var(--color-primary); color: var(--color-on-primary); }
+.api-docs .responses { padding-left: var(--space-m); }
+
+/* ----- catalog listing ----- */
+.filters { background: var(--color-surface); border-radius: var(--radius-l); padding: var(--space-m); display: flex; flex-wrap: wrap; gap: 15px; align-items: flex-end; }
+.filter { display: flex; flex-direction: column; min-width: 90px; }
+.filter label { font-weight: bold; color: var(--color-heading); font-size: var(--size-small); }
+.filter input { padding: 8px; width: 100%; box-sizing: border-box; }
+.filter-check { color: var(--color-heading); padding: 9px 0; }
+.results { margin-top: 25px; }
+.pagination { text-align: center; margin-top: var(--space-l); }
+.pagination a, .pagination .current { display: inline-block; margin: 0 4px; padding: 6px 12px; border-radius: 4px; }
+.pagination a { text-decoration: none; background: var(--color-surface); }
+.pagination .current { background: var(--color-primary); color: var(--color-on-primary); }
+`
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. CSS CUSTOM PROPERTIES - Tokens become --variables; rules use var(--name)
+// 2. MEDIA QUERIES - prefers-color-scheme gives "auto" dark mode with no JavaScript
+// 3. PACKAGE INITIALISATION - The stylesheets map is computed once, at startup
+// 4. CONTENT HASHING - The ETag changes only when the CSS does
@@ -0,0 +1,173 @@
+// Package theme holds the site's design tokens - the named colors, spacing,
+// type sizes and corner radii every component uses - and turns them into a
+// stylesheet of CSS classes.
+//
+// Components never write a color or size directly. They use class names
+// (see stylesheet.go), and each class refers to tokens through CSS custom
+// properties such as var(--color-primary). Swapping the token values swaps
+// the whole look of the site, which is how dark mode works.
+package theme
+
+// Colors are the palette, named by ROLE rather than hue:
+// "Primary" stays meaningful even when a theme makes it blue instead of orange
+type Colors struct {
+	Page        string // page background behind everything
+	Surface     string // cards, panels, forms
+	SurfaceAlt  string // subtle boxes inside a surface (e.g. health info)
+	Text        string // body text
+	TextMuted   string // secondary text, captions, empty states
+	Heading     string // headings and labels
+	Primary     string // buttons and links - the brand color
+	OnPrimary   string // text drawn on top of Primary
+	Secondary   string // less important buttons
+	OnSecondary string
+	Header      string // site banner background
+	OnHeader    string
+	Footer      string
+	OnFooter    string
+	Highlight   string // the page heading strip
+	OnHighlight string
+	Border      string
+	Danger      string // error text
+	DangerBg    string // error message background
+	Success     string
+	SuccessBg   string
+}
+
+// Spacing is a small scale, so gaps and padding line up across the site
+type Spacing struct {
+	XS, S, M, L, XL string
+}
+
+// Typography covers the font and the handful of sizes we use
+type Typography struct {
+	FontFamily string
+	LineHeight string
+	SizeSmall  string
+	SizeBase   string
+	SizeLarge  string
+	SizeXL     string
+	SizeHero   string
+}
+
+// Radius is how rounded corners are
+type Radius struct {
+	S, M, L string
+}
+
+// Tokens is the complete set of design decisions for one theme
+// NESTED STRUCTS group related tokens, so a literal reads like a design spec
+type Tokens struct {
+	Color  Colors
+	Space  Spacing
+	Type   Typography
+	Radius Radius
+	Shadow string // card elevation
+}
+
+// Theme is a named set of tokens
+// (the switcher's display names live in the i18n catalog as "theme.<name>")
+type Theme struct {
+	Name   string
+	Tokens Tokens
+}
+
+// Theme names accepted in ?theme= and the theme cookie
+const (
+	NameLight = "light"
+	NameDark  = "dark"
+	NameAuto  = "auto" // follow the visitor's operating system setting
+)
+
+// base is shared by every theme - only colors differ between light and dark
+var base = Tokens{
+	Space: Spacing{XS: "5px", S: "10px", M: "20px", L: "30px", XL: "40px"},
+	Type: Typography{
+		FontFamily: `-apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif`,
+		LineHeight: "1.6",
+		SizeSmall:  "0.9em",
+		SizeBase:   "1em",
+		SizeLarge:  "1.2em",
+		SizeXL:     "2.2em",
+		SizeHero:   "2.5em",
+	},
+	Radius: Radius{S: "5px", M: "8px", L: "10px"},
+	Shadow: "0 4px 6px rgba(0,0,0,0.1)",
+}
+
+// withColors returns base with the given palette
+// base is a struct VALUE, so the assignment below changes only the copy
+func withColors(c Colors) Tokens {
+	t := base
+	t.Color = c
+	return t
+}
+
+// Light is the original look of the site: tan page, navy headings, orange buttons
+var Light = Theme{Name: NameLight, Tokens: withColors(Colors{
+	Page:        "tan",
+	Surface:     "white",
+	SurfaceAlt:  "#f8f4ec",
+	Text:        "#555",
+	TextMuted:   "#777",
+	Heading:     "#2c3e50",
+	Primary:     "#e67e22",
+	OnPrimary:   "white",
+	Secondary:   "#bdc3c7",
+	OnSecondary: "#2c3e50",
+	Header:      "#2c3e50",
+	OnHeader:    "white",
+	Footer:      "lightgray",
+	OnFooter:    "gray",
+	Highlight:   "#dfc673",
+	OnHighlight: "maroon",
+	Border:      "#ddd",
+	Danger:      "#c0392b",
+	DangerBg:    "#fdedec",
+	Success:     "#1e8449",
+	SuccessBg:   "#eafaf1",
+})}
+
+// Dark keeps the same warm character with light text on deep backgrounds
+var Dark = Theme{Name: NameDark, Tokens: withColors(Colors{
+	Page:        "#1e1b18",
+	Surface:     "#2a2622",
+	SurfaceAlt:  "#342f2a",
+	Text:        "#d6d0c8",
+	TextMuted:   "#a39b91",
+	Heading:     "#f0e6d8",
+	Primary:     "#f0883e",
+	OnPrimary:   "#1e1b18",
+	Secondary:   "#4a433c",
+	OnSecondary: "#f0e6d8",
+	Header:      "#14171a",
+	OnHeader:    "#f0e6d8",
+	Footer:      "#14120f",
+	OnFooter:    "#a39b91",
+	Highlight:   "#3d3423",
+	OnHighlight: "#f3c77a",
+	Border:      "#4a433c",
+	Danger:      "#ff8a7a",
+	DangerBg:    "#3b1f1c",
+	Success:     "#7dd3a0",
+	SuccessBg:   "#1d3326",
+})}
+
+// Names lists every selectable theme in switcher order
+var Names = []string{NameAuto, NameLight, NameDark}
+
+// Valid reports whether name is a selectable theme
+func Valid(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. DESIGN TOKENS - Named values instead of literals scattered through components
+// 2. NESTED STRUCTS - Grouping tokens by kind (color, spacing, type, radius)
+// 3. VALUE SEMANTICS - withColors copies base, so themes can't affect each other
+// 4. SEMANTIC NAMING - Colors are named by role, not by hue
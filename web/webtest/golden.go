@@ -0,0 +1,167 @@
+package webtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// update rewrites golden files instead of comparing against them:
+//
+//	go test ./web/... -update
+//
+// Review the resulting diff in git before committing - the golden file IS the expectation
+var update = flag.Bool("update", false, "rewrite testdata/*.golden files with the current output")
+
+// Golden compares the document with testdata/<name>.golden
+//
+// GOLDEN FILES (snapshot tests) catch any unintended change to a page's markup.
+// Both sides are Normalized first, so attribute order and incidental whitespace
+// never cause a failure - only real changes do
+func (d *Doc) Golden(name string) {
+	d.t.Helper()
+
+	got := Normalize(d.root)
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			d.t.Fatalf("webtest: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			d.t.Fatalf("webtest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		d.t.Fatalf("webtest: %s does not exist - run go test with -update to create it", path)
+	}
+	if err != nil {
+		d.t.Fatalf("webtest: %v", err)
+	}
+	if string(want) != got {
+		d.t.Errorf("output differs from %s (run go test with -update if the change is intended)\n%s",
+			path, firstDifference(string(want), got))
+	}
+}
+
+// Normalize renders a parsed tree in a canonical form: one element per line,
+// two-space indentation and attributes sorted by name
+// Elements whose only content is short text stay on one line: <h3>Luna</h3>
+func Normalize(root *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		indent := strings.Repeat("  ", depth)
+
+		switch n.Type {
+		case html.DocumentNode:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c, depth)
+			}
+
+		case html.DoctypeNode:
+			sb.WriteString("<!DOCTYPE " + n.Data + ">\n")
+
+		case html.CommentNode:
+			sb.WriteString(indent + "<!--" + n.Data + "-->\n")
+
+		case html.TextNode:
+			if text := strings.TrimSpace(n.Data); text != "" {
+				sb.WriteString(indent + escapeText(n.Parent, text) + "\n")
+			}
+
+		case html.ElementNode:
+			sb.WriteString(indent + openTag(n))
+			if voidElements[n.Data] {
+				sb.WriteString("\n")
+				return
+			}
+			if text, ok := onlyText(n); ok {
+				sb.WriteString(escapeText(n, text) + "</" + n.Data + ">\n")
+				return
+			}
+			sb.WriteString("\n")
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c, depth+1)
+			}
+			sb.WriteString(indent + "</" + n.Data + ">\n")
+		}
+	}
+	walk(root, 0)
+	return sb.String()
+}
+
+// voidElements never have content or a closing tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// openTag writes <tag a="1" b="2"> with attributes in alphabetical order
+func openTag(n *html.Node) string {
+	attrs := make([]html.Attribute, len(n.Attr))
+	copy(attrs, n.Attr) // sort a copy - the tree itself is left alone
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+
+	var sb strings.Builder
+	sb.WriteString("<" + n.Data)
+	for _, a := range attrs {
+		sb.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+// onlyText reports whether n holds nothing but a single text node
+func onlyText(n *html.Node) (string, bool) {
+	c := n.FirstChild
+	if c == nil {
+		return "", true // empty element: <textarea></textarea>
+	}
+	if c.NextSibling != nil || c.Type != html.TextNode {
+		return "", false
+	}
+	return strings.Join(strings.Fields(c.Data), " "), true
+}
+
+// escapeText re-escapes decoded text, except inside <script>/<style> where it is raw
+func escapeText(parent *html.Node, text string) string {
+	if p := parent; p != nil && (p.Data == "script" || p.Data == "style") {
+		return text
+	}
+	return html.EscapeString(text)
+}
+
+// firstDifference shows the first line where want and got disagree, with a little context
+func firstDifference(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			end := min(i, len(gl))
+			context := strings.Join(gl[max(0, end-3):end], "\n")
+			return "after:\n" + context + "\nline " + strconv.Itoa(i+1) + ":\n want: " + w + "\n  got: " + g
+		}
+	}
+	return ""
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. GOLDEN FILES - Expected output lives in testdata/ and is reviewed like code
+// 2. TEST FLAGS - A package-level flag.Bool is parsed by "go test" along with -v, -run, ...
+// 3. CANONICAL FORMS - Normalizing both sides so only meaningful changes fail
+// 4. TESTDATA - The go tool ignores testdata/ directories when building packages
@@ -0,0 +1,240 @@
+package webtest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A deliberately small subset of CSS selectors - enough for component tests:
+//
+//	tag  #id  .class  [attr]  [attr=value]  [attr="quoted value"]  *
+//	compounds such as  a.btn.btn-primary[href="/cats"]
+//	descendant ("footer a") and child ("ul > li") combinators
+//
+// Pseudo-classes, sibling combinators and selector lists (a, b) are not supported.
+
+// compound is one "word" of a selector, e.g. a.btn[href]
+type compound struct {
+	tag     string // "" or "*" matches any element
+	id      string
+	classes []string
+	attrs   []attrMatch
+}
+
+// attrMatch is one [attr] or [attr=value] test
+type attrMatch struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// step is a compound plus how it relates to the step before it
+type step struct {
+	compound
+	child bool // true for "parent > this", false for "ancestor this"
+}
+
+// selector is a parsed selector; the LAST step describes the element being matched
+type selector []step
+
+// parseSelector turns "ul.steps > li.current" into steps
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	child := false
+
+	for _, tok := range splitSelector(s) {
+		if tok == ">" {
+			if len(sel) == 0 || child {
+				return nil, fmt.Errorf("selector %q: misplaced '>'", s)
+			}
+			child = true
+			continue
+		}
+		c, err := parseCompound(tok)
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", s, err)
+		}
+		sel = append(sel, step{compound: c, child: child})
+		child = false
+	}
+
+	if len(sel) == 0 || child {
+		return nil, fmt.Errorf("selector %q is incomplete", s)
+	}
+	return sel, nil
+}
+
+// splitSelector splits on whitespace and '>', except inside [...] where
+// attribute values may contain either
+func splitSelector(s string) []string {
+	var toks []string
+	var cur strings.Builder
+	inBracket := false
+
+	flush := func() {
+		if cur.Len() > 0 {
+			toks = append(toks, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case r == '[':
+			inBracket = true
+			cur.WriteRune(r)
+		case r == ']':
+			inBracket = false
+			cur.WriteRune(r)
+		case inBracket:
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case r == '>':
+			flush()
+			toks = append(toks, ">")
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return toks
+}
+
+// parseCompound parses one token such as "a.btn#go[href=/x]"
+func parseCompound(tok string) (compound, error) {
+	var c compound
+	i := 0
+
+	// ident reads a name made of letters, digits, '-' and '_' starting at i
+	ident := func() string {
+		start := i
+		for i < len(tok) && isIdentChar(tok[i]) {
+			i++
+		}
+		return tok[start:i]
+	}
+
+	if i < len(tok) && tok[i] == '*' {
+		c.tag = "*"
+		i++
+	} else {
+		c.tag = strings.ToLower(ident())
+	}
+
+	for i < len(tok) {
+		switch tok[i] {
+		case '#':
+			i++
+			if c.id = ident(); c.id == "" {
+				return c, fmt.Errorf("empty #id in %q", tok)
+			}
+		case '.':
+			i++
+			class := ident()
+			if class == "" {
+				return c, fmt.Errorf("empty .class in %q", tok)
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			end := strings.IndexByte(tok[i:], ']')
+			if end < 0 {
+				return c, fmt.Errorf("unclosed [ in %q", tok)
+			}
+			a, err := parseAttrMatch(tok[i+1 : i+end])
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, a)
+			i += end + 1
+		default:
+			return c, fmt.Errorf("unexpected %q in %q", tok[i], tok)
+		}
+	}
+	return c, nil
+}
+
+// parseAttrMatch parses the inside of [...]: name, name=value or name="value"
+// Other operators (^= *= ~=) are rejected rather than silently misread
+func parseAttrMatch(s string) (attrMatch, error) {
+	name, value, hasValue := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return r > 127 || !isIdentChar(byte(r)) }) >= 0 {
+		return attrMatch{}, fmt.Errorf("unsupported attribute test [%s]", s)
+	}
+	return attrMatch{name: name, value: strings.Trim(value, `"'`), hasValue: hasValue}, nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '-' || c == '_' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// matches reports whether element n matches the whole selector
+// Matching runs RIGHT TO LEFT, like browsers do: first n itself, then its ancestors
+func (sel selector) matches(n *html.Node) bool {
+	return sel.matchFrom(len(sel)-1, n)
+}
+
+func (sel selector) matchFrom(i int, n *html.Node) bool {
+	if !sel[i].compound.matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if sel[i].child {
+		p := parentElement(n)
+		return p != nil && sel.matchFrom(i-1, p)
+	}
+	for p := parentElement(n); p != nil; p = parentElement(p) {
+		if sel.matchFrom(i-1, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches tests a single element against one compound
+func (c compound) matches(n *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" {
+		if id, _ := AttrOf(n, "id"); id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := AttrOf(n, "class")
+		have := strings.Fields(class)
+		for _, want := range c.classes {
+			if !slices.Contains(have, want) {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		v, ok := AttrOf(n, a.name)
+		if !ok || (a.hasValue && v != a.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// parentElement skips the synthetic document root
+func parentElement(n *html.Node) *html.Node {
+	if p := n.Parent; p != nil && p.Type == html.ElementNode {
+		return p
+	}
+	return nil
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. HAND-WRITTEN PARSERS - A tokenizer plus a small per-token parser
+// 2. RIGHT-TO-LEFT MATCHING - Start from the element, then check its ancestors
+// 3. STRUCT EMBEDDING - step embeds compound and adds the combinator
@@ -0,0 +1,263 @@
+// Package webtest is a small harness for testing element.Builder components.
+//
+// A test renders a component (or a whole page) to HTML, the HTML is parsed into
+// a DOM tree with golang.org/x/net/html, and the test then asks questions about
+// the tree with CSS selectors:
+//
+//	doc := webtest.Render(t, shared.Banner{Title: "Cats"})
+//	doc.AssertText("header h1", "Cats")
+//	doc.AssertAttr("header", "class", "site-header")
+//
+// Checking the parsed tree instead of comparing strings means a test doesn't
+// break when attribute order changes (element writes attributes in map order)
+// or when whitespace moves around - only when the page really changes.
+//
+// For whole-page snapshots see Golden (golden.go).
+package webtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rohanthewiz/element"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page is anything that renders a complete document - every type in web/pages
+// with a Render() string method satisfies it
+type Page interface {
+	Render() string
+}
+
+// Doc is rendered HTML, parsed and ready for assertions
+// The assertion methods report failures with t.Errorf, so one test can list
+// every problem at once instead of stopping at the first
+type Doc struct {
+	t    testing.TB
+	root *html.Node
+	HTML string // the markup exactly as the component produced it
+}
+
+// Render renders a single component on a fresh builder and parses the result
+// INTERFACE PARAMETER: any type with Render(*element.Builder) any can be tested
+func Render(t testing.TB, c element.Component) *Doc {
+	t.Helper()
+	b := element.NewBuilder()
+	c.Render(b)
+	return Parse(t, b.String())
+}
+
+// RenderPage renders a complete page
+func RenderPage(t testing.TB, p Page) *Doc {
+	t.Helper()
+	return Parse(t, p.Render())
+}
+
+// Parse builds a Doc from markup
+// Complete documents (and a lone <head>) are parsed as-is; other fragments - what a
+// single component produces - are parsed as if they appeared inside <body> (or a
+// table, for rows and cells), so no <html>/<body> wrappers are invented
+func Parse(t testing.TB, src string) *Doc {
+	t.Helper()
+
+	trimmed := strings.ToLower(strings.TrimSpace(src))
+	if strings.HasPrefix(trimmed, "<!doctype") || strings.HasPrefix(trimmed, "<html") || strings.HasPrefix(trimmed, "<head") {
+		root, err := html.Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("webtest: parsing document: %v", err)
+		}
+		return &Doc{t: t, root: root, HTML: src}
+	}
+
+	nodes, err := html.ParseFragment(strings.NewReader(src), fragmentContext(trimmed))
+	if err != nil {
+		t.Fatalf("webtest: parsing fragment: %v", err)
+	}
+	// ParseFragment returns detached nodes; hang them under one root so they can be searched together
+	root := &html.Node{Type: html.DocumentNode}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return &Doc{t: t, root: root, HTML: src}
+}
+
+// fragmentContext picks the element a fragment is parsed inside
+// Table parts are only valid inside a table: parsed in <body>, a bare <tr><td>..
+// loses its tags and becomes plain text, so rows get a <tbody> and cells a <tr>
+func fragmentContext(src string) *html.Node {
+	switch {
+	case strings.HasPrefix(src, "<tr"):
+		return &html.Node{Type: html.ElementNode, Data: "tbody", DataAtom: atom.Tbody}
+	case strings.HasPrefix(src, "<td"), strings.HasPrefix(src, "<th"):
+		return &html.Node{Type: html.ElementNode, Data: "tr", DataAtom: atom.Tr}
+	}
+	return &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+}
+
+// Find returns every element matching selector, in document order
+// A malformed selector is a mistake in the test itself, so it stops the test
+func (d *Doc) Find(selector string) []*html.Node {
+	d.t.Helper()
+	sel, err := parseSelector(selector)
+	if err != nil {
+		d.t.Fatalf("webtest: %v", err)
+	}
+
+	var found []*html.Node
+	// RECURSIVE CLOSURE: declared first so it can call itself
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && sel.matches(n) {
+			found = append(found, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(d.root)
+	return found
+}
+
+// Count returns how many elements match selector
+func (d *Doc) Count(selector string) int {
+	d.t.Helper()
+	return len(d.Find(selector))
+}
+
+// Text returns the text of the first element matching selector, with runs of
+// whitespace collapsed to single spaces ("" when nothing matches)
+// Entities are already decoded: "&copy;" comes back as "©"
+func (d *Doc) Text(selector string) string {
+	d.t.Helper()
+	if nodes := d.Find(selector); len(nodes) > 0 {
+		return TextOf(nodes[0])
+	}
+	return ""
+}
+
+// Texts returns the text of every element matching selector
+func (d *Doc) Texts(selector string) []string {
+	d.t.Helper()
+	var out []string
+	for _, n := range d.Find(selector) {
+		out = append(out, TextOf(n))
+	}
+	return out
+}
+
+// Attr returns an attribute of the first element matching selector
+// The bool is false when nothing matches or the attribute is absent
+func (d *Doc) Attr(selector, name string) (string, bool) {
+	d.t.Helper()
+	if nodes := d.Find(selector); len(nodes) > 0 {
+		return AttrOf(nodes[0], name)
+	}
+	return "", false
+}
+
+// AssertExists fails the test unless at least one element matches selector
+func (d *Doc) AssertExists(selector string) {
+	d.t.Helper()
+	if d.Count(selector) == 0 {
+		d.t.Errorf("expected an element matching %q, found none\n%s", selector, d.HTML)
+	}
+}
+
+// AssertMissing fails the test if any element matches selector
+func (d *Doc) AssertMissing(selector string) {
+	d.t.Helper()
+	if n := d.Count(selector); n > 0 {
+		d.t.Errorf("expected no element matching %q, found %d\n%s", selector, n, d.HTML)
+	}
+}
+
+// AssertCount fails the test unless exactly want elements match selector
+func (d *Doc) AssertCount(selector string, want int) {
+	d.t.Helper()
+	if got := d.Count(selector); got != want {
+		d.t.Errorf("expected %d elements matching %q, found %d\n%s", want, selector, got, d.HTML)
+	}
+}
+
+// AssertText compares the (whitespace-collapsed) text of the first match with want
+func (d *Doc) AssertText(selector, want string) {
+	d.t.Helper()
+	if !d.exists(selector) {
+		return
+	}
+	if got := d.Text(selector); got != want {
+		d.t.Errorf("text of %q:\n got: %q\nwant: %q", selector, got, want)
+	}
+}
+
+// AssertTextContains checks that the first match's text contains want
+func (d *Doc) AssertTextContains(selector, want string) {
+	d.t.Helper()
+	if !d.exists(selector) {
+		return
+	}
+	if got := d.Text(selector); !strings.Contains(got, want) {
+		d.t.Errorf("text of %q:\n got: %q\nwant it to contain: %q", selector, got, want)
+	}
+}
+
+// AssertAttr checks an attribute of the first match
+func (d *Doc) AssertAttr(selector, name, want string) {
+	d.t.Helper()
+	if !d.exists(selector) {
+		return
+	}
+	got, ok := d.Attr(selector, name)
+	if !ok {
+		d.t.Errorf("%q has no %s attribute", selector, name)
+		return
+	}
+	if got != want {
+		d.t.Errorf("%s of %q:\n got: %q\nwant: %q", name, selector, got, want)
+	}
+}
+
+// exists reports a missing element once, so the assertions above don't pile on
+// a second confusing message ("text was \"\"") for the same problem
+func (d *Doc) exists(selector string) bool {
+	d.t.Helper()
+	if d.Count(selector) == 0 {
+		d.t.Errorf("no element matches %q\n%s", selector, d.HTML)
+		return false
+	}
+	return true
+}
+
+// TextOf returns all the text inside n, with whitespace collapsed
+func TextOf(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	// strings.Fields splits on any whitespace run, Join puts single spaces back
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// AttrOf returns one attribute of n
+func AttrOf(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// KEY CONCEPTS demonstrated in this file:
+// 1. TEST HELPERS - t.Helper() makes failures point at the test, not this file
+// 2. TESTING.TB - One helper works for tests and benchmarks alike
+// 3. DOM PARSING - Asserting on structure instead of comparing raw strings
+// 4. RECURSIVE CLOSURES - Walking a tree with a func that calls itself
@@ -0,0 +1,173 @@
+package webtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rohanthewiz/element"
+)
+
+// list is a tiny component used to exercise the harness
+type list struct {
+	Items []string
+}
+
+func (l list) Render(b *element.Builder) (dontCare any) {
+	b.Ul("class", "items main", "id", "things").R(
+		b.Wrap(func() {
+			for i, item := range l.Items {
+				attrs := []string{"data-index", string(rune('0' + i))}
+				if i == 0 {
+					attrs = append(attrs, "class", "first")
+				}
+				b.Li(attrs...).R(b.A("href", "/"+item).T(item))
+			}
+		}),
+	)
+	return
+}
+
+func TestSelectors(t *testing.T) {
+	doc := Render(t, list{Items: []string{"luna", "shadow", "whiskers"}})
+
+	// TABLE-DRIVEN TEST: each case is a selector and how many elements it should match
+	cases := []struct {
+		selector string
+		want     int
+	}{
+		{"ul", 1},
+		{"li", 3},
+		{"*", 7},
+		{"#things", 1},
+		{"ul#things.items.main", 1},
+		{".items", 1},
+		{".items.missing", 0},
+		{"li.first", 1},
+		{"[data-index]", 3},
+		{"[data-index=1]", 1},
+		{`li[data-index="2"] a`, 1},
+		{"ul a", 3},
+		{"ul > li", 3},
+		{"ul > a", 0}, // a is a grandchild, not a child
+		{"ul>li>a", 3},
+		{`a[href="/shadow"]`, 1},
+		{"section a", 0},
+	}
+	for _, tc := range cases {
+		if got := doc.Count(tc.selector); got != tc.want {
+			t.Errorf("Count(%q) = %d, want %d", tc.selector, got, tc.want)
+		}
+	}
+}
+
+func TestBadSelectors(t *testing.T) {
+	for _, s := range []string{"", "> li", "ul >", "ul > > li", "a..b", "a[href", "a!", "p, a", "[href^=/]", "[]"} {
+		if _, err := parseSelector(s); err == nil {
+			t.Errorf("parseSelector(%q) should fail", s)
+		}
+	}
+}
+
+func TestTextAndAttributes(t *testing.T) {
+	doc := Parse(t, `<p class="note">  Hello,
+		<b>world</b>  &amp; friends &copy;</p><a href="/cats?a=1&amp;b=2">x</a>`)
+
+	// Whitespace is collapsed and entities are decoded
+	doc.AssertText("p.note", "Hello, world & friends ©")
+	doc.AssertTextContains("p", "world")
+	doc.AssertAttr("a", "href", "/cats?a=1&b=2")
+
+	if _, ok := doc.Attr("a", "title"); ok {
+		t.Error("Attr should report a missing attribute")
+	}
+	if got := doc.Texts("b"); len(got) != 1 || got[0] != "world" {
+		t.Errorf("Texts(b) = %q", got)
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	doc := Parse(t, `<!DOCTYPE html><html lang="en"><head><title>T</title></head><body><h1>Hi</h1></body></html>`)
+	doc.AssertText("head > title", "T")
+	doc.AssertAttr("html", "lang", "en")
+	doc.AssertCount("body", 1)
+}
+
+// Fragments must not gain <html>/<body> wrappers - component tests rely on that
+func TestParseFragment(t *testing.T) {
+	doc := Parse(t, `<footer><p>x</p></footer>`)
+	doc.AssertMissing("body")
+	doc.AssertExists("footer > p")
+
+	// A lone table row keeps its tags instead of collapsing to text
+	row := Parse(t, `<tr><td><a href="/x">x</a></td></tr>`)
+	row.AssertExists("tr > td > a")
+	row.AssertMissing("tbody")
+}
+
+func TestNormalize(t *testing.T) {
+	// Same markup, different attribute order and whitespace
+	a := Parse(t, `<div id="x" class="y"><p>Hi   there</p><img src="a.png" alt="A"></div>`)
+	b := Parse(t, "<div class=\"y\" id=\"x\">\n  <p>Hi there</p>\n  <img alt=\"A\" src=\"a.png\">\n</div>")
+
+	na, nb := Normalize(a.root), Normalize(b.root)
+	if na != nb {
+		t.Fatalf("normalized forms differ:\n%s\n---\n%s", na, nb)
+	}
+
+	want := strings.Join([]string{
+		`<div class="y" id="x">`,
+		`  <p>Hi there</p>`,
+		`  <img alt="A" src="a.png">`,
+		`</div>`,
+		``,
+	}, "\n")
+	if na != want {
+		t.Errorf("Normalize =\n%s\nwant\n%s", na, want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Render(t, list{Items: []string{"luna", "shadow"}}).Golden("list")
+}
+
+func TestFirstDifference(t *testing.T) {
+	diff := firstDifference("a\nb\nc", "a\nb\nX\nd")
+	if !strings.Contains(diff, "line 3") || !strings.Contains(diff, "want: c") || !strings.Contains(diff, "got: X") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if firstDifference("same", "same") != "" {
+		t.Error("identical inputs should have no difference")
+	}
+}
+
+// recorder is a testing.TB that collects failures instead of failing the real test
+// EMBEDDING the interface supplies every other method; we override only what we watch
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, format)
+}
+
+// The assertions must actually fail when the page is wrong
+func TestAssertionsReportFailures(t *testing.T) {
+	rec := &recorder{TB: t}
+	doc := Parse(rec, `<p class="a" title="x">text</p>`)
+
+	doc.AssertExists("h1")
+	doc.AssertMissing("p")
+	doc.AssertCount("p", 2)
+	doc.AssertText("p", "other")
+	doc.AssertTextContains("p", "zzz")
+	doc.AssertAttr("p", "title", "y")
+	doc.AssertAttr("p", "id", "x")
+	doc.AssertText("h2", "anything") // missing element: reported once
+
+	if got, want := len(rec.failures), 8; got != want {
+		t.Errorf("recorded %d failures, want %d: %q", got, want, rec.failures)
+	}
+}